@@ -1,39 +1,111 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/apt-defender/helper-v2/internal/api"
+	"github.com/apt-defender/helper-v2/internal/autostart"
 	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/discovery"
+	"github.com/apt-defender/helper-v2/internal/logging"
+	"github.com/apt-defender/helper-v2/internal/piclient"
+	"github.com/apt-defender/helper-v2/internal/supervisor"
+	"github.com/apt-defender/helper-v2/internal/tray"
+	"github.com/apt-defender/helper-v2/internal/updater"
+	"github.com/apt-defender/helper-v2/internal/version"
 )
 
 func main() {
+	// Every config field can be overridden with --<key> or HELPER_<KEY>;
+	// flags win over the environment, which wins over the file
+	configPath := flag.String("config", "", "config file path (env HELPER_CONFIG)")
+	headless := flag.Bool("headless", false, "run only the API server, with no browser or tray icon, e.g. on servers and kiosks (env HELPER_HEADLESS)")
+	flag.BoolVar(headless, "no-gui", false, "same as --headless (env HELPER_NO_GUI)")
+	noBrowser := flag.Bool("no-browser", false, "show the tray icon but don't open the dashboard in a browser")
+	listBackups := flag.Bool("list-config-backups", false, "list saved config versions and exit")
+	rollback := flag.String("rollback-config", "", "restore a saved config version (name or \"latest\") and exit")
+	pairWith := flag.String("pair", "", "pair with the Pi Agent at host[:port] (or \"auto\" to discover it) over HTTPS and exit")
+	pairingCode := flag.String("pairing-code", "", "pairing code shown by the Pi Agent (with --pair)")
+	piFingerprint := flag.String("pi-fingerprint", "", "expected Pi Agent certificate fingerprint (with --pair; prompts when empty)")
+	supervise := flag.Bool("supervise", false, "run the helper under a watchdog that restarts it if it crashes or hangs (env HELPER_SUPERVISE)")
+	overrides := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *configPath != "" {
+		os.Setenv("HELPER_CONFIG", *configPath)
+	}
+	if !isFlagSet("headless") && !isFlagSet("no-gui") {
+		for _, env := range []string{"HELPER_NO_GUI", "HELPER_HEADLESS"} {
+			if v, err := strconv.ParseBool(os.Getenv(env)); err == nil {
+				*headless = v
+			}
+		}
+	}
+
+	// Local recovery for when a bad config keeps the API from coming up
+	if *listBackups || *rollback != "" {
+		os.Exit(configBackups(*listBackups, *rollback))
+	}
+	if *pairWith != "" {
+		os.Exit(pair(*pairWith, *pairingCode, *piFingerprint))
+	}
+
+	if v, err := strconv.ParseBool(os.Getenv("HELPER_SUPERVISE")); err == nil && !isFlagSet("supervise") {
+		*supervise = v
+	}
+	if *supervise && os.Getenv(supervisor.EnvSupervised) == "" {
+		os.Exit(runSupervisor(overrides))
+	}
+
 	// Setup logging to both file and console
-	logFile, err := os.OpenFile("apt-defender-v2.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	logFile, err := os.OpenFile(logging.File, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
 	if err == nil {
 		defer logFile.Close()
+		logging.Setup("info", logFile)
+	} else {
+		logging.Setup("info", nil)
 	}
 
+	// After an update the new build waits for the old one to free the port
+	updater.WaitForPrevious(30 * time.Second)
+
 	printBanner()
-	log.Println("=== APT Defender Helper v2.0 Starting ===")
-	fmt.Println("✅ APT Defender Helper v2.0 Starting...")
+	slog.Info("APT Defender Helper starting", "component", "main", "version", version.Version)
+
+	// A service runs in session 0, where there is no desktop for the tray
+	// icon or a browser
+	if !*headless && !tray.Interactive() {
+		*headless = true
+		slog.Info("no interactive desktop, running headless", "component", "main")
+	}
+	fmt.Printf("✅ APT Defender Helper v%s (%s) Starting...\n", version.Version, version.Hash())
 
 	// Load configuration
 	cfgPath := config.GetConfigPath()
+	if archived, err := config.MigrateV1(cfgPath); err != nil {
+		slog.Warn("v1 config migration failed", "component", "config", "error", err)
+		fmt.Printf("⚠️  Could not migrate v1 config: %v\n", err)
+	} else if archived != "" {
+		fmt.Printf("✅ v1 config migrated (original archived as %s)\n", archived)
+	}
 	cfg, err := config.Load(cfgPath)
 	if err != nil {
-		log.Printf("Config load error: %v, using defaults", err)
+		slog.Warn("config load failed, using defaults", "component", "config", "path", cfgPath, "error", err)
 		fmt.Printf("⚠️  Config not found, using defaults\n")
 		cfg = config.DefaultConfig()
 
 		// Try to save default config
 		if err := cfg.Save(cfgPath); err != nil {
-			log.Printf("Warning: Could not save default config: %v", err)
+			slog.Warn("could not save default config", "component", "config", "path", cfgPath, "error", err)
 		} else {
 			fmt.Printf("✅ Default config saved to: %s\n", cfgPath)
 		}
@@ -41,7 +113,46 @@ func main() {
 		fmt.Printf("✅ Configuration loaded from: %s\n", cfgPath)
 	}
 
-	log.Printf("Configuration: Host=%s Port=%d", cfg.Host, cfg.Port)
+	// Overrides are applied after the defaults are saved so they only live
+	// for this run
+	envKeys, err := cfg.ApplyEnv()
+	if err != nil {
+		slog.Error("invalid environment override", "component", "config", "error", err)
+		os.Exit(1)
+	}
+	flagKeys, err := overrides.Apply(cfg, flag.CommandLine)
+	if err != nil {
+		slog.Error("invalid command-line override", "component", "config", "error", err)
+		os.Exit(1)
+	}
+	if len(envKeys) > 0 || len(flagKeys) > 0 {
+		slog.Info("config overrides applied", "component", "config", "env", envKeys, "flags", flagKeys)
+		fmt.Printf("✅ Config overrides applied: %s\n", strings.Join(append(envKeys, flagKeys...), ", "))
+	}
+
+	if err := logging.SetLevel(cfg.LogLevel); err != nil {
+		slog.Warn("invalid log_level, using info", "component", "config", "error", err)
+	}
+	logging.SetDeviceID(cfg.PiDeviceID)
+	slog.Info("configuration loaded", "component", "config", "host", cfg.Host, "port", cfg.Port, "log_level", cfg.LogLevel)
+
+	// Keep the login Run entry in step with the config, e.g. after the
+	// executable has moved
+	if err := autostart.Sync(cfg.StartAtLogin, cfgPath); err != nil && cfg.StartAtLogin {
+		slog.Warn("could not register the helper to start at login", "component", "main", "error", err)
+	}
+
+	// Starting minimized only skips the browser once there is a Pi Agent;
+	// until then the dashboard opens on the pairing page
+	dashboardURL := fmt.Sprintf("http://localhost:%d/dashboard", cfg.Port)
+	startView := ""
+	if cfg.StartMinimized {
+		if cfg.RegisteredWithPi {
+			*noBrowser = true
+		} else {
+			startView = "pairing"
+		}
+	}
 
 	// Print service info
 	fmt.Println("\n" + strings.Repeat("=", 60))
@@ -58,37 +169,190 @@ func main() {
 	fmt.Println("  • File Locking (read-only protection)")
 	fmt.Println("  • Network Blocking (Windows Firewall control)")
 	fmt.Println("  • Application Network Blocking")
+	fmt.Println("  • DNS Query Monitoring (blocklist & DGA detection)")
 	fmt.Println(strings.Repeat("=", 60))
 
 	fmt.Println("\n📡 Starting API Server...")
 	fmt.Println("⏳ Waiting for commands from Pi Agent...")
-	fmt.Println("\n🌐 Dashboard URL: http://localhost:" + fmt.Sprintf("%d", cfg.Port) + "/dashboard")
-	fmt.Println("   Opening dashboard in browser...\n")
+	fmt.Println("\n🌐 Dashboard URL: " + dashboardURL)
+	if *headless {
+		fmt.Println("   Running headless: no browser or tray icon")
+	} else if !*noBrowser {
+		fmt.Println("   Opening dashboard in browser...")
+	}
 
 	// Start API server in background
 	server := api.New(cfg)
 	go func() {
 		if err := server.Start(); err != nil {
-			log.Fatalf("Server error: %v", err)
+			slog.Error("server failed", "component", "api", "error", err)
+			os.Exit(1)
 		}
 	}()
 
 	// Wait for server to start
 	time.Sleep(1 * time.Second)
 
-	// Open dashboard in default browser
-	dashboardURL := fmt.Sprintf("http://localhost:%d/dashboard", cfg.Port)
-	openBrowser(dashboardURL)
+	// Open dashboard in default browser and keep it a click away in the tray
+	if !*headless {
+		if !*noBrowser {
+			openBrowser(server.DashboardURL(startView))
+		}
+		openView := func(view string) {
+			openBrowser(server.DashboardURL(view))
+		}
+		if err := server.ShowTray(openView); err != nil {
+			slog.Warn("no tray icon", "component", "main", "error", err)
+		}
+	}
 	// Keep program running
 	fmt.Println("\n✅ Server is running. Press Ctrl+C to exit.")
 	select {} // Block forever
 }
 
+// runSupervisor runs this executable again without --supervise and keeps it
+// running; the child's health endpoint is found from the same config
+// and overrides it will load
+func runSupervisor(overrides *config.Flags) int {
+	cfg, err := config.Load(config.GetConfigPath())
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	if _, err := cfg.ApplyEnv(); err != nil {
+		fmt.Printf("❌ Invalid environment override: %v\n", err)
+		return 1
+	}
+	if _, err := overrides.Apply(cfg, flag.CommandLine); err != nil {
+		fmt.Printf("❌ Invalid command-line override: %v\n", err)
+		return 1
+	}
+
+	args := []string{}
+	for _, arg := range os.Args[1:] {
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && name == "supervise" {
+			continue
+		}
+		args = append(args, arg)
+	}
+
+	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+	fmt.Printf("🐕 Supervising helper (API on %s)\n", addr)
+	return supervisor.New(args, addr, config.GetDataDir()).Run()
+}
+
+// configBackups lists or restores config backups and returns the exit code
+func configBackups(list bool, rollback string) int {
+	cfgPath := config.GetConfigPath()
+	if list {
+		backups, err := config.Backups(cfgPath)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		if len(backups) == 0 {
+			fmt.Println("No config backups")
+		}
+		for _, b := range backups {
+			fmt.Printf("%s  %s  %d bytes\n", b.Name, b.SavedAt.Format(time.RFC3339), b.Size)
+		}
+		return 0
+	}
+
+	if rollback == "latest" {
+		rollback = ""
+	}
+	if _, err := config.Rollback(cfgPath, rollback); err != nil {
+		fmt.Printf("❌ Rollback failed: %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Config restored to %s\n", cfgPath)
+	return 0
+}
+
+// pair pairs with a Pi Agent from the command line and returns the exit
+// code. The Pi's certificate fingerprint is shown for confirmation unless
+// it was passed in.
+func pair(addr, code, fingerprint string) int {
+	if addr == "auto" {
+		services, err := discovery.Browse(3 * time.Second)
+		if err != nil {
+			fmt.Printf("❌ Discovery failed: %v\n", err)
+			return 1
+		}
+		if len(services) != 1 {
+			fmt.Printf("❌ Found %d Pi Agents, pass one with --pair host[:port]\n", len(services))
+			for _, svc := range services {
+				fmt.Printf("  %s  %s:%d\n", svc.Instance, svc.IP, svc.Port)
+			}
+			return 1
+		}
+		addr = net.JoinHostPort(services[0].IP, strconv.Itoa(services[0].Port))
+		fmt.Printf("🔎 Found Pi Agent %s at %s\n", services[0].Instance, addr)
+	}
+
+	host, port, err := piclient.SplitAddress(addr)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	if fingerprint == "" {
+		if fingerprint, err = piclient.Fingerprint(host, port); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return 1
+		}
+		fmt.Printf("Pi Agent certificate fingerprint:\n  %s\n", fingerprint)
+		fmt.Print("Does this match the fingerprint shown on the Pi? [y/N] ")
+		answer, _ := stdin.ReadString('\n')
+		if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+			fmt.Println("❌ Pairing cancelled")
+			return 1
+		}
+	}
+	if code == "" {
+		fmt.Print("Pairing code: ")
+		code, _ = stdin.ReadString('\n')
+	}
+
+	cfgPath := config.GetConfigPath()
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	result, err := piclient.Pair(cfg, host, port, code, fingerprint)
+	if err != nil {
+		fmt.Printf("❌ Pairing failed: %v\n", err)
+		return 1
+	}
+	if err := cfg.Save(cfgPath); err != nil {
+		fmt.Printf("❌ Paired but could not save config: %v\n", err)
+		return 1
+	}
+	fmt.Printf("✅ Paired with Pi Agent at %s:%d (device %d)\n", result.PiAgentIP, result.PiAgentPort, result.DeviceID)
+	if result.ClientCert {
+		fmt.Printf("🔑 Client certificate stored at %s\n", cfg.ClientCertFile)
+	}
+	return 0
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func printBanner() {
 	banner := `
 ╔══════════════════════════════════════════════════════════╗
 ║                                                          ║
-║        APT DEFENDER HELPER SERVICE v2.0                 ║
+║        APT DEFENDER HELPER SERVICE                       ║
 ║        Advanced PC Protection & Remote Control          ║
 ║                                                          ║
 ╚══════════════════════════════════════════════════════════╝
@@ -99,7 +363,7 @@ func printBanner() {
 func openBrowser(url string) {
 	cmd := exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
 	if err := cmd.Start(); err != nil {
-		log.Printf("Failed to open browser: %v", err)
+		slog.Warn("failed to open browser", "component", "main", "error", err)
 		fmt.Println("⚠️  Could not open browser automatically. Please open manually:")
 		fmt.Println("   " + url)
 	}
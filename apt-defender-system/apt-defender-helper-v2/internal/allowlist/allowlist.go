@@ -0,0 +1,215 @@
+package allowlist
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const storeFile = "allowlist.json"
+
+// Enforcement modes
+const (
+	ModeOff     = "off"
+	ModeAudit   = "audit"
+	ModeEnforce = "enforce"
+)
+
+// Rule types
+const (
+	RuleHash      = "hash"
+	RulePath      = "path"
+	RulePublisher = "publisher"
+)
+
+// Rule allows executables matching a hash, path pattern or publisher
+type Rule struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Value       string    `json:"value"`
+	Description string    `json:"description,omitempty"`
+	Hash        string    `json:"hash,omitempty"` // Authenticode SHA256 for hash rules
+	FileName    string    `json:"file_name,omitempty"`
+	FileLength  int64     `json:"file_length,omitempty"`
+	AddedAt     time.Time `json:"added_at"`
+}
+
+type state struct {
+	Mode      string    `json:"mode"`
+	Rules     []Rule    `json:"rules"`
+	AppliedAt time.Time `json:"applied_at,omitempty"`
+}
+
+// Manager stores the allowlist and applies it as an AppLocker policy
+type Manager struct {
+	mutex     sync.Mutex
+	storePath string
+	state     state
+}
+
+func New(dataDir string) *Manager {
+	m := &Manager{
+		storePath: filepath.Join(dataDir, storeFile),
+		state:     state{Mode: ModeOff, Rules: []Rule{}},
+	}
+	if data, err := os.ReadFile(m.storePath); err == nil {
+		if err := json.Unmarshal(data, &m.state); err != nil {
+			log.Printf("⚠️ Failed to parse allowlist: %v", err)
+		}
+	}
+	return m
+}
+
+// Mode returns the current enforcement mode
+func (m *Manager) Mode() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.state.Mode
+}
+
+// Rules returns the configured rules
+func (m *Manager) Rules() []Rule {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]Rule{}, m.state.Rules...)
+}
+
+// SetMode switches between off, audit and enforce and applies the policy
+func (m *Manager) SetMode(mode string) error {
+	switch mode {
+	case ModeOff, ModeAudit, ModeEnforce:
+	default:
+		return fmt.Errorf("unknown mode %q (off, audit or enforce)", mode)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if mode == ModeEnforce && len(m.state.Rules) == 0 {
+		log.Println("⚠️ Enforcing an allowlist with only the built-in rules")
+	}
+	previous := m.state.Mode
+	m.state.Mode = mode
+	if err := m.apply(); err != nil {
+		m.state.Mode = previous
+		return err
+	}
+
+	log.Printf("🛡️ Application allowlist mode: %s", mode)
+	return nil
+}
+
+// AddRule validates a rule, resolves hashes/publishers from files on disk
+// and re-applies the policy
+func (m *Manager) AddRule(rule Rule) (*Rule, error) {
+	rule.Value = strings.TrimSpace(rule.Value)
+	if rule.Value == "" {
+		return nil, fmt.Errorf("value is required")
+	}
+
+	switch rule.Type {
+	case RuleHash:
+		info, err := fileInformation(rule.Value)
+		if err != nil {
+			return nil, err
+		}
+		rule.Hash, rule.FileName, rule.FileLength = info.Hash, info.Name, info.Length
+	case RulePublisher:
+		// A path to a signed file is turned into its publisher name
+		if filepath.IsAbs(rule.Value) {
+			info, err := fileInformation(rule.Value)
+			if err != nil {
+				return nil, err
+			}
+			if info.Publisher == "" {
+				return nil, fmt.Errorf("%s is not signed", rule.Value)
+			}
+			rule.Value = info.Publisher
+		}
+	case RulePath:
+	default:
+		return nil, fmt.Errorf("unknown rule type %q (hash, path or publisher)", rule.Type)
+	}
+
+	id, err := newGUID()
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = id
+	rule.AddedAt = time.Now()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.state.Rules = append(m.state.Rules, rule)
+	if err := m.apply(); err != nil {
+		m.state.Rules = m.state.Rules[:len(m.state.Rules)-1]
+		return nil, err
+	}
+
+	log.Printf("🛡️ Allowlist rule added: %s %s", rule.Type, rule.Value)
+	return &rule, nil
+}
+
+// RemoveRule deletes a rule and re-applies the policy
+func (m *Manager) RemoveRule(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	previous := m.state.Rules
+	kept := []Rule{}
+	for _, r := range previous {
+		if r.ID != id {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == len(previous) {
+		return fmt.Errorf("rule not found: %s", id)
+	}
+
+	m.state.Rules = kept
+	if err := m.apply(); err != nil {
+		m.state.Rules = previous
+		return err
+	}
+	return nil
+}
+
+// apply pushes the policy to AppLocker and persists the state; callers hold the mutex
+func (m *Manager) apply() error {
+	if err := applyPolicy(m.state.Mode, m.state.Rules); err != nil {
+		return err
+	}
+	m.state.AppliedAt = time.Now()
+	return m.save()
+}
+
+func (m *Manager) save() error {
+	data, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal allowlist: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.storePath), 0700); err != nil {
+		return fmt.Errorf("failed to create data dir: %w", err)
+	}
+	if err := os.WriteFile(m.storePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write allowlist: %w", err)
+	}
+	return nil
+}
+
+func newGUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate rule ID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
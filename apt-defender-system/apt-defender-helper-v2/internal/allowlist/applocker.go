@@ -0,0 +1,274 @@
+package allowlist
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	sidEveryone       = "S-1-1-0"
+	sidAdministrators = "S-1-5-32-544"
+
+	appLockerLog = "Microsoft-Windows-AppLocker/EXE and DLL"
+	eventAudited = 8003 // would have been blocked (audit mode)
+	eventBlocked = 8004
+)
+
+// Built-in path rules that keep Windows and installed software working.
+// Administrators may always run anything so the allowlist can be recovered.
+var defaultRules = []struct {
+	Name string
+	SID  string
+	Path string
+}{
+	{"Windows", sidEveryone, `%WINDIR%\*`},
+	{"Program Files", sidEveryone, `%PROGRAMFILES%\*`},
+	{"Administrators", sidAdministrators, `*`},
+}
+
+type policy struct {
+	XMLName     xml.Name     `xml:"AppLockerPolicy"`
+	Version     int          `xml:"Version,attr"`
+	Collections []collection `xml:"RuleCollection"`
+}
+
+type collection struct {
+	Type            string          `xml:"Type,attr"`
+	EnforcementMode string          `xml:"EnforcementMode,attr"`
+	PathRules       []pathRule      `xml:"FilePathRule"`
+	PublisherRules  []publisherRule `xml:"FilePublisherRule"`
+	HashRules       []hashRule      `xml:"FileHashRule"`
+}
+
+type ruleHeader struct {
+	ID          string `xml:"Id,attr"`
+	Name        string `xml:"Name,attr"`
+	Description string `xml:"Description,attr"`
+	SID         string `xml:"UserOrGroupSid,attr"`
+	Action      string `xml:"Action,attr"`
+}
+
+type pathRule struct {
+	ruleHeader
+	Condition pathCondition `xml:"Conditions>FilePathCondition"`
+}
+
+type pathCondition struct {
+	Path string `xml:"Path,attr"`
+}
+
+type publisherRule struct {
+	ruleHeader
+	Condition publisherCondition `xml:"Conditions>FilePublisherCondition"`
+}
+
+type publisherCondition struct {
+	PublisherName string       `xml:"PublisherName,attr"`
+	ProductName   string       `xml:"ProductName,attr"`
+	BinaryName    string       `xml:"BinaryName,attr"`
+	Versions      versionRange `xml:"BinaryVersionRange"`
+}
+
+type versionRange struct {
+	Low  string `xml:"LowSection,attr"`
+	High string `xml:"HighSection,attr"`
+}
+
+type hashRule struct {
+	ruleHeader
+	Hash fileHash `xml:"Conditions>FileHashCondition>FileHash"`
+}
+
+type fileHash struct {
+	Type   string `xml:"Type,attr"`
+	Data   string `xml:"Data,attr"`
+	Name   string `xml:"SourceFileName,attr"`
+	Length int64  `xml:"SourceFileLength,attr"`
+}
+
+// buildPolicy renders the EXE rule collection. With the mode off the
+// collection is left empty, since AppLocker still enforces rules in a
+// NotConfigured collection.
+func buildPolicy(mode string, rules []Rule) ([]byte, error) {
+	c := collection{Type: "Exe", EnforcementMode: "NotConfigured"}
+	switch mode {
+	case ModeAudit:
+		c.EnforcementMode = "AuditOnly"
+	case ModeEnforce:
+		c.EnforcementMode = "Enabled"
+	}
+
+	if mode != ModeOff {
+		for _, d := range defaultRules {
+			id, err := newGUID()
+			if err != nil {
+				return nil, err
+			}
+			c.PathRules = append(c.PathRules, pathRule{
+				ruleHeader: ruleHeader{ID: id, Name: "APTDefender default: " + d.Name, SID: d.SID, Action: "Allow"},
+				Condition:  pathCondition{Path: d.Path},
+			})
+		}
+		if exe, err := os.Executable(); err == nil {
+			id, err := newGUID()
+			if err != nil {
+				return nil, err
+			}
+			c.PathRules = append(c.PathRules, pathRule{
+				ruleHeader: ruleHeader{ID: id, Name: "APTDefender helper", SID: sidEveryone, Action: "Allow"},
+				Condition:  pathCondition{Path: exe},
+			})
+		}
+
+		for _, r := range rules {
+			header := ruleHeader{
+				ID:          r.ID,
+				Name:        "APTDefender " + r.Type + ": " + r.Value,
+				Description: r.Description,
+				SID:         sidEveryone,
+				Action:      "Allow",
+			}
+			switch r.Type {
+			case RulePath:
+				c.PathRules = append(c.PathRules, pathRule{ruleHeader: header, Condition: pathCondition{Path: r.Value}})
+			case RulePublisher:
+				c.PublisherRules = append(c.PublisherRules, publisherRule{
+					ruleHeader: header,
+					Condition: publisherCondition{
+						PublisherName: r.Value,
+						ProductName:   "*",
+						BinaryName:    "*",
+						Versions:      versionRange{Low: "*", High: "*"},
+					},
+				})
+			case RuleHash:
+				c.HashRules = append(c.HashRules, hashRule{
+					ruleHeader: header,
+					Hash:       fileHash{Type: "SHA256", Data: r.Hash, Name: r.FileName, Length: r.FileLength},
+				})
+			}
+		}
+	}
+
+	return xml.MarshalIndent(policy{Version: 1, Collections: []collection{c}}, "", "  ")
+}
+
+// applyPolicy replaces the local AppLocker policy and makes sure the
+// Application Identity service, which AppLocker depends on, is running
+func applyPolicy(mode string, rules []Rule) error {
+	data, err := buildPolicy(mode, rules)
+	if err != nil {
+		return fmt.Errorf("failed to build policy: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "aptd-applocker-*.xml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	query := fmt.Sprintf("Set-AppLockerPolicy -XmlPolicy %s", psQuote(tmp.Name()))
+	if output, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to apply AppLocker policy: %v, output: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	if mode != ModeOff {
+		// Exit code 1056 means the service is already running
+		if output, err := exec.Command("sc", "start", "AppIDSvc").CombinedOutput(); err != nil && !strings.Contains(string(output), "1056") {
+			log.Printf("⚠️ Failed to start AppIDSvc, allowlist will not take effect: %s", strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+type fileInfo struct {
+	Hash      string `json:"hash"`
+	Name      string `json:"name"`
+	Length    int64  `json:"length"`
+	Publisher string `json:"publisher"`
+}
+
+// fileInformation asks AppLocker for a file's Authenticode hash and
+// publisher, which is what hash and publisher rules match against
+func fileInformation(path string) (*fileInfo, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("$i = Get-AppLockerFileInformation -Path %s; "+
+		"@{hash=$i.Hash.HashDataString; name=$i.Hash.SourceFileName; length=$i.Hash.SourceFileLength; "+
+		"publisher=$(if ($i.Publisher) { $i.Publisher.PublisherName } else { '' })} | ConvertTo-Json -Compress", psQuote(path))
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file information for %s: %w", path, err)
+	}
+
+	var info fileInfo
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse file information: %w", err)
+	}
+	if info.Hash == "" {
+		return nil, fmt.Errorf("no hash returned for %s", path)
+	}
+	return &info, nil
+}
+
+// Event is an executable that AppLocker blocked or, in audit mode, would have blocked
+type Event struct {
+	Time    time.Time `json:"time"`
+	Blocked bool      `json:"blocked"`
+	Message string    `json:"message"`
+}
+
+type winEvent struct {
+	TimeCreated string
+	ID          int `json:"Id"`
+	Message     string
+}
+
+// Events returns recent audit and block events from the AppLocker log
+func Events(limit int) ([]Event, error) {
+	query := fmt.Sprintf("Get-WinEvent -LogName %s -MaxEvents %d -ErrorAction SilentlyContinue | "+
+		"Where-Object { $_.Id -in %d,%d } | "+
+		"Select-Object @{n='TimeCreated';e={$_.TimeCreated.ToUniversalTime().ToString('o')}},Id,Message | ConvertTo-Json -Compress",
+		psQuote(appLockerLog), limit, eventAudited, eventBlocked)
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AppLocker events: %w", err)
+	}
+
+	raw := strings.TrimSpace(string(out))
+	if raw == "" {
+		return []Event{}, nil
+	}
+	if !strings.HasPrefix(raw, "[") {
+		raw = "[" + raw + "]"
+	}
+
+	var list []winEvent
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse AppLocker events: %w", err)
+	}
+
+	result := make([]Event, 0, len(list))
+	for _, e := range list {
+		t, _ := time.Parse(time.RFC3339Nano, e.TimeCreated)
+		result = append(result, Event{Time: t, Blocked: e.ID == eventBlocked, Message: e.Message})
+	}
+	return result, nil
+}
+
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
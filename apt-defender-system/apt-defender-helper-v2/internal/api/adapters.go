@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+)
+
+// handleAdapters lists network adapters and marks the one that reaches the Pi Agent
+func (s *Server) handleAdapters(w http.ResponseWriter, r *http.Request) {
+	adapters, err := control.Adapters()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"adapters": adapters,
+		"count":    len(adapters),
+		"pi_link":  s.piAdapter(),
+	})
+}
+
+// handleAdapterDisable cuts a single NIC. Disabling the adapter that carries
+// the Pi Agent connection requires force, since the Pi can't undo it.
+func (s *Server) handleAdapterDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Name  string `json:"name"`
+		Force bool   `json:"force"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	var err error
+	if link := s.piAdapter(); link != "" && strings.EqualFold(link, req.Name) && !req.Force {
+		err = fmt.Errorf("%s carries the Pi Agent connection; set force to disable it anyway", req.Name)
+	} else {
+		err = control.DisableAdapter(req.Name)
+	}
+	s.recordAudit(r, "network.adapter_disable", req.Name, err, map[string]interface{}{"force": req.Force})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]string{"name": req.Name, "status": "disabled"})
+}
+
+// handleAdapterEnable re-enables a NIC
+func (s *Server) handleAdapterEnable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	err := control.EnableAdapter(req.Name)
+	s.recordAudit(r, "network.adapter_enable", req.Name, err, nil)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]string{"name": req.Name, "status": "enabled"})
+}
+
+// piAdapter returns the adapter used to reach the Pi Agent, if known
+func (s *Server) piAdapter() string {
+	if s.config.PiAgentIP == "" {
+		return ""
+	}
+	name, err := control.AdapterFor(s.config.PiAgentIP, s.config.PiAgentPort)
+	if err != nil {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+	"github.com/apt-defender/helper-v2/internal/scanner"
+)
+
+// alertTypes are the detections and containment actions that can be
+// reported to the Pi Agent as they happen (pi_alerts picks which),
+// queued in the outbox while it is unreachable
+var alertTypes = []string{"scan.threat", "fim.change", "playbook.executed"}
+
+// forwardAlerts sends alerts from the event bus to the Pi Agent in the order
+// they were raised
+func (s *Server) forwardAlerts() {
+	ch, _ := s.events.Subscribe()
+	go func() {
+		for ev := range ch {
+			if !slices.Contains(s.config.PiAlerts, ev.Type) || !s.piClient.Available() {
+				continue
+			}
+			if err := s.piClient.Notify(ev.Type, ev.Data); err != nil {
+				log.Printf("⚠️ Could not report %s to Pi Agent: %v", ev.Type, err)
+			}
+		}
+	}()
+}
+
+// notifyUser tells the user at the desktop about threats and finished
+// scans. Threats are announced as soon as a scan finds the first one and
+// again when it finishes; they are always shown while the tray icon is up,
+// otherwise only with notify_threats. notify_scans adds a notice for scans
+// that found nothing.
+func (s *Server) notifyUser() {
+	ch, _ := s.events.Subscribe()
+	go func() {
+		announced := false // first threat of the running scan was shown
+		for ev := range ch {
+			threats := s.config.NotifyThreats || s.trayIcon.Load() != nil
+			switch ev.Type {
+			case "scan.started":
+				announced = false
+			case "scan.threat":
+				threat, ok := ev.Data.(*scanner.Threat)
+				if !ok || announced || !threats {
+					continue
+				}
+				announced = true
+				s.notify("APT Defender detected a threat",
+					fmt.Sprintf("%s in %s. The scan continues; open the dashboard to review it.", threat.Type, threat.Path), true)
+			case "scan.completed":
+				summary, ok := ev.Data.(scanner.ScanSummary)
+				if !ok {
+					continue
+				}
+				if summary.ThreatsFound > 0 && (threats || s.config.NotifyScans) {
+					s.notify("APT Defender found threats",
+						fmt.Sprintf("The %s scan found %d threats. Open the dashboard to review them.", summary.ScanType, summary.ThreatsFound), true)
+				} else if summary.ThreatsFound == 0 && s.config.NotifyScans {
+					s.notify("APT Defender scan finished",
+						fmt.Sprintf("The %s scan checked %d files and found no threats.", summary.ScanType, summary.ScannedFiles), false)
+				}
+			}
+		}
+	}()
+}
+
+// commandNotices are the remote commands the user is told about with
+// notify_commands, as title and message (%s is who sent the command)
+var commandNotices = map[string][2]string{
+	"system.lock":     {"This PC was locked", "It was locked remotely by %s."},
+	"system.shutdown": {"This PC is shutting down", "%s asked it to shut down."},
+	"system.restart":  {"This PC is restarting", "%s asked it to restart."},
+	"system.logoff":   {"You are being signed out", "%s signed this session out."},
+	"network.isolate": {"This PC was cut off the network", "%s isolated it because of a security concern. Contact your administrator before reconnecting it."},
+	"network.restore": {"Network access restored", "%s reconnected this PC to the network."},
+}
+
+// notifyCommand tells the user at the desktop when someone elsewhere locks,
+// shuts down, restarts or isolates this PC. Commands from this PC's own
+// dashboard aren't announced, nor delayed shutdowns, which show their own
+// countdown.
+func (s *Server) notifyCommand(r *http.Request, action string, details map[string]interface{}) {
+	notice, ok := commandNotices[action]
+	if !ok || !s.config.NotifyCommands {
+		return
+	}
+	if _, delayed := details["delay_seconds"]; delayed {
+		return
+	}
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if ip := net.ParseIP(host); ip == nil || ip.IsLoopback() {
+		return
+	}
+
+	sender := "An administrator at " + host
+	if host == s.config.PiAgentIP {
+		sender = "The Pi Agent (" + host + ")"
+	}
+	go s.notify(notice[0], fmt.Sprintf(notice[1], sender), action != "network.restore")
+}
+
+// notify shows a notification from the tray icon when it is up, otherwise
+// as a toast on the console session
+func (s *Server) notify(title, message string, warning bool) {
+	var err error
+	if icon := s.trayIcon.Load(); icon != nil {
+		err = icon.Balloon(title, message, warning)
+	} else {
+		var session uint32
+		if session, err = control.ActiveConsoleSession(); err == nil {
+			err = control.NotifyToast(session, title, message)
+		}
+	}
+	if err != nil {
+		log.Printf("⚠️ Could not show notification %q: %v", title, err)
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/apt-defender/helper-v2/internal/allowlist"
+)
+
+// handleAllowlist returns the application allowlist mode and rules
+func (s *Server) handleAllowlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	rules := s.allowlist.Rules()
+	s.sendJSON(w, map[string]interface{}{
+		"mode":  s.allowlist.Mode(),
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// handleAllowlistRules adds a rule (POST) or removes one (DELETE ?id=)
+func (s *Server) handleAllowlistRules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req allowlist.Rule
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid request")
+			return
+		}
+
+		rule, err := s.allowlist.AddRule(req)
+		s.recordAudit(r, "allowlist.add", req.Value, err, map[string]interface{}{"type": req.Type, "rule": rule})
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.sendJSON(w, rule)
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		err := s.allowlist.RemoveRule(id)
+		s.recordAudit(r, "allowlist.remove", id, err, nil)
+		if err != nil {
+			s.sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.sendJSON(w, map[string]string{"id": id, "status": "removed"})
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleAllowlistMode switches between off, audit and enforce
+func (s *Server) handleAllowlistMode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Mode         string `json:"mode"`
+		RestorePoint *bool  `json:"restore_point"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if req.Mode == allowlist.ModeEnforce {
+		if err := s.restorePointBefore(r, req.RestorePoint, "allowlist enforcement"); err != nil {
+			s.sendError(w, http.StatusConflict, err.Error())
+			return
+		}
+	}
+
+	previous := s.allowlist.Mode()
+	err := s.allowlist.SetMode(req.Mode)
+	s.recordAudit(r, "allowlist.mode", req.Mode, err, map[string]interface{}{"previous": previous})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]string{"mode": req.Mode, "previous": previous})
+}
+
+// handleAllowlistEvents lists executables AppLocker blocked or would have blocked
+func (s *Server) handleAllowlistEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+
+	list, err := allowlist.Events(limit)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"events": list,
+		"count":  len(list),
+	})
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIVersion is one API namespace the helper serves. A frozen version never
+// changes shape; breaking changes only land in the current one.
+type APIVersion struct {
+	Version  string `json:"version"`
+	BasePath string `json:"base_path"`
+	Status   string `json:"status"` // frozen or current
+}
+
+var apiVersions = []APIVersion{
+	{Version: "v1", BasePath: "/api/v1", Status: "frozen"},
+	{Version: "v2", BasePath: "/api/v2", Status: "current"},
+}
+
+// currentAPIVersion is the newest namespace; routes registered on v2Mux live there
+const currentAPIVersion = "v2"
+
+// apiVersioning routes /api/vN requests. v2 routes registered on v2Mux
+// replace their v1 counterparts; every other v2 path is served by the v1
+// handler, so v2 is v1 plus its breaking changes. Responses name the version
+// that served them, and unknown versions get the supported list back.
+func (s *Server) apiVersioning(next http.Handler) http.Handler {
+	supported := make([]string, len(apiVersions))
+	for i, v := range apiVersions {
+		supported[i] = v.Version
+	}
+	header := strings.Join(supported, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/api/")
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("X-API-Versions", header)
+
+		version, _, _ := strings.Cut(rest, "/")
+		switch {
+		case version == "v1":
+			w.Header().Set("X-API-Version", version)
+			next.ServeHTTP(w, r)
+		case version == "v2":
+			w.Header().Set("X-API-Version", version)
+			if _, pattern := s.v2Mux.Handler(r); pattern != "" {
+				s.v2Mux.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, withPath(r, "/api/v1"+strings.TrimPrefix(r.URL.Path, "/api/v2")))
+		case len(version) > 1 && version[0] == 'v' && strings.Trim(version[1:], "0123456789") == "":
+			s.sendError(w, http.StatusNotFound, fmt.Sprintf("API version %s is not supported (supported: %s)", version, header))
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// withPath returns a shallow copy of r addressed to path
+func withPath(r *http.Request, path string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path = path
+	u.RawPath = ""
+	r2.URL = &u
+	return r2
+}
+
+// handleAPIVersions lets a Pi Agent pick the newest API version both sides
+// speak before it sends commands, so mixed-version fleets keep working
+func (s *Server) handleAPIVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"versions":       apiVersions,
+		"current":        currentAPIVersion,
+		"helper_version": s.build.Version,
+		"capabilities":   s.build.Capabilities,
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/audit"
+)
+
+// handleAudit lists audit entries, newest first. action matches exactly or
+// as a prefix ("files" covers files.fetch and files.put), outcome is success
+// or failure, and since/until take RFC 3339 times or a duration ago ("24h").
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := audit.Filter{
+		Action:  query.Get("action"),
+		Outcome: query.Get("outcome"),
+		Limit:   200,
+	}
+	if filter.Outcome != "" && filter.Outcome != audit.OutcomeSuccess && filter.Outcome != audit.OutcomeFailure {
+		s.sendError(w, http.StatusBadRequest, "outcome must be success or failure")
+		return
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.sendError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = n
+	}
+
+	var err error
+	if filter.Since, err = parseAuditTime(query.Get("since")); err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid since: "+err.Error())
+		return
+	}
+	if filter.Until, err = parseAuditTime(query.Get("until")); err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid until: "+err.Error())
+		return
+	}
+
+	entries, total, err := s.audit.Query(filter)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	actions, err := s.audit.Actions()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+		"total":   total,
+		"actions": actions,
+	})
+}
+
+// parseAuditTime reads an RFC 3339 time or a duration before now; empty is the zero time
+func parseAuditTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a duration", v)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/capture"
+)
+
+const defaultCaptureSeconds = 30
+
+// handleCaptureInterfaces lists the devices available for packet capture
+func (s *Server) handleCaptureInterfaces(w http.ResponseWriter, r *http.Request) {
+	ifaces, err := capture.Interfaces()
+	if err != nil {
+		s.sendError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"interfaces": ifaces,
+		"count":      len(ifaces),
+	})
+}
+
+// handleCapture records a short packet capture into the staging area
+func (s *Server) handleCapture(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Interface string `json:"interface"`
+		Seconds   int    `json:"seconds"`
+		Filter    string `json:"filter"`
+		Upload    bool   `json:"upload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Interface == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if req.Seconds == 0 {
+		req.Seconds = defaultCaptureSeconds
+	}
+
+	path, err := s.staging.NewPath("capture", ".pcap")
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Printf("🦈 Capturing %ds on %s (filter: %q)", req.Seconds, req.Interface, req.Filter)
+	result, err := capture.Capture(path, capture.Options{
+		Interface: req.Interface,
+		Filter:    req.Filter,
+		Duration:  time.Duration(req.Seconds) * time.Second,
+		MaxBytes:  int64(s.config.MaxArtifactMB) * megabyte,
+	})
+	if err != nil {
+		os.Remove(path)
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	artifact, err := s.staging.Describe(path)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Printf("✅ Capture staged as %s (%d packets, %d bytes)", artifact.Name, result.Packets, artifact.Size)
+
+	response := map[string]interface{}{
+		"capture":  result,
+		"artifact": artifact,
+		"download": "/api/v1/staging/" + artifact.Name,
+	}
+	if req.Upload {
+		if err := s.startUpload(path, artifact); err != nil {
+			response["upload_error"] = err.Error()
+		} else {
+			response["upload"] = "started"
+		}
+	}
+	s.sendJSON(w, response)
+}
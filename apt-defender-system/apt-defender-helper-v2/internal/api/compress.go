@@ -0,0 +1,173 @@
+package api
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Responses smaller than this aren't worth the gzip header and CPU
+const gzipMinSize = 1024
+
+// Already-compressed downloads (dumps, triage zips, captures) are left alone
+var compressibleTypes = []string{"application/json", "application/x-ndjson", "text/"}
+
+var gzipWriters = sync.Pool{New: func() interface{} {
+	gz, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+	return gz
+}}
+
+// compress gzips large JSON and text responses for clients that send
+// Accept-Encoding: gzip. Process listings and connection histories on a
+// busy machine run to hundreds of KB and shrink by an order of magnitude.
+func (s *Server) compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer gw.Close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter holds back the first gzipMinSize bytes to decide
+// whether the response is worth compressing, then either gzips or passes
+// everything through
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.decided {
+		g.ResponseWriter.WriteHeader(status)
+		return
+	}
+	g.status = status
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !g.decided {
+		g.buf = append(g.buf, p...)
+		if len(g.buf) < gzipMinSize {
+			return len(p), nil
+		}
+		if err := g.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if g.gz != nil {
+		return g.gz.Write(p)
+	}
+	return g.ResponseWriter.Write(p)
+}
+
+// decide sends the headers and buffered bytes, compressed when the
+// response qualifies
+func (g *gzipResponseWriter) decide() error {
+	g.decided = true
+	header := g.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" && len(g.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(g.buf))
+	}
+
+	if g.compressible() {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", "gzip")
+		g.gz = gzipWriters.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+
+	buf := g.buf
+	g.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if g.gz != nil {
+		_, err = g.gz.Write(buf)
+	} else {
+		_, err = g.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (g *gzipResponseWriter) compressible() bool {
+	header := g.ResponseWriter.Header()
+	if len(g.buf) < gzipMinSize || g.status < 200 || g.status == http.StatusNoContent ||
+		g.status == http.StatusPartialContent || g.status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+	for _, t := range compressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flush commits to a decision so streams (server-sent events) reach the
+// client as they are written
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		g.decide()
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close sends whatever is still buffered and ends the gzip stream
+func (g *gzipResponseWriter) Close() {
+	if !g.decided {
+		g.decide()
+	}
+	if g.gz != nil {
+		g.gz.Close()
+		gzipWriters.Put(g.gz)
+		g.gz = nil
+	}
+}
+
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/autostart"
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/logging"
+)
+
+// ConfigPatch lists the settings that can be changed remotely; nil fields are left untouched
+type ConfigPatch struct {
+	Host           *string   `json:"host"`
+	Port           *int      `json:"port"`
+	LogLevel       *string   `json:"log_level"`
+	ScanPaths      *[]string `json:"scan_paths"`
+	ScanExclusions *[]string `json:"scan_exclusions"`
+	ScanInterval   *int      `json:"scan_interval"`
+	ScanSkipWarn   *int      `json:"scan_skip_warn"`
+	NotifyThreats  *bool     `json:"notify_threats"`
+	NotifyScans    *bool     `json:"notify_scans"`
+	NotifyCommands *bool     `json:"notify_commands"`
+	PiAlerts       *[]string `json:"pi_alerts"`
+	StartAtLogin   *bool     `json:"start_at_login"`
+	StartMinimized *bool     `json:"start_minimized"`
+	Theme          *string   `json:"theme"`
+}
+
+// keys lists the config keys the patch changes
+func (p ConfigPatch) keys() []string {
+	keys := []string{}
+	for key, given := range map[string]bool{
+		"host":            p.Host != nil,
+		"port":            p.Port != nil,
+		"log_level":       p.LogLevel != nil,
+		"scan_paths":      p.ScanPaths != nil,
+		"scan_exclusions": p.ScanExclusions != nil,
+		"scan_interval":   p.ScanInterval != nil,
+		"scan_skip_warn":  p.ScanSkipWarn != nil,
+		"notify_threats":  p.NotifyThreats != nil,
+		"notify_scans":    p.NotifyScans != nil,
+		"notify_commands": p.NotifyCommands != nil,
+		"pi_alerts":       p.PiAlerts != nil,
+		"start_at_login":  p.StartAtLogin != nil,
+		"start_minimized": p.StartMinimized != nil,
+		"theme":           p.Theme != nil,
+	} {
+		if given {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validate checks every field before any is applied, so a rejected patch
+// changes nothing
+func (p ConfigPatch) validate(cfg *config.Config) error {
+	for _, key := range p.keys() {
+		if cfg.Overridden(key) {
+			return fmt.Errorf("%s is set by an environment variable or command-line flag and can't be changed here", key)
+		}
+	}
+
+	if p.Host != nil && net.ParseIP(*p.Host) == nil {
+		return fmt.Errorf("host must be an IP address, e.g. 0.0.0.0 or 127.0.0.1")
+	}
+	if p.Port != nil && (*p.Port < 1 || *p.Port > 65535) {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+	if p.LogLevel != nil {
+		if _, err := logging.ParseLevel(*p.LogLevel); err != nil {
+			return err
+		}
+	}
+	if p.ScanPaths != nil {
+		if len(*p.ScanPaths) == 0 {
+			return fmt.Errorf("scan_paths needs at least one folder")
+		}
+		for _, path := range *p.ScanPaths {
+			expanded := config.ExpandPath(path)
+			if !filepath.IsAbs(expanded) {
+				return fmt.Errorf("scan path %q is not a full path", path)
+			}
+			if info, err := os.Stat(expanded); err != nil || !info.IsDir() {
+				return fmt.Errorf("scan path %q is not a folder on this PC", path)
+			}
+		}
+	}
+	if p.ScanExclusions != nil {
+		for _, e := range *p.ScanExclusions {
+			if e == "" {
+				return fmt.Errorf("scan exclusions can't be empty")
+			}
+			if _, err := filepath.Match(e, ""); err != nil {
+				return fmt.Errorf("scan exclusion %q is not a valid pattern", e)
+			}
+		}
+	}
+	if p.ScanInterval != nil && (*p.ScanInterval < 0 || *p.ScanInterval > 24*30) {
+		return fmt.Errorf("scan_interval must be between 0 (off) and 720 hours")
+	}
+	if p.ScanSkipWarn != nil && (*p.ScanSkipWarn < 1 || *p.ScanSkipWarn > 100) {
+		return fmt.Errorf("scan_skip_warn must be between 1 and 100 percent")
+	}
+	if p.PiAlerts != nil {
+		for _, t := range *p.PiAlerts {
+			if !slices.Contains(alertTypes, t) {
+				return fmt.Errorf("unknown Pi alert %q (one of %v)", t, alertTypes)
+			}
+		}
+	}
+	if p.Theme != nil && !slices.Contains(themes, *p.Theme) {
+		return fmt.Errorf("theme must be one of %v", themes)
+	}
+	if p.StartAtLogin != nil && *p.StartAtLogin {
+		if err := autostart.Available(); err != nil {
+			return fmt.Errorf("start_at_login: %w", err)
+		}
+	}
+	return nil
+}
+
+// handleConfig returns (GET) or updates (PATCH) the running configuration
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.sendJSON(w, s.redactedConfig())
+
+	case http.MethodPatch:
+		var patch ConfigPatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid request")
+			return
+		}
+
+		// A new listener address is bound up front, so an address that's in
+		// use rejects the whole patch instead of half-applying it
+		var ln net.Listener
+		err := patch.validate(s.config)
+		host, port := s.config.Host, s.config.Port
+		if patch.Host != nil {
+			host = *patch.Host
+		}
+		if patch.Port != nil {
+			port = *patch.Port
+		}
+		if err == nil && !s.listening(host, port) {
+			ln, err = bind(host, port)
+		}
+		s.recordAudit(r, "config.update", "", err, map[string]interface{}{"keys": patch.keys()})
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		previous := *s.config
+		s.config.Host, s.config.Port = host, port
+		if patch.LogLevel != nil {
+			s.config.LogLevel = *patch.LogLevel
+		}
+		if patch.ScanPaths != nil {
+			s.config.ScanPaths = *patch.ScanPaths
+		}
+		if patch.ScanExclusions != nil {
+			s.config.ScanExclusions = *patch.ScanExclusions
+		}
+		if patch.ScanInterval != nil {
+			s.config.ScanInterval = *patch.ScanInterval
+		}
+		if patch.ScanSkipWarn != nil {
+			s.config.ScanSkipWarn = *patch.ScanSkipWarn
+		}
+		if patch.NotifyThreats != nil {
+			s.config.NotifyThreats = *patch.NotifyThreats
+		}
+		if patch.NotifyScans != nil {
+			s.config.NotifyScans = *patch.NotifyScans
+		}
+		if patch.NotifyCommands != nil {
+			s.config.NotifyCommands = *patch.NotifyCommands
+		}
+		if patch.PiAlerts != nil {
+			s.config.PiAlerts = *patch.PiAlerts
+		}
+		if patch.StartAtLogin != nil {
+			s.config.StartAtLogin = *patch.StartAtLogin
+		}
+		if patch.StartMinimized != nil {
+			s.config.StartMinimized = *patch.StartMinimized
+		}
+		if patch.Theme != nil {
+			s.config.Theme = *patch.Theme
+		}
+		if err := s.config.Save(config.GetConfigPath()); err != nil {
+			*s.config = previous
+			if ln != nil {
+				ln.Close()
+			}
+			log.Printf("⚠️ Failed to save config: %v", err)
+			s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save config, nothing was changed: %v", err))
+			return
+		}
+
+		if patch.LogLevel != nil {
+			logging.SetLevel(s.config.LogLevel)
+		}
+		if patch.StartAtLogin != nil {
+			if err := autostart.Sync(s.config.StartAtLogin, config.GetConfigPath()); err != nil {
+				log.Printf("⚠️ Failed to update the startup entry: %v", err)
+			}
+		}
+		s.applyScanSettings()
+
+		// Listener changes go last so the response is still delivered on the
+		// old listener while it drains
+		if ln != nil {
+			s.moveListener(ln)
+		}
+
+		log.Println("⚙️ Configuration updated via API")
+		s.sendJSON(w, s.redactedConfig())
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// applyScanSettings passes the scan settings from the config to the scanner
+func (s *Server) applyScanSettings() {
+	s.scanner.SetScanPaths(expandPaths(s.config.ScanPaths))
+	s.scanner.SetExclusions(expandPaths(s.config.ScanExclusions))
+	s.scanner.SetSchedule(time.Duration(s.config.ScanInterval) * time.Hour)
+	s.scanner.SetSkipWarnRatio(float64(s.config.ScanSkipWarn) / 100)
+}
+
+func expandPaths(paths []string) []string {
+	expanded := make([]string, len(paths))
+	for i, p := range paths {
+		expanded[i] = config.ExpandPath(p)
+	}
+	return expanded
+}
+
+// redactedConfig returns a copy of the config safe to send over the wire
+func (s *Server) redactedConfig() config.Config {
+	cfg := *s.config
+	cfg.AuthToken = "********"
+	if cfg.PiAccessToken != "" {
+		cfg.PiAccessToken = "********"
+	}
+	if cfg.DashboardPIN != "" {
+		cfg.DashboardPIN = "********"
+	}
+	cfg.APITokens = make([]config.APIToken, len(s.config.APITokens))
+	for i, t := range s.config.APITokens {
+		t.Token = "********"
+		cfg.APITokens[i] = t
+	}
+	return cfg
+}
+
+// handleConfigBackups lists the saved config versions, newest first
+func (s *Server) handleConfigBackups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	backups, err := config.Backups(config.GetConfigPath())
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"backups": backups,
+		"count":   len(backups),
+		"keep":    s.config.ConfigBackups,
+	})
+}
+
+// handleConfigRollback restores a saved config version (the newest when
+// no name is given) and applies it to the running helper
+func (s *Server) handleConfigRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	restored, err := config.Rollback(config.GetConfigPath(), req.Name)
+	s.recordAudit(r, "config.rollback", req.Name, err, nil)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The listener is moved by Rebind, so the running address is kept until then
+	host, port := restored.Host, restored.Port
+	restored.Host, restored.Port = s.config.Host, s.config.Port
+	s.config.Replace(restored)
+	s.applyScanSettings()
+	logging.SetLevel(s.config.LogLevel)
+	log.Println("⚙️ Configuration rolled back via API")
+
+	if s.config.Overridden("host") {
+		host = s.config.Host
+	}
+	if s.config.Overridden("port") {
+		port = s.config.Port
+	}
+	if err := s.Rebind(host, port); err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, s.redactedConfig())
+}
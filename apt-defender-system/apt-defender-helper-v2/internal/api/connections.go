@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+)
+
+// handleKillConnection closes individual TCP sessions without blocking the
+// whole application or host
+func (s *Server) handleKillConnection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var filter control.ConnectionFilter
+	if err := json.NewDecoder(r.Body).Decode(&filter); err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	killed, err := control.KillConnections(filter)
+	s.recordAudit(r, "network.kill_connection", filter.RemoteAddress, err, map[string]interface{}{
+		"filter": filter,
+		"killed": len(killed),
+	})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(killed) == 0 {
+		s.sendError(w, http.StatusNotFound, "No matching connection")
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"killed": killed,
+		"count":  len(killed),
+	})
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+)
+
+// corsHeaders are the request headers the API reads, always allowed in a preflight
+var corsHeaders = []string{"Authorization", "Content-Type", "Last-Event-ID", "X-API-Version", "X-Nonce", "X-Request-ID", "X-Signature", "X-Timestamp"}
+
+// corsExposed are the response headers a browser script may read
+var corsExposed = []string{"Content-Disposition", "X-API-Version", "X-API-Versions", "X-Content-SHA256", "X-Request-ID", "X-Total-Count"}
+
+// cors applies the configured CORS policy so a web console served from the
+// Pi can call the API directly. Preflights are answered here, before the
+// source filter and auth, since browsers send them without credentials;
+// the request that follows is still checked as usual.
+func (s *Server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		allowed := s.allowedOrigin(origin)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if !allowed {
+			if preflight {
+				log.Printf("🚫 Refused CORS preflight from origin %s", origin)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header.Set("Access-Control-Allow-Origin", origin)
+		if !preflight {
+			header.Set("Access-Control-Expose-Headers", strings.Join(corsExposed, ", "))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		policy := s.config.CORS
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", strings.Join(append(append([]string{}, policy.AllowedMethods...), http.MethodOptions), ", "))
+		header.Set("Access-Control-Allow-Headers", strings.Join(append(append([]string{}, corsHeaders...), policy.AllowedHeaders...), ", "))
+		if policy.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allowedOrigin matches an Origin header against cors.allowed_origins
+func (s *Server) allowedOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+
+	for _, allowed := range s.config.CORS.AllowedOrigins {
+		allowed = strings.TrimSuffix(strings.TrimSpace(allowed), "/")
+		switch {
+		case allowed == "*":
+			return true
+		case allowed == "pi":
+			if s.config.PiAgentIP != "" && net.ParseIP(u.Hostname()).Equal(net.ParseIP(s.config.PiAgentIP)) {
+				return true
+			}
+		case strings.EqualFold(allowed, origin):
+			return true
+		}
+	}
+	return false
+}
+
+// checkCORS warns about origins that open the API to any web page
+func checkCORS(policy config.CORS) {
+	for _, origin := range policy.AllowedOrigins {
+		if strings.TrimSpace(origin) == "*" {
+			log.Println("⚠️ cors.allowed_origins contains \"*\"; any web page can call the API with a token the browser holds")
+		}
+	}
+}
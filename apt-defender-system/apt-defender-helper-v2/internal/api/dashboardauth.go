@@ -0,0 +1,363 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/dashboard"
+)
+
+const (
+	sessionCookie    = "helper_session"
+	sessionTTL       = 12 * time.Hour
+	launchTTL        = 2 * time.Minute
+	loginMaxFailures = 5
+	loginLockout     = 5 * time.Minute
+)
+
+// dashboardSessions tracks browsers on other machines that signed in to the
+// dashboard with the PIN, browsers on this PC that the helper opened with a
+// launch code, and failed PIN attempts per address. Sessions live in
+// memory, so restarting the helper signs everyone out.
+type dashboardSessions struct {
+	mutex    sync.Mutex
+	sessions map[string]dashboardSession // session ID -> session
+	launches map[string]time.Time        // launch code -> expiry
+	failures map[string]*loginFailures
+}
+
+// dashboardSession is a signed-in browser. Local sessions were started from
+// a launch code and only count for loopback requests.
+type dashboardSession struct {
+	expires time.Time
+	local   bool
+}
+
+type loginFailures struct {
+	count       int
+	lockedUntil time.Time
+}
+
+func newDashboardSessions() *dashboardSessions {
+	return &dashboardSessions{
+		sessions: make(map[string]dashboardSession),
+		launches: make(map[string]time.Time),
+		failures: make(map[string]*loginFailures),
+	}
+}
+
+// dashboardScope is the token scope a request gets through the dashboard
+// without a token: control for the browser the helper opened on this PC
+// (a local session), dashboard_scope for other machines with a signed-in
+// session, read for any other loopback caller, and "" for anything else.
+// Any program on this PC can reach loopback, so only the launch code the
+// helper hands its own browser unlocks changes. Pages from other sites can
+// make the browser send requests here too, so anything that changes state
+// must come from the dashboard's own origin, and every request must name
+// this PC as its Host.
+func (s *Server) dashboardScope(r *http.Request) string {
+	if !s.validHost(r.Host) {
+		return ""
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && !sameOrigin(r) {
+		return ""
+	}
+	switch {
+	case s.hasLocalSession(r):
+		return config.ScopeControl
+	case s.hasDashboardSession(r) && slices.Contains(config.Scopes, s.config.DashboardScope):
+		return s.config.DashboardScope
+	case isLoopback(r):
+		return config.ScopeRead
+	}
+	return ""
+}
+
+// dashboardAllowed reports whether a request may use the dashboard at all
+func (s *Server) dashboardAllowed(r *http.Request) bool {
+	return s.dashboardScope(r) != ""
+}
+
+// dashboardPermits runs next when the dashboard grants r the required
+// scope. A signed-in dashboard without it is refused rather than asked for
+// a token; anything else is left to the caller's token check.
+func (s *Server) dashboardPermits(w http.ResponseWriter, r *http.Request, required string, next http.HandlerFunc) bool {
+	scope := s.dashboardScope(r)
+	if scopeAllows(scope, required) {
+		next(w, r)
+		return true
+	}
+	if scope != "" && r.Header.Get("Authorization") == "" {
+		if isLoopback(r) && !s.hasDashboardSession(r) {
+			s.sendError(w, http.StatusForbidden, fmt.Sprintf("Open the dashboard from the APT Defender tray icon to make changes, or send a token with %s scope", required))
+			return true
+		}
+		s.sendError(w, http.StatusForbidden, fmt.Sprintf("This dashboard session has %s access (dashboard_scope), %s is needed", scope, required))
+		return true
+	}
+	return s.refuseHost(w, r)
+}
+
+// hasDashboardSession reports whether r carries a PIN session
+func (s *Server) hasDashboardSession(r *http.Request) bool {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || s.config.DashboardPIN == "" {
+		return false
+	}
+	session, ok := s.sessions.lookup(cookie.Value, time.Now())
+	return ok && !session.local
+}
+
+// hasLocalSession reports whether r comes from loopback with a session
+// started from a launch code
+func (s *Server) hasLocalSession(r *http.Request) bool {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil || !isLoopback(r) {
+		return false
+	}
+	session, ok := s.sessions.lookup(cookie.Value, time.Now())
+	return ok && session.local
+}
+
+// DashboardURL returns the address to open the dashboard at a view ("" for
+// the overview) in the browser on this PC. It carries a one-time launch
+// code, which that browser exchanges for a local session that can make
+// changes; the code expires after two minutes.
+func (s *Server) DashboardURL(view string) string {
+	url := fmt.Sprintf("http://localhost:%d/dashboard", s.config.Port)
+	if code, err := s.sessions.launch(time.Now()); err != nil {
+		log.Printf("⚠️ Dashboard opens read-only: %v", err)
+	} else {
+		url += "?launch=" + code
+	}
+	if view != "" {
+		url += "#" + view
+	}
+	return url
+}
+
+// redeemLaunch exchanges a launch code from DashboardURL for a local
+// session cookie. Codes work once, and only from loopback.
+func (s *Server) redeemLaunch(w http.ResponseWriter, r *http.Request, code string) {
+	if !isLoopback(r) || !s.sessions.redeem(code, time.Now()) {
+		log.Printf("🚫 Ignored an unknown or expired dashboard launch code from %s", r.RemoteAddr)
+		return
+	}
+	id, err := s.sessions.start("", true, time.Now())
+	if err != nil {
+		log.Printf("⚠️ Dashboard opens read-only: %v", err)
+		return
+	}
+	setSessionCookie(w, r, id)
+}
+
+// checkDashboardAccess warns about remote dashboard settings at startup
+func checkDashboardAccess(cfg *config.Config) {
+	if cfg.DashboardPIN == "" {
+		return
+	}
+	log.Printf("⚠️ dashboard_pin is set: remote sign-ins send the PIN and session cookie over plain HTTP, so use them only on a network you trust")
+	if !slices.Contains(config.Scopes, cfg.DashboardScope) {
+		log.Printf("⚠️ dashboard_scope %q is not read, scan or control, signed-in dashboards will be refused everything", cfg.DashboardScope)
+	}
+}
+
+// handleDashboardLogin checks the PIN and starts a session
+func (s *Server) handleDashboardLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+	if s.config.DashboardPIN == "" {
+		s.sendLogin(w, http.StatusForbidden, "Remote dashboard access is disabled.")
+		return
+	}
+
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if wait := s.sessions.lockedFor(host, time.Now()); wait > 0 {
+		s.sendLogin(w, http.StatusTooManyRequests, fmt.Sprintf("Too many attempts. Try again in %s.", wait.Round(time.Second)))
+		return
+	}
+
+	if !tokenEqual(r.FormValue("pin"), s.config.DashboardPIN) {
+		locked := s.sessions.fail(host, time.Now())
+		s.recordAudit(r, "dashboard.login", host, fmt.Errorf("wrong PIN"), nil)
+		if locked {
+			log.Printf("🚫 Dashboard login from %s locked for %s after %d wrong PINs", host, loginLockout, loginMaxFailures)
+		}
+		s.sendLogin(w, http.StatusUnauthorized, "Wrong PIN.")
+		return
+	}
+
+	id, err := s.sessions.start(host, false, time.Now())
+	if err != nil {
+		s.sendLogin(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.recordAudit(r, "dashboard.login", host, nil, nil)
+	log.Printf("🔑 Dashboard session started for %s", host)
+
+	setSessionCookie(w, r, id)
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+func setSessionCookie(w http.ResponseWriter, r *http.Request, id string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// handleDashboardLogout ends the caller's session
+func (s *Server) handleDashboardLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		s.sessions.end(cookie.Value)
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+	http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+}
+
+// sendDashboardDenied answers a dashboard request from another machine
+// that isn't signed in
+func (s *Server) sendDashboardDenied(w http.ResponseWriter) {
+	if s.config.DashboardPIN == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("The APT Defender dashboard is only available on this PC.\n"))
+		return
+	}
+	s.sendLogin(w, http.StatusUnauthorized, "")
+}
+
+func (s *Server) sendLogin(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write([]byte(strings.Replace(s.dashboardPage(dashboard.LoginHTML), "{{ERROR}}", html.EscapeString(message), 1)))
+}
+
+func (d *dashboardSessions) lookup(id string, now time.Time) (dashboardSession, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	session, ok := d.sessions[id]
+	if ok && now.After(session.expires) {
+		delete(d.sessions, id)
+		return dashboardSession{}, false
+	}
+	return session, ok
+}
+
+func (d *dashboardSessions) start(host string, local bool, now time.Time) (string, error) {
+	id, err := randomID()
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for sid, session := range d.sessions {
+		if now.After(session.expires) {
+			delete(d.sessions, sid)
+		}
+	}
+	d.sessions[id] = dashboardSession{expires: now.Add(sessionTTL), local: local}
+	delete(d.failures, host)
+	return id, nil
+}
+
+// launch creates a one-time launch code for DashboardURL
+func (d *dashboardSessions) launch(now time.Time) (string, error) {
+	code, err := randomID()
+	if err != nil {
+		return "", fmt.Errorf("failed to create launch code: %w", err)
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for c, expires := range d.launches {
+		if now.After(expires) {
+			delete(d.launches, c)
+		}
+	}
+	d.launches[code] = now.Add(launchTTL)
+	return code, nil
+}
+
+// redeem uses up a launch code and reports whether it was still valid
+func (d *dashboardSessions) redeem(code string, now time.Time) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	expires, ok := d.launches[code]
+	delete(d.launches, code)
+	return ok && !now.After(expires)
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (d *dashboardSessions) end(id string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.sessions, id)
+}
+
+// lockedFor returns how long host must wait before trying another PIN
+func (d *dashboardSessions) lockedFor(host string, now time.Time) time.Duration {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if f, ok := d.failures[host]; ok && now.Before(f.lockedUntil) {
+		return f.lockedUntil.Sub(now)
+	}
+	return 0
+}
+
+// fail counts a wrong PIN and reports whether host is now locked out
+func (d *dashboardSessions) fail(host string, now time.Time) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	f, ok := d.failures[host]
+	if !ok || (!f.lockedUntil.IsZero() && now.After(f.lockedUntil)) {
+		f = &loginFailures{}
+		d.failures[host] = f
+	}
+	f.count++
+	if f.count >= loginMaxFailures {
+		f.lockedUntil = now.Add(loginLockout)
+		return true
+	}
+	return false
+}
+
+// themes are the dashboard theme settings; system follows the light or dark
+// app mode chosen in Windows, which browsers report as prefers-color-scheme
+var themes = []string{"system", "light", "dark"}
+
+// dashboardPage fills a page's {{THEME}} with the theme setting, so it is
+// applied before the page first renders
+func (s *Server) dashboardPage(page string) string {
+	theme := s.config.Theme
+	if !slices.Contains(themes, theme) {
+		theme = "system"
+	}
+	return strings.Replace(page, "{{THEME}}", theme, 1)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/defender"
+)
+
+// handleDefenderStatus reports Microsoft Defender protection state
+func (s *Server) handleDefenderStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := defender.GetStatus()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, status)
+}
+
+// handleDefenderScan starts a Defender quick (default) or full scan
+func (s *Server) handleDefenderScan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Type string `json:"type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	err := defender.StartScan(req.Type)
+	s.recordAudit(r, "defender.scan", req.Type, err, nil)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]string{"message": "Defender scan started"})
+}
+
+// handleDefenderEnableRealtime turns real-time protection back on
+func (s *Server) handleDefenderEnableRealtime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	err := defender.EnableRealTimeProtection()
+	s.recordAudit(r, "defender.enable_realtime", "", err, nil)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	status, err := defender.GetStatus()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, status)
+}
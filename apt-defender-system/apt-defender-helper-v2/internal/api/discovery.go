@@ -0,0 +1,67 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/discovery"
+	"github.com/apt-defender/helper-v2/internal/piclient"
+)
+
+// handleDiscoverPi lists Pi Agents advertising themselves over mDNS, so the
+// pairing form can be pre-filled instead of typing the Pi's IP
+func (s *Server) handleDiscoverPi(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	services, err := discovery.Browse(3 * time.Second)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"services": services,
+		"count":    len(services),
+		"paired":   s.config.PiAgentIP,
+	})
+}
+
+// piMoved follows the paired Pi Agent to its new DHCP address
+func (s *Server) piMoved(ip string, port int) {
+	old := s.config.PiAgentIP
+	s.config.PiAgentIP = ip
+	s.config.PiAgentPort = port
+	if err := s.config.Save(config.GetConfigPath()); err != nil {
+		log.Printf("⚠️ Failed to save config after Pi address change: %v", err)
+	}
+
+	s.events.Publish("pi.address_changed", map[string]interface{}{
+		"old_ip": old,
+		"new_ip": ip,
+		"port":   port,
+	})
+	s.piLink.Kick()
+}
+
+// piPinned reports certificate pinning on outbound Pi connections: a pin
+// taken on first use (already saved by the client) and a mismatch are
+// raised as events
+func (s *Server) piPinned(kind, fingerprint string) {
+	switch kind {
+	case piclient.PinFirstUse:
+		s.events.Publish("pi.cert_pinned", map[string]interface{}{
+			"pi_agent_ip": s.config.PiAgentIP,
+			"fingerprint": fingerprint,
+		})
+	case piclient.PinMismatch:
+		s.events.Publish("pi.cert_mismatch", map[string]interface{}{
+			"pi_agent_ip": s.config.PiAgentIP,
+			"fingerprint": fingerprint,
+			"pinned":      piclient.DisplayFingerprint(s.config.PiCertFingerprint),
+		})
+	}
+}
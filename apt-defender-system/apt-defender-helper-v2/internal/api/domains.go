@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+)
+
+// handleBlockDomain blocks a domain via the hosts file sinkhole, a firewall
+// rule on its resolved addresses, or both
+func (s *Server) handleBlockDomain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Domain string `json:"domain"`
+		Method string `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Domain == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if req.Method == "" {
+		req.Method = control.DomainMethodHosts
+	}
+
+	result := map[string]interface{}{"domain": req.Domain, "method": req.Method}
+	err := s.blockDomain(req.Domain, req.Method, result)
+	s.recordAudit(r, "network.block_domain", req.Domain, err, result)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, result)
+}
+
+func (s *Server) blockDomain(domain, method string, result map[string]interface{}) error {
+	switch method {
+	case control.DomainMethodHosts, control.DomainMethodFirewall, "both":
+	default:
+		return fmt.Errorf("unknown method %q (hosts, firewall or both)", method)
+	}
+
+	// Resolve before the sinkhole is in place
+	if method != control.DomainMethodHosts {
+		ips, err := control.BlockDomainFirewall(domain)
+		if err != nil {
+			return err
+		}
+		result["blocked_ips"] = ips
+	}
+	if method != control.DomainMethodFirewall {
+		return control.BlockDomain(domain)
+	}
+	return nil
+}
+
+// handleUnblockDomain removes every block the helper placed on a domain
+func (s *Server) handleUnblockDomain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Domain string `json:"domain"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Domain == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	err := control.UnblockDomain(req.Domain)
+	s.recordAudit(r, "network.unblock_domain", req.Domain, err, nil)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]string{"domain": req.Domain, "status": "unblocked"})
+}
+
+// handleBlockedDomains lists the domains the helper currently blocks
+func (s *Server) handleBlockedDomains(w http.ResponseWriter, r *http.Request) {
+	domains, err := control.BlockedDomains()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"domains": domains,
+		"count":   len(domains),
+	})
+}
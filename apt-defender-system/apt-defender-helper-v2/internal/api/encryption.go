@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/bitlocker"
+)
+
+// handleEncryption reports BitLocker status per volume, and whether the
+// operating system volume is protected
+func (s *Server) handleEncryption(w http.ResponseWriter, r *http.Request) {
+	volumes, err := bitlocker.Volumes()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	osProtected := false
+	allProtected := len(volumes) > 0
+	for _, v := range volumes {
+		if v.VolumeType == "OperatingSystem" {
+			osProtected = v.Protected
+		}
+		if !v.Protected {
+			allProtected = false
+		}
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"volumes":       volumes,
+		"os_protected":  osProtected,
+		"all_protected": allProtected,
+	})
+}
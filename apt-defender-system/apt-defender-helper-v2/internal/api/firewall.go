@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+)
+
+// handleFirewallRules lists helper-created firewall rules (GET) or removes
+// them (DELETE), optionally limited to one kind with ?kind= or one rule
+// with ?name=. isolated reports whether a full network block is in force.
+func (s *Server) handleFirewallRules(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	name := r.URL.Query().Get("name")
+
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := control.ManagedRules()
+		if err != nil {
+			s.sendError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		filtered := []control.FirewallRule{}
+		isolated := false
+		for _, rule := range rules {
+			if rule.Kind == control.RuleKindAll && rule.Enabled && rule.Action == "Block" {
+				isolated = true
+			}
+			if kind == "" || rule.Kind == kind {
+				filtered = append(filtered, rule)
+			}
+		}
+		s.sendJSON(w, map[string]interface{}{
+			"rules":    filtered,
+			"count":    len(filtered),
+			"isolated": isolated,
+		})
+
+	case http.MethodDelete:
+		if name != "" {
+			err := control.RemoveRule(name)
+			s.recordAudit(r, "network.rule_remove", name, err, nil)
+			if err != nil {
+				s.sendError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.sendJSON(w, map[string]interface{}{
+				"removed": []string{name},
+				"count":   1,
+			})
+			return
+		}
+
+		removed, err := control.RemoveManagedRules(kind)
+		s.recordAudit(r, "network.rules_cleanup", kind, err, map[string]interface{}{"removed": removed})
+		if err != nil {
+			s.sendError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.sendJSON(w, map[string]interface{}{
+			"removed": removed,
+			"count":   len(removed),
+		})
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleFirewallRuleEnable switches a helper-created rule back on
+func (s *Server) handleFirewallRuleEnable(w http.ResponseWriter, r *http.Request) {
+	s.toggleFirewallRule(w, r, true)
+}
+
+// handleFirewallRuleDisable switches a helper-created rule off without deleting it
+func (s *Server) handleFirewallRuleDisable(w http.ResponseWriter, r *http.Request) {
+	s.toggleFirewallRule(w, r, false)
+}
+
+func (s *Server) toggleFirewallRule(w http.ResponseWriter, r *http.Request, enabled bool) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	action := "network.rule_disable"
+	if enabled {
+		action = "network.rule_enable"
+	}
+	err := control.SetRuleEnabled(req.Name, enabled)
+	s.recordAudit(r, action, req.Name, err, nil)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{"name": req.Name, "enabled": enabled})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apt-defender/helper-v2/internal/inventory"
+)
+
+// handlePatches returns the OS build and installed hotfixes
+func (s *Server) handlePatches(w http.ResponseWriter, r *http.Request) {
+	patches, err := inventory.GetPatches()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, patches)
+}
+
+// handleSoftware lists installed programs (include_system=true adds hidden
+// components and updates)
+func (s *Server) handleSoftware(w http.ResponseWriter, r *http.Request) {
+	programs := inventory.Software(r.URL.Query().Get("include_system") == "true")
+	s.sendJSON(w, map[string]interface{}{
+		"programs": programs,
+		"count":    len(programs),
+	})
+}
+
+// handleUsers audits local accounts (recent_days sets the "recently created"
+// window, default 30)
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	days := 30
+	if v, err := strconv.Atoi(r.URL.Query().Get("recent_days")); err == nil && v > 0 {
+		days = v
+	}
+
+	users, err := inventory.Users(days)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"users": users,
+		"count": len(users),
+	})
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+)
+
+type ipRequest struct {
+	Address   string `json:"address"`
+	Direction string `json:"direction"`
+}
+
+// handleBlockIP blocks traffic to and from a remote IP or CIDR
+func (s *Server) handleBlockIP(w http.ResponseWriter, r *http.Request) {
+	s.handleIPRule(w, r, "network.block_ip", control.BlockIP)
+}
+
+// handleUnblockIP removes an IP block with the same arguments
+func (s *Server) handleUnblockIP(w http.ResponseWriter, r *http.Request) {
+	s.handleIPRule(w, r, "network.unblock_ip", control.UnblockIP)
+}
+
+func (s *Server) handleIPRule(w http.ResponseWriter, r *http.Request, action string,
+	apply func(address, direction string) ([]control.IPRule, error)) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req ipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Address == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if action == "network.block_ip" {
+		if err := s.checkBlockable(req.Address); err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	rules, err := apply(req.Address, req.Direction)
+	s.recordAudit(r, action, req.Address, err, map[string]interface{}{
+		"direction": req.Direction,
+		"rules":     rules,
+	})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{"rules": rules})
+}
+
+// checkBlockable refuses blocks that would cut the paired Pi Agent off,
+// since it could not lift them afterwards
+func (s *Server) checkBlockable(address string) error {
+	address, err := control.NormalizeAddress(address)
+	if err != nil {
+		return err
+	}
+	pi := net.ParseIP(s.config.PiAgentIP)
+	if pi == nil {
+		return nil
+	}
+	if ip := net.ParseIP(address); ip != nil && ip.Equal(pi) {
+		return fmt.Errorf("%s is the paired Pi Agent", address)
+	}
+	if _, network, err := net.ParseCIDR(address); err == nil && network.Contains(pi) {
+		return fmt.Errorf("%s contains the paired Pi Agent %s", address, s.config.PiAgentIP)
+	}
+	return nil
+}
+
+// handleBlockedIPs lists IP blocks managed by the helper
+func (s *Server) handleBlockedIPs(w http.ResponseWriter, r *http.Request) {
+	rules, err := control.BlockedIPs()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/supervisor"
+)
+
+const drainTimeout = 30 * time.Second
+
+// bind checks host and port and binds them without serving yet, so a
+// caller can find out the address is usable before changing anything
+func bind(host string, port int) (net.Listener, error) {
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port: %d", port)
+	}
+	if net.ParseIP(host) == nil {
+		return nil, fmt.Errorf("invalid host: %s", host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", host, port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return ln, nil
+}
+
+// serve serves the API mux on ln in the background
+func (s *Server) serve(ln net.Listener) *http.Server {
+	addr := ln.Addr().String()
+	srv := &http.Server{Addr: addr, Handler: s.requestLog(s.compress(s.cors(s.sourceFilter(s.apiVersioning(s.mux)))))}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("HTTP server on %s failed: %v", addr, err)
+			s.listenerMu.Lock()
+			current := s.httpServer == srv
+			s.listenerMu.Unlock()
+			if current {
+				s.serveErrors <- err
+			}
+		}
+	}()
+
+	return srv
+}
+
+// listening reports whether the API already serves on host:port
+func (s *Server) listening(host string, port int) bool {
+	s.listenerMu.Lock()
+	defer s.listenerMu.Unlock()
+	return host == s.config.Host && port == s.config.Port
+}
+
+// Rebind moves the API to a new host/port without dropping remote control:
+// the new listener is started first, then the old one is drained
+func (s *Server) Rebind(host string, port int) error {
+	if s.listening(host, port) {
+		return nil
+	}
+
+	ln, err := bind(host, port)
+	if err != nil {
+		return err
+	}
+
+	previousHost, previousPort := s.config.Host, s.config.Port
+	s.config.Host, s.config.Port = host, port
+	if err := s.config.Save(config.GetConfigPath()); err != nil {
+		ln.Close()
+		s.config.Host, s.config.Port = previousHost, previousPort
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	s.moveListener(ln)
+	return nil
+}
+
+// moveListener serves on ln, which is already bound to the configured
+// host/port, drains the old listener and tells the Pi Agent where the
+// helper now listens
+func (s *Server) moveListener(ln net.Listener) {
+	srv := s.serve(ln)
+
+	s.listenerMu.Lock()
+	old := s.httpServer
+	s.httpServer = srv
+	s.listenerMu.Unlock()
+
+	oldAddr := ""
+	if old != nil {
+		oldAddr = old.Addr
+	}
+	log.Printf("🔁 API listener moved from %s to %s", oldAddr, srv.Addr)
+	s.recordAddress(srv.Addr)
+
+	s.events.Publish("config.listener_changed", map[string]interface{}{
+		"old_address": oldAddr,
+		"new_address": srv.Addr,
+		"host":        s.config.Host,
+		"port":        s.config.Port,
+	})
+
+	// The Pi Agent connects back on the registered port, so a move it
+	// doesn't hear about would cut off remote control
+	if s.piClient.Available() {
+		go func() {
+			if err := s.piClient.Register(); err != nil {
+				log.Printf("⚠️ Failed to tell the Pi Agent about the new listener: %v", err)
+				return
+			}
+			log.Printf("📡 Re-registered with the Pi Agent on port %d", s.config.Port)
+		}()
+	}
+
+	if old != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+			defer cancel()
+			if err := old.Shutdown(ctx); err != nil {
+				// Long-lived streams (SSE) don't drain on their own
+				old.Close()
+			}
+			log.Printf("Old listener %s drained", oldAddr)
+		}()
+	}
+}
+
+// recordAddress tells a supervisor where to send its health checks
+func (s *Server) recordAddress(addr string) {
+	if os.Getenv(supervisor.EnvSupervised) == "" {
+		return
+	}
+	if err := supervisor.RecordAddress(config.GetDataDir(), addr); err != nil {
+		log.Printf("⚠️ Failed to record the listen address for the supervisor: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/authenticode"
+	"github.com/apt-defender/helper-v2/internal/process"
+	"github.com/apt-defender/helper-v2/internal/telemetry"
+)
+
+// handleListeners lists listening sockets with the owning binary and its
+// signing status, to spot bind shells and RAT listeners
+func (s *Server) handleListeners(w http.ResponseWriter, r *http.Request) {
+	listeners, err := telemetry.GetListeners()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	images := map[uint32]string{}
+	paths := []string{}
+	for _, l := range listeners {
+		if _, ok := images[l.PID]; ok {
+			continue
+		}
+		image, _ := process.ImagePath(l.PID)
+		images[l.PID] = image
+		paths = append(paths, image)
+	}
+
+	results := authenticode.Check(paths)
+	exposed := 0
+	for i := range listeners {
+		l := &listeners[i]
+		l.ImagePath = images[l.PID]
+		if res, ok := results[strings.ToLower(l.ImagePath)]; ok {
+			l.Signature = res.Status
+			l.Signer = res.Signer
+		}
+		if l.Exposed {
+			exposed++
+		}
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"listeners": listeners,
+		"count":     len(listeners),
+		"exposed":   exposed,
+	})
+}
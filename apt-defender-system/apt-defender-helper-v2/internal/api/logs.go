@@ -0,0 +1,97 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/logging"
+	"github.com/apt-defender/helper-v2/internal/logship"
+)
+
+// newLogShipper forwards log records and security events to the destination
+// chosen by log_shipping; it returns nil when shipping is off
+func (s *Server) newLogShipper() *logship.Forwarder {
+	var send logship.Sender
+	destination := strings.ToLower(strings.TrimSpace(s.config.LogShipping))
+	switch destination {
+	case "", "pi":
+		destination, send = "pi", s.piClient.PostLogs
+	case "syslog":
+		destination, send = "syslog "+s.config.SyslogAddress, logship.Syslog(s.config.SyslogAddress)
+	case "off":
+		return nil
+	default:
+		log.Printf("⚠️ Unknown log_shipping %q, log shipping disabled", s.config.LogShipping)
+		return nil
+	}
+
+	forwarder := logship.New(destination, send, filepath.Join(config.GetDataDir(), "log-spool"), s.config.LogSpoolMB)
+	logging.AddSink(forwarder)
+	return forwarder
+}
+
+// handleLogShipping reports what has been shipped and what is spooled
+func (s *Server) handleLogShipping(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.logShip == nil {
+		s.sendJSON(w, logship.Status{Destination: "off"})
+		return
+	}
+	s.sendJSON(w, s.logShip.Status())
+}
+
+// handleLogs pages through the helper's own log file for the dashboard's
+// log viewer: level is the minimum level, q searches messages and
+// attributes, and after is the offset from the previous response for
+// following the file as it grows
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := logging.Filter{
+		Component: query.Get("component"),
+		Search:    strings.TrimSpace(query.Get("q")),
+		Limit:     1000,
+	}
+	if v := query.Get("level"); v != "" {
+		level, err := logging.ParseLevel(v)
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		filter.Level = level
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.sendError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = n
+	}
+	if v := query.Get("after"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			s.sendError(w, http.StatusBadRequest, "Invalid after")
+			return
+		}
+		filter.After = n
+	}
+
+	page, err := logging.Read(logging.File, filter)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, page)
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const ndjsonType = "application/x-ndjson"
+
+// Records are flushed to the client in batches of this many
+const ndjsonFlushEvery = 100
+
+// wantsNDJSON reports whether the client sent Accept: application/x-ndjson
+func wantsNDJSON(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), ndjsonType) {
+			return true
+		}
+	}
+	return false
+}
+
+// ndjsonWriter writes one JSON record per line as results are produced,
+// instead of encoding a whole listing in memory first. Once streaming has
+// started the status can't change, so a failure is reported as a final
+// {"error": "..."} record.
+type ndjsonWriter struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	pending int
+}
+
+func newNDJSONWriter(w http.ResponseWriter) *ndjsonWriter {
+	w.Header().Set("Content-Type", ndjsonType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	return &ndjsonWriter{w: w, enc: json.NewEncoder(w)}
+}
+
+// Write sends one record; an error means the client has gone away
+func (n *ndjsonWriter) Write(record interface{}) error {
+	if err := n.enc.Encode(record); err != nil {
+		return err
+	}
+	if n.pending++; n.pending >= ndjsonFlushEvery {
+		n.Flush()
+	}
+	return nil
+}
+
+// Fail ends the stream with an error record
+func (n *ndjsonWriter) Fail(err error) {
+	n.enc.Encode(map[string]string{"error": err.Error()})
+	n.Flush()
+}
+
+func (n *ndjsonWriter) Flush() {
+	n.pending = 0
+	if f, ok := n.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// sendNDJSON streams items one per line
+func sendNDJSON[T any](w http.ResponseWriter, items []T) {
+	n := newNDJSONWriter(w)
+	for _, item := range items {
+		if n.Write(item) != nil {
+			return
+		}
+	}
+	n.Flush()
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Tokenless access is granted by where a request comes from, and a browser
+// on this PC will send requests here for any site it visits. A page on
+// another site can POST to http://127.0.0.1:7890 with a text/plain body the
+// handlers still decode as JSON, so state-changing requests without a token
+// must come from a page the helper served. A site can also point its own
+// name at 127.0.0.1 (DNS rebinding), making its pages same-origin with the
+// helper; the Host it sends is then still its own name, so tokenless
+// requests must also name this PC.
+
+// sameOrigin reports whether a browser request came from a page served by
+// this helper. Browsers send Origin with every POST, PUT, PATCH and DELETE,
+// so a request without one isn't from the dashboard page.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// validHost reports whether a request's Host names this PC: localhost, a
+// loopback address, the configured host, an address of this PC when
+// listening on all of them, or its computer name
+func (s *Server) validHost(hostport string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() {
+			return true
+		}
+		if bind := net.ParseIP(s.config.Host); bind != nil && !bind.IsUnspecified() {
+			return ip.Equal(bind)
+		}
+		addrs, _ := net.InterfaceAddrs()
+		for _, addr := range addrs {
+			if n, ok := addr.(*net.IPNet); ok && n.IP.Equal(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if strings.EqualFold(host, s.config.Host) {
+		return true
+	}
+	name, err := os.Hostname()
+	return err == nil && (strings.EqualFold(host, name) || strings.EqualFold(host, name+".local"))
+}
+
+// refuseHost answers 403 to a tokenless request whose Host isn't this PC
+// and reports whether it did. Requests with a token are left to the token
+// check.
+func (s *Server) refuseHost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" || s.validHost(r.Host) {
+		return false
+	}
+	log.Printf("🚫 Refused %s %s from %s: Host %q is not this PC", r.Method, r.URL.Path, r.RemoteAddr, r.Host)
+	s.sendError(w, http.StatusForbidden, "Host not allowed")
+	return true
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// pageParams reads limit and offset. A limit of 0 means everything.
+func pageParams(r *http.Request, defaultLimit int) (offset, limit int, err error) {
+	query := r.URL.Query()
+	limit = defaultLimit
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			return 0, 0, errors.New("Invalid limit")
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, errors.New("Invalid offset")
+		}
+	}
+	return offset, limit, nil
+}
+
+// page returns the items from offset, at most limit of them (0 for no limit)
+func page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/playbook"
+)
+
+// handlePlaybooks lists (GET), adds/replaces (POST) or deletes (DELETE ?id=) playbooks
+func (s *Server) handlePlaybooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.sendJSON(w, map[string]interface{}{"playbooks": s.playbooks.List()})
+
+	case http.MethodPost:
+		var req struct {
+			Playbooks []playbook.Playbook `json:"playbooks"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Playbooks) == 0 {
+			s.sendError(w, http.StatusBadRequest, "Invalid request")
+			return
+		}
+		if err := s.playbooks.Put(req.Playbooks); err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("📘 Received %d playbook(s) from Pi Agent", len(req.Playbooks))
+		s.sendJSON(w, map[string]interface{}{"playbooks": s.playbooks.List()})
+
+	case http.MethodDelete:
+		if err := s.playbooks.Delete(r.URL.Query().Get("id")); err != nil {
+			s.sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.sendJSON(w, map[string]string{"message": "Playbook deleted"})
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (s *Server) handlePlaybookExecutions(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, map[string]interface{}{"executions": s.playbooks.GetExecutions()})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+)
+
+type portRequest struct {
+	Service   string `json:"service"`
+	Protocol  string `json:"protocol"`
+	Ports     string `json:"ports"`
+	Direction string `json:"direction"`
+}
+
+// handleBlockPort blocks traffic on specific ports, or a named service such as smb
+func (s *Server) handleBlockPort(w http.ResponseWriter, r *http.Request) {
+	s.handlePortRule(w, r, "network.block_port", control.BlockPorts)
+}
+
+// handleUnblockPort removes a port block with the same arguments
+func (s *Server) handleUnblockPort(w http.ResponseWriter, r *http.Request) {
+	s.handlePortRule(w, r, "network.unblock_port", control.UnblockPorts)
+}
+
+func (s *Server) handlePortRule(w http.ResponseWriter, r *http.Request, action string,
+	apply func(protocol, ports, direction string) ([]control.PortRule, error)) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req portRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Service == "" && req.Ports == "") {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	targets := []control.PortRule{{Protocol: req.Protocol, Ports: req.Ports}}
+	if req.Service != "" {
+		var err error
+		if targets, err = control.ServicePorts(req.Service); err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	rules := []control.PortRule{}
+	var err error
+	for _, target := range targets {
+		var applied []control.PortRule
+		if applied, err = apply(target.Protocol, target.Ports, req.Direction); err != nil {
+			break
+		}
+		rules = append(rules, applied...)
+	}
+
+	s.recordAudit(r, action, req.Service+req.Ports, err, map[string]interface{}{
+		"protocol":  req.Protocol,
+		"direction": req.Direction,
+		"rules":     rules,
+	})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{"rules": rules})
+}
+
+// handleBlockedPorts lists port blocks managed by the helper
+func (s *Server) handleBlockedPorts(w http.ResponseWriter, r *http.Request) {
+	rules, err := control.BlockedPorts()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
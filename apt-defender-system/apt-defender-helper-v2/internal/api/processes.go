@@ -0,0 +1,327 @@
+package api
+
+import (
+	"cmp"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/authenticode"
+	"github.com/apt-defender/helper-v2/internal/process"
+)
+
+// processPageSize is the /api/v2/processes default page; v1 returns every process
+const processPageSize = 100
+
+var processSorts = map[string]func(a, b process.Info) int{
+	"pid":    func(a, b process.Info) int { return cmp.Compare(a.PID, b.PID) },
+	"name":   func(a, b process.Info) int { return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) },
+	"user":   func(a, b process.Info) int { return cmp.Compare(strings.ToLower(a.User), strings.ToLower(b.User)) },
+	"cpu":    func(a, b process.Info) int { return cmp.Compare(a.CPUPercent, b.CPUPercent) },
+	"memory": func(a, b process.Info) int { return cmp.Compare(a.MemoryBytes, b.MemoryBytes) },
+}
+
+// handleProcesses lists running processes with owner, CPU and memory.
+// name and user filter by case-insensitive substring, sort is pid, name,
+// user, cpu or memory (order asc or desc; cpu and memory default to
+// highest first) and limit/offset page through the result.
+func (s *Server) handleProcesses(w http.ResponseWriter, r *http.Request) {
+	s.listProcesses(w, r, 0)
+}
+
+// handleProcessesV2 is handleProcesses with a default page size
+func (s *Server) handleProcessesV2(w http.ResponseWriter, r *http.Request) {
+	s.listProcesses(w, r, processPageSize)
+}
+
+func (s *Server) listProcesses(w http.ResponseWriter, r *http.Request, defaultLimit int) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	offset, limit, err := pageParams(r, defaultLimit)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sortBy := strings.ToLower(query.Get("sort"))
+	if sortBy == "" {
+		sortBy = "pid"
+	}
+	compare, ok := processSorts[sortBy]
+	if !ok {
+		s.sendError(w, http.StatusBadRequest, "sort must be pid, name, user, cpu or memory")
+		return
+	}
+	descending := sortBy == "cpu" || sortBy == "memory"
+	switch strings.ToLower(query.Get("order")) {
+	case "":
+	case "asc":
+		descending = false
+	case "desc":
+		descending = true
+	default:
+		s.sendError(w, http.StatusBadRequest, "order must be asc or desc")
+		return
+	}
+
+	all, err := process.ListDetailed()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	name := strings.ToLower(query.Get("name"))
+	user := strings.ToLower(query.Get("user"))
+	matched := []process.Info{}
+	for _, p := range all {
+		if name != "" && !strings.Contains(strings.ToLower(p.Name), name) {
+			continue
+		}
+		if user != "" && !strings.Contains(strings.ToLower(p.User), user) {
+			continue
+		}
+		matched = append(matched, p)
+	}
+
+	slices.SortStableFunc(matched, func(a, b process.Info) int {
+		if c := compare(a, b); c != 0 {
+			if descending {
+				return -c
+			}
+			return c
+		}
+		return cmp.Compare(a.PID, b.PID)
+	})
+
+	processes := page(matched, offset, limit)
+	if wantsNDJSON(r) {
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(matched)))
+		sendNDJSON(w, processes)
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"processes": processes,
+		"count":     len(processes),
+		"total":     len(matched),
+		"offset":    offset,
+		"limit":     limit,
+	})
+}
+
+// handleKillByName terminates every process matching a name or image path glob
+func (s *Server) handleKillByName(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Pattern string `json:"pattern"`
+		DryRun  bool   `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Pattern == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	matches, err := process.KillMatching(req.Pattern, req.DryRun)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	killed := 0
+	for _, m := range matches {
+		if m.Killed {
+			killed++
+		}
+	}
+
+	if !req.DryRun {
+		s.recordAudit(r, "process.kill", req.Pattern, nil, map[string]interface{}{"matched": len(matches), "killed": killed})
+	}
+
+	if req.DryRun {
+		log.Printf("🔎 Kill dry-run for %q matched %d processes", req.Pattern, len(matches))
+	} else {
+		log.Printf("💀 Killed %d/%d processes matching %q", killed, len(matches), req.Pattern)
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"pattern": req.Pattern,
+		"dry_run": req.DryRun,
+		"matches": matches,
+		"matched": len(matches),
+		"killed":  killed,
+	})
+}
+
+// handleProcessKill terminates a single process by PID
+func (s *Server) handleProcessKill(w http.ResponseWriter, r *http.Request) {
+	pid, ok := s.pathPID(w, r)
+	if !ok {
+		return
+	}
+
+	match, err := process.KillPID(pid)
+	target := match.ImagePath
+	if target == "" {
+		target = match.Name
+	}
+	s.recordAudit(r, "process.kill", target, err, map[string]interface{}{"pid": pid})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	log.Printf("💀 Killed %s (PID %d)", match.Name, pid)
+	s.sendJSON(w, match)
+}
+
+// pathPID parses the {pid} segment of process routes
+func (s *Server) pathPID(w http.ResponseWriter, r *http.Request) (uint32, bool) {
+	pid, err := strconv.ParseUint(r.PathValue("pid"), 10, 32)
+	if err != nil || pid == 0 {
+		s.sendError(w, http.StatusBadRequest, "Invalid pid")
+		return 0, false
+	}
+	return uint32(pid), true
+}
+
+// handleProcessModules lists the DLLs mapped into a process; signatures=true
+// adds Authenticode status to spot injected, unsigned modules
+func (s *Server) handleProcessModules(w http.ResponseWriter, r *http.Request) {
+	pid, ok := s.pathPID(w, r)
+	if !ok {
+		return
+	}
+
+	modules, err := process.Modules(pid)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if r.URL.Query().Get("signatures") == "true" {
+		paths := make([]string, len(modules))
+		for i, m := range modules {
+			paths[i] = m.Path
+		}
+		results := authenticode.Check(paths)
+		for i := range modules {
+			if res, ok := results[strings.ToLower(modules[i].Path)]; ok {
+				modules[i].Signature = res.Status
+				modules[i].Signer = res.Signer
+			}
+		}
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"pid":     pid,
+		"modules": modules,
+		"count":   len(modules),
+	})
+}
+
+// handleProcessHandles lists a process's open file and registry key handles
+func (s *Server) handleProcessHandles(w http.ResponseWriter, r *http.Request) {
+	pid, ok := s.pathPID(w, r)
+	if !ok {
+		return
+	}
+
+	handles, err := process.Handles(pid)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"pid":     pid,
+		"handles": handles,
+		"count":   len(handles),
+	})
+}
+
+// handleHandleSearch finds every process holding a handle to a path
+func (s *Server) handleHandleSearch(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		s.sendError(w, http.StatusBadRequest, "path is required")
+		return
+	}
+
+	handles, err := process.FindHandles(path)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"path":    path,
+		"handles": handles,
+		"count":   len(handles),
+	})
+}
+
+// handleProcessDump writes a full-memory dump of a process into the staging
+// area and optionally uploads it to the Pi Agent for offline analysis
+func (s *Server) handleProcessDump(w http.ResponseWriter, r *http.Request) {
+	pid, ok := s.pathPID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Upload bool `json:"upload"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid request")
+			return
+		}
+	}
+
+	path, err := s.staging.NewPath("dump", ".dmp")
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Printf("🧠 Dumping memory of PID %d", pid)
+	if err := process.Dump(pid, path); err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := s.enforceArtifactLimit(path); err != nil {
+		s.sendError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+
+	artifact, err := s.staging.Describe(path)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Printf("✅ Memory dump of PID %d staged as %s (%d bytes)", pid, artifact.Name, artifact.Size)
+
+	result := map[string]interface{}{
+		"pid":      pid,
+		"artifact": artifact,
+		"download": "/api/v1/staging/" + artifact.Name,
+	}
+	if req.Upload {
+		if err := s.startUpload(path, artifact); err != nil {
+			result["upload_error"] = err.Error()
+		} else {
+			result["upload"] = "started"
+		}
+	}
+	s.sendJSON(w, result)
+}
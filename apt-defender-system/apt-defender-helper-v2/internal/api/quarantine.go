@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+)
+
+// handleQuarantine lists quarantined items (GET) or permanently deletes one (DELETE ?id=)
+func (s *Server) handleQuarantine(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := s.quarantine.List()
+		if err != nil {
+			s.sendError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		var total int64
+		for _, item := range items {
+			total += item.Size
+		}
+		retention := s.quarantine.Retention()
+		s.sendJSON(w, map[string]interface{}{
+			"items":      items,
+			"count":      len(items),
+			"total_size": total,
+			"retention": map[string]interface{}{
+				"max_age_days": int(retention.MaxAge.Hours() / 24),
+				"max_bytes":    retention.MaxBytes,
+			},
+		})
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		item, err := s.quarantine.Delete(id)
+		target := ""
+		if item != nil {
+			target = item.OriginalPath
+		}
+		s.recordAudit(r, "quarantine.delete", target, err, map[string]interface{}{"id": id})
+		if err != nil {
+			s.sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.sendJSON(w, map[string]string{"id": id, "status": "deleted"})
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
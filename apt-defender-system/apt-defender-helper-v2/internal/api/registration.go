@@ -1,11 +1,20 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/logging"
+	"github.com/apt-defender/helper-v2/internal/piclient"
 )
 
 type RegistrationNotification struct {
@@ -41,3 +50,253 @@ func (s *Server) handleRegistrationNotification(w http.ResponseWriter, r *http.R
 		"status":  "connected",
 	})
 }
+
+// handlePair pairs with a Pi Agent over HTTPS. Without a fingerprint it only
+// returns the Pi's certificate fingerprint for the user to compare with the
+// one the Pi displays; the pairing code is sent once they confirm it.
+func (s *Server) handlePair(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		PiAddress   string `json:"pi_address"`
+		PairingCode string `json:"pairing_code"`
+		Fingerprint string `json:"fingerprint"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PiAddress == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	host, port, err := piclient.SplitAddress(req.PiAddress)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Fingerprint == "" {
+		fingerprint, err := piclient.Fingerprint(host, port)
+		if err != nil {
+			s.sendError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		s.sendJSON(w, map[string]string{
+			"status":      "confirm",
+			"pi_address":  net.JoinHostPort(host, strconv.Itoa(port)),
+			"fingerprint": fingerprint,
+		})
+		return
+	}
+
+	// Pairing again while paired replaces the old pairing, e.g. after the
+	// Pi was reset and forgot this PC
+	repair := s.config.RegisteredWithPi
+	result, err := piclient.Pair(s.config, host, port, req.PairingCode, req.Fingerprint)
+	s.recordAudit(r, "pair", req.PiAddress, err, map[string]interface{}{"fingerprint": piclient.DisplayFingerprint(req.Fingerprint), "repair": repair})
+	if err != nil {
+		s.sendError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if err := s.config.Save(config.GetConfigPath()); err != nil {
+		log.Printf("⚠️ Failed to save config after pairing: %v", err)
+	}
+
+	log.Printf("🔐 Paired with Pi Agent at %s:%d (certificate %s)", result.PiAgentIP, result.PiAgentPort, result.Fingerprint)
+	logging.SetDeviceID(result.DeviceID)
+	s.piLink.Kick()
+	s.sendJSON(w, result)
+}
+
+// localOrControl lets the dashboard through without a token when it has
+// control (always on this PC, per dashboard_scope elsewhere) and requires a
+// control token otherwise
+func (s *Server) localOrControl(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.dashboardPermits(w, r, config.ScopeControl, next) {
+			return
+		}
+		s.authMiddleware(next)(w, r)
+	}
+}
+
+// handleUnpair lets the paired Pi Agent revoke its own pairing. The Pi's
+// address and pinned certificate are forgotten, the client certificate it
+// issued is no longer presented and the auth token is rotated, so the credentials the Pi held stop working with this response.
+func (s *Server) handleUnpair(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	if !s.config.RegisteredWithPi || host != s.config.PiAgentIP {
+		s.sendError(w, http.StatusForbidden, "Only the paired Pi Agent can unpair")
+		return
+	}
+
+	previous := s.config.PiAgentIP
+	err := s.unpair("pi")
+	s.recordAudit(r, "unpair", previous, err, nil)
+	if err != nil {
+		s.sendError(w, unpairStatus(err), err.Error())
+		return
+	}
+
+	log.Printf("🔓 Unpaired by Pi Agent at %s, auth token rotated", previous)
+	s.sendJSON(w, map[string]string{"status": "unpaired"})
+}
+
+// PairingStatus is what the dashboard's pairing page shows
+type PairingStatus struct {
+	Paired            bool                `json:"paired"`
+	PiAgentIP         string              `json:"pi_agent_ip,omitempty"`
+	PiAgentHost       string              `json:"pi_agent_host,omitempty"`
+	PiAgentPort       int                 `json:"pi_agent_port,omitempty"`
+	Fingerprint       string              `json:"fingerprint,omitempty"`
+	DeviceID          int                 `json:"device_id,omitempty"`
+	ClientCertificate bool                `json:"client_certificate"`
+	Link              piclient.LinkStatus `json:"link"`
+	TokenOverridden   bool                `json:"token_overridden"` // auth_token comes from the environment or a flag, so unpairing is refused
+}
+
+// handlePairing reports the current pairing (GET) or drops it from this
+// PC (DELETE). The Pi Agent isn't told; its credentials stop working
+// because the auth token is rotated, and it shows the PC as offline until
+// the device is deleted there too.
+func (s *Server) handlePairing(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.sendJSON(w, PairingStatus{
+			Paired:            s.config.RegisteredWithPi,
+			PiAgentIP:         s.config.PiAgentIP,
+			PiAgentHost:       s.config.PiAgentHost,
+			PiAgentPort:       s.config.PiAgentPort,
+			Fingerprint:       piclient.DisplayFingerprint(s.config.PiCertFingerprint),
+			DeviceID:          s.config.PiDeviceID,
+			ClientCertificate: s.config.EnableMTLS,
+			Link:              s.piLink.Status(),
+			TokenOverridden:   s.config.Overridden("auth_token"),
+		})
+	case http.MethodDelete:
+		if !s.config.RegisteredWithPi {
+			s.sendError(w, http.StatusConflict, "Not paired with a Pi Agent")
+			return
+		}
+		previous := s.config.PiAgentIP
+		err := s.unpair("pc")
+		s.recordAudit(r, "unpair", previous, err, nil)
+		if err != nil {
+			s.sendError(w, unpairStatus(err), err.Error())
+			return
+		}
+		log.Printf("🔓 Unpaired from Pi Agent at %s on this PC, auth token rotated", previous)
+		s.sendJSON(w, map[string]string{"status": "unpaired"})
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// errTokenOverridden refuses an unpair that couldn't revoke the Pi's
+// credentials: an auth_token from the environment or a flag isn't saved, so
+// the rotated token would revert to the old one on the next start
+var errTokenOverridden = errors.New("auth_token is set by an environment variable or command-line flag, so it can't be rotated; remove the override (or set a new token there) and unpair again")
+
+// unpair forgets the Pi Agent's address, pinned certificate and issued
+// credentials, rotates the auth token and clears the outbox. by is "pi"
+// when the Pi revoked the pairing and "pc" when it was dropped here. If the
+// config can't be saved nothing changes: the old token would come back on
+// the next start while the Pi was told its credentials were revoked.
+func (s *Server) unpair(by string) error {
+	if s.config.Overridden("auth_token") {
+		log.Printf("⚠️ Unpair refused: auth_token is overridden and would come back after a restart")
+		return errTokenOverridden
+	}
+
+	token, err := newAuthToken()
+	if err != nil {
+		return err
+	}
+
+	cfg := s.config
+	previous := *cfg
+	cfg.AuthToken = token
+	cfg.PiAgentIP = ""
+	cfg.PiAgentHost = ""
+	cfg.RegisteredWithPi = false
+	cfg.PiCertFingerprint = ""
+	cfg.EnableMTLS = false
+	cfg.ClientCertFile = ""
+	cfg.ClientKeyFile = ""
+	cfg.PiCAFile = ""
+	cfg.PiDeviceID = 0
+	cfg.PiAccessToken = ""
+	if err := cfg.Save(config.GetConfigPath()); err != nil {
+		cfg.AuthToken = previous.AuthToken
+		cfg.PiAgentIP = previous.PiAgentIP
+		cfg.PiAgentHost = previous.PiAgentHost
+		cfg.RegisteredWithPi = previous.RegisteredWithPi
+		cfg.PiCertFingerprint = previous.PiCertFingerprint
+		cfg.EnableMTLS = previous.EnableMTLS
+		cfg.ClientCertFile = previous.ClientCertFile
+		cfg.ClientKeyFile = previous.ClientKeyFile
+		cfg.PiCAFile = previous.PiCAFile
+		cfg.PiDeviceID = previous.PiDeviceID
+		cfg.PiAccessToken = previous.PiAccessToken
+		log.Printf("⚠️ Unpair failed, still paired with %s: %v", previous.PiAgentIP, err)
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	logging.SetDeviceID(0)
+	piclient.RemoveClientCert(previous.ClientCertFile, previous.ClientKeyFile, previous.PiCAFile)
+	if err := s.piClient.DiscardOutbox(); err != nil {
+		log.Printf("⚠️ Failed to clear the Pi outbox after unpair: %v", err)
+	}
+
+	s.events.Publish("pi.unpaired", map[string]interface{}{"pi_agent_ip": previous.PiAgentIP, "by": by})
+	return nil
+}
+
+// handlePiEvents streams pairing and connection changes to the dashboard
+func (s *Server) handlePiEvents(w http.ResponseWriter, r *http.Request) {
+	s.streamEvents(w, r, "pi.")
+}
+
+// handlePiStatus reports whether the paired Pi Agent is reachable
+func (s *Server) handlePiStatus(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, s.piLink.Status())
+}
+
+// piCheckTimeout covers a health check plus the LAN search that follows a
+// failed one
+const piCheckTimeout = 20 * time.Second
+
+// handlePiCheck contacts the Pi Agent now instead of waiting for the next
+// heartbeat or retry, and reports how that went
+func (s *Server) handlePiCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !s.config.RegisteredWithPi {
+		s.sendError(w, http.StatusConflict, "Not paired with a Pi Agent")
+		return
+	}
+	s.sendJSON(w, s.piLink.CheckNow(piCheckTimeout))
+}
+
+// unpairStatus is the HTTP status for an unpair error
+func unpairStatus(err error) int {
+	if errors.Is(err, errTokenOverridden) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func newAuthToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate auth token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/remoteaccess"
+)
+
+// handleRemoteAccess reports (GET) whether RDP/WinRM/SSH are locked down
+func (s *Server) handleRemoteAccess(w http.ResponseWriter, r *http.Request) {
+	state := remoteaccess.Status(config.GetDataDir())
+	s.sendJSON(w, map[string]interface{}{
+		"disabled": state != nil,
+		"state":    state,
+	})
+}
+
+// handleRemoteAccessDisable turns off RDP, WinRM and SSH in one call
+func (s *Server) handleRemoteAccessDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	state, err := remoteaccess.Disable(config.GetDataDir())
+	s.recordAudit(r, "system.remote_access_disable", "", err, map[string]interface{}{"state": state})
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, state)
+}
+
+// handleRemoteAccessEnable restores the remote access configuration saved at lockdown
+func (s *Server) handleRemoteAccessEnable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	state, err := remoteaccess.Enable(config.GetDataDir())
+	s.recordAudit(r, "system.remote_access_enable", "", err, map[string]interface{}{"state": state})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.sendJSON(w, state)
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Replay protection modes
+const (
+	replayOff     = "off"
+	replayAudit   = "audit"
+	replayEnforce = "enforce"
+)
+
+const (
+	defaultReplayWindow = 5 * time.Minute
+	maxNonces           = 100000
+	replayWarnInterval  = 10 * time.Minute
+	signedBodyMemory    = 1 << 20 // Larger bodies are spooled to a temp file while they are hashed
+)
+
+// replayGuard remembers the nonces of recent token requests. A captured
+// request can only be replayed within the timestamp window, and within that
+// window its nonce has already been seen.
+type replayGuard struct {
+	mutex     sync.Mutex
+	nonces    map[string]time.Time // nonce -> when it can be forgotten
+	lastPrune time.Time
+	warned    map[string]time.Time // token -> last logged, for audit mode
+}
+
+func newReplayGuard() *replayGuard {
+	return &replayGuard{
+		nonces: make(map[string]time.Time),
+		warned: make(map[string]time.Time),
+	}
+}
+
+// checkReplay applies replay_protection to a request authenticated with
+// token, which is called name in logs. In audit mode failures are logged and
+// the request proceeds.
+func (s *Server) checkReplay(r *http.Request, name, token string) error {
+	mode := strings.ToLower(strings.TrimSpace(s.config.ReplayProtection))
+	if mode == replayOff {
+		return nil
+	}
+
+	window := time.Duration(s.config.ReplayWindow) * time.Second
+	if window <= 0 {
+		window = defaultReplayWindow
+	}
+	timestamp, nonce := r.Header.Get("X-Timestamp"), r.Header.Get("X-Nonce")
+	err := s.replay.check(timestamp, nonce, window, time.Now(), func() error {
+		return checkSignature(r, token, timestamp, nonce)
+	})
+	if err == nil || mode == replayEnforce {
+		return err
+	}
+	if s.replay.shouldWarn(name, time.Now()) {
+		log.Printf("⚠️ Replay protection (audit): %s %s with token %q from %s: %v", r.Method, r.URL.Path, name, r.RemoteAddr, err)
+	}
+	return nil
+}
+
+// checkSignature verifies X-Signature: the hex HMAC-SHA256, keyed by the
+// token, of timestamp|nonce|method|request URI|hex SHA-256 of the body. It
+// ties the timestamp and nonce to this one request, so they can't be moved
+// onto another. The request URI is the path and query exactly as sent.
+func checkSignature(r *http.Request, token, timestamp, nonce string) error {
+	signature := r.Header.Get("X-Signature")
+	if signature == "" {
+		return fmt.Errorf("missing X-Signature")
+	}
+	body, err := bodyDigest(r)
+	if err != nil {
+		return fmt.Errorf("failed to read the request body: %w", err)
+	}
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(strings.Join([]string{timestamp, nonce, r.Method, r.RequestURI, body}, "|")))
+	want := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(strings.ToLower(signature))) {
+		return fmt.Errorf("X-Signature does not match the request")
+	}
+	return nil
+}
+
+// bodyDigest returns the hex SHA-256 of r's body and puts an unread copy
+// back for the handler. File puts can be large, so anything past
+// signedBodyMemory goes to a temp file that is removed with the request.
+func bodyDigest(r *http.Request) (string, error) {
+	h := sha256.New()
+	if r.Body == nil || r.Body == http.NoBody {
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	var buf bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(&buf, h), io.LimitReader(r.Body, signedBodyMemory+1))
+	if err != nil {
+		return "", err
+	}
+	if n <= signedBodyMemory {
+		r.Body = io.NopCloser(&buf)
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	tmp, err := os.CreateTemp("", "aptd-body-*")
+	if err != nil {
+		return "", err
+	}
+	context.AfterFunc(r.Context(), func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	})
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(io.MultiWriter(tmp, h), r.Body); err != nil {
+		return "", err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	r.Body = io.NopCloser(tmp)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// check validates the timestamp, then runs verify (the signature check),
+// and only then records the nonce, so a forged request can't use up the
+// nonce of a genuine one
+func (g *replayGuard) check(timestamp, nonce string, window time.Duration, now time.Time, verify func() error) error {
+	if timestamp == "" {
+		return fmt.Errorf("missing X-Timestamp")
+	}
+	at, err := parseRequestTime(timestamp)
+	if err != nil {
+		return fmt.Errorf("invalid X-Timestamp %q", timestamp)
+	}
+	if skew := now.Sub(at); skew > window || skew < -window {
+		return fmt.Errorf("X-Timestamp is %s off the helper's clock (allowed %s)", skew.Round(time.Second), window)
+	}
+	if len(nonce) < 16 || len(nonce) > 128 {
+		return fmt.Errorf("missing or malformed X-Nonce (16-128 characters)")
+	}
+	if err := verify(); err != nil {
+		return err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if now.Sub(g.lastPrune) > time.Second || len(g.nonces) >= maxNonces {
+		for n, expires := range g.nonces {
+			if now.After(expires) {
+				delete(g.nonces, n)
+			}
+		}
+		g.lastPrune = now
+	}
+	if _, seen := g.nonces[nonce]; seen {
+		return fmt.Errorf("replayed request (nonce already used)")
+	}
+	if len(g.nonces) >= maxNonces {
+		return fmt.Errorf("too many requests in the replay window")
+	}
+	// The timestamp stays acceptable until at+window, so the nonce must be
+	// remembered at least that long
+	g.nonces[nonce] = at.Add(window)
+	return nil
+}
+
+// shouldWarn rate-limits audit mode logging to once per token per interval
+func (g *replayGuard) shouldWarn(key string, now time.Time) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if last, ok := g.warned[key]; ok && now.Sub(last) < replayWarnInterval {
+		return false
+	}
+	g.warned[key] = now
+	return true
+}
+
+// parseRequestTime accepts Unix seconds or RFC 3339
+func parseRequestTime(v string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// checkReplayMode warns about a replay_protection value that isn't known
+func checkReplayMode(mode string) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case replayOff, replayAudit, replayEnforce:
+	default:
+		log.Printf("⚠️ Unknown replay_protection %q (off, audit or enforce), treating it as audit", mode)
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/audit"
+	"github.com/apt-defender/helper-v2/internal/control"
+	"github.com/apt-defender/helper-v2/internal/quarantine"
+	"github.com/apt-defender/helper-v2/internal/report"
+	"github.com/apt-defender/helper-v2/internal/telemetry"
+	"github.com/apt-defender/helper-v2/internal/version"
+)
+
+// maxReportAudit caps the audit entries in a report, newest first
+const maxReportAudit = 1000
+
+// handleReport downloads an incident report bundle (report.json and
+// report.html in a zip) with telemetry, threats, quarantine, firewall rules
+// and the audit entries since since (default 168h)
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	since := r.URL.Query().Get("since")
+	if since == "" {
+		since = "168h"
+	}
+	auditSince, err := parseAuditTime(since)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid since: "+err.Error())
+		return
+	}
+
+	rep := s.buildReport(auditSince)
+	var buf bytes.Buffer
+	err = rep.WriteBundle(&buf)
+	s.recordAudit(r, "report.export", rep.Filename(), err, map[string]interface{}{"since": since})
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+rep.Filename()+`"`)
+	w.Write(buf.Bytes())
+}
+
+// buildReport collects every section it can; failures are noted in the report
+func (s *Server) buildReport(auditSince time.Time) *report.Report {
+	hostname, _ := os.Hostname()
+	rep := &report.Report{
+		GeneratedAt:    time.Now(),
+		Hostname:       hostname,
+		AgentVersion:   version.Version,
+		PiAgentIP:      s.config.PiAgentIP,
+		AuditSince:     auditSince,
+		Scan:           s.scanner.GetStatus(),
+		Threats:        []report.Threat{},
+		FalsePositives: s.scanner.FalsePositives().List(),
+		Quarantine:     []quarantine.Item{},
+		Firewall:       []control.FirewallRule{},
+		Audit:          []audit.Entry{},
+	}
+
+	if stats, err := telemetry.GetSystemStats(); err != nil {
+		rep.Fail("telemetry", err)
+	} else {
+		rep.Telemetry = stats
+	}
+
+	if items, err := s.quarantine.List(); err != nil {
+		rep.Fail("quarantine", err)
+	} else {
+		rep.Quarantine = items
+	}
+	for _, t := range rep.Scan.Threats {
+		rep.Threats = append(rep.Threats, report.Threat(threatState(t, rep.Quarantine)))
+	}
+
+	if rules, err := control.ManagedRules(); err != nil {
+		rep.Fail("firewall", err)
+	} else {
+		rep.Firewall = rules
+		for _, rule := range rules {
+			if rule.Kind == control.RuleKindAll && rule.Enabled && rule.Action == "Block" {
+				rep.Isolated = true
+			}
+		}
+	}
+
+	if entries, _, err := s.audit.Query(audit.Filter{Since: auditSince, Limit: maxReportAudit}); err != nil {
+		rep.Fail("audit", err)
+	} else {
+		rep.Audit = entries
+	}
+
+	return rep
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/supervisor"
+)
+
+// reportRestarts tells the Pi Agent about restarts the supervisor made since
+// the helper last ran. They go through the outbox, so they reach the Pi even
+// if it is down right now.
+func (s *Server) reportRestarts() {
+	restarts, err := supervisor.TakePending(config.GetDataDir())
+	if err != nil {
+		log.Printf("⚠️ Failed to read pending restarts: %v", err)
+	}
+	for _, restart := range restarts {
+		log.Printf("🐕 Helper was restarted by the supervisor after a %s at %s", restart.Reason, restart.Time.Format("2006-01-02 15:04:05"))
+		s.events.Publish("helper.restarted", restart)
+	}
+	if len(restarts) == 0 || !s.piClient.Available() {
+		return
+	}
+	go func() {
+		for _, restart := range restarts {
+			if err := s.piClient.Notify("helper.restarted", restart); err != nil {
+				log.Printf("⚠️ Failed to report restart to Pi Agent: %v", err)
+			}
+		}
+	}()
+}
+
+// handleRestarts lists the supervisor's restarts, newest first
+func (s *Server) handleRestarts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	restarts, err := supervisor.History(config.GetDataDir())
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"supervised": os.Getenv(supervisor.EnvSupervised) != "",
+		"restarts":   restarts,
+		"count":      len(restarts),
+	})
+}
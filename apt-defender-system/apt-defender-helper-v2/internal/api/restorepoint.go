@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+)
+
+// restorePointBefore creates a System Restore point ahead of a destructive
+// action when the request asks for one, or restore_points is enabled and the
+// request doesn't opt out. The action should not go ahead if this fails.
+func (s *Server) restorePointBefore(r *http.Request, requested *bool, action string) error {
+	enabled := s.config.RestorePoints
+	if requested != nil {
+		enabled = *requested
+	}
+	if !enabled {
+		return nil
+	}
+
+	seq, err := control.CreateRestorePoint("APT Defender: " + action)
+	s.recordAudit(r, "system.restore_point", action, err, map[string]interface{}{"sequence_number": seq})
+	if err != nil {
+		return fmt.Errorf("failed to create restore point (set \"restore_point\": false to continue without one): %w", err)
+	}
+	return nil
+}
+
+// handleRestorePoints lists restore points (GET) or creates one (POST)
+func (s *Server) handleRestorePoints(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		points, err := control.RestorePoints()
+		if err != nil {
+			s.sendError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.sendJSON(w, map[string]interface{}{
+			"restore_points": points,
+			"count":          len(points),
+		})
+
+	case http.MethodPost:
+		var req struct {
+			Description string `json:"description"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid request")
+			return
+		}
+		if req.Description == "" {
+			req.Description = "manual"
+		}
+
+		seq, err := control.CreateRestorePoint("APT Defender: " + req.Description)
+		s.recordAudit(r, "system.restore_point", req.Description, err, map[string]interface{}{"sequence_number": seq})
+		if err != nil {
+			s.sendError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.sendJSON(w, map[string]interface{}{"sequence_number": seq})
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/events"
+)
+
+// resultHistorySize is how many results a reconnecting client can catch up on
+const resultHistorySize = 500
+
+// resultPrefixes are the asynchronous outcomes a Pi would otherwise poll for
+var resultPrefixes = []string{"scan.", "triage.", "playbook.", "fim."}
+
+// isResult keeps job, scan and detection outcomes; progress ticks stay on
+// /scan/events
+func isResult(ev events.Event) bool {
+	if strings.HasSuffix(ev.Type, ".progress") {
+		return false
+	}
+	for _, prefix := range resultPrefixes {
+		if strings.HasPrefix(ev.Type, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleResults streams command results (scan started/finished, threats,
+// triage jobs, playbook runs, FIM changes) as server-sent events, so the Pi
+// subscribes once per helper instead of polling scan/status. Every event
+// has an id; a client that reconnects with Last-Event-ID (or
+// ?last_event_id=) first gets the results it missed. types narrows the
+// stream to comma-separated type prefixes, e.g. types=scan.,triage.
+func (s *Server) handleResults(w http.ResponseWriter, r *http.Request) {
+	var types []string
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	lastID, _ := strconv.ParseUint(cmp.Or(r.Header.Get("Last-Event-ID"), r.URL.Query().Get("last_event_id")), 10, 64)
+	if lastID > s.results.Last() {
+		// IDs restart with the helper; the client's ID is from a previous run
+		lastID = 0
+	}
+
+	flusher, ok := s.startStream(w)
+	if !ok {
+		return
+	}
+	fmt.Fprint(w, "retry: 5000\n\n")
+
+	// Subscribe before reading the backlog so nothing falls in between
+	ch, cancel := s.results.Subscribe()
+	defer cancel()
+
+	sent := lastID
+	write := func(n events.Numbered) {
+		if n.ID <= sent {
+			return
+		}
+		sent = n.ID
+		if !matchesPrefix(n.Type, types) {
+			return
+		}
+		data, err := json.Marshal(n.Event)
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", n.ID, n.Type, data)
+	}
+	if lastID > 0 {
+		for _, n := range s.results.Since(lastID) {
+			write(n)
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case n, ok := <-ch:
+			if !ok {
+				return
+			}
+			write(n)
+			flusher.Flush()
+		}
+	}
+}
+
+// matchesPrefix reports whether eventType starts with one of prefixes; no prefixes match everything
+func matchesPrefix(eventType string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(eventType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// handleScanHistory lists finished scans, newest first. since takes an
+// RFC 3339 time or a duration ago ("720h"); limit defaults to 100.
+func (s *Server) handleScanHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.sendError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+	since, err := parseAuditTime(query.Get("since"))
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid since: "+err.Error())
+		return
+	}
+
+	scans, total, err := s.scanner.History().List(since, limit)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"scans": scans,
+		"count": len(scans),
+		"total": total,
+	})
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+)
+
+type tokenNameKey struct{}
+
+// requireScope authenticates the bearer token and checks it grants scope.
+// The configured auth_token has full control; api_tokens are limited to
+// their own scope.
+func (s *Server) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, granted, ok := s.tokenScope(r.Header.Get("Authorization"))
+		if !ok {
+			s.sendError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if err := s.checkReplay(r, name, token); err != nil {
+			s.sendError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if !scopeAllows(granted, scope) {
+			s.sendError(w, http.StatusForbidden, fmt.Sprintf("Token %q (%s) does not allow %s", name, granted, scope))
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), tokenNameKey{}, name)))
+	}
+}
+
+// readAuth lets read-scoped tokens GET a route; any other method on the
+// same route needs full control
+func (s *Server) readAuth(next http.HandlerFunc) http.HandlerFunc {
+	read := s.requireScope(config.ScopeRead, next)
+	control := s.requireScope(config.ScopeControl, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			read(w, r)
+			return
+		}
+		control(w, r)
+	}
+}
+
+// scanAuth lets scan-scoped tokens use a route with any method
+func (s *Server) scanAuth(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireScope(config.ScopeScan, next)
+}
+
+// tokenScope resolves an Authorization header to a token name and scope
+func (s *Server) tokenScope(header string) (name, scope string, ok bool) {
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		return "", "", false
+	}
+	if tokenEqual(token, s.config.AuthToken) {
+		return "auth_token", config.ScopeControl, true
+	}
+	for _, t := range s.config.APITokens {
+		if t.Token != "" && tokenEqual(token, t.Token) {
+			return t.Name, t.Scope, true
+		}
+	}
+	return "", "", false
+}
+
+// scopeAllows reports whether a granted scope covers the required one
+func scopeAllows(granted, required string) bool {
+	g, r := slices.Index(config.Scopes, granted), slices.Index(config.Scopes, required)
+	return g >= 0 && r >= 0 && g >= r
+}
+
+func tokenEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// tokenName returns the name of the token that authenticated the request
+func tokenName(r *http.Request) string {
+	name, _ := r.Context().Value(tokenNameKey{}).(string)
+	return name
+}
+
+// checkTokens warns about api_tokens that can never authenticate
+func checkTokens(tokens []config.APIToken) {
+	for _, t := range tokens {
+		switch {
+		case t.Token == "":
+			log.Printf("⚠️ API token %q has no token value and is ignored", t.Name)
+		case !slices.Contains(config.Scopes, t.Scope):
+			log.Printf("⚠️ API token %q has unknown scope %q (read, scan or control), every request will be refused", t.Name, t.Scope)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/control"
+	"github.com/apt-defender/helper-v2/internal/piclient"
+)
+
+// Self-test results
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// certExpiryWarning is how close to expiry a certificate is reported as a warning
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// Check is the outcome of one self-test step
+type Check struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// handleSelfTest exercises what the helper needs to respond to an incident
+// (privileges, firewall and hosts access, writable quarantine and staging,
+// valid certificates) and reports what would fail, leaving nothing changed
+func (s *Server) handleSelfTest(w http.ResponseWriter, r *http.Request) {
+	steps := []struct {
+		name string
+		run  func() (string, string)
+	}{
+		{"shutdown_privilege", privilegeCheck("SeShutdownPrivilege")},
+		{"debug_privilege", privilegeCheck("SeDebugPrivilege")},
+		{"firewall", func() (string, string) {
+			if err := control.TestFirewallAccess(); err != nil {
+				return checkFail, err.Error()
+			}
+			return checkPass, "Added and deleted a disabled rule"
+		}},
+		{"hosts_file", func() (string, string) {
+			if err := control.TestHostsAccess(); err != nil {
+				return checkFail, fmt.Sprintf("Domain blocking via hosts will fail: %v", err)
+			}
+			return checkPass, ""
+		}},
+		{"data_dir", writableCheck(config.GetDataDir())},
+		{"quarantine_dir", writableCheck(s.quarantine.Dir())},
+		{"staging_dir", writableCheck(s.staging.Dir())},
+		{"tls_certificate", s.tlsCertCheck},
+		{"client_certificate", s.clientCertCheck},
+		{"auth_token", s.authTokenCheck},
+		{"pi_agent", s.piAgentCheck},
+	}
+
+	checks := make([]Check, 0, len(steps))
+	summary := map[string]int{checkPass: 0, checkWarn: 0, checkFail: 0, checkSkip: 0}
+	for _, step := range steps {
+		start := time.Now()
+		status, detail := step.run()
+		checks = append(checks, Check{Name: step.name, Status: status, Detail: detail, DurationMS: time.Since(start).Milliseconds()})
+		summary[status]++
+	}
+
+	overall := checkPass
+	if summary[checkFail] > 0 {
+		overall = checkFail
+	} else if summary[checkWarn] > 0 {
+		overall = checkWarn
+	}
+	log.Printf("🩺 Self-test: %s (%d passed, %d warnings, %d failed)", overall, summary[checkPass], summary[checkWarn], summary[checkFail])
+
+	s.sendJSON(w, map[string]interface{}{
+		"status":  overall,
+		"summary": summary,
+		"checks":  checks,
+	})
+}
+
+func privilegeCheck(name string) func() (string, string) {
+	return func() (string, string) {
+		if err := control.EnablePrivilege(name); err != nil {
+			return checkFail, fmt.Sprintf("Cannot enable %s: %v", name, err)
+		}
+		return checkPass, ""
+	}
+}
+
+// writableCheck creates and removes a file in dir
+func writableCheck(dir string) func() (string, string) {
+	return func() (string, string) {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return checkFail, err.Error()
+		}
+		f, err := os.CreateTemp(dir, ".selftest-*")
+		if err != nil {
+			return checkFail, fmt.Sprintf("%s is not writable: %v", dir, err)
+		}
+		f.Close()
+		if err := os.Remove(f.Name()); err != nil {
+			return checkWarn, fmt.Sprintf("Could not remove %s: %v", f.Name(), err)
+		}
+		return checkPass, dir
+	}
+}
+
+func (s *Server) tlsCertCheck() (string, string) {
+	if !s.config.EnableTLS {
+		return checkSkip, "enable_tls is off"
+	}
+	return certCheck(s.config.CertFile, s.config.KeyFile)
+}
+
+func (s *Server) clientCertCheck() (string, string) {
+	if !s.config.EnableMTLS {
+		return checkSkip, "enable_mtls is off"
+	}
+	status, detail := certCheck(s.config.ClientCertFile, s.config.ClientKeyFile)
+	if status == checkFail || s.config.PiCAFile == "" {
+		return status, detail
+	}
+	data, err := os.ReadFile(s.config.PiCAFile)
+	if err != nil || !x509.NewCertPool().AppendCertsFromPEM(data) {
+		return checkFail, fmt.Sprintf("Pi Agent CA %s is unreadable or not PEM", s.config.PiCAFile)
+	}
+	return status, detail
+}
+
+// certCheck loads a certificate and key pair and checks the validity period
+func certCheck(certFile, keyFile string) (string, string) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return checkFail, fmt.Sprintf("Cannot load %s: %v", filepath.Base(certFile), err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return checkFail, fmt.Sprintf("Cannot parse %s", filepath.Base(certFile))
+	}
+
+	now := time.Now()
+	switch {
+	case now.Before(cert.NotBefore):
+		return checkFail, fmt.Sprintf("Not valid until %s", cert.NotBefore.Format(time.RFC3339))
+	case now.After(cert.NotAfter):
+		return checkFail, fmt.Sprintf("Expired %s", cert.NotAfter.Format(time.RFC3339))
+	case cert.NotAfter.Sub(now) < certExpiryWarning:
+		return checkWarn, fmt.Sprintf("Expires %s", cert.NotAfter.Format(time.RFC3339))
+	}
+	return checkPass, fmt.Sprintf("%s, valid until %s", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+}
+
+func (s *Server) authTokenCheck() (string, string) {
+	if s.config.AuthToken == config.DefaultConfig().AuthToken {
+		return checkWarn, "auth_token is still the default value"
+	}
+	return checkPass, ""
+}
+
+func (s *Server) piAgentCheck() (string, string) {
+	status := s.piLink.Status()
+	switch status.State {
+	case piclient.LinkConnected:
+		return checkPass, status.PiAgentIP
+	case piclient.LinkUnpaired:
+		return checkWarn, "Not paired with a Pi Agent"
+	case piclient.LinkUnreachable:
+		return checkFail, fmt.Sprintf("Pi Agent %s is unreachable: %s", status.PiAgentIP, status.LastError)
+	default:
+		return checkWarn, fmt.Sprintf("Pi Agent %s link is %s", status.PiAgentIP, status.State)
+	}
+}
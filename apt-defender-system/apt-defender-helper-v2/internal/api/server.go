@@ -3,19 +3,81 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/apt-defender/helper-v2/internal/allowlist"
+	"github.com/apt-defender/helper-v2/internal/audit"
 	"github.com/apt-defender/helper-v2/internal/config"
 	"github.com/apt-defender/helper-v2/internal/control"
 	"github.com/apt-defender/helper-v2/internal/dashboard"
+	"github.com/apt-defender/helper-v2/internal/discovery"
+	"github.com/apt-defender/helper-v2/internal/dns"
+	"github.com/apt-defender/helper-v2/internal/events"
+	"github.com/apt-defender/helper-v2/internal/fim"
+	"github.com/apt-defender/helper-v2/internal/hashing"
+	"github.com/apt-defender/helper-v2/internal/ipinfo"
+	"github.com/apt-defender/helper-v2/internal/logship"
+	"github.com/apt-defender/helper-v2/internal/mesh"
+	"github.com/apt-defender/helper-v2/internal/netmon"
+	"github.com/apt-defender/helper-v2/internal/pathpolicy"
+	"github.com/apt-defender/helper-v2/internal/persistence"
+	"github.com/apt-defender/helper-v2/internal/piclient"
+	"github.com/apt-defender/helper-v2/internal/playbook"
+	"github.com/apt-defender/helper-v2/internal/quarantine"
 	"github.com/apt-defender/helper-v2/internal/scanner"
+	"github.com/apt-defender/helper-v2/internal/staging"
+	"github.com/apt-defender/helper-v2/internal/supervisor"
 	"github.com/apt-defender/helper-v2/internal/telemetry"
+	"github.com/apt-defender/helper-v2/internal/tray"
+	"github.com/apt-defender/helper-v2/internal/triage"
+	"github.com/apt-defender/helper-v2/internal/updater"
+	"github.com/apt-defender/helper-v2/internal/version"
+	"github.com/apt-defender/helper-v2/internal/webhook"
 )
 
 type Server struct {
-	config  *config.Config
-	scanner *scanner.Scanner
+	config     *config.Config
+	events     *events.Broker
+	scanner    *scanner.Scanner
+	dnsMonitor *dns.Monitor
+	netMonitor *netmon.Monitor
+	fimMonitor *fim.Monitor
+	pathPolicy *pathpolicy.Policy
+	quarantine *quarantine.Store
+	staging    *staging.Area
+	triage     *triage.Collector
+	audit      *audit.Log
+	allowlist  *allowlist.Manager
+	playbooks  *playbook.Engine
+	piClient   *piclient.Client
+	mesh       *mesh.Monitor
+	discovery  *discovery.Watcher
+	piLink     *piclient.Link
+	logShip    *logship.Forwarder
+	results    *events.History
+	replay     *replayGuard
+	sessions   *dashboardSessions
+	webhooks   *webhook.Manager
+	ipHints    *ipinfo.Resolver
+	usage      *telemetry.History
+	updater    *updater.Updater
+	build      *version.Report
+	trayIcon   atomic.Pointer[tray.Icon] // set once ShowTray succeeds
+
+	mux         *http.ServeMux
+	v2Mux       *http.ServeMux // /api/v2 routes that differ from v1
+	listenerMu  sync.Mutex
+	httpServer  *http.Server
+	serveErrors chan error
 }
 
 type Response struct {
@@ -25,63 +87,334 @@ type Response struct {
 }
 
 func New(cfg *config.Config) *Server {
-	return &Server{
-		config:  cfg,
-		scanner: scanner.New(cfg.ScanPaths),
+	broker := events.NewBroker()
+	s := &Server{
+		config:     cfg,
+		events:     broker,
+		scanner:    scanner.New(cfg.ScanPaths, broker, scanner.NewFalsePositives(config.GetDataDir()), scanner.NewHistory(config.GetDataDir())),
+		dnsMonitor: dns.New(cfg.DNSBlocklist),
+		netMonitor: netmon.New(),
+		fimMonitor: fim.New(cfg.FIMPaths, broker),
+		pathPolicy: pathpolicy.New(cfg.ProtectedPaths, cfg.PathOverrides),
+		quarantine: quarantine.New(filepath.Join(config.GetDataDir(), "quarantine")),
+		staging:    staging.New(filepath.Join(config.GetDataDir(), "staging")),
+		piClient:   piclient.New(cfg),
+		audit:      audit.New(config.GetDataDir()),
+		allowlist:  allowlist.New(config.GetDataDir()),
+		webhooks:   webhook.New(config.GetDataDir()),
+		ipHints:    ipinfo.New(cfg.IPLookups),
+		usage:      telemetry.NewHistory(2*time.Second, 180),
+		replay:     newReplayGuard(),
+		sessions:   newDashboardSessions(),
+
+		mux:         http.NewServeMux(),
+		v2Mux:       http.NewServeMux(),
+		serveErrors: make(chan error, 1),
+	}
+
+	s.playbooks = playbook.NewEngine(config.GetDataDir(), playbook.Actions{
+		Quarantine: func(path, reason string) error {
+			_, err := s.quarantineFile(path, reason)
+			return err
+		},
+		BlockDomain: control.BlockDomain,
+		Notify: func(title, message string) error {
+			return control.NotifyUser(title, message, 0)
+		},
+		IsolateNetwork:  control.BlockAllNetwork,
+		LockWorkstation: control.LockWorkstation,
+	}, broker)
+
+	var updates updater.Source = updater.PiSource{Client: s.piClient}
+	if cfg.UpdateURL != "" {
+		updates = updater.NewURLSource(cfg.UpdateURL)
+	}
+	s.updater = updater.New(updates, cfg.UpdatePublicKey)
+
+	s.triage = triage.New(s.staging, int64(cfg.MaxArtifactMB)*megabyte, s.uploadArtifact, broker)
+
+	s.mesh = mesh.New(cfg.MeshPeers, s.piClient.Notify, broker)
+	s.discovery = discovery.NewWatcher(cfg, s.piMoved)
+	s.piLink = piclient.NewLink(s.piClient, broker, s.discovery.Check)
+	s.piClient.OnPin(s.piPinned)
+	s.logShip = s.newLogShipper()
+	s.results = events.NewHistory(broker, resultHistorySize, isResult)
+
+	checkTokens(cfg.APITokens)
+	checkSources(cfg.AllowedSources)
+	checkDashboardAccess(cfg)
+	checkCORS(cfg.CORS)
+	checkReplayMode(cfg.ReplayProtection)
+
+	build, err := version.Track(config.GetDataDir())
+	if err != nil {
+		log.Printf("⚠️ Failed to record version state: %v", err)
 	}
+	s.build = build
+	s.applyScanSettings()
+
+	return s
 }
 
 func (s *Server) Start() error {
-	// Dashboard (no auth required)
-	http.HandleFunc("/", s.handleDashboard)
-	http.HandleFunc("/dashboard", s.handleDashboard)
+	mux := s.mux
+
+	// Dashboard (this PC, or a PIN-signed-in session from another machine)
+	mux.HandleFunc("/", s.handleDashboard)
+	mux.HandleFunc("/dashboard", s.handleDashboard)
+	mux.HandleFunc("/dashboard/login", s.handleDashboardLogin)
+	mux.HandleFunc("POST /dashboard/logout", s.handleDashboardLogout)
 
 	// Setup routes
-	http.HandleFunc("/api/v1/health", s.handleHealth)
-	http.HandleFunc("/api/v1/telemetry", s.handleTelemetry)
+	mux.HandleFunc("/api/v1/health", s.handleHealth)
+	mux.HandleFunc("/api/v1/version", s.readAuth(s.handleVersion))
+	mux.HandleFunc("/api/v1/update", s.localOrAuthMiddleware(s.handleUpdate))
+	mux.HandleFunc("/api/v1/update/check", s.localOrControl(s.handleUpdateCheck))
+	mux.HandleFunc("/api/v1/update/install", s.localOrControl(s.handleUpdateInstall))
+	mux.HandleFunc("/api/versions", s.readAuth(s.handleAPIVersions))
+	mux.HandleFunc("/api/v1/telemetry", s.localOrAuthMiddleware(s.handleTelemetry))
+	mux.HandleFunc("/api/v1/telemetry/history", s.localOrAuthMiddleware(s.handleTelemetryHistory))
 
 	// Scanner endpoints
-	http.HandleFunc("/api/v1/scan/start", s.authMiddleware(s.handleScanStart))
-	http.HandleFunc("/api/v1/scan/status", s.authMiddleware(s.handleScanStatus))
-	http.HandleFunc("/api/v1/scan/stop", s.authMiddleware(s.handleScanStop))
+	mux.HandleFunc("/api/v1/scan/start", s.scanAuth(s.handleScanStart))
+	mux.HandleFunc("/api/v1/scan/status", s.readAuth(s.handleScanStatus))
+	mux.HandleFunc("/api/v1/scan/stop", s.scanAuth(s.handleScanStop))
+	mux.HandleFunc("/api/v1/scan/history", s.localOrAuthMiddleware(s.handleScanHistory))
+	mux.HandleFunc("/api/v1/report", s.localOrAuthMiddleware(s.handleReport))
+	mux.HandleFunc("/api/v1/signatures", s.readAuth(s.handleSignatures))
+	mux.HandleFunc("/api/v1/scan/events", s.localOrAuthMiddleware(s.handleScanEvents))
+	mux.HandleFunc("/api/v1/results/stream", s.localOrAuthMiddleware(s.handleResults))
 
 	// System control endpoints
-	http.HandleFunc("/api/v1/system/shutdown", s.authMiddleware(s.handleShutdown))
-	http.HandleFunc("/api/v1/system/restart", s.authMiddleware(s.handleRestart))
-	http.HandleFunc("/api/v1/system/lock", s.authMiddleware(s.handleLock))
+	mux.HandleFunc("/api/v1/system/shutdown", s.authMiddleware(s.handleShutdown))
+	mux.HandleFunc("/api/v1/system/shutdown/cancel", s.authMiddleware(s.handleShutdownCancel))
+	mux.HandleFunc("/api/v1/system/restart", s.authMiddleware(s.handleRestart))
+	mux.HandleFunc("/api/v1/system/lock", s.authMiddleware(s.handleLock))
+	mux.HandleFunc("/api/v1/system/sessions", s.readAuth(s.handleSessions))
+	mux.HandleFunc("/api/v1/system/logoff", s.authMiddleware(s.handleLogoff))
+	mux.HandleFunc("/api/v1/system/notify", s.authMiddleware(s.handleNotify))
+	mux.HandleFunc("/api/v1/system/sleep", s.authMiddleware(s.handleSleep))
+	mux.HandleFunc("/api/v1/system/hibernate", s.authMiddleware(s.handleHibernate))
+	mux.HandleFunc("/api/v1/system/encryption", s.readAuth(s.handleEncryption))
+	mux.HandleFunc("/api/v1/system/restore-points", s.readAuth(s.handleRestorePoints))
+	mux.HandleFunc("/api/v1/system/remote-access", s.readAuth(s.handleRemoteAccess))
+	mux.HandleFunc("/api/v1/system/remote-access/disable", s.authMiddleware(s.handleRemoteAccessDisable))
+	mux.HandleFunc("/api/v1/system/remote-access/enable", s.authMiddleware(s.handleRemoteAccessEnable))
 
 	// File control endpoints
-	http.HandleFunc("/api/v1/files/lock", s.authMiddleware(s.handleFileLock))
-	http.HandleFunc("/api/v1/files/unlock", s.authMiddleware(s.handleFileUnlock))
+	mux.HandleFunc("/api/v1/files/lock", s.authMiddleware(s.handleFileLock))
+	mux.HandleFunc("/api/v1/files/unlock", s.authMiddleware(s.handleFileUnlock))
+	mux.HandleFunc("/api/v1/files/quarantine", s.localOrControl(s.handleFileQuarantine))
+	mux.HandleFunc("/api/v1/files/restore", s.localOrControl(s.handleFileRestore))
+	mux.HandleFunc("/api/v1/quarantine", s.localOrAuthMiddleware(s.handleQuarantine))
+	mux.HandleFunc("/api/v1/threats", s.localOrAuthMiddleware(s.handleThreats))
+	mux.HandleFunc("/api/v1/threats/false-positives", s.localOrControl(s.handleFalsePositives))
+	mux.HandleFunc("/api/v1/files/reveal", s.handleFileReveal)
+	mux.HandleFunc("/api/v1/files/hash", s.readAuth(s.handleFileHash))
+	mux.HandleFunc("/api/v1/files/fetch", s.authMiddleware(s.handleFileFetch))
+	mux.HandleFunc("/api/v1/files/put", s.authMiddleware(s.handleFilePut))
 
 	// Network control endpoints
-	http.HandleFunc("/api/v1/network/block", s.authMiddleware(s.handleNetworkBlock))
-	http.HandleFunc("/api/v1/network/unblock", s.authMiddleware(s.handleNetworkUnblock))
-	http.HandleFunc("/api/v1/network/status", s.authMiddleware(s.handleNetworkStatus))
-	http.HandleFunc("/api/v1/network/block-app", s.authMiddleware(s.handleBlockApp))
+	mux.HandleFunc("/api/v1/network/block", s.authMiddleware(s.handleNetworkBlock))
+	mux.HandleFunc("/api/v1/network/unblock", s.authMiddleware(s.handleNetworkUnblock))
+	mux.HandleFunc("/api/v1/network/status", s.localOrAuthMiddleware(s.handleNetworkStatus))
+	mux.HandleFunc("/api/v1/network/block-app", s.localOrControl(s.handleBlockApp))
+	mux.HandleFunc("/api/v1/network/block-domain", s.authMiddleware(s.handleBlockDomain))
+	mux.HandleFunc("/api/v1/network/unblock-domain", s.authMiddleware(s.handleUnblockDomain))
+	mux.HandleFunc("/api/v1/network/blocked-domains", s.readAuth(s.handleBlockedDomains))
+	mux.HandleFunc("/api/v1/network/block-port", s.authMiddleware(s.handleBlockPort))
+	mux.HandleFunc("/api/v1/network/unblock-port", s.authMiddleware(s.handleUnblockPort))
+	mux.HandleFunc("/api/v1/network/blocked-ports", s.readAuth(s.handleBlockedPorts))
+	mux.HandleFunc("/api/v1/network/block-ip", s.localOrControl(s.handleBlockIP))
+	mux.HandleFunc("/api/v1/network/unblock-ip", s.localOrControl(s.handleUnblockIP))
+	mux.HandleFunc("/api/v1/network/blocked-ips", s.localOrAuthMiddleware(s.handleBlockedIPs))
+	mux.HandleFunc("/api/v1/network/rules", s.localOrAuthMiddleware(s.handleFirewallRules))
+	mux.HandleFunc("/api/v1/network/rules/enable", s.authMiddleware(s.handleFirewallRuleEnable))
+	mux.HandleFunc("/api/v1/network/rules/disable", s.authMiddleware(s.handleFirewallRuleDisable))
+	mux.HandleFunc("/api/v1/network/adapters", s.readAuth(s.handleAdapters))
+	mux.HandleFunc("/api/v1/network/adapters/disable", s.authMiddleware(s.handleAdapterDisable))
+	mux.HandleFunc("/api/v1/network/adapters/enable", s.authMiddleware(s.handleAdapterEnable))
+	mux.HandleFunc("/api/v1/network/connections", s.localOrAuthMiddleware(s.handleConnections))
+	mux.HandleFunc("/api/v1/network/connections/history", s.readAuth(s.handleConnectionHistory))
+	mux.HandleFunc("/api/v1/network/listeners", s.readAuth(s.handleListeners))
+	mux.HandleFunc("/api/v1/network/kill-connection", s.authMiddleware(s.handleKillConnection))
+	mux.HandleFunc("/api/v1/network/beacons", s.readAuth(s.handleBeacons))
+	mux.HandleFunc("/api/v1/network/capture", s.authMiddleware(s.handleCapture))
+	mux.HandleFunc("/api/v1/network/capture/interfaces", s.readAuth(s.handleCaptureInterfaces))
+	mux.HandleFunc("/api/v1/network/wol", s.authMiddleware(s.handleWakeOnLAN))
+
+	// DNS monitoring endpoints
+	mux.HandleFunc("/api/v1/dns/queries", s.readAuth(s.handleDNSQueries))
+
+	// Persistence enumeration
+	mux.HandleFunc("/api/v1/persistence", s.readAuth(s.handlePersistence))
+	mux.HandleFunc("/api/v1/persistence/remove", s.authMiddleware(s.handlePersistenceRemove))
+	mux.HandleFunc("/api/v1/autoruns", s.readAuth(s.handleAutoruns))
+
+	// Scheduled task management
+	mux.HandleFunc("/api/v1/tasks", s.readAuth(s.handleTasks))
+	mux.HandleFunc("/api/v1/tasks/disable", s.authMiddleware(s.handleTaskDisable))
+	mux.HandleFunc("/api/v1/tasks/enable", s.authMiddleware(s.handleTaskEnable))
+	mux.HandleFunc("/api/v1/tasks/delete", s.authMiddleware(s.handleTaskDelete))
+
+	// Process control
+	mux.HandleFunc("/api/v1/processes", s.localOrAuthMiddleware(s.handleProcesses))
+	s.v2Mux.HandleFunc("/api/v2/processes", s.readAuth(s.handleProcessesV2))
+	mux.HandleFunc("/api/v1/process/kill-by-name", s.authMiddleware(s.handleKillByName))
+	mux.HandleFunc("POST /api/v1/process/{pid}/kill", s.authMiddleware(s.handleProcessKill))
+	mux.HandleFunc("GET /api/v1/process/{pid}/modules", s.readAuth(s.handleProcessModules))
+	mux.HandleFunc("GET /api/v1/process/{pid}/handles", s.readAuth(s.handleProcessHandles))
+	mux.HandleFunc("POST /api/v1/process/{pid}/dump", s.authMiddleware(s.handleProcessDump))
+	mux.HandleFunc("/api/v1/handles/search", s.readAuth(s.handleHandleSearch))
+
+	// Staged artifacts (memory dumps, packet captures, triage packages)
+	mux.HandleFunc("GET /api/v1/staging", s.readAuth(s.handleStaging))
+	mux.HandleFunc("GET /api/v1/staging/{name}", s.authMiddleware(s.handleStagingDownload))
+	mux.HandleFunc("DELETE /api/v1/staging/{name}", s.authMiddleware(s.handleStagingDelete))
+	mux.HandleFunc("POST /api/v1/staging/{name}/upload", s.authMiddleware(s.handleStagingUpload))
+
+	// Application allowlisting
+	mux.HandleFunc("/api/v1/allowlist", s.readAuth(s.handleAllowlist))
+	mux.HandleFunc("/api/v1/allowlist/rules", s.authMiddleware(s.handleAllowlistRules))
+	mux.HandleFunc("/api/v1/allowlist/mode", s.authMiddleware(s.handleAllowlistMode))
+	mux.HandleFunc("/api/v1/allowlist/events", s.readAuth(s.handleAllowlistEvents))
+
+	// Microsoft Defender
+	mux.HandleFunc("/api/v1/defender/status", s.readAuth(s.handleDefenderStatus))
+	mux.HandleFunc("/api/v1/defender/scan", s.scanAuth(s.handleDefenderScan))
+	mux.HandleFunc("/api/v1/defender/realtime/enable", s.authMiddleware(s.handleDefenderEnableRealtime))
+
+	// Inventory
+	mux.HandleFunc("/api/v1/inventory/patches", s.readAuth(s.handlePatches))
+	mux.HandleFunc("/api/v1/inventory/software", s.readAuth(s.handleSoftware))
+	mux.HandleFunc("/api/v1/inventory/users", s.readAuth(s.handleUsers))
+
+	// USB device history
+	mux.HandleFunc("/api/v1/usb/history", s.readAuth(s.handleUSBHistory))
+
+	// Forensic triage packages
+	mux.HandleFunc("/api/v1/triage", s.readAuth(s.handleTriage))
 
-	// System info endpoint (no auth needed for local dashboard)
-	http.HandleFunc("/api/v1/system/info", s.handleSystemInfo)
+	// Service management
+	mux.HandleFunc("/api/v1/services", s.readAuth(s.handleServices))
+	mux.HandleFunc("/api/v1/services/stop", s.authMiddleware(s.handleServiceStop))
+	mux.HandleFunc("/api/v1/services/disable", s.authMiddleware(s.handleServiceDisable))
+
+	// Response playbook endpoints
+	mux.HandleFunc("/api/v1/playbooks", s.readAuth(s.handlePlaybooks))
+	mux.HandleFunc("/api/v1/playbooks/executions", s.readAuth(s.handlePlaybookExecutions))
+
+	// File integrity monitoring endpoints
+	mux.HandleFunc("/api/v1/fim/changes", s.readAuth(s.handleFIMChanges))
+	mux.HandleFunc("/api/v1/fim/status", s.readAuth(s.handleFIMStatus))
+	mux.HandleFunc("/api/v1/fim/baseline", s.scanAuth(s.handleFIMBaseline))
+
+	// LAN health mesh endpoints
+	mux.HandleFunc("/api/v1/mesh/peers", s.readAuth(s.handleMeshPeers))
+
+	// Configuration endpoint
+	mux.HandleFunc("GET /api/v1/config", s.localOrAuthMiddleware(s.handleConfig))
+	mux.HandleFunc("/api/v1/config", s.authMiddleware(s.handleConfig))
+	mux.HandleFunc("/api/v1/config/backups", s.readAuth(s.handleConfigBackups))
+	mux.HandleFunc("/api/v1/config/rollback", s.authMiddleware(s.handleConfigRollback))
+
+	// System info endpoint for the dashboard
+	mux.HandleFunc("/api/v1/system/info", s.localOrAuthMiddleware(s.handleSystemInfo))
 
 	// Registration notification endpoint (for Pi Agent to tell PC it's been added)
-	http.HandleFunc("/api/v1/register-notification", s.authMiddleware(s.handleRegistrationNotification))
+	mux.HandleFunc("/api/v1/register-notification", s.authMiddleware(s.handleRegistrationNotification))
+
+	// Pairing with a Pi Agent (certificate fingerprint verified before the code is sent)
+	mux.HandleFunc("/api/v1/pair", s.authMiddleware(s.handlePair))
+	mux.HandleFunc("/api/v1/pairing", s.localOrControl(s.handlePairing))
+	mux.HandleFunc("/api/v1/discovery/pi", s.localOrAuthMiddleware(s.handleDiscoverPi))
+	mux.HandleFunc("/api/v1/auth/unpair", s.authMiddleware(s.handleUnpair))
+	mux.HandleFunc("/api/v1/pi/events", s.localOrAuthMiddleware(s.handlePiEvents))
+	mux.HandleFunc("/api/v1/pi/status", s.localOrAuthMiddleware(s.handlePiStatus))
+	mux.HandleFunc("/api/v1/pi/check", s.localOrControl(s.handlePiCheck))
+	mux.HandleFunc("/api/v1/logs", s.localOrAuthMiddleware(s.handleLogs))
+	mux.HandleFunc("/api/v1/logs/shipping", s.readAuth(s.handleLogShipping))
+	mux.HandleFunc("/api/v1/system/restarts", s.readAuth(s.handleRestarts))
+	mux.HandleFunc("/api/v1/audit", s.localOrAuthMiddleware(s.handleAudit))
+	mux.HandleFunc("/api/v1/selftest", s.scanAuth(s.handleSelfTest))
+
+	// Webhook callbacks for scan, detection and containment events
+	mux.HandleFunc("GET /api/v1/webhooks", s.readAuth(s.handleWebhooks))
+	mux.HandleFunc("POST /api/v1/webhooks", s.authMiddleware(s.handleWebhookAdd))
+	mux.HandleFunc("DELETE /api/v1/webhooks/{id}", s.authMiddleware(s.handleWebhookDelete))
+	mux.HandleFunc("POST /api/v1/webhooks/{id}/test", s.authMiddleware(s.handleWebhookTest))
+
+	// Inspecting other processes' modules and handles needs SeDebugPrivilege
+	if err := control.EnablePrivilege("SeDebugPrivilege"); err != nil {
+		log.Printf("⚠️ SeDebugPrivilege unavailable, process inspection limited: %v", err)
+	}
+
+	// Background monitors
+	s.dnsMonitor.Start(5 * time.Second)
+	s.netMonitor.Start(5 * time.Second)
+
+	fimInterval := s.config.FIMInterval
+	if fimInterval <= 0 {
+		fimInterval = 15
+	}
+	s.fimMonitor.Start(time.Duration(fimInterval) * time.Minute)
+	s.playbooks.Start()
+	s.mesh.Start(30 * time.Second)
+	s.discovery.Start(5 * time.Minute)
+	s.piLink.Start(time.Minute)
+	if s.logShip != nil {
+		s.logShip.Start(s.events)
+	}
+	s.reportRestarts()
+	s.forwardAlerts()
+	s.notifyUser()
+	s.scanner.StartScheduler()
+	s.webhooks.Start(s.events)
+	s.quarantine.StartJanitor(quarantine.Retention{
+		MaxAge:   time.Duration(s.config.QuarantineDays) * 24 * time.Hour,
+		MaxBytes: int64(s.config.QuarantineMaxMB) * megabyte,
+	}, time.Hour)
+	s.announceUpdate()
+	s.checkUpdates()
 
 	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
 	log.Printf("🚀 Starting HTTP server on %s", addr)
-	log.Printf("✅ APT Defender Helper v2.0 Ready")
 
-	return http.ListenAndServe(addr, nil)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	srv := s.serve(ln)
+	s.listenerMu.Lock()
+	s.httpServer = srv
+	s.listenerMu.Unlock()
+	s.recordAddress(srv.Addr)
+
+	log.Printf("✅ APT Defender Helper v%s Ready", version.Version)
+
+	// Block until a listener fails outright; rebinds swap servers underneath
+	return <-s.serveErrors
 }
 
-// authMiddleware validates the auth token
+// authMiddleware validates the auth token and requires full control
 func (s *Server) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireScope(config.ScopeControl, next)
+}
+
+// localOrAuthMiddleware lets the dashboard read without a token (browsers
+// can't attach headers to EventSource), whether local or signed in from
+// another machine, and change things when its scope allows; it requires
+// auth otherwise
+func (s *Server) localOrAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if token != "Bearer "+s.config.AuthToken {
-			s.sendError(w, http.StatusUnauthorized, "Unauthorized")
+		required := config.ScopeControl
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			required = config.ScopeRead
+		}
+		if s.dashboardPermits(w, r, required, next) {
 			return
 		}
-		next(w, r)
+		s.readAuth(next)(w, r)
 	}
 }
 
@@ -96,9 +429,27 @@ func (s *Server) sendError(w http.ResponseWriter, statusCode int, message string
 	json.NewEncoder(w).Encode(Response{Success: false, Error: message})
 }
 
-// Health check
+// Health check. Service uptime spans supervisor restarts; uptime is this
+// process only.
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	s.sendJSON(w, map[string]string{"status": "healthy", "version": "2.0"})
+	now := time.Now()
+	serviceStarted := version.Started
+	supervised := os.Getenv(supervisor.EnvSupervised) != ""
+	if t, err := time.Parse(time.RFC3339Nano, os.Getenv(supervisor.EnvServiceStarted)); err == nil && supervised {
+		serviceStarted = t
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"status":                 "healthy",
+		"version":                version.Version,
+		"build_hash":             version.Hash(),
+		"started_at":             version.Started.UTC().Format(time.RFC3339),
+		"uptime_seconds":         int64(now.Sub(version.Started).Seconds()),
+		"service_started_at":     serviceStarted.UTC().Format(time.RFC3339),
+		"service_uptime_seconds": int64(now.Sub(serviceStarted).Seconds()),
+		"os_uptime_seconds":      telemetry.Uptime(),
+		"supervised":             supervised,
+	})
 }
 
 // Scanner handlers
@@ -112,7 +463,9 @@ func (s *Server) handleScanStart(w http.ResponseWriter, r *http.Request) {
 		req.ScanType = "full"
 	}
 
-	if err := s.scanner.StartScan(req.ScanType); err != nil {
+	err := s.scanner.StartScan(req.ScanType)
+	s.recordAudit(r, "scan.start", req.ScanType, err, nil)
+	if err != nil {
 		s.sendError(w, http.StatusConflict, err.Error())
 		return
 	}
@@ -126,12 +479,37 @@ func (s *Server) handleScanStatus(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleScanStop(w http.ResponseWriter, r *http.Request) {
 	s.scanner.StopScan()
+	s.recordAudit(r, "scan.stop", "", nil, nil)
 	s.sendJSON(w, map[string]string{"message": "Scan stopped"})
 }
 
+func (s *Server) handleSignatures(w http.ResponseWriter, r *http.Request) {
+	signatures := scanner.Signatures()
+	s.sendJSON(w, map[string]interface{}{
+		"signatures": signatures,
+		"count":      len(signatures),
+	})
+}
+
 // System control handlers
 func (s *Server) handleShutdown(w http.ResponseWriter, r *http.Request) {
+	// The body is optional; an empty one keeps the immediate shutdown
+	var req struct {
+		DelaySeconds int    `json:"delay_seconds"`
+		Message      string `json:"message"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if req.DelaySeconds > 0 {
+		s.scheduleShutdown(w, r, time.Duration(req.DelaySeconds)*time.Second, req.Message)
+		return
+	}
+
 	log.Println("⚠️ SHUTDOWN REQUEST RECEIVED FROM PI AGENT")
+	s.recordAudit(r, "system.shutdown", "", nil, nil)
 	s.sendJSON(w, map[string]string{"message": "Shutdown initiated"})
 
 	// Shutdown in goroutine to allow response to be sent
@@ -142,8 +520,47 @@ func (s *Server) handleShutdown(w http.ResponseWriter, r *http.Request) {
 	}()
 }
 
+func (s *Server) scheduleShutdown(w http.ResponseWriter, r *http.Request, delay time.Duration, message string) {
+	log.Printf("⚠️ DELAYED SHUTDOWN REQUEST RECEIVED FROM PI AGENT (%s)", delay)
+
+	if message == "" {
+		message = "APT Defender: your administrator has scheduled this computer to shut down. Save your work now."
+	}
+
+	firesAt, err := control.ScheduleShutdown(delay, message)
+	s.recordAudit(r, "system.shutdown", "", err, map[string]interface{}{"delay_seconds": int(delay / time.Second)})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"message":       "Shutdown scheduled",
+		"delay_seconds": int(delay / time.Second),
+		"fires_at":      firesAt,
+	})
+}
+
+// handleShutdownCancel aborts a delayed shutdown before it fires
+func (s *Server) handleShutdownCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	firesAt, _ := control.PendingShutdown()
+	err := control.CancelShutdown()
+	s.recordAudit(r, "system.shutdown_cancel", "", err, map[string]interface{}{"fires_at": firesAt})
+	if err != nil {
+		s.sendError(w, http.StatusConflict, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]string{"message": "Shutdown cancelled"})
+}
+
 func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
 	log.Println("⚠️ RESTART REQUEST RECEIVED FROM PI AGENT")
+	s.recordAudit(r, "system.restart", "", nil, nil)
 	s.sendJSON(w, map[string]string{"message": "Restart initiated"})
 
 	go func() {
@@ -153,10 +570,164 @@ func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
 	}()
 }
 
+func (s *Server) handleSleep(w http.ResponseWriter, r *http.Request) {
+	s.suspend(w, r, false)
+}
+
+func (s *Server) handleHibernate(w http.ResponseWriter, r *http.Request) {
+	s.suspend(w, r, true)
+}
+
+// suspend answers first and then sleeps/hibernates, like shutdown
+func (s *Server) suspend(w http.ResponseWriter, r *http.Request, hibernate bool) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	action, message := "system.sleep", "Sleep initiated"
+	if hibernate {
+		action, message = "system.hibernate", "Hibernate initiated"
+	}
+	log.Printf("💤 %s REQUEST RECEIVED FROM PI AGENT", action)
+
+	err := control.CanSuspend(hibernate)
+	s.recordAudit(r, action, "", err, nil)
+	if err != nil {
+		s.sendError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]string{"message": message})
+
+	go func() {
+		// Give the response time to reach the Pi before the NIC goes down
+		time.Sleep(time.Second)
+		if err := control.SuspendPC(hibernate); err != nil {
+			log.Printf("Suspend error: %v", err)
+		}
+	}()
+}
+
+// handleSessions lists logged-on user sessions, for picking a logoff target
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := control.Sessions()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+// handleLogoff signs out the console session, or session_id when given
+func (s *Server) handleLogoff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		SessionID *uint32 `json:"session_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	log.Println("🚪 LOGOFF REQUEST RECEIVED FROM PI AGENT")
+
+	var id uint32
+	var err error
+	if req.SessionID != nil {
+		id = *req.SessionID
+	} else {
+		id, err = control.ActiveConsoleSession()
+	}
+	if err == nil {
+		err = control.LogoffSession(id)
+	}
+	s.recordAudit(r, "system.logoff", strconv.FormatUint(uint64(id), 10), err, nil)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"message":    "Session logged off",
+		"session_id": id,
+	})
+}
+
+// handleNotify shows a message on the user's desktop, e.g. to explain a
+// containment action
+func (s *Server) handleNotify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Title          string  `json:"title"`
+		Message        string  `json:"message"`
+		Style          string  `json:"style"`
+		TimeoutSeconds int     `json:"timeout_seconds"`
+		SessionID      *uint32 `json:"session_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Message == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if req.Title == "" {
+		req.Title = "APT Defender"
+	}
+	if req.Style == "" {
+		req.Style = control.StylePopup
+	}
+
+	// Toasts and full-screen messages go to one session, the console by default
+	var session uint32
+	var err error
+	if req.Style != control.StylePopup {
+		if req.SessionID != nil {
+			session = *req.SessionID
+		} else {
+			session, err = control.ActiveConsoleSession()
+		}
+	}
+
+	if err == nil {
+		switch req.Style {
+		case control.StylePopup:
+			err = control.NotifyUser(req.Title, req.Message, req.TimeoutSeconds)
+		case control.StyleToast:
+			err = control.NotifyToast(session, req.Title, req.Message)
+		case control.StyleFullScreen:
+			err = control.NotifyFullScreen(session, req.Title, req.Message, req.TimeoutSeconds)
+		default:
+			err = fmt.Errorf("unknown style %q (popup, toast or fullscreen)", req.Style)
+		}
+	}
+	s.recordAudit(r, "system.notify", req.Title, err, map[string]interface{}{
+		"style":   req.Style,
+		"message": req.Message,
+	})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]string{"message": "Notification shown", "style": req.Style})
+}
+
 func (s *Server) handleLock(w http.ResponseWriter, r *http.Request) {
 	log.Println("🔒 LOCK REQUEST RECEIVED FROM PI AGENT")
 
-	if err := control.LockWorkstation(); err != nil {
+	err := control.LockWorkstation()
+	s.recordAudit(r, "system.lock", "", err, nil)
+	if err != nil {
 		s.sendError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -174,12 +745,21 @@ func (s *Server) handleFileLock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := control.LockFile(req.Path); err != nil {
+	path, err := s.pathPolicy.Check(req.Path)
+	if err != nil {
+		s.recordAudit(r, "files.lock", req.Path, err, nil)
+		s.sendError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	err = control.LockFile(path)
+	s.recordAudit(r, "files.lock", path, err, nil)
+	if err != nil {
 		s.sendError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	s.sendJSON(w, map[string]string{"message": "File locked", "path": req.Path})
+	s.sendJSON(w, map[string]string{"message": "File locked", "path": path})
 }
 
 func (s *Server) handleFileUnlock(w http.ResponseWriter, r *http.Request) {
@@ -191,19 +771,141 @@ func (s *Server) handleFileUnlock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := control.UnlockFile(req.Path); err != nil {
+	path, err := s.pathPolicy.Check(req.Path)
+	if err != nil {
+		s.recordAudit(r, "files.unlock", req.Path, err, nil)
+		s.sendError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	err = control.UnlockFile(path)
+	s.recordAudit(r, "files.unlock", path, err, nil)
+	if err != nil {
 		s.sendError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	s.sendJSON(w, map[string]string{"message": "File unlocked", "path": req.Path})
+	s.sendJSON(w, map[string]string{"message": "File unlocked", "path": path})
+}
+
+func (s *Server) handleFileQuarantine(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path         string `json:"path"`
+		Reason       string `json:"reason"`
+		RestorePoint *bool  `json:"restore_point"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := s.restorePointBefore(r, req.RestorePoint, "quarantine "+req.Path); err != nil {
+		s.sendError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	item, err := s.quarantineFile(req.Path, req.Reason)
+	details := map[string]interface{}{"reason": req.Reason}
+	if item != nil {
+		details["id"], details["sha256"] = item.ID, item.SHA256
+	}
+	s.recordAudit(r, "files.quarantine", req.Path, err, details)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.sendJSON(w, item)
+}
+
+func (s *Server) handleFileHash(w http.ResponseWriter, r *http.Request) {
+	path, err := s.pathPolicy.Check(r.URL.Query().Get("path"))
+	if err != nil {
+		s.sendError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	algorithms, err := hashing.ParseAlgorithms(r.URL.Query().Get("algorithms"))
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hashes, err := hashing.File(path, algorithms)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to hash file: %v", err))
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"path":   path,
+		"hashes": hashes,
+	})
+}
+
+// handleFileRestore moves a quarantined file back to its original location
+func (s *Server) handleFileRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		ID        string `json:"id"`
+		Overwrite bool   `json:"overwrite"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	item, err := s.restoreFile(req.ID, req.Overwrite)
+	details := map[string]interface{}{"id": req.ID, "overwrite": req.Overwrite}
+	target := ""
+	if item != nil {
+		target = item.OriginalPath
+		details["sha256"] = item.SHA256
+	}
+	s.recordAudit(r, "files.restore", target, err, details)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, item)
+}
+
+// restoreFile applies the path policy to the original location before restoring
+func (s *Server) restoreFile(id string, overwrite bool) (*quarantine.Item, error) {
+	item, err := s.quarantine.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.pathPolicy.Check(item.OriginalPath); err != nil {
+		return item, err
+	}
+	return s.quarantine.Restore(id, overwrite)
+}
+
+// quarantineFile applies the path policy before moving a file into quarantine
+func (s *Server) quarantineFile(path, reason string) (*quarantine.Item, error) {
+	path, err := s.pathPolicy.Check(path)
+	if err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		reason = "Manual quarantine"
+	}
+	return s.quarantine.Quarantine(path, reason)
 }
 
 // Network control handlers
 func (s *Server) handleNetworkBlock(w http.ResponseWriter, r *http.Request) {
 	log.Println("🚫 NETWORK BLOCK REQUEST RECEIVED FROM PI AGENT")
 
-	if err := control.BlockAllNetwork(); err != nil {
+	err := control.BlockAllNetwork()
+	s.recordAudit(r, "network.isolate", "", err, nil)
+	if err != nil {
 		s.sendError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -212,9 +914,11 @@ func (s *Server) handleNetworkBlock(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleNetworkUnblock(w http.ResponseWriter, r *http.Request) {
-	log.Println("✅ NETWORK UNBLOCK REQUEST RECEIVED FROM PI AGENT")
+	log.Println("✅ NETWORK UNBLOCK REQUEST RECEIVED")
 
-	if err := control.UnblockAllNetwork(); err != nil {
+	err := control.UnblockAllNetwork()
+	s.recordAudit(r, "network.restore", "", err, nil)
+	if err != nil {
 		s.sendError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -244,18 +948,288 @@ func (s *Server) handleBlockApp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := control.BlockApplication(req.Path); err != nil {
+	path, err := s.pathPolicy.Check(req.Path)
+	if err != nil {
+		s.recordAudit(r, "network.block_app", req.Path, err, nil)
+		s.sendError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	err = control.BlockApplication(path)
+	s.recordAudit(r, "network.block_app", path, err, nil)
+	if err != nil {
 		s.sendError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	s.sendJSON(w, map[string]string{"message": "Application blocked", "path": req.Path})
+	s.sendJSON(w, map[string]string{"message": "Application blocked", "path": path})
+}
+
+// handleConnections lists active connections; hints=true adds scope, ASN
+// and country hints per remote address
+func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
+	connections := s.netMonitor.GetActive()
+	if wantsNDJSON(r) {
+		sendNDJSON(w, connections)
+		return
+	}
+	result := map[string]interface{}{
+		"connections": connections,
+		"count":       len(connections),
+	}
+	if r.URL.Query().Get("hints") == "true" {
+		hints := map[string]ipinfo.Hint{}
+		for _, c := range connections {
+			if _, ok := hints[c.RemoteAddress]; !ok {
+				hints[c.RemoteAddress] = s.ipHints.Lookup(c.RemoteAddress)
+			}
+		}
+		result["hints"] = hints
+	}
+	s.sendJSON(w, result)
+}
+
+func (s *Server) handleConnectionHistory(w http.ResponseWriter, r *http.Request) {
+	limit := 500
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.sendError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	history := s.netMonitor.GetHistory(limit)
+	if wantsNDJSON(r) {
+		sendNDJSON(w, history)
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"connections": history,
+		"count":       len(history),
+	})
+}
+
+func (s *Server) handleBeacons(w http.ResponseWriter, r *http.Request) {
+	findings := s.netMonitor.GetFindings()
+	s.sendJSON(w, map[string]interface{}{
+		"findings": findings,
+		"count":    len(findings),
+	})
+}
+
+// DNS monitoring handlers
+func (s *Server) handleDNSQueries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	flaggedOnly := query.Get("flagged") == "true"
+
+	var pid uint32
+	if v := query.Get("pid"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid pid")
+			return
+		}
+		pid = uint32(n)
+	}
+
+	limit := 500
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.sendError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	queries := s.dnsMonitor.GetQueries(flaggedOnly, pid, limit)
+	s.sendJSON(w, map[string]interface{}{
+		"queries": queries,
+		"count":   len(queries),
+	})
+}
+
+// Persistence handlers
+func (s *Server) handlePersistence(w http.ResponseWriter, r *http.Request) {
+	entries, err := persistence.GetPersistence()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	suspicious := 0
+	for _, e := range entries {
+		if e.Suspicious {
+			suspicious++
+		}
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"entries":    entries,
+		"count":      len(entries),
+		"suspicious": suspicious,
+	})
+}
+
+func (s *Server) handleAutoruns(w http.ResponseWriter, r *http.Request) {
+	if wantsNDJSON(r) {
+		n := newNDJSONWriter(w)
+		err := persistence.EachAutorun(func(batch []persistence.Entry) error {
+			for _, e := range batch {
+				if err := n.Write(e); err != nil {
+					return err
+				}
+			}
+			// Signature checks make each location slow, so send what's found
+			n.Flush()
+			return nil
+		})
+		if err != nil {
+			n.Fail(err)
+		}
+		return
+	}
+
+	entries, err := persistence.GetAutoruns()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	categories := make(map[string]int)
+	suspicious := 0
+	for _, e := range entries {
+		categories[e.Category]++
+		if e.Suspicious {
+			suspicious++
+		}
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"entries":    entries,
+		"count":      len(entries),
+		"suspicious": suspicious,
+		"categories": categories,
+	})
+}
+
+func (s *Server) handlePersistenceRemove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		ID           string `json:"id"`
+		RestorePoint *bool  `json:"restore_point"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := s.restorePointBefore(r, req.RestorePoint, "persistence removal"); err != nil {
+		s.sendError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	removal, err := persistence.Remove(req.ID, func(path, reason string) (string, error) {
+		item, err := s.quarantineFile(path, reason)
+		if err != nil {
+			return "", err
+		}
+		return item.ID, nil
+	})
+	target := req.ID
+	details := map[string]interface{}{"id": req.ID}
+	if removal != nil {
+		target = removal.Entry.Name
+		details["category"], details["backup_id"] = removal.Entry.Category, removal.BackupID
+	}
+	s.recordAudit(r, "persistence.remove", target, err, details)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Printf("🧹 Removed persistence %s %q (backup %s)", removal.Entry.Category, removal.Entry.Name, removal.BackupID)
+	s.sendJSON(w, removal)
+}
+
+// LAN health mesh handlers
+func (s *Server) handleMeshPeers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.sendJSON(w, map[string]interface{}{"peers": s.mesh.GetPeers()})
+
+	case http.MethodPut, http.MethodPost:
+		var req struct {
+			Peers []string `json:"peers"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid request")
+			return
+		}
+
+		s.mesh.SetPeers(req.Peers)
+		s.config.MeshPeers = req.Peers
+		if err := s.config.Save(config.GetConfigPath()); err != nil {
+			log.Printf("⚠️ Failed to save config after mesh update: %v", err)
+		}
+
+		log.Printf("🕸️ Mesh peer list updated: %d peers", len(req.Peers))
+		s.sendJSON(w, map[string]interface{}{"peers": s.mesh.GetPeers()})
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// File integrity monitoring handlers
+func (s *Server) handleFIMChanges(w http.ResponseWriter, r *http.Request) {
+	changes := s.fimMonitor.GetChanges()
+	s.sendJSON(w, map[string]interface{}{
+		"changes": changes,
+		"count":   len(changes),
+	})
+}
+
+func (s *Server) handleFIMStatus(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, s.fimMonitor.GetStatus())
+}
+
+func (s *Server) handleFIMBaseline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if err := s.fimMonitor.Rebaseline(); err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.sendJSON(w, s.fimMonitor.GetStatus())
 }
 
 // Dashboard handler
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if s.refuseHost(w, r) {
+		return
+	}
+	if code := r.URL.Query().Get("launch"); code != "" {
+		s.redeemLaunch(w, r, code)
+		http.Redirect(w, r, "/dashboard", http.StatusSeeOther)
+		return
+	}
+	if !s.dashboardAllowed(r) {
+		s.sendDashboardDenied(w)
+		return
+	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Write([]byte(dashboard.HTML))
+	w.Write([]byte(s.dashboardPage(dashboard.HTML)))
 }
 
 // Telemetry handler
@@ -269,6 +1243,25 @@ func (s *Server) handleTelemetry(w http.ResponseWriter, r *http.Request) {
 	s.sendJSON(w, stats)
 }
 
+// handleTelemetryHistory returns the last six minutes of CPU, memory and
+// network use, or only the samples after since (RFC 3339)
+func (s *Server) handleTelemetryHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, "Invalid since")
+			return
+		}
+		since = t
+	}
+	s.sendJSON(w, s.usage.Samples(since))
+}
+
 // System info handler (includes IP addresses)
 func (s *Server) handleSystemInfo(w http.ResponseWriter, r *http.Request) {
 	ips := telemetry.GetLocalIPs()
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/services"
+)
+
+type serviceRequest struct {
+	Name string `json:"name"`
+}
+
+// handleServices lists services; signatures=false skips the slower signing check
+func (s *Server) handleServices(w http.ResponseWriter, r *http.Request) {
+	withSignatures := r.URL.Query().Get("signatures") != "false"
+
+	list, err := services.List(withSignatures)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if state := r.URL.Query().Get("state"); state != "" {
+		filtered := list[:0]
+		for _, svc := range list {
+			if strings.EqualFold(svc.State, state) {
+				filtered = append(filtered, svc)
+			}
+		}
+		list = filtered
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"services": list,
+		"count":    len(list),
+	})
+}
+
+func (s *Server) handleServiceStop(w http.ResponseWriter, r *http.Request) {
+	s.controlService(w, r, services.Stop, "Service stop requested")
+}
+
+func (s *Server) handleServiceDisable(w http.ResponseWriter, r *http.Request) {
+	s.controlService(w, r, services.Disable, "Service disabled")
+}
+
+func (s *Server) controlService(w http.ResponseWriter, r *http.Request, apply func(string) error, message string) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req serviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := apply(req.Name); err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]string{"message": message, "name": req.Name})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// sourceFilter refuses /api requests from hosts outside allowed_sources.
+// The dashboard pages, loopback callers and browsers signed in to the
+// dashboard with the PIN are always let through.
+func (s *Server) sourceFilter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") && !isLoopback(r) && !s.hasDashboardSession(r) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil || !s.allowedSource(net.ParseIP(host)) {
+				log.Printf("🚫 Refused %s %s from %s (not in allowed_sources)", r.Method, r.URL.Path, r.RemoteAddr)
+				s.sendError(w, http.StatusForbidden, "Source not allowed")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedSource checks ip against allowed_sources. When that list is empty
+// the paired Pi Agent and the mesh peers are allowed, and an unpaired
+// helper accepts any source so the Pi can still register it.
+func (s *Server) allowedSource(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	sources := s.config.AllowedSources
+	if len(sources) == 0 {
+		if s.config.PiAgentIP == "" {
+			return true
+		}
+		sources = append([]string{s.config.PiAgentIP}, s.config.MeshPeers...)
+	}
+
+	for _, source := range sources {
+		if n := parseSource(source); n != nil && n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSource turns an IP, CIDR or peer address (ip:port or URL) into
+// a network; it returns nil for anything else
+func parseSource(source string) *net.IPNet {
+	source = strings.TrimSpace(source)
+	for _, scheme := range []string{"http://", "https://"} {
+		source = strings.TrimPrefix(source, scheme)
+	}
+	source = strings.TrimSuffix(source, "/")
+	if _, n, err := net.ParseCIDR(source); err == nil {
+		return n
+	}
+	if host, _, err := net.SplitHostPort(source); err == nil {
+		source = host
+	}
+	ip := net.ParseIP(source)
+	if ip == nil {
+		return nil
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
+
+// checkSources warns about allowed_sources entries that match nothing
+func checkSources(sources []string) {
+	for _, source := range sources {
+		if parseSource(source) == nil {
+			log.Printf("⚠️ allowed_sources entry %q is not an IP or CIDR and is ignored", source)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/apt-defender/helper-v2/internal/staging"
+)
+
+const megabyte = 1024 * 1024
+
+// handleStaging lists collected artifacts waiting for download or upload
+func (s *Server) handleStaging(w http.ResponseWriter, r *http.Request) {
+	artifacts, err := s.staging.List()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"artifacts": artifacts,
+		"count":     len(artifacts),
+	})
+}
+
+// handleStagingDownload streams an artifact to the caller
+func (s *Server) handleStagingDownload(w http.ResponseWriter, r *http.Request) {
+	path, err := s.staging.Path(r.PathValue("name"))
+	if err != nil {
+		s.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+r.PathValue("name")+`"`)
+	http.ServeFile(w, r, path)
+}
+
+// handleStagingDelete removes an artifact once it has been collected
+func (s *Server) handleStagingDelete(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := s.staging.Remove(name); err != nil {
+		s.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	log.Printf("🗑️ Removed staged artifact %s", name)
+	s.sendJSON(w, map[string]string{"name": name, "status": "deleted"})
+}
+
+// handleStagingUpload pushes an artifact to the Pi Agent in the background
+func (s *Server) handleStagingUpload(w http.ResponseWriter, r *http.Request) {
+	path, err := s.staging.Path(r.PathValue("name"))
+	if err != nil {
+		s.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	artifact, err := s.staging.Describe(path)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := s.startUpload(path, artifact); err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{"artifact": artifact, "upload": "started"})
+}
+
+// checkUpload verifies an artifact can be sent to the Pi Agent
+func (s *Server) checkUpload(artifact *staging.Artifact) error {
+	if !s.piClient.Available() {
+		return fmt.Errorf("not registered with a Pi Agent")
+	}
+	if limit := int64(s.config.MaxUploadMB) * megabyte; artifact.Size > limit {
+		return fmt.Errorf("artifact is %d bytes, upload limit is %d", artifact.Size, limit)
+	}
+	return nil
+}
+
+// uploadArtifact sends an artifact to the Pi Agent and waits for it to finish
+func (s *Server) uploadArtifact(path string, artifact *staging.Artifact) error {
+	if err := s.checkUpload(artifact); err != nil {
+		return err
+	}
+
+	log.Printf("📤 Uploading %s (%d bytes) to Pi Agent", artifact.Name, artifact.Size)
+	if err := s.piClient.UploadArtifact(path, artifact.Kind, artifact.SHA256); err != nil {
+		return err
+	}
+	log.Printf("✅ Uploaded %s to Pi Agent", artifact.Name)
+	return nil
+}
+
+// startUpload checks the upload limits and sends an artifact in the background
+func (s *Server) startUpload(path string, artifact *staging.Artifact) error {
+	if err := s.checkUpload(artifact); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.uploadArtifact(path, artifact); err != nil {
+			log.Printf("⚠️ Upload of %s failed: %v", artifact.Name, err)
+		}
+	}()
+	return nil
+}
+
+// enforceArtifactLimit discards a freshly collected file that exceeds max_artifact_mb
+func (s *Server) enforceArtifactLimit(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if limit := int64(s.config.MaxArtifactMB) * megabyte; info.Size() > limit {
+		os.Remove(path)
+		return fmt.Errorf("artifact is %d bytes, staging limit is %d", info.Size(), limit)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const streamKeepAlive = 15 * time.Second
+
+// handleScanEvents streams scan lifecycle events (started, progress, threat, completed)
+func (s *Server) handleScanEvents(w http.ResponseWriter, r *http.Request) {
+	s.streamEvents(w, r, "scan.")
+}
+
+// startStream sends the server-sent event headers
+func (s *Server) startStream(w http.ResponseWriter) (http.Flusher, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.sendError(w, http.StatusInternalServerError, "Streaming not supported")
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+	return flusher, true
+}
+
+// streamEvents writes broker events whose type starts with prefix as server-sent events
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, prefix string) {
+	flusher, ok := s.startStream(w)
+	if !ok {
+		return
+	}
+
+	ch, cancel := s.events.Subscribe()
+	defer cancel()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			if !strings.HasPrefix(ev.Type, prefix) {
+				continue
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// isLoopback reports whether the request originated from this machine
+func isLoopback(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
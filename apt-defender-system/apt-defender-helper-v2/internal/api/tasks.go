@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/tasks"
+)
+
+type taskRequest struct {
+	Name string `json:"name"`
+}
+
+// handleTasks lists scheduled tasks; Microsoft's own tasks are hidden unless
+// include_microsoft=true
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	list, err := tasks.List()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	includeMicrosoft := r.URL.Query().Get("include_microsoft") == "true"
+	result := make([]tasks.Task, 0, len(list))
+	for _, t := range list {
+		if !includeMicrosoft && strings.HasPrefix(t.Name, `\Microsoft\`) {
+			continue
+		}
+		result = append(result, t)
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"tasks": result,
+		"count": len(result),
+	})
+}
+
+func (s *Server) handleTaskDisable(w http.ResponseWriter, r *http.Request) {
+	s.changeTask(w, r, tasks.Disable, "Task disabled")
+}
+
+func (s *Server) handleTaskEnable(w http.ResponseWriter, r *http.Request) {
+	s.changeTask(w, r, tasks.Enable, "Task enabled")
+}
+
+func (s *Server) changeTask(w http.ResponseWriter, r *http.Request, apply func(string) error, message string) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req taskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := apply(req.Name); err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]string{"message": message, "name": req.Name})
+}
+
+// handleTaskDelete saves the task XML to quarantine before unregistering it,
+// so it can be re-created with schtasks /Create /XML
+func (s *Server) handleTaskDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req taskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	xml, err := tasks.Export(req.Name)
+	if err != nil {
+		s.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	tmp, err := os.CreateTemp("", "task-*.xml")
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create backup: %v", err))
+		return
+	}
+	_, writeErr := tmp.Write(xml)
+	tmp.Close()
+	if writeErr != nil {
+		os.Remove(tmp.Name())
+		s.sendError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to write backup: %v", writeErr))
+		return
+	}
+
+	item, err := s.quarantine.Quarantine(tmp.Name(), "Scheduled task deleted: "+req.Name)
+	if err != nil {
+		os.Remove(tmp.Name())
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tasks.Delete(req.Name); err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Printf("🗑️ Task %s deleted (backup %s)", req.Name, item.ID)
+	s.sendJSON(w, map[string]string{
+		"message":   "Task deleted",
+		"name":      req.Name,
+		"backup_id": item.ID,
+	})
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+	"github.com/apt-defender/helper-v2/internal/quarantine"
+	"github.com/apt-defender/helper-v2/internal/scanner"
+)
+
+// Threat states shown next to each detection
+const (
+	threatActive      = "active"
+	threatQuarantined = "quarantined"
+	threatMissing     = "missing"
+)
+
+// threatView is a detection from the latest scan with what has happened to
+// the file since
+type threatView struct {
+	scanner.Threat
+	Status       string `json:"status"`
+	QuarantineID string `json:"quarantine_id,omitempty"`
+}
+
+// handleThreats lists detections from the latest scan and the marked false positives
+func (s *Server) handleThreats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	items, err := s.quarantine.List()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	status := s.scanner.GetStatus()
+	threats := make([]threatView, 0, len(status.Threats))
+	for _, t := range status.Threats {
+		threats = append(threats, threatState(t, items))
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"threats":         threats,
+		"count":           len(threats),
+		"scan_active":     status.Active,
+		"scan_type":       status.ScanType,
+		"scan_started":    status.StartTime,
+		"false_positives": s.scanner.FalsePositives().List(),
+	})
+}
+
+// threatState matches a detection against quarantined items moved from the
+// same path after it was detected
+func threatState(t scanner.Threat, items []quarantine.Item) threatView {
+	view := threatView{Threat: t, Status: threatActive}
+	for _, item := range items {
+		if strings.EqualFold(item.OriginalPath, t.Path) && !item.QuarantinedAt.Before(t.DetectedAt) {
+			view.Status, view.QuarantineID = threatQuarantined, item.ID
+			return view
+		}
+	}
+	if _, err := os.Stat(t.Path); os.IsNotExist(err) {
+		view.Status = threatMissing
+	}
+	return view
+}
+
+// handleFalsePositives marks a detection as benign (POST) or unmarks a file (DELETE ?sha256=)
+func (s *Server) handleFalsePositives(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req struct {
+			Path string `json:"path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+			s.sendError(w, http.StatusBadRequest, "Invalid request")
+			return
+		}
+
+		entry, err := s.scanner.MarkFalsePositive(req.Path)
+		details := map[string]interface{}{}
+		if entry != nil {
+			details["sha256"], details["signature"] = entry.SHA256, entry.Signature
+		}
+		s.recordAudit(r, "threats.false_positive", req.Path, err, details)
+		if err != nil {
+			s.sendError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.sendJSON(w, entry)
+
+	case http.MethodDelete:
+		sha256 := r.URL.Query().Get("sha256")
+		err := s.scanner.FalsePositives().Remove(sha256)
+		s.recordAudit(r, "threats.false_positive.remove", sha256, err, nil)
+		if err != nil {
+			s.sendError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.sendJSON(w, map[string]string{"sha256": sha256, "status": "removed"})
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleFileReveal opens Explorer at a detected or quarantined file's folder.
+// It puts a window on this PC's screen, so only the dashboard on this PC may
+// call it after the helper opened it; signed-in dashboards elsewhere and
+// tokens are refused.
+func (s *Server) handleFileReveal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if s.dashboardScope(r) == "" || !s.hasLocalSession(r) {
+		s.sendError(w, http.StatusForbidden, "Folders can only be opened from the dashboard on this PC")
+		return
+	}
+
+	var req struct {
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Path == "" {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	if err := control.RevealFile(req.Path); err != nil {
+		s.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]string{"path": req.Path, "status": "opened"})
+}
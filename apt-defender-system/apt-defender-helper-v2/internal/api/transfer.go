@@ -0,0 +1,189 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/audit"
+)
+
+// handleFileFetch streams a file off the endpoint. The SHA256 is computed
+// first and sent in X-Content-SHA256; if the caller supplies sha256= the
+// transfer is refused when the file no longer matches.
+func (s *Server) handleFileFetch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	requested := r.URL.Query().Get("path")
+	expected := strings.ToLower(r.URL.Query().Get("sha256"))
+
+	status, size, digest, err := s.fetchFile(w, requested, expected)
+	s.recordAudit(r, "files.fetch", requested, err, map[string]interface{}{
+		"size":   size,
+		"sha256": digest,
+	})
+	if err != nil {
+		s.sendError(w, status, err.Error())
+		return
+	}
+	log.Printf("📤 Sent %s (%d bytes) to %s", requested, size, r.RemoteAddr)
+}
+
+// fetchFile validates and streams the file; on error nothing has been written yet
+func (s *Server) fetchFile(w http.ResponseWriter, requested, expected string) (int, int64, string, error) {
+	path, err := s.pathPolicy.Check(requested)
+	if err != nil {
+		return http.StatusForbidden, 0, "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return http.StatusNotFound, 0, "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return http.StatusInternalServerError, 0, "", err
+	}
+	if info.IsDir() {
+		return http.StatusBadRequest, 0, "", fmt.Errorf("%s is a directory", path)
+	}
+	if limit := int64(s.config.MaxUploadMB) * megabyte; info.Size() > limit {
+		return http.StatusRequestEntityTooLarge, info.Size(), "", fmt.Errorf("file is %d bytes, transfer limit is %d", info.Size(), limit)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return http.StatusInternalServerError, info.Size(), "", fmt.Errorf("failed to hash file: %w", err)
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	if expected != "" && expected != digest {
+		return http.StatusConflict, info.Size(), digest, fmt.Errorf("hash mismatch: file is %s", digest)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return http.StatusInternalServerError, info.Size(), digest, err
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filepath.Base(path)+`"`)
+	w.Header().Set("X-Content-SHA256", digest)
+	io.Copy(w, f)
+
+	return http.StatusOK, info.Size(), digest, nil
+}
+
+// handleFilePut writes the request body to a path on the endpoint, e.g. a
+// remediation script. The file is written next to its destination and only
+// renamed into place once the size cap and optional sha256= check pass.
+func (s *Server) handleFilePut(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	requested := query.Get("path")
+	overwrite := query.Get("overwrite") == "true"
+
+	status, size, digest, err := s.putFile(w, r, requested, strings.ToLower(query.Get("sha256")), overwrite)
+	s.recordAudit(r, "files.put", requested, err, map[string]interface{}{
+		"size":      size,
+		"sha256":    digest,
+		"overwrite": overwrite,
+	})
+	if err != nil {
+		s.sendError(w, status, err.Error())
+		return
+	}
+
+	log.Printf("📥 Received %s (%d bytes) from %s", requested, size, r.RemoteAddr)
+	s.sendJSON(w, map[string]interface{}{
+		"path":   requested,
+		"size":   size,
+		"sha256": digest,
+	})
+}
+
+func (s *Server) putFile(w http.ResponseWriter, r *http.Request, requested, expected string, overwrite bool) (int, int64, string, error) {
+	path, err := s.pathPolicy.Check(requested)
+	if err != nil {
+		return http.StatusForbidden, 0, "", err
+	}
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return http.StatusConflict, 0, "", fmt.Errorf("%s already exists (set overwrite=true to replace it)", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return http.StatusInternalServerError, 0, "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".aptd-put-*")
+	if err != nil {
+		return http.StatusInternalServerError, 0, "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	limit := int64(s.config.MaxUploadMB) * megabyte
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), http.MaxBytesReader(w, r.Body, limit))
+	closeErr := tmp.Close()
+	if err != nil {
+		return http.StatusRequestEntityTooLarge, size, "", fmt.Errorf("failed to receive file (limit %d bytes): %w", limit, err)
+	}
+	if closeErr != nil {
+		return http.StatusInternalServerError, size, "", closeErr
+	}
+
+	digest := hex.EncodeToString(h.Sum(nil))
+	if expected != "" && expected != digest {
+		return http.StatusBadRequest, size, digest, fmt.Errorf("hash mismatch: received %s", digest)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return http.StatusInternalServerError, size, digest, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return http.StatusOK, size, digest, nil
+}
+
+// recordAudit writes a command and its outcome to the audit log
+func (s *Server) recordAudit(r *http.Request, action, target string, err error, details map[string]interface{}) {
+	entry := audit.Entry{
+		Action:    action,
+		Target:    target,
+		Remote:    r.RemoteAddr,
+		Token:     tokenName(r),
+		RequestID: requestID(r),
+		Details:   details,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.audit.Record(entry)
+
+	requestLogger(r).Info("audit", "action", action, "target", target, "token", entry.Token, "remote", r.RemoteAddr, "error", entry.Error)
+
+	if err == nil {
+		s.notifyCommand(r, action, details)
+	}
+
+	if err == nil && slices.Contains(containmentActions, action) {
+		s.events.Publish("containment.executed", map[string]interface{}{
+			"action":  action,
+			"target":  target,
+			"token":   entry.Token,
+			"details": details,
+		})
+	}
+}
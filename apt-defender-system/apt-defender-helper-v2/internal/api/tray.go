@@ -0,0 +1,143 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/audit"
+	"github.com/apt-defender/helper-v2/internal/scanner"
+	"github.com/apt-defender/helper-v2/internal/tray"
+	"github.com/apt-defender/helper-v2/internal/version"
+)
+
+const trayTip = "APT Defender Helper"
+
+// trayThreats is how many threats the Status dialog lists
+const trayThreats = 10
+
+// ShowTray puts the helper's icon in the notification area of the
+// signed-in user, so it stays reachable with no console or browser window.
+// The icon has no views of its own: openDashboard opens the dashboard at a
+// view ("" for the overview) for a double-click and the menu's page items.
+// It fails when there is no desktop, e.g. when running as a service.
+func (s *Server) ShowTray(openDashboard func(view string)) error {
+	open := func(view string) func() {
+		return func() { openDashboard(view) }
+	}
+	icon, err := tray.Start(trayTip, open(""), []tray.Item{
+		{Label: "Open dashboard", Action: open("")},
+		{Label: "Status", Action: func() { tray.MessageBox(trayTip, s.trayStatus()) }},
+		{Label: "Threats", Action: open("threats")},
+		{Label: "Pairing", Action: open("pairing")},
+		{},
+		{Label: "Start scan", Action: s.trayScan},
+		{Label: "Stop scan", Action: s.trayStopScan},
+		{},
+		{Label: "Exit", Action: func() { s.trayExit() }},
+	})
+	if err != nil {
+		return err
+	}
+	s.trayIcon.Store(icon)
+	s.trayEvents(icon)
+	log.Printf("🛡️ Tray icon added")
+	return nil
+}
+
+// trayStatus summarises the helper for the Status menu item
+func (s *Server) trayStatus() string {
+	lines := []string{fmt.Sprintf("Version %s", version.Version)}
+
+	if s.config.RegisteredWithPi {
+		link := s.piLink.Status()
+		contact := "none since the helper started"
+		if !link.LastSeen.IsZero() {
+			contact = link.LastSeen.Format("2006-01-02 15:04")
+		}
+		lines = append(lines, fmt.Sprintf("Pi Agent: %s (%s, last contact %s)", s.config.PiAgentIP, link.State, contact))
+	} else {
+		lines = append(lines, "Pi Agent: not paired")
+	}
+
+	status := s.scanner.GetStatus()
+	switch {
+	case status.Active:
+		lines = append(lines, fmt.Sprintf("Scan: running in %s, %d of %d files, %d threats so far", status.CurrentFolder, status.ScannedFiles, status.TotalFiles, len(status.Threats)))
+	case status.StartTime.IsZero():
+		lines = append(lines, "Scan: none since the helper started")
+	default:
+		lines = append(lines, fmt.Sprintf("Last scan: %s, %d threats", status.StartTime.Format("2006-01-02 15:04"), len(status.Threats)))
+	}
+	for n, threat := range status.Threats {
+		if n == trayThreats {
+			lines = append(lines, fmt.Sprintf("  ...and %d more in the dashboard", len(status.Threats)-n))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", threat.Type, threat.Path))
+	}
+
+	lines = append(lines, fmt.Sprintf("Dashboard: http://localhost:%d/dashboard", s.config.Port))
+	return strings.Join(lines, "\n")
+}
+
+// trayScan starts a full scan for the user at the desktop
+func (s *Server) trayScan() {
+	err := s.scanner.StartScan("full")
+	entry := audit.Entry{Action: "scan.start", Target: "full", Details: map[string]interface{}{"via": "tray"}}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.audit.Record(entry)
+	if err != nil {
+		tray.MessageBox(trayTip, "Could not start a scan: "+err.Error())
+	}
+}
+
+// trayStopScan stops the running scan, if any
+func (s *Server) trayStopScan() {
+	if !s.scanner.GetStatus().Active {
+		tray.MessageBox(trayTip, "No scan is running.")
+		return
+	}
+	s.scanner.StopScan()
+	s.audit.Record(audit.Entry{Action: "scan.stop", Details: map[string]interface{}{"via": "tray"}})
+}
+
+func (s *Server) trayExit() {
+	log.Printf("👋 Exit chosen from the tray icon")
+	s.audit.Record(audit.Entry{Action: "helper.exit", Details: map[string]interface{}{"via": "tray"}})
+	if icon := s.trayIcon.Load(); icon != nil {
+		icon.Remove()
+	}
+	os.Exit(0)
+}
+
+// trayEvents keeps the icon's tooltip in step with scans, showing progress
+// and the folder being scanned while one runs
+func (s *Server) trayEvents(icon *tray.Icon) {
+	ch, _ := s.events.Subscribe()
+	go func() {
+		for ev := range ch {
+			switch ev.Type {
+			case "scan.started":
+				icon.SetTip(trayTip + " - scanning")
+			case "scan.progress":
+				progress, _ := ev.Data.(map[string]interface{})
+				scanned, _ := progress["scanned_files"].(int64)
+				total, _ := progress["total_files"].(int64)
+				folder, _ := progress["current_folder"].(string)
+				if total > 0 {
+					icon.SetTip(fmt.Sprintf("%s - scanning %d%%\n%s", trayTip, min(100, scanned*100/total), folder))
+				}
+			case "scan.completed":
+				if summary, ok := ev.Data.(scanner.ScanSummary); ok && summary.ThreatsFound > 0 {
+					icon.SetTip(fmt.Sprintf("%s - %d threats in the last scan", trayTip, summary.ThreatsFound))
+				} else {
+					icon.SetTip(trayTip)
+				}
+			}
+		}
+	}()
+}
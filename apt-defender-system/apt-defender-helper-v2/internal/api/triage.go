@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// handleTriage starts a triage collection (POST) or lists recent ones (GET)
+func (s *Server) handleTriage(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jobs := s.triage.Jobs()
+		s.sendJSON(w, map[string]interface{}{
+			"jobs":  jobs,
+			"count": len(jobs),
+		})
+
+	case http.MethodPost:
+		req := struct {
+			Upload bool `json:"upload"`
+		}{Upload: true}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				s.sendError(w, http.StatusBadRequest, "Invalid request")
+				return
+			}
+		}
+
+		job, err := s.triage.Start(req.Upload)
+		if err != nil {
+			s.sendError(w, http.StatusConflict, err.Error())
+			return
+		}
+		s.sendJSON(w, job)
+
+	default:
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
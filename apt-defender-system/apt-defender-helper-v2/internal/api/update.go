@@ -0,0 +1,137 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/audit"
+	"github.com/apt-defender/helper-v2/internal/updater"
+)
+
+// updateFirstCheck gives the helper time to settle, and the Pi link time to
+// come up, before the first scheduled check
+const updateFirstCheck = 5 * time.Minute
+
+// checkUpdates looks for a newer build every update_interval hours. A build
+// found is announced as update.available and, with update_auto, installed.
+func (s *Server) checkUpdates() {
+	if s.config.UpdateInterval <= 0 {
+		return
+	}
+	interval := time.Duration(s.config.UpdateInterval) * time.Hour
+	go func() {
+		time.Sleep(updateFirstCheck)
+		for {
+			s.scheduledUpdateCheck()
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func (s *Server) scheduledUpdateCheck() {
+	manifest, err := s.updater.Check()
+	if err != nil {
+		if !errors.Is(err, updater.ErrBusy) {
+			log.Printf("⚠️ Update check failed: %v", err)
+		}
+		return
+	}
+	if manifest == nil {
+		return
+	}
+	log.Printf("⬆️ Version %s is available from %s", manifest.Version, s.updater.Status().Source)
+	s.events.Publish("update.available", manifest)
+	if !s.config.UpdateAuto || !s.updater.Status().CanInstall {
+		return
+	}
+
+	_, err = s.updater.Install()
+	entry := audit.Entry{Action: "helper.update", Target: manifest.Version, Details: map[string]interface{}{"via": "auto"}}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	s.audit.Record(entry)
+	if err != nil {
+		log.Printf("❌ Automatic update to %s failed: %v", manifest.Version, err)
+		return
+	}
+	s.restartForUpdate()
+}
+
+// handleUpdate reports the running version, the newest build found and
+// what the updater is doing
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	s.sendJSON(w, s.updater.Status())
+}
+
+// handleUpdateCheck asks the update source for a newer build now
+func (s *Server) handleUpdateCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	manifest, err := s.updater.Check()
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, updater.ErrBusy) {
+			status = http.StatusConflict
+		}
+		s.sendError(w, status, err.Error())
+		return
+	}
+	if manifest != nil {
+		s.events.Publish("update.available", manifest)
+	}
+	s.sendJSON(w, s.updater.Status())
+}
+
+// handleUpdateInstall downloads and verifies the newest build, puts it in
+// place and restarts the helper into it once the response is sent
+func (s *Server) handleUpdateInstall(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	manifest, err := s.updater.Install()
+	target := ""
+	if manifest != nil {
+		target = manifest.Version
+	} else if available := s.updater.Status().Available; available != nil {
+		target = available.Version
+	}
+	s.recordAudit(r, "helper.update", target, err, nil)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, updater.ErrBusy) {
+			status = http.StatusConflict
+		}
+		s.sendError(w, status, err.Error())
+		return
+	}
+
+	s.sendJSON(w, s.updater.Status())
+	go func() {
+		// Let the response reach the caller first
+		time.Sleep(time.Second)
+		s.restartForUpdate()
+	}()
+}
+
+// restartForUpdate tells listeners, then replaces this process with the
+// build just installed
+func (s *Server) restartForUpdate() {
+	s.events.Publish("update.restarting", s.updater.Status().Available)
+	if icon := s.trayIcon.Load(); icon != nil {
+		icon.Remove()
+	}
+	if err := updater.Restart(); err != nil {
+		log.Printf("❌ %v; restart the helper to finish the update", err)
+	}
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/usb"
+)
+
+// handleUSBHistory lists USB storage devices that have ever been connected
+func (s *Server) handleUSBHistory(w http.ResponseWriter, r *http.Request) {
+	devices, err := usb.History()
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]interface{}{
+		"devices": devices,
+		"count":   len(devices),
+	})
+}
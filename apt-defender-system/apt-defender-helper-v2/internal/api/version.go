@@ -0,0 +1,43 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"runtime"
+)
+
+// handleVersion reports the running build, what changed since the version
+// previously recorded on this machine, and the capability diff
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, map[string]interface{}{
+		"version":             s.build.Version,
+		"build_hash":          s.build.BuildHash,
+		"go_version":          runtime.Version(),
+		"previous_version":    s.build.PreviousVersion,
+		"previous_build_hash": s.build.PreviousHash,
+		"updated":             s.build.Updated,
+		"changelog":           s.build.Changelog,
+		"capabilities":        s.build.Capabilities,
+		"capability_diff":     s.build.CapabilityDiff,
+	})
+}
+
+// announceUpdate emits agent.updated once when this run is a new build
+func (s *Server) announceUpdate() {
+	if !s.build.Updated {
+		return
+	}
+
+	log.Printf("⬆️ Updated from %s (%s) to %s (%s)",
+		s.build.PreviousVersion, s.build.PreviousHash, s.build.Version, s.build.BuildHash)
+	s.events.Publish("agent.updated", s.build)
+
+	if !s.piClient.Available() {
+		return
+	}
+	go func() {
+		if err := s.piClient.Notify("agent.updated", s.build); err != nil {
+			log.Printf("⚠️ Could not report update to Pi Agent: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/webhook"
+)
+
+// containmentActions are the audited actions that also raise a
+// containment.executed event when they succeed
+var containmentActions = []string{
+	"files.lock",
+	"files.quarantine",
+	"network.adapter_disable",
+	"network.block_app",
+	"network.block_domain",
+	"network.block_ip",
+	"network.block_port",
+	"network.isolate",
+	"network.kill_connection",
+	"persistence.remove",
+	"process.kill",
+	"system.lock",
+	"system.remote_access_disable",
+}
+
+// handleWebhooks lists the registered webhooks and the events they can subscribe to
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	s.sendJSON(w, map[string]interface{}{
+		"webhooks": s.webhooks.List(),
+		"events":   webhook.Events,
+	})
+}
+
+// handleWebhookAdd registers a webhook. The response carries the signing
+// secret, which isn't shown again.
+func (s *Server) handleWebhookAdd(w http.ResponseWriter, r *http.Request) {
+	var req webhook.Hook
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	hook, err := s.webhooks.Add(req)
+	details := map[string]interface{}{"events": req.Events}
+	if hook != nil {
+		details["id"], details["events"] = hook.ID, hook.Events
+	}
+	s.recordAudit(r, "webhook.add", req.URL, err, details)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.sendJSON(w, hook)
+}
+
+// handleWebhookDelete unregisters a webhook
+func (s *Server) handleWebhookDelete(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.webhooks.Remove(id)
+	s.recordAudit(r, "webhook.remove", id, err, nil)
+	if err != nil {
+		s.sendError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]string{"id": id, "status": "removed"})
+}
+
+// handleWebhookTest sends a signed webhook.test event and reports the result
+func (s *Server) handleWebhookTest(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := s.webhooks.Test(id); err != nil {
+		s.sendError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	s.sendJSON(w, map[string]string{"id": id, "status": "delivered"})
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+)
+
+// handleWakeOnLAN relays a magic packet so the Pi can wake machines on this
+// helper's subnet
+func (s *Server) handleWakeOnLAN(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		MAC       string `json:"mac"`
+		Broadcast string `json:"broadcast"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	sent, err := control.WakeOnLAN(req.MAC, req.Broadcast)
+	s.recordAudit(r, "network.wol", req.MAC, err, map[string]interface{}{"sent_to": sent})
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.sendJSON(w, map[string]interface{}{
+		"mac":     req.MAC,
+		"sent_to": sent,
+	})
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const logFile = "audit.jsonl"
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Entry records one command received by the helper
+type Entry struct {
+	Time      time.Time              `json:"time"`
+	Action    string                 `json:"action"`
+	Target    string                 `json:"target,omitempty"`
+	Outcome   string                 `json:"outcome"`
+	Error     string                 `json:"error,omitempty"`
+	Remote    string                 `json:"remote,omitempty"`
+	Token     string                 `json:"token,omitempty"`      // name of the token used
+	RequestID string                 `json:"request_id,omitempty"` // X-Request-ID of the API call, matching the helper's log records
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// Log is an append-only JSON-lines audit trail in the data directory
+type Log struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func New(dir string) *Log {
+	return &Log{path: filepath.Join(dir, logFile)}
+}
+
+// Record appends an entry. Failures are logged but never block the command.
+func (l *Log) Record(e Entry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Outcome == "" {
+		e.Outcome = OutcomeSuccess
+		if e.Error != "" {
+			e.Outcome = OutcomeFailure
+		}
+	}
+
+	if err := l.append(e); err != nil {
+		log.Printf("⚠️ Failed to write audit entry for %s: %v", e.Action, err)
+	}
+}
+
+func (l *Log) append(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Filter selects audit entries; zero fields match everything
+type Filter struct {
+	Action  string // exact action, or a prefix such as "files" for files.*
+	Outcome string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+func (f Filter) matches(e Entry) bool {
+	if f.Action != "" && e.Action != f.Action && !strings.HasPrefix(e.Action, f.Action+".") {
+		return false
+	}
+	if f.Outcome != "" && e.Outcome != f.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Query returns the entries matching f, newest first, and how many matched
+// before Limit was applied
+func (l *Log) Query(f Filter) ([]Entry, int, error) {
+	l.mutex.Lock()
+	data, err := os.ReadFile(l.path)
+	l.mutex.Unlock()
+	if os.IsNotExist(err) {
+		return []Entry{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := []Entry{}
+	lines := bytes.Split(data, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var e Entry
+		if len(bytes.TrimSpace(lines[i])) == 0 || json.Unmarshal(lines[i], &e) != nil {
+			continue
+		}
+		if f.matches(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	total := len(entries)
+	if f.Limit > 0 && total > f.Limit {
+		entries = entries[:f.Limit]
+	}
+	return entries, total, nil
+}
+
+// Actions lists the distinct actions in the log, for filter pickers
+func (l *Log) Actions() ([]string, error) {
+	entries, _, err := l.Query(Filter{})
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	actions := []string{}
+	for _, e := range entries {
+		if !seen[e.Action] {
+			seen[e.Action] = true
+			actions = append(actions, e.Action)
+		}
+	}
+	sort.Strings(actions)
+	return actions, nil
+}
@@ -0,0 +1,73 @@
+package authenticode
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Signature status values as reported by Get-AuthenticodeSignature
+const (
+	StatusValid        = "Valid"
+	StatusNotSigned    = "NotSigned"
+	StatusHashMismatch = "HashMismatch"
+)
+
+// query reads image paths from stdin and prints Authenticode status.
+// Get-AuthenticodeSignature also honours catalog signatures, which covers
+// most inbox Windows binaries.
+const query = `$input | ForEach-Object { $s = Get-AuthenticodeSignature -LiteralPath $_; ` +
+	`[pscustomobject]@{Path=$_;Status=[string]$s.Status;Signer=[string]$s.SignerCertificate.Subject} } | ConvertTo-Json -Compress`
+
+// Result is the signing state of one file
+type Result struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Signer string `json:"signer,omitempty"`
+}
+
+// Check verifies every distinct existing file in a single PowerShell call.
+// Results are keyed by lower-cased path.
+func Check(paths []string) map[string]Result {
+	results := make(map[string]Result)
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, p := range paths {
+		key := strings.ToLower(p)
+		if p == "" || seen[key] {
+			continue
+		}
+		if info, err := os.Stat(p); err != nil || info.IsDir() {
+			continue
+		}
+		seen[key] = true
+		files = append(files, p)
+	}
+	if len(files) == 0 {
+		return results
+	}
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query)
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return results
+	}
+
+	// ConvertTo-Json emits a bare object for a single result
+	var list []Result
+	if err := json.Unmarshal(out, &list); err != nil {
+		var single Result
+		if json.Unmarshal(out, &single) != nil {
+			return results
+		}
+		list = []Result{single}
+	}
+
+	for _, r := range list {
+		results[strings.ToLower(r.Path)] = r
+	}
+	return results
+}
@@ -0,0 +1,79 @@
+package autostart
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const (
+	runKey    = `HKCU\Software\Microsoft\Windows\CurrentVersion\Run`
+	valueName = "APTDefenderHelper"
+)
+
+// Available reports why the helper can't start itself at login, or nil.
+// A helper running as a service already starts with Windows, and its
+// HKCU is the service account's rather than the user's.
+func Available() error {
+	if control.InServiceSession() {
+		return fmt.Errorf("the helper runs as a service, which already starts with Windows")
+	}
+	return nil
+}
+
+// Command is what the Run entry starts: this executable with its config file
+func Command(configPath string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("can't locate the helper executable: %w", err)
+	}
+	return fmt.Sprintf(`"%s" --config "%s"`, exe, configPath), nil
+}
+
+// Sync adds, updates or removes the current user's Run entry to match
+// enabled. It leaves the registry alone when it already matches.
+func Sync(enabled bool, configPath string) error {
+	current := registered()
+	if !enabled {
+		if current == "" {
+			return nil
+		}
+		if out, err := exec.Command("reg", "delete", runKey, "/v", valueName, "/f").CombinedOutput(); err != nil {
+			return fmt.Errorf("reg delete failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		log.Printf("🚀 Removed the helper from startup")
+		return nil
+	}
+
+	if err := Available(); err != nil {
+		return err
+	}
+	command, err := Command(configPath)
+	if err != nil {
+		return err
+	}
+	if current == command {
+		return nil
+	}
+	if out, err := exec.Command("reg", "add", runKey, "/v", valueName, "/t", "REG_SZ", "/d", command, "/f").CombinedOutput(); err != nil {
+		return fmt.Errorf("reg add failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	log.Printf("🚀 Helper starts at login: %s", command)
+	return nil
+}
+
+// registered returns the command in the Run entry, or "" without one
+func registered() string {
+	key, err := winreg.Open(runKey)
+	if err != nil {
+		return ""
+	}
+	defer key.Close()
+	command, _ := key.GetString(valueName)
+	return command
+}
@@ -0,0 +1,62 @@
+package bitlocker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Volume is the BitLocker state of one volume. Key protector types are
+// reported, never the recovery passwords themselves.
+type Volume struct {
+	MountPoint           string   `json:"mount_point"`
+	VolumeType           string   `json:"volume_type"`
+	ProtectionStatus     string   `json:"protection_status"`
+	VolumeStatus         string   `json:"volume_status"`
+	EncryptionMethod     string   `json:"encryption_method"`
+	EncryptionPercentage float64  `json:"encryption_percentage"`
+	LockStatus           string   `json:"lock_status"`
+	KeyProtectors        []string `json:"key_protectors"`
+	Protected            bool     `json:"protected"`
+}
+
+// Enums are stringified in PowerShell; ConvertTo-Json would emit numbers
+const volumesQuery = `Get-BitLockerVolume | ForEach-Object {
+  [pscustomobject]@{
+    MountPoint = $_.MountPoint; VolumeType = [string]$_.VolumeType
+    ProtectionStatus = [string]$_.ProtectionStatus; VolumeStatus = [string]$_.VolumeStatus
+    EncryptionMethod = [string]$_.EncryptionMethod; EncryptionPercentage = $_.EncryptionPercentage
+    LockStatus = [string]$_.LockStatus; KeyProtectors = @($_.KeyProtector | ForEach-Object { [string]$_.KeyProtectorType })
+  }
+} | ConvertTo-Json -Compress -Depth 3`
+
+// Volumes reports BitLocker status for every volume
+func Volumes() ([]Volume, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", volumesQuery).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query BitLocker (is the BitLocker feature available on this edition?): %w", err)
+	}
+
+	raw := strings.TrimSpace(string(out))
+	if raw == "" {
+		return []Volume{}, nil
+	}
+	if !strings.HasPrefix(raw, "[") {
+		raw = "[" + raw + "]"
+	}
+
+	var volumes []Volume
+	if err := json.Unmarshal([]byte(raw), &volumes); err != nil {
+		return nil, fmt.Errorf("failed to parse BitLocker status: %w", err)
+	}
+	for i := range volumes {
+		v := &volumes[i]
+		if v.KeyProtectors == nil {
+			v.KeyProtectors = []string{}
+		}
+		// Suspended protection leaves the key in the clear on disk
+		v.Protected = v.ProtectionStatus == "On" && v.VolumeStatus == "FullyEncrypted"
+	}
+	return volumes, nil
+}
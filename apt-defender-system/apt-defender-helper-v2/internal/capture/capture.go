@@ -0,0 +1,286 @@
+package capture
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	MaxDuration = 5 * time.Minute
+
+	snapLen        = 65535
+	readTimeoutMS  = 500
+	errbufSize     = 256
+	pcapNetmaskAny = 0xffffffff
+	pcapIfLoopback = 0x1
+)
+
+var (
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procSetDllDirectoryW = kernel32.NewProc("SetDllDirectoryW")
+
+	loadOnce sync.Once
+	loadErr  error
+	wpcap    *syscall.DLL
+
+	// Only one capture runs at a time; they are short and share the adapter
+	running sync.Mutex
+)
+
+// Interface is a capture device as reported by Npcap
+type Interface struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Loopback    bool   `json:"loopback"`
+}
+
+// Options describes a capture request
+type Options struct {
+	Interface string
+	Filter    string // BPF expression, e.g. "host 1.2.3.4 and tcp port 443"
+	Duration  time.Duration
+	MaxBytes  int64 // stop early once the file reaches this size
+}
+
+// Result summarises a finished capture
+type Result struct {
+	Interface string  `json:"interface"`
+	Filter    string  `json:"filter,omitempty"`
+	Packets   int     `json:"packets"`
+	Bytes     int64   `json:"bytes"`
+	Seconds   float64 `json:"seconds"`
+	Truncated bool    `json:"truncated"`
+}
+
+type pcapIf struct {
+	Next        *pcapIf
+	Name        *byte
+	Description *byte
+	Addresses   uintptr
+	Flags       uint32
+}
+
+type pktHeader struct {
+	Sec    int32
+	Usec   int32
+	CapLen uint32
+	Len    uint32
+}
+
+type bpfProgram struct {
+	Len   uint32
+	Insns uintptr
+}
+
+// load resolves wpcap.dll from the Npcap install directory so its
+// Packet.dll dependency is found as well
+func load() error {
+	loadOnce.Do(func() {
+		dir := filepath.Join(os.Getenv("SystemRoot"), "System32", "Npcap")
+		if _, err := os.Stat(dir); err == nil {
+			if p, err := syscall.UTF16PtrFromString(dir); err == nil {
+				procSetDllDirectoryW.Call(uintptr(unsafe.Pointer(p)))
+			}
+		}
+
+		wpcap, loadErr = syscall.LoadDLL("wpcap.dll")
+		if loadErr != nil {
+			loadErr = fmt.Errorf("packet capture requires Npcap (https://npcap.com): %w", loadErr)
+		}
+	})
+	return loadErr
+}
+
+func call(name string, args ...uintptr) (uintptr, error) {
+	proc, err := wpcap.FindProc(name)
+	if err != nil {
+		return 0, err
+	}
+	ret, _, _ := proc.Call(args...)
+	return ret, nil
+}
+
+// Interfaces lists the devices Npcap can capture on
+func Interfaces() ([]Interface, error) {
+	if err := load(); err != nil {
+		return nil, err
+	}
+
+	var devs *pcapIf
+	errbuf := make([]byte, errbufSize)
+	ret, err := call("pcap_findalldevs", uintptr(unsafe.Pointer(&devs)), uintptr(unsafe.Pointer(&errbuf[0])))
+	if err != nil {
+		return nil, err
+	}
+	if int32(ret) != 0 {
+		return nil, fmt.Errorf("pcap_findalldevs: %s", cString(&errbuf[0]))
+	}
+	defer call("pcap_freealldevs", uintptr(unsafe.Pointer(devs)))
+
+	result := []Interface{}
+	for d := devs; d != nil; d = d.Next {
+		result = append(result, Interface{
+			Name:        cString(d.Name),
+			Description: cString(d.Description),
+			Loopback:    d.Flags&pcapIfLoopback != 0,
+		})
+	}
+	return result, nil
+}
+
+// Resolve maps a device name or a fragment of its description to the
+// Npcap device name
+func Resolve(name string) (string, error) {
+	ifaces, err := Interfaces()
+	if err != nil {
+		return "", err
+	}
+
+	var match []Interface
+	for _, iface := range ifaces {
+		if strings.EqualFold(iface.Name, name) {
+			return iface.Name, nil
+		}
+		if strings.Contains(strings.ToLower(iface.Description), strings.ToLower(name)) {
+			match = append(match, iface)
+		}
+	}
+
+	switch len(match) {
+	case 0:
+		return "", fmt.Errorf("no capture interface matches %q", name)
+	case 1:
+		return match[0].Name, nil
+	default:
+		return "", fmt.Errorf("%q matches %d interfaces, use the device name", name, len(match))
+	}
+}
+
+// Capture records traffic on an interface into a pcap file at path
+func Capture(path string, opts Options) (*Result, error) {
+	if opts.Duration <= 0 || opts.Duration > MaxDuration {
+		return nil, fmt.Errorf("duration must be between 1s and %s", MaxDuration)
+	}
+
+	device, err := Resolve(opts.Interface)
+	if err != nil {
+		return nil, err
+	}
+
+	if !running.TryLock() {
+		return nil, fmt.Errorf("a capture is already running")
+	}
+	defer running.Unlock()
+
+	errbuf := make([]byte, errbufSize)
+	devicePtr, err := syscall.BytePtrFromString(device)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := call("pcap_open_live", uintptr(unsafe.Pointer(devicePtr)), snapLen, 1, readTimeoutMS, uintptr(unsafe.Pointer(&errbuf[0])))
+	if err != nil {
+		return nil, err
+	}
+	if handle == 0 {
+		return nil, fmt.Errorf("failed to open %s: %s", device, cString(&errbuf[0]))
+	}
+	defer call("pcap_close", handle)
+
+	if opts.Filter != "" {
+		if err := setFilter(handle, opts.Filter); err != nil {
+			return nil, err
+		}
+	}
+
+	pathPtr, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	dumper, err := call("pcap_dump_open", handle, uintptr(unsafe.Pointer(pathPtr)))
+	if err != nil {
+		return nil, err
+	}
+	if dumper == 0 {
+		return nil, fmt.Errorf("failed to create %s: %s", path, lastError(handle))
+	}
+	defer call("pcap_dump_close", dumper)
+
+	result := &Result{Interface: device, Filter: opts.Filter}
+	start := time.Now()
+	deadline := start.Add(opts.Duration)
+	size := int64(24) // pcap global header
+
+	for time.Now().Before(deadline) {
+		var hdr *pktHeader
+		var data *byte
+		ret, _ := call("pcap_next_ex", handle, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data)))
+
+		switch int32(ret) {
+		case 1:
+			call("pcap_dump", dumper, uintptr(unsafe.Pointer(hdr)), uintptr(unsafe.Pointer(data)))
+			result.Packets++
+			size += 16 + int64(hdr.CapLen)
+		case 0:
+			// Read timeout with no packets; check the deadline again
+			continue
+		default:
+			return nil, fmt.Errorf("capture failed: %s", lastError(handle))
+		}
+
+		if opts.MaxBytes > 0 && size >= opts.MaxBytes {
+			result.Truncated = true
+			break
+		}
+	}
+
+	result.Bytes = size
+	result.Seconds = time.Since(start).Seconds()
+	return result, nil
+}
+
+func setFilter(handle uintptr, filter string) error {
+	filterPtr, err := syscall.BytePtrFromString(filter)
+	if err != nil {
+		return err
+	}
+
+	var prog bpfProgram
+	ret, err := call("pcap_compile", handle, uintptr(unsafe.Pointer(&prog)), uintptr(unsafe.Pointer(filterPtr)), 1, pcapNetmaskAny)
+	if err != nil {
+		return err
+	}
+	if int32(ret) != 0 {
+		return fmt.Errorf("invalid BPF filter: %s", lastError(handle))
+	}
+	defer call("pcap_freecode", uintptr(unsafe.Pointer(&prog)))
+
+	if ret, _ := call("pcap_setfilter", handle, uintptr(unsafe.Pointer(&prog))); int32(ret) != 0 {
+		return fmt.Errorf("failed to apply filter: %s", lastError(handle))
+	}
+	return nil
+}
+
+func lastError(handle uintptr) string {
+	ret, err := call("pcap_geterr", handle)
+	if err != nil || ret == 0 {
+		return "unknown error"
+	}
+	return cString(*(**byte)(unsafe.Pointer(&ret)))
+}
+
+func cString(p *byte) string {
+	if p == nil {
+		return ""
+	}
+	var b []byte
+	for ptr := unsafe.Pointer(p); *(*byte)(ptr) != 0; ptr = unsafe.Add(ptr, 1) {
+		b = append(b, *(*byte)(ptr))
+	}
+	return string(b)
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupDir        = "config-backups"
+	backupTimeLayout = "20060102-150405.000"
+)
+
+// Backup is a previous version of the config file
+type Backup struct {
+	Name    string    `json:"name"`
+	SavedAt time.Time `json:"saved_at"`
+	Size    int64     `json:"size"`
+}
+
+// backupCurrent copies the config file about to be replaced into
+// config-backups and prunes all but the newest keep copies. Unchanged
+// files aren't copied again.
+func backupCurrent(path string, next []byte, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	current, err := os.ReadFile(path)
+	if err != nil || bytes.Equal(current, next) {
+		return nil
+	}
+
+	dir := filepath.Join(filepath.Dir(path), backupDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create backup dir: %w", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name := fmt.Sprintf("%s.%s.yaml", base, time.Now().Format(backupTimeLayout))
+	if err := os.WriteFile(filepath.Join(dir, name), current, 0600); err != nil {
+		return fmt.Errorf("failed to write config backup: %w", err)
+	}
+
+	backups, err := Backups(path)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups[min(keep, len(backups)):] {
+		os.Remove(filepath.Join(dir, b.Name))
+	}
+	return nil
+}
+
+// Backups lists saved config versions, newest first
+func Backups(path string) ([]Backup, error) {
+	dir := filepath.Join(filepath.Dir(path), backupDir)
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []Backup{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config backups: %w", err)
+	}
+
+	backups := []Backup{}
+	for _, e := range entries {
+		stamp, ok := strings.CutPrefix(e.Name(), base+".")
+		if !ok || e.IsDir() {
+			continue
+		}
+		savedAt, err := time.ParseInLocation(backupTimeLayout, strings.TrimSuffix(stamp, ".yaml"), time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Name: e.Name(), SavedAt: savedAt, Size: info.Size()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].SavedAt.After(backups[j].SavedAt) })
+	return backups, nil
+}
+
+// Rollback restores a backup (the newest when name is empty) as the config
+// file. The config being replaced is backed up first, so a rollback can
+// itself be rolled back.
+func Rollback(path, name string) (*Config, error) {
+	backups, err := Backups(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(backups) == 0 {
+		return nil, fmt.Errorf("no config backups")
+	}
+	if name == "" {
+		name = backups[0].Name
+	}
+	if filepath.Base(name) != name {
+		return nil, fmt.Errorf("invalid backup name: %s", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), backupDir, name))
+	if err != nil {
+		return nil, fmt.Errorf("config backup not found: %s", name)
+	}
+
+	cfg, version, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("backup %s: %w", name, err)
+	}
+	if version < CurrentSchemaVersion {
+		// Restore the upgraded form so the file matches what is applied
+		if data, err = cfg.marshal(); err != nil {
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
+		}
+	}
+
+	if err := backupCurrent(path, data, max(cfg.ConfigBackups, 1)); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Replace swaps in a config loaded from disk while keeping any active
+// environment/flag overrides
+func (c *Config) Replace(next *Config) {
+	overrides := map[string]reflect.Value{}
+	for key := range c.fileValues {
+		current := c.field(key)
+		value := reflect.New(current.Type()).Elem()
+		value.Set(current)
+		overrides[key] = value
+	}
+
+	*c = *next
+	c.fileValues = nil
+	for key, value := range overrides {
+		c.rememberFileValue(key)
+		c.field(key).Set(value)
+	}
+}
+
+// Overridden reports whether a key is set from the environment or a flag
+func (c *Config) Overridden(key string) bool {
+	_, ok := c.fileValues[key]
+	return ok
+}
@@ -2,22 +2,104 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
-
-	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 )
 
+var envVarPattern = regexp.MustCompile(`%([^%]+)%`)
+
 type Config struct {
-	Host             string   `yaml:"host"`
-	Port             int      `yaml:"port"`
-	AuthToken        string   `yaml:"auth_token"`
-	EnableTLS        bool     `yaml:"enable_tls"`
-	CertFile         string   `yaml:"cert_file"`
-	KeyFile          string   `yaml:"key_file"`
-	LogLevel         string   `yaml:"log_level"`
-	ScanPaths        []string `yaml:"scan_paths"`
-	PiAgentIP        string   `yaml:"pi_agent_ip"`        // IP of the Pi Agent this PC is registered with
-	RegisteredWithPi bool     `yaml:"registered_with_pi"` // Whether this PC has been registered
+	SchemaVersion     int        `yaml:"schema_version" json:"schema_version"` // Config layout version, upgraded automatically on load
+	Host              string     `yaml:"host" json:"host"`
+	Port              int        `yaml:"port" json:"port"`
+	AuthToken         string     `yaml:"auth_token" json:"auth_token"`
+	EnableTLS         bool       `yaml:"enable_tls" json:"enable_tls"`
+	CertFile          string     `yaml:"cert_file" json:"cert_file"`
+	KeyFile           string     `yaml:"key_file" json:"key_file"`
+	LogLevel          string     `yaml:"log_level" json:"log_level"`
+	ScanPaths         []string   `yaml:"scan_paths" json:"scan_paths"`
+	ScanExclusions    []string   `yaml:"scan_exclusions" json:"scan_exclusions"`         // Folders, files and name patterns (*.iso) scans skip
+	ScanInterval      int        `yaml:"scan_interval" json:"scan_interval"`             // Hours between scheduled full scans (0 = off)
+	ScanSkipWarn      int        `yaml:"scan_skip_warn" json:"scan_skip_warn"`           // Percent of unreadable files that raises scan.warning
+	NotifyThreats     bool       `yaml:"notify_threats" json:"notify_threats"`           // Show a toast on this PC when a scan finds threats
+	NotifyScans       bool       `yaml:"notify_scans" json:"notify_scans"`               // Show a toast on this PC when any scan finishes
+	NotifyCommands    bool       `yaml:"notify_commands" json:"notify_commands"`         // Tell the user when this PC is locked, shut down, restarted or isolated remotely
+	PiAlerts          []string   `yaml:"pi_alerts" json:"pi_alerts"`                     // Events reported to the Pi Agent as they happen
+	StartAtLogin      bool       `yaml:"start_at_login" json:"start_at_login"`           // Start the helper when the user signs in (HKCU Run entry)
+	StartMinimized    bool       `yaml:"start_minimized" json:"start_minimized"`         // Start in the tray without opening the dashboard, unless pairing is needed
+	Theme             string     `yaml:"theme" json:"theme"`                             // Dashboard theme: system (follow Windows), light or dark
+	UpdateURL         string     `yaml:"update_url" json:"update_url"`                   // Update manifest URL; empty asks the paired Pi Agent
+	UpdatePublicKey   string     `yaml:"update_public_key" json:"update_public_key"`     // Base64 Ed25519 key that release builds are signed with
+	UpdateInterval    int        `yaml:"update_interval" json:"update_interval"`         // Hours between update checks, 0 = only on request
+	UpdateAuto        bool       `yaml:"update_auto" json:"update_auto"`                 // Install verified updates as soon as they are found
+	PiAgentIP         string     `yaml:"pi_agent_ip" json:"pi_agent_ip"`                 // IP of the Pi Agent this PC is registered with
+	PiAgentHost       string     `yaml:"pi_agent_host" json:"pi_agent_host"`             // Name the Pi Agent was paired by, resolved to pi_agent_ip once at pairing
+	RegisteredWithPi  bool       `yaml:"registered_with_pi" json:"registered_with_pi"`   // Whether this PC has been registered
+	DNSBlocklist      []string   `yaml:"dns_blocklist" json:"dns_blocklist"`             // Domains flagged by the DNS monitor (subdomains included)
+	ProtectedPaths    []string   `yaml:"protected_paths" json:"protected_paths"`         // Extra paths file operations may never touch
+	PathOverrides     []string   `yaml:"path_overrides" json:"path_overrides"`           // Explicit exceptions carved out of protected paths
+	FIMPaths          []string   `yaml:"fim_paths" json:"fim_paths"`                     // Files/folders watched by file integrity monitoring
+	FIMInterval       int        `yaml:"fim_interval" json:"fim_interval"`               // Minutes between integrity re-checks
+	PiAgentPort       int        `yaml:"pi_agent_port" json:"pi_agent_port"`             // HTTPS port of the Pi Agent API
+	PiCertFingerprint string     `yaml:"pi_cert_fingerprint" json:"pi_cert_fingerprint"` // SHA-256 of the Pi Agent certificate, pinned at pairing
+	PiDeviceID        int        `yaml:"pi_device_id" json:"pi_device_id"`               // Device ID the Pi Agent assigned at pairing, included in log records
+	PiAccessToken     string     `yaml:"pi_access_token" json:"pi_access_token"`         // Device token the Pi Agent issued at pairing, refreshed before it expires
+	LogShipping       string     `yaml:"log_shipping" json:"log_shipping"`               // Where logs and security events are forwarded: pi, syslog or off
+	SyslogAddress     string     `yaml:"syslog_address" json:"syslog_address"`           // host:port (UDP) or tcp://host:port for log_shipping: syslog
+	LogSpoolMB        int        `yaml:"log_spool_mb" json:"log_spool_mb"`               // Records kept on disk while the log destination is unreachable
+	EnableMTLS        bool       `yaml:"enable_mtls" json:"enable_mtls"`                 // Present the client certificate to the Pi Agent
+	ClientCertFile    string     `yaml:"client_cert_file" json:"client_cert_file"`       // Client certificate issued by the Pi Agent at pairing
+	ClientKeyFile     string     `yaml:"client_key_file" json:"client_key_file"`         // Private key of the client certificate (never leaves this PC)
+	PiCAFile          string     `yaml:"pi_ca_file" json:"pi_ca_file"`                   // Pi Agent's device CA, which signed the client certificate
+	MeshPeers         []string   `yaml:"mesh_peers" json:"mesh_peers"`                   // Other helpers (ip:port) whose health this helper watches
+	MaxArtifactMB     int        `yaml:"max_artifact_mb" json:"max_artifact_mb"`         // Largest dump/capture/triage file kept in staging
+	MaxUploadMB       int        `yaml:"max_upload_mb" json:"max_upload_mb"`             // Largest artifact uploaded to the Pi Agent
+	QuarantineMaxMB   int        `yaml:"quarantine_max_mb" json:"quarantine_max_mb"`     // Oldest quarantined files are deleted beyond this total (0 = unlimited)
+	QuarantineDays    int        `yaml:"quarantine_days" json:"quarantine_days"`         // Quarantined files older than this are deleted (0 = keep forever)
+	RestorePoints     bool       `yaml:"restore_points" json:"restore_points"`           // Create a System Restore point before quarantine, persistence removal and allowlist enforcement
+	ConfigBackups     int        `yaml:"config_backups" json:"config_backups"`           // Previous config versions kept in config-backups (0 = none)
+	APITokens         []APIToken `yaml:"api_tokens" json:"api_tokens"`                   // Extra bearer tokens limited to a scope
+	AllowedSources    []string   `yaml:"allowed_sources" json:"allowed_sources"`         // IPs/CIDRs allowed to call /api (empty = paired Pi and mesh peers)
+	CORS              CORS       `yaml:"cors" json:"cors"`                               // Browser origins allowed to call the API, e.g. a web console on the Pi
+	ReplayProtection  string     `yaml:"replay_protection" json:"replay_protection"`     // Fresh X-Timestamp, unused X-Nonce and valid X-Signature on token requests: off, audit (log only) or enforce
+	ReplayWindow      int        `yaml:"replay_window" json:"replay_window"`             // Seconds X-Timestamp may be off from the helper's clock
+	DashboardPIN      string     `yaml:"dashboard_pin" json:"dashboard_pin"`             // PIN for opening the dashboard from another machine (empty = this PC only)
+	DashboardScope    string     `yaml:"dashboard_scope" json:"dashboard_scope"`         // What a PIN-signed-in dashboard may do: read, scan or control
+	IPLookups         bool       `yaml:"ip_lookups" json:"ip_lookups"`                   // Look up reverse DNS, ASN and country of remote addresses for the dashboard
+
+	fileValues map[string]reflect.Value // values replaced by env/flag overrides, restored on Save
+	unknown    map[string]interface{}   // keys from the file this build doesn't know, written back on Save
+}
+
+// Token scopes, each allowing everything the previous one does
+const (
+	ScopeRead    = "read"    // telemetry, inventory and status
+	ScopeScan    = "scan"    // plus starting/stopping scans and baselines
+	ScopeControl = "control" // plus everything that changes the system
+)
+
+// Scopes lists the token scopes from least to most privileged
+var Scopes = []string{ScopeRead, ScopeScan, ScopeControl}
+
+// CORS lets web consoles on other origins call the API from a browser.
+// It is off while AllowedOrigins is empty.
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins"` // scheme://host[:port], "pi" for any origin on the paired Pi, or "*"
+	AllowedMethods []string `yaml:"allowed_methods" json:"allowed_methods"`
+	AllowedHeaders []string `yaml:"allowed_headers" json:"allowed_headers"` // request headers allowed in addition to the ones the API reads
+	MaxAge         int      `yaml:"max_age" json:"max_age"`                 // seconds browsers may cache a preflight
+}
+
+// APIToken is an extra bearer token for monitoring dashboards and other
+// integrations that shouldn't hold the full-control auth_token
+type APIToken struct {
+	Name  string `yaml:"name" json:"name"`
+	Token string `yaml:"token" json:"token"`
+	Scope string `yaml:"scope" json:"scope"`
 }
 
 func Load(path string) (*Config, error) {
@@ -30,20 +112,35 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
+	cfg, version, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write upgraded files back (the old version lands in config-backups)
+	if version < CurrentSchemaVersion {
+		if err := cfg.Save(path); err != nil {
+			log.Printf("⚠️ Failed to save migrated config: %v", err)
+		}
 	}
 
-	return &cfg, nil
+	return cfg, nil
 }
 
 func (c *Config) Save(path string) error {
-	data, err := yaml.Marshal(c)
+	if err := checkOverwrite(path); err != nil {
+		return err
+	}
+
+	data, err := c.withoutOverrides().marshal()
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	if err := backupCurrent(path, data, c.ConfigBackups); err != nil {
+		return err
+	}
+
 	if err := os.WriteFile(path, data, 0600); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
@@ -54,6 +151,7 @@ func (c *Config) Save(path string) error {
 func DefaultConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
 	return &Config{
+		SchemaVersion:    CurrentSchemaVersion,
 		Host:             "0.0.0.0",
 		Port:             7890,
 		AuthToken:        "change-me-in-production",
@@ -66,6 +164,44 @@ func DefaultConfig() *Config {
 			homeDir + "\\Documents",
 			homeDir + "\\Desktop",
 		},
+		ScanExclusions: []string{},
+		ScanSkipWarn:   5,
+		NotifyCommands: true,
+		Theme:          "system",
+		UpdateInterval: 24,
+		PiAlerts:       []string{"scan.threat", "fim.change", "playbook.executed"},
+		DNSBlocklist:   []string{},
+		ProtectedPaths: []string{},
+		PathOverrides:  []string{},
+		FIMPaths: []string{
+			"%SystemRoot%\\System32\\drivers\\etc\\hosts",
+			"%ProgramData%\\Microsoft\\Windows\\Start Menu\\Programs\\StartUp",
+			"%APPDATA%\\Microsoft\\Windows\\Start Menu\\Programs\\Startup",
+			"%SystemRoot%\\System32\\Tasks",
+			"%SystemRoot%\\System32\\GroupPolicy",
+		},
+		FIMInterval:     15,
+		PiAgentPort:     8443,
+		LogShipping:     "pi",
+		LogSpoolMB:      50,
+		MeshPeers:       []string{},
+		MaxArtifactMB:   4096,
+		MaxUploadMB:     512,
+		QuarantineMaxMB: 2048,
+		QuarantineDays:  90,
+		ConfigBackups:   10,
+		APITokens:       []APIToken{},
+		AllowedSources:  []string{},
+		CORS: CORS{
+			AllowedOrigins: []string{},
+			AllowedMethods: []string{"GET", "POST", "PATCH", "DELETE"},
+			AllowedHeaders: []string{},
+			MaxAge:         600,
+		},
+		ReplayProtection: "audit",
+		ReplayWindow:     300,
+		DashboardScope:   ScopeRead,
+		IPLookups:        true,
 	}
 }
 
@@ -75,3 +211,15 @@ func GetConfigPath() string {
 	}
 	return "C:\\ProgramData\\APTDefender\\helper-v2-config.yaml"
 }
+
+// GetDataDir returns the directory holding the helper's state files
+func GetDataDir() string {
+	return filepath.Dir(GetConfigPath())
+}
+
+// ExpandPath resolves Windows-style %VAR% references in a configured path
+func ExpandPath(path string) string {
+	return envVarPattern.ReplaceAllStringFunc(path, func(ref string) string {
+		return os.Getenv(strings.Trim(ref, "%"))
+	})
+}
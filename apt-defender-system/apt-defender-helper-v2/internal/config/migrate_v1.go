@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// V1ConfigPaths are where helper-service (v1) kept its config
+var V1ConfigPaths = []string{
+	`C:\ProgramData\APTDefender\config.yaml`,
+	"/etc/apt-defender/config.yaml",
+}
+
+// v1Keys maps v1 keys, normalized to lower case without separators, onto v2
+// keys. v1 used both tagged snake_case and untagged Go field names.
+var v1Keys = map[string]string{
+	"host":              "host",
+	"port":              "port",
+	"authtoken":         "auth_token",
+	"token":             "auth_token",
+	"apitoken":          "auth_token",
+	"certfile":          "cert_file",
+	"keyfile":           "key_file",
+	"loglevel":          "log_level",
+	"scanpaths":         "scan_paths",
+	"piagentip":         "pi_agent_ip",
+	"piip":              "pi_agent_ip",
+	"piagentport":       "pi_agent_port",
+	"picertfingerprint": "pi_cert_fingerprint",
+	"ispaired":          "registered_with_pi",
+	"paired":            "registered_with_pi",
+	"registered":        "registered_with_pi",
+	"registeredwithpi":  "registered_with_pi",
+}
+
+// MigrateV1 converts a v1 config into the v2 file at path when no v2 config
+// exists yet, so an upgraded PC stays paired. The v1 file is renamed so the
+// migration only runs once; the archived path is returned ("" if nothing
+// was migrated).
+func MigrateV1(path string) (string, error) {
+	if _, err := os.Stat(path); err == nil {
+		return "", nil
+	}
+
+	for _, v1Path := range V1ConfigPaths {
+		data, err := os.ReadFile(v1Path)
+		if err != nil {
+			continue
+		}
+
+		cfg, err := fromV1(data, filepath.Dir(v1Path))
+		if err != nil {
+			return "", fmt.Errorf("failed to migrate %s: %w", v1Path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return "", fmt.Errorf("failed to create config dir: %w", err)
+		}
+		if err := cfg.Save(path); err != nil {
+			return "", err
+		}
+
+		archived := fmt.Sprintf("%s.v1-%s", v1Path, time.Now().Format("20060102-150405"))
+		if err := os.Rename(v1Path, archived); err != nil {
+			return "", fmt.Errorf("migrated but failed to archive %s: %w", v1Path, err)
+		}
+		log.Printf("📦 Migrated v1 config %s (archived as %s)", v1Path, archived)
+		return archived, nil
+	}
+	return "", nil
+}
+
+// fromV1 maps a v1 config onto the v2 defaults. Relative cert paths are
+// resolved against the v1 config directory.
+func fromV1(data []byte, dir string) (*Config, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse v1 config: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	for key, value := range raw {
+		normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+		target, ok := v1Keys[normalized]
+		if !ok {
+			log.Printf("⚠️ v1 config key %q has no v2 equivalent, skipped", key)
+			continue
+		}
+		if err := cfg.Set(target, v1String(value)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range []*string{&cfg.CertFile, &cfg.KeyFile} {
+		if *file != "" && !filepath.IsAbs(*file) {
+			*file = filepath.Join(dir, *file)
+		}
+	}
+
+	// v1 always served TLS when it had a certificate, and the Pi Agent
+	// expects to keep talking to it that way
+	cfg.EnableTLS = cfg.CertFile != "" && cfg.KeyFile != ""
+	if cfg.PiAgentIP == "" {
+		cfg.RegisteredWithPi = false
+	}
+	return cfg, nil
+}
+
+// v1String renders a YAML value in the form Config.Set expects
+func v1String(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, v1String(item))
+		}
+		return strings.Join(items, ",")
+	case int:
+		return strconv.Itoa(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
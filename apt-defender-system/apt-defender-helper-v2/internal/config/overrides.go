@@ -0,0 +1,203 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is prepended to the upper-cased YAML key, e.g. HELPER_PORT
+const EnvPrefix = "HELPER_"
+
+// Keys lists every overridable config field by its YAML key
+func Keys() []string {
+	return keys(true)
+}
+
+// keys lists config fields by YAML key, optionally only those that can be
+// set from a string
+func keys(overridableOnly bool) []string {
+	keys := []string{}
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if key := yamlKey(field); key != "" && field.IsExported() && (!overridableOnly || overridable(field)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// overridable reports whether a field can be set from an env var or flag
+func overridable(field reflect.StructField) bool {
+	if yamlKey(field) == schemaKey {
+		return false
+	}
+	switch field.Type.Kind() {
+	case reflect.String, reflect.Int, reflect.Bool:
+		return true
+	case reflect.Slice:
+		return field.Type.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// Set assigns a field from its string form. Lists are comma-separated.
+func (c *Config) Set(key, value string) error {
+	field := c.field(key)
+	if !field.IsValid() {
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", key, value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s: %q is not true or false", key, value)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%s can't be overridden", key)
+		}
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		field.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("%s can't be overridden", key)
+	}
+	return nil
+}
+
+// ApplyEnv overrides fields from HELPER_<KEY> environment variables and
+// returns the keys it changed
+func (c *Config) ApplyEnv() ([]string, error) {
+	applied := []string{}
+	for _, key := range Keys() {
+		value, ok := os.LookupEnv(EnvPrefix + strings.ToUpper(key))
+		if !ok {
+			continue
+		}
+		c.rememberFileValue(key)
+		if err := c.Set(key, value); err != nil {
+			return applied, fmt.Errorf("%s%s: %w", EnvPrefix, strings.ToUpper(key), err)
+		}
+		applied = append(applied, key)
+	}
+	return applied, nil
+}
+
+// Flags holds command-line overrides until the config file has been loaded
+type Flags struct {
+	values map[string]*flagValue
+}
+
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (f *flagValue) String() string     { return f.value }
+func (f *flagValue) Set(v string) error { f.value = v; return nil }
+func (f *flagValue) IsBoolFlag() bool   { return f.isBool }
+
+// RegisterFlags adds a --kebab-case flag for every config field
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{values: map[string]*flagValue{}}
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := yamlKey(field)
+		if key == "" || !field.IsExported() || !overridable(field) {
+			continue
+		}
+		v := &flagValue{isBool: field.Type.Kind() == reflect.Bool}
+		flags.values[key] = v
+		fs.Var(v, strings.ReplaceAll(key, "_", "-"), fmt.Sprintf("override %s (env %s%s)", key, EnvPrefix, strings.ToUpper(key)))
+	}
+	return flags
+}
+
+// Apply overrides the fields whose flags were given on the command line
+// and returns the keys it changed
+func (f *Flags) Apply(c *Config, fs *flag.FlagSet) ([]string, error) {
+	applied := []string{}
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		key := strings.ReplaceAll(fl.Name, "-", "_")
+		v, ok := f.values[key]
+		if !ok || err != nil {
+			return
+		}
+		c.rememberFileValue(key)
+		if err = c.Set(key, v.value); err != nil {
+			err = fmt.Errorf("--%s: %w", fl.Name, err)
+			return
+		}
+		applied = append(applied, key)
+	})
+	return applied, err
+}
+
+// rememberFileValue keeps the value a key had before its first override
+func (c *Config) rememberFileValue(key string) {
+	if c.fileValues == nil {
+		c.fileValues = map[string]reflect.Value{}
+	}
+	if _, ok := c.fileValues[key]; ok {
+		return
+	}
+	if field := c.field(key); field.IsValid() {
+		original := reflect.New(field.Type()).Elem()
+		original.Set(field)
+		c.fileValues[key] = original
+	}
+}
+
+// withoutOverrides returns a copy holding the file values of overridden
+// keys, so Save never persists an override
+func (c *Config) withoutOverrides() *Config {
+	saved := *c
+	for key, original := range c.fileValues {
+		if field := saved.field(key); field.IsValid() {
+			field.Set(original)
+		}
+	}
+	return &saved
+}
+
+func (c *Config) field(key string) reflect.Value {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() && yamlKey(t.Field(i)) == key {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+func yamlKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
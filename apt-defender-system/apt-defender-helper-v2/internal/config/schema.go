@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const schemaKey = "schema_version"
+
+// migration upgrades a raw config from schema version to version+1. Files
+// written before schema_version existed are version 1.
+type migration struct {
+	description string
+	apply       func(raw map[string]interface{}) error
+}
+
+// migrations are applied in order on load; append one (and never edit an
+// existing one) whenever a key is renamed, moved or changes meaning.
+// migrations[0] upgrades version 1 to 2 and so on.
+var migrations = []migration{
+	{"rename helper-service (v1) keys", renameV1Keys},
+}
+
+// CurrentSchemaVersion is the schema version this build writes
+var CurrentSchemaVersion = len(migrations) + 1
+
+// parse decodes a config file, running any pending migrations first.
+// It reports the version the file was written with.
+func parse(data []byte) (*Config, int, error) {
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+
+	version := 1
+	if v, ok := raw[schemaKey]; ok {
+		n, ok := v.(int)
+		if !ok || n < 1 {
+			return nil, 0, fmt.Errorf("invalid %s: %v", schemaKey, v)
+		}
+		version = n
+	}
+
+	switch {
+	case version > CurrentSchemaVersion:
+		log.Printf("⚠️ Config schema v%d is newer than this helper (v%d), unknown keys are ignored and the file won't be overwritten", version, CurrentSchemaVersion)
+	case version < CurrentSchemaVersion:
+		for i := version; i < CurrentSchemaVersion; i++ {
+			m := migrations[i-1]
+			if err := m.apply(raw); err != nil {
+				return nil, 0, fmt.Errorf("config migration v%d→v%d (%s) failed: %w", i, i+1, m.description, err)
+			}
+			log.Printf("⚙️ Config migrated v%d→v%d: %s", i, i+1, m.description)
+		}
+		raw[schemaKey] = CurrentSchemaVersion
+	}
+
+	known := map[string]bool{}
+	for _, key := range keys(false) {
+		known[key] = true
+	}
+	unknown := map[string]interface{}{}
+	for key, value := range raw {
+		if !known[key] {
+			log.Printf("⚠️ Unknown config key %q ignored (kept in the file)", key)
+			unknown[key] = value
+		}
+	}
+
+	// Start from defaults so fields missing from older files keep sane values
+	cfg := DefaultConfig()
+	migrated, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to re-encode config: %w", err)
+	}
+	if err := yaml.Unmarshal(migrated, cfg); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if len(unknown) > 0 {
+		cfg.unknown = unknown
+	}
+	return cfg, version, nil
+}
+
+// marshal encodes the config followed by the unknown keys it was loaded
+// with, so a key this build doesn't know survives a save
+func (c *Config) marshal() ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(c); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(c.unknown))
+	for name := range c.unknown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var key, value yaml.Node
+		key.SetString(name)
+		if err := value.Encode(c.unknown[name]); err != nil {
+			return nil, err
+		}
+		doc.Content = append(doc.Content, &key, &value)
+	}
+
+	return yaml.Marshal(&doc)
+}
+
+// checkOverwrite refuses to replace a config written by a newer helper,
+// whose keys this build may not understand
+func checkOverwrite(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var header struct {
+		SchemaVersion int `yaml:"schema_version"`
+	}
+	if yaml.Unmarshal(data, &header) != nil {
+		return nil
+	}
+	if header.SchemaVersion > CurrentSchemaVersion {
+		return fmt.Errorf("%s was written by a newer helper (schema v%d, this one writes v%d) and won't be overwritten", path, header.SchemaVersion, CurrentSchemaVersion)
+	}
+	return nil
+}
+
+// renameV1Keys upgrades schema 1 to 2: a helper-service (v1) config copied
+// into place by hand uses v1 key names, which map onto v2 keys the same way
+// MigrateV1 does. A v2 key that is already set wins.
+func renameV1Keys(raw map[string]interface{}) error {
+	for key, value := range raw {
+		normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+		target, ok := v1Keys[normalized]
+		if !ok || target == key {
+			continue
+		}
+		if _, exists := raw[target]; !exists {
+			raw[target] = value
+		}
+		delete(raw, key)
+	}
+	return nil
+}
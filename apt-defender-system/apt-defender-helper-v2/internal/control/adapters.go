@@ -0,0 +1,128 @@
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// Adapter is a network interface as reported by Get-NetAdapter
+type Adapter struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Index       int      `json:"index"`
+	Status      string   `json:"status"`
+	MAC         string   `json:"mac"`
+	LinkSpeed   string   `json:"link_speed"`
+	Addresses   []string `json:"addresses"`
+}
+
+type netAdapter struct {
+	Name                 string
+	InterfaceDescription string
+	IfIndex              int `json:"ifIndex"`
+	Status               string
+	MacAddress           string
+	LinkSpeed            string
+}
+
+// Adapters lists physical and virtual network adapters, including disabled ones
+func Adapters() ([]Adapter, error) {
+	query := "Get-NetAdapter | Select-Object Name,InterfaceDescription,ifIndex,Status,MacAddress,LinkSpeed | ConvertTo-Json -Compress"
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list adapters: %w", err)
+	}
+
+	raw := strings.TrimSpace(string(out))
+	if raw == "" {
+		return []Adapter{}, nil
+	}
+	// ConvertTo-Json emits a bare object when there is a single adapter
+	if !strings.HasPrefix(raw, "[") {
+		raw = "[" + raw + "]"
+	}
+
+	var list []netAdapter
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse adapter list: %w", err)
+	}
+
+	addresses := map[int][]string{}
+	if ifaces, err := net.Interfaces(); err == nil {
+		for _, iface := range ifaces {
+			addrs, _ := iface.Addrs()
+			for _, addr := range addrs {
+				if ipnet, ok := addr.(*net.IPNet); ok {
+					addresses[iface.Index] = append(addresses[iface.Index], ipnet.IP.String())
+				}
+			}
+		}
+	}
+
+	adapters := make([]Adapter, 0, len(list))
+	for _, a := range list {
+		adapters = append(adapters, Adapter{
+			Name:        a.Name,
+			Description: a.InterfaceDescription,
+			Index:       a.IfIndex,
+			Status:      a.Status,
+			MAC:         a.MacAddress,
+			LinkSpeed:   a.LinkSpeed,
+			Addresses:   addresses[a.IfIndex],
+		})
+	}
+	return adapters, nil
+}
+
+// DisableAdapter administratively disables a named adapter
+func DisableAdapter(name string) error {
+	return setAdapterState(name, "disabled")
+}
+
+// EnableAdapter re-enables a named adapter
+func EnableAdapter(name string) error {
+	return setAdapterState(name, "enabled")
+}
+
+// AdapterFor returns the name of the adapter used to reach host
+func AdapterFor(host string, port int) (string, error) {
+	// UDP "dial" only picks a route; nothing is sent
+	conn, err := net.Dial("udp", net.JoinHostPort(host, fmt.Sprint(port)))
+	if err != nil {
+		return "", err
+	}
+	local := conn.LocalAddr().(*net.UDPAddr).IP
+	conn.Close()
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range ifaces {
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(local) {
+				return iface.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no adapter owns %s", local)
+}
+
+func setAdapterState(name, state string) error {
+	if name == "" || strings.ContainsAny(name, "\"\r\n") {
+		return fmt.Errorf("invalid adapter name: %q", name)
+	}
+
+	log.Printf("🔌 Setting adapter %q %s", name, state)
+
+	cmd := exec.Command("netsh", "interface", "set", "interface", "name="+name, "admin="+state)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set adapter %q %s: %v, output: %s", name, state, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"syscall"
+	"unsafe"
+
+	"github.com/apt-defender/helper-v2/internal/telemetry"
+)
+
+const mibTCPStateDeleteTCB = 12
+
+var (
+	iphlpapi        = syscall.NewLazyDLL("iphlpapi.dll")
+	procSetTcpEntry = iphlpapi.NewProc("SetTcpEntry")
+)
+
+// ConnectionFilter selects TCP connections to close, either by full 4-tuple
+// or by owning PID plus remote address
+type ConnectionFilter struct {
+	LocalAddress  string `json:"local_address"`
+	LocalPort     uint16 `json:"local_port"`
+	RemoteAddress string `json:"remote_address"`
+	RemotePort    uint16 `json:"remote_port"`
+	PID           uint32 `json:"pid"`
+}
+
+type mibTCPRow struct {
+	State      uint32
+	LocalAddr  uint32
+	LocalPort  uint32
+	RemoteAddr uint32
+	RemotePort uint32
+}
+
+// Validate requires a 4-tuple or a PID with a remote address, so a filter
+// can never match every connection on the machine
+func (f ConnectionFilter) Validate() error {
+	if f.RemoteAddress == "" || net.ParseIP(f.RemoteAddress).To4() == nil {
+		return fmt.Errorf("an IPv4 remote_address is required")
+	}
+	fullTuple := f.LocalAddress != "" && f.LocalPort != 0 && f.RemotePort != 0
+	if !fullTuple && f.PID == 0 {
+		return fmt.Errorf("give local_address, local_port and remote_port, or a pid")
+	}
+	return nil
+}
+
+func (f ConnectionFilter) matches(c telemetry.Connection) bool {
+	if c.State == "LISTEN" || c.RemoteAddress != f.RemoteAddress {
+		return false
+	}
+	if f.PID != 0 && c.PID != f.PID {
+		return false
+	}
+	if f.LocalAddress != "" && c.LocalAddress != f.LocalAddress {
+		return false
+	}
+	if f.LocalPort != 0 && c.LocalPort != f.LocalPort {
+		return false
+	}
+	if f.RemotePort != 0 && c.RemotePort != f.RemotePort {
+		return false
+	}
+	return true
+}
+
+// KillConnections closes every IPv4 TCP connection matching the filter by
+// deleting its TCB, which sends a RST to the remote end
+func KillConnections(f ConnectionFilter) ([]telemetry.Connection, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	connections, err := telemetry.GetNetworkConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	killed := []telemetry.Connection{}
+	for _, c := range connections {
+		if !f.matches(c) {
+			continue
+		}
+
+		row := mibTCPRow{
+			State:      mibTCPStateDeleteTCB,
+			LocalAddr:  ipv4ToNetwork(c.LocalAddress),
+			LocalPort:  portToNetwork(c.LocalPort),
+			RemoteAddr: ipv4ToNetwork(c.RemoteAddress),
+			RemotePort: portToNetwork(c.RemotePort),
+		}
+		ret, _, _ := procSetTcpEntry.Call(uintptr(unsafe.Pointer(&row)))
+		if ret != 0 {
+			return killed, fmt.Errorf("SetTcpEntry failed for %s:%d -> %s:%d: error %d",
+				c.LocalAddress, c.LocalPort, c.RemoteAddress, c.RemotePort, ret)
+		}
+
+		log.Printf("✂️ Closed connection %s:%d -> %s:%d (%s, PID %d)",
+			c.LocalAddress, c.LocalPort, c.RemoteAddress, c.RemotePort, c.ProcessName, c.PID)
+		killed = append(killed, c)
+	}
+
+	return killed, nil
+}
+
+// ipv4ToNetwork packs a dotted address the way the TCP table stores it
+func ipv4ToNetwork(addr string) uint32 {
+	ip := net.ParseIP(addr).To4()
+	if ip == nil {
+		return 0
+	}
+	return uint32(ip[0]) | uint32(ip[1])<<8 | uint32(ip[2])<<16 | uint32(ip[3])<<24
+}
+
+// portToNetwork stores a port in network byte order in the low 16 bits
+func portToNetwork(port uint16) uint32 {
+	return uint32(port>>8) | uint32(port&0xff)<<8
+}
@@ -0,0 +1,216 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	hostsMarker          = "# APTDefender"
+	domainRulePrefix     = "APTDefender_Block_Domain_"
+	DomainMethodHosts    = "hosts"
+	DomainMethodFirewall = "firewall"
+)
+
+// BlockedDomain is a domain blocked by the helper and how it is blocked
+type BlockedDomain struct {
+	Domain  string   `json:"domain"`
+	Methods []string `json:"methods"`
+}
+
+// TestHostsAccess opens the hosts file for writing without changing it
+func TestHostsAccess() error {
+	f, err := os.OpenFile(hostsFilePath(), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// hostsFilePath returns the location of the Windows hosts file
+func hostsFilePath() string {
+	systemRoot := os.Getenv("SystemRoot")
+	if systemRoot == "" {
+		systemRoot = `C:\Windows`
+	}
+	return filepath.Join(systemRoot, "System32", "drivers", "etc", "hosts")
+}
+
+// normalizeDomain lowercases a domain and rejects anything that could
+// inject extra hosts file content or firewall arguments
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if domain == "" || strings.ContainsAny(domain, " \t\r\n#\"'=,") {
+		return "", fmt.Errorf("invalid domain: %q", domain)
+	}
+	return domain, nil
+}
+
+// BlockDomain sinkholes a domain by pointing it at 0.0.0.0 in the hosts file
+func BlockDomain(domain string) error {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🚫 BLOCKING DOMAIN: %s", domain)
+
+	path := hostsFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read hosts file: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "0.0.0.0" && strings.EqualFold(fields[1], domain) {
+			return nil // already blocked
+		}
+	}
+
+	content := string(data)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\r\n"
+	}
+	content += fmt.Sprintf("0.0.0.0 %s %s\r\n", domain, hostsMarker)
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write hosts file: %w", err)
+	}
+
+	exec.Command("ipconfig", "/flushdns").Run()
+
+	log.Printf("✅ Domain blocked: %s", domain)
+	return nil
+}
+
+// BlockDomainFirewall resolves a domain and blocks outbound traffic to its
+// current addresses. Must run before the hosts sinkhole, or the lookup
+// returns 0.0.0.0.
+func BlockDomainFirewall(domain string) ([]string, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := net.LookupHost(domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", domain, err)
+	}
+
+	var ips []string
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr); ip != nil && !ip.IsUnspecified() && !ip.IsLoopback() {
+			ips = append(ips, ip.String())
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%s resolves to no routable addresses", domain)
+	}
+
+	log.Printf("🚫 BLOCKING DOMAIN %s AT FIREWALL: %s", domain, strings.Join(ips, ", "))
+
+	ruleName := domainRulePrefix + domain
+	exec.Command("netsh", "advfirewall", "firewall", "delete", "rule", "name="+ruleName).Run()
+
+	cmd := exec.Command("netsh", "advfirewall", "firewall", "add", "rule",
+		"name="+ruleName,
+		"dir=out",
+		"action=block",
+		"remoteip="+strings.Join(ips, ","),
+		"enable=yes",
+		ruleDescription("network.block_domain"),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to add firewall rule: %v, output: %s", err, output)
+	}
+
+	return ips, nil
+}
+
+// UnblockDomain removes the hosts entry and firewall rule added for a domain
+func UnblockDomain(domain string) error {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("✅ UNBLOCKING DOMAIN: %s", domain)
+
+	path := hostsFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read hosts file: %w", err)
+	}
+
+	var kept []string
+	removed := false
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\r\n"), "\n") {
+		if d, ok := managedHostsEntry(line); ok && d == domain {
+			removed = true
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, "\r"))
+	}
+	if removed {
+		if err := os.WriteFile(path, []byte(strings.Join(kept, "\r\n")+"\r\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write hosts file: %w", err)
+		}
+		exec.Command("ipconfig", "/flushdns").Run()
+	}
+
+	// Ignore errors if the rule doesn't exist
+	exec.Command("netsh", "advfirewall", "firewall", "delete", "rule", "name="+domainRulePrefix+domain).Run()
+
+	return nil
+}
+
+// BlockedDomains lists domains blocked by the helper, from the marked hosts
+// entries and the helper's firewall rules
+func BlockedDomains() ([]BlockedDomain, error) {
+	methods := map[string][]string{}
+
+	data, err := os.ReadFile(hostsFilePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hosts file: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if domain, ok := managedHostsEntry(line); ok {
+			methods[domain] = append(methods[domain], DomainMethodHosts)
+		}
+	}
+
+	query := fmt.Sprintf("Get-NetFirewallRule -DisplayName '%s*' | Select-Object -ExpandProperty DisplayName", domainRulePrefix)
+	if out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).Output(); err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			if domain := strings.TrimPrefix(strings.TrimSpace(line), domainRulePrefix); domain != strings.TrimSpace(line) {
+				methods[domain] = append(methods[domain], DomainMethodFirewall)
+			}
+		}
+	}
+
+	result := make([]BlockedDomain, 0, len(methods))
+	for domain, m := range methods {
+		result = append(result, BlockedDomain{Domain: domain, Methods: m})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Domain < result[j].Domain })
+	return result, nil
+}
+
+// managedHostsEntry returns the domain of a hosts line written by BlockDomain
+func managedHostsEntry(line string) (string, bool) {
+	if !strings.Contains(line, hostsMarker) {
+		return "", false
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "0.0.0.0" {
+		return "", false
+	}
+	return strings.ToLower(fields[1]), true
+}
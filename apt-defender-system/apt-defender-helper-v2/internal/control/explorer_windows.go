@@ -0,0 +1,43 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// RevealFile opens Explorer on the console user's desktop at the folder
+// holding path, with the file selected if it still exists. A helper running
+// as a service starts Explorer in the console session; one running on the
+// desktop starts it directly.
+func RevealFile(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("path must be absolute: %s", path)
+	}
+
+	// Paths can't contain quotes, so quoting is enough for Explorer's parser
+	commandLine := fmt.Sprintf(`explorer.exe /select,"%s"`, path)
+	if _, err := os.Stat(path); err != nil {
+		dir := filepath.Dir(path)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("neither %s nor its folder exists", path)
+		}
+		commandLine = fmt.Sprintf(`explorer.exe "%s"`, dir)
+	}
+	log.Printf("📂 Opening Explorer: %s", commandLine)
+
+	if !InServiceSession() {
+		cmd := exec.Command("explorer.exe")
+		cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: commandLine}
+		return cmd.Start()
+	}
+
+	console, err := ActiveConsoleSession()
+	if err != nil {
+		return err
+	}
+	return RunInSession(console, commandLine)
+}
@@ -0,0 +1,140 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+const ipRulePrefix = "APTDefender_Block_IP_"
+
+// IPRule is a remote address block managed by the helper
+type IPRule struct {
+	Address   string `json:"address"`
+	Direction string `json:"direction"`
+}
+
+// NormalizeAddress validates an IP or CIDR to block and returns it in
+// canonical form. Loopback and unspecified addresses are refused.
+func NormalizeAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if _, network, err := net.ParseCIDR(address); err == nil {
+		if network.IP.IsLoopback() || network.IP.IsUnspecified() {
+			return "", fmt.Errorf("refusing to block %s", address)
+		}
+		return network.String(), nil
+	}
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return "", fmt.Errorf("invalid IP address %q", address)
+	}
+	if ip.IsLoopback() || ip.IsUnspecified() {
+		return "", fmt.Errorf("refusing to block %s", address)
+	}
+	return ip.String(), nil
+}
+
+// BlockIP adds firewall rules blocking traffic to and/or from a remote IP
+// or CIDR. Direction is "out", "in" or "both" (the default).
+func BlockIP(address, direction string) ([]IPRule, error) {
+	rules, err := expandIPRules(address, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		log.Printf("🚫 BLOCKING %s IP %s", rule.Direction, rule.Address)
+
+		name := ipRuleName(rule)
+		exec.Command("netsh", "advfirewall", "firewall", "delete", "rule", "name="+name).Run()
+
+		cmd := exec.Command("netsh", "advfirewall", "firewall", "add", "rule",
+			"name="+name,
+			"dir="+strings.ToLower(rule.Direction),
+			"action=block",
+			"remoteip="+rule.Address,
+			"enable=yes",
+			ruleDescription("network.block_ip"),
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to block %s: %v, output: %s", rule.Address, err, output)
+		}
+	}
+
+	return rules, nil
+}
+
+// UnblockIP removes the rules added by BlockIP for the same arguments
+func UnblockIP(address, direction string) ([]IPRule, error) {
+	rules, err := expandIPRules(address, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		log.Printf("✅ UNBLOCKING %s IP %s", rule.Direction, rule.Address)
+		// Ignore errors if the rule doesn't exist
+		exec.Command("netsh", "advfirewall", "firewall", "delete", "rule", "name="+ipRuleName(rule)).Run()
+	}
+
+	return rules, nil
+}
+
+// BlockedIPs lists the address blocks managed by the helper
+func BlockedIPs() ([]IPRule, error) {
+	query := fmt.Sprintf("Get-NetFirewallRule -DisplayName '%s*' | Select-Object -ExpandProperty DisplayName", ipRulePrefix)
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list firewall rules: %w", err)
+	}
+
+	rules := []IPRule{}
+	for _, line := range strings.Split(string(out), "\n") {
+		name := strings.TrimSpace(line)
+		if !strings.HasPrefix(name, ipRulePrefix) {
+			continue
+		}
+		// APTDefender_Block_IP_<address>_<direction>
+		rest := strings.TrimPrefix(name, ipRulePrefix)
+		i := strings.LastIndex(rest, "_")
+		if i < 0 {
+			continue
+		}
+		rules = append(rules, IPRule{Address: rest[:i], Direction: rest[i+1:]})
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return ipRuleName(rules[i]) < ipRuleName(rules[j]) })
+	return rules, nil
+}
+
+func expandIPRules(address, direction string) ([]IPRule, error) {
+	address, err := NormalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var directions []string
+	switch strings.ToLower(direction) {
+	case "", "both":
+		directions = []string{"Out", "In"}
+	case "out":
+		directions = []string{"Out"}
+	case "in":
+		directions = []string{"In"}
+	default:
+		return nil, fmt.Errorf("unsupported direction %q (out, in or both)", direction)
+	}
+
+	rules := make([]IPRule, 0, len(directions))
+	for _, d := range directions {
+		rules = append(rules, IPRule{Address: address, Direction: d})
+	}
+	return rules, nil
+}
+
+func ipRuleName(rule IPRule) string {
+	return ipRulePrefix + rule.Address + "_" + rule.Direction
+}
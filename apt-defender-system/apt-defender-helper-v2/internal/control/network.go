@@ -9,6 +9,8 @@ import (
 
 const (
 	firewallRuleName = "APTDefender_Block_All"
+	appRulePrefix    = "APTDefender_Block_App_"
+	selfTestRuleName = "APTDefender_SelfTest"
 )
 
 // BlockAllNetwork blocks all network traffic using Windows Firewall
@@ -21,6 +23,7 @@ func BlockAllNetwork() error {
 		"dir=out",
 		"action=block",
 		"enable=yes",
+		ruleDescription("network.block"),
 	)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to block outbound traffic: %v, output: %s", err, output)
@@ -32,6 +35,7 @@ func BlockAllNetwork() error {
 		"dir=in",
 		"action=block",
 		"enable=yes",
+		ruleDescription("network.block"),
 	)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to block inbound traffic: %v, output: %s", err, output)
@@ -65,7 +69,7 @@ func UnblockAllNetwork() error {
 func BlockApplication(programPath string) error {
 	log.Printf("🚫 BLOCKING APPLICATION: %s", programPath)
 
-	ruleName := fmt.Sprintf("APTDefender_Block_App_%s", sanitizeRuleName(programPath))
+	ruleName := appRulePrefix + sanitizeRuleName(programPath)
 
 	// Block outbound traffic for the application
 	cmd := exec.Command("netsh", "advfirewall", "firewall", "add", "rule",
@@ -74,6 +78,7 @@ func BlockApplication(programPath string) error {
 		"action=block",
 		"program="+programPath,
 		"enable=yes",
+		ruleDescription("network.block_app"),
 	)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to block application: %v, output: %s", err, output)
@@ -87,7 +92,7 @@ func BlockApplication(programPath string) error {
 func UnblockApplication(programPath string) error {
 	log.Printf("✅ UNBLOCKING APPLICATION: %s", programPath)
 
-	ruleName := fmt.Sprintf("APTDefender_Block_App_%s", sanitizeRuleName(programPath))
+	ruleName := appRulePrefix + sanitizeRuleName(programPath)
 
 	cmd := exec.Command("netsh", "advfirewall", "firewall", "delete", "rule",
 		"name="+ruleName,
@@ -100,6 +105,28 @@ func UnblockApplication(programPath string) error {
 	return nil
 }
 
+// TestFirewallAccess adds a disabled rule and deletes it again, proving the
+// helper can change Windows Firewall without affecting any traffic
+func TestFirewallAccess() error {
+	cmd := exec.Command("netsh", "advfirewall", "firewall", "add", "rule",
+		"name="+selfTestRuleName,
+		"dir=out",
+		"action=block",
+		"enable=no",
+		"remoteip=192.0.2.1",
+		ruleDescription("selftest"),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to add firewall rule: %v, output: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	cmd = exec.Command("netsh", "advfirewall", "firewall", "delete", "rule", "name="+selfTestRuleName)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("added a firewall rule but failed to delete %s: %v, output: %s", selfTestRuleName, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // GetNetworkStatus checks if network is currently blocked
 func GetNetworkStatus() (bool, error) {
 	cmd := exec.Command("netsh", "advfirewall", "firewall", "show", "rule",
@@ -0,0 +1,90 @@
+package control
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"unicode/utf16"
+)
+
+// Notification styles
+const (
+	StylePopup      = "popup"
+	StyleToast      = "toast"
+	StyleFullScreen = "fullscreen"
+)
+
+// AppID PowerShell toasts are shown under; it is registered on every install
+const powershellAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+// NotifyUser shows a message box on every interactive session
+func NotifyUser(title, message string, timeoutSeconds int) error {
+	log.Printf("💬 Notifying user: %s", title)
+
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 60
+	}
+
+	cmd := exec.Command("msg", "*", fmt.Sprintf("/TIME:%d", timeoutSeconds), title+": "+message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to notify user: %v, output: %s", err, output)
+	}
+
+	return nil
+}
+
+// NotifyToast shows a Windows toast notification in a user session
+func NotifyToast(sessionID uint32, title, message string) error {
+	log.Printf("💬 Toast to session %d: %s", sessionID, title)
+
+	script := fmt.Sprintf(`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$x = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$t = $x.GetElementsByTagName('text')
+[void]$t.Item(0).AppendChild($x.CreateTextNode(%s))
+[void]$t.Item(1).AppendChild($x.CreateTextNode(%s))
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(%s).Show([Windows.UI.Notifications.ToastNotification]::new($x))`,
+		psQuote(title), psQuote(message), psQuote(powershellAppID))
+
+	return RunInSession(sessionID, powershellCommand(script))
+}
+
+// NotifyFullScreen covers the user's screen with a topmost message until
+// they dismiss it or timeoutSeconds pass (0 waits for the user)
+func NotifyFullScreen(sessionID uint32, title, message string, timeoutSeconds int) error {
+	log.Printf("💬 Full-screen message to session %d: %s", sessionID, title)
+
+	timer := ""
+	if timeoutSeconds > 0 {
+		timer = fmt.Sprintf("$tm = New-Object Windows.Forms.Timer; $tm.Interval = %d; $tm.Add_Tick({ $f.Close() }); $tm.Start()", timeoutSeconds*1000)
+	}
+
+	script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms, System.Drawing
+$f = New-Object Windows.Forms.Form
+$f.FormBorderStyle = 'None'; $f.WindowState = 'Maximized'; $f.TopMost = $true; $f.ShowInTaskbar = $false; $f.BackColor = 'DarkRed'
+$b = New-Object Windows.Forms.Button
+$b.Text = 'OK'; $b.Dock = 'Bottom'; $b.Height = 60; $b.ForeColor = 'White'; $b.Add_Click({ $f.Close() })
+$l = New-Object Windows.Forms.Label
+$l.Dock = 'Fill'; $l.TextAlign = 'MiddleCenter'; $l.ForeColor = 'White'; $l.Font = New-Object Drawing.Font('Segoe UI', 28)
+$l.Text = %s + [Environment]::NewLine + [Environment]::NewLine + %s
+$f.Controls.Add($b); $f.Controls.Add($l); $l.BringToFront()
+%s
+[void]$f.ShowDialog()`, psQuote(title), psQuote(message), timer)
+
+	return RunInSession(sessionID, powershellCommand(script))
+}
+
+// powershellCommand encodes a script so it survives command-line quoting
+func powershellCommand(script string) string {
+	encoded := utf16.Encode([]rune(script))
+	raw := make([]byte, 0, len(encoded)*2)
+	for _, c := range encoded {
+		raw = append(raw, byte(c), byte(c>>8))
+	}
+	return "powershell.exe -NoProfile -NonInteractive -WindowStyle Hidden -EncodedCommand " + base64.StdEncoding.EncodeToString(raw)
+}
+
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
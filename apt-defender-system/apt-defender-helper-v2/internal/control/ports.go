@@ -0,0 +1,187 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const portRulePrefix = "APTDefender_Block_Port_"
+
+// Well-known services that can be blocked by name
+var servicePorts = map[string][]PortRule{
+	"smb":    {{Protocol: "TCP", Ports: "139,445"}},
+	"smtp":   {{Protocol: "TCP", Ports: "25,465,587"}},
+	"rdp":    {{Protocol: "TCP", Ports: "3389"}, {Protocol: "UDP", Ports: "3389"}},
+	"winrm":  {{Protocol: "TCP", Ports: "5985,5986"}},
+	"ssh":    {{Protocol: "TCP", Ports: "22"}},
+	"telnet": {{Protocol: "TCP", Ports: "23"}},
+	"ftp":    {{Protocol: "TCP", Ports: "20,21"}},
+	"dns":    {{Protocol: "UDP", Ports: "53"}, {Protocol: "TCP", Ports: "53"}},
+	"irc":    {{Protocol: "TCP", Ports: "6660-6669,6697"}},
+	"tor":    {{Protocol: "TCP", Ports: "9001,9030,9050,9051,9150"}},
+}
+
+// PortRule is a port block managed by the helper
+type PortRule struct {
+	Protocol  string `json:"protocol"`
+	Ports     string `json:"ports"`
+	Direction string `json:"direction"`
+}
+
+// ServicePorts expands a well-known service name into its port rules
+func ServicePorts(service string) ([]PortRule, error) {
+	rules, ok := servicePorts[strings.ToLower(service)]
+	if !ok {
+		names := make([]string, 0, len(servicePorts))
+		for name := range servicePorts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown service %q (known: %s)", service, strings.Join(names, ", "))
+	}
+	return append([]PortRule(nil), rules...), nil
+}
+
+// BlockPorts adds firewall rules blocking a protocol on a port list such as
+// "445", "25,465,587" or "6660-6669". Direction is "out", "in" or "both";
+// protocol "any" blocks both TCP and UDP.
+func BlockPorts(protocol, ports, direction string) ([]PortRule, error) {
+	rules, err := expandPortRules(protocol, ports, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		log.Printf("🚫 BLOCKING %s %s PORTS %s", rule.Direction, rule.Protocol, rule.Ports)
+
+		name := portRuleName(rule)
+		exec.Command("netsh", "advfirewall", "firewall", "delete", "rule", "name="+name).Run()
+
+		args := []string{"advfirewall", "firewall", "add", "rule",
+			"name=" + name,
+			"dir=" + strings.ToLower(rule.Direction),
+			"action=block",
+			"protocol=" + rule.Protocol,
+			"enable=yes",
+			ruleDescription("network.block_port"),
+		}
+		// Outbound traffic is matched on the port it connects to, inbound on the port it arrives at
+		if rule.Direction == "Out" {
+			args = append(args, "remoteport="+rule.Ports)
+		} else {
+			args = append(args, "localport="+rule.Ports)
+		}
+
+		if output, err := exec.Command("netsh", args...).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to block %s %s: %v, output: %s", rule.Protocol, rule.Ports, err, output)
+		}
+	}
+
+	return rules, nil
+}
+
+// UnblockPorts removes the rules added by BlockPorts for the same arguments
+func UnblockPorts(protocol, ports, direction string) ([]PortRule, error) {
+	rules, err := expandPortRules(protocol, ports, direction)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		log.Printf("✅ UNBLOCKING %s %s PORTS %s", rule.Direction, rule.Protocol, rule.Ports)
+		// Ignore errors if the rule doesn't exist
+		exec.Command("netsh", "advfirewall", "firewall", "delete", "rule", "name="+portRuleName(rule)).Run()
+	}
+
+	return rules, nil
+}
+
+// BlockedPorts lists the port blocks managed by the helper
+func BlockedPorts() ([]PortRule, error) {
+	query := fmt.Sprintf("Get-NetFirewallRule -DisplayName '%s*' | Select-Object -ExpandProperty DisplayName", portRulePrefix)
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list firewall rules: %w", err)
+	}
+
+	rules := []PortRule{}
+	for _, line := range strings.Split(string(out), "\n") {
+		name := strings.TrimSpace(line)
+		if !strings.HasPrefix(name, portRulePrefix) {
+			continue
+		}
+		// APTDefender_Block_Port_<protocol>_<ports>_<direction>
+		parts := strings.Split(strings.TrimPrefix(name, portRulePrefix), "_")
+		if len(parts) != 3 {
+			continue
+		}
+		rules = append(rules, PortRule{Protocol: parts[0], Ports: parts[1], Direction: parts[2]})
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return portRuleName(rules[i]) < portRuleName(rules[j]) })
+	return rules, nil
+}
+
+func expandPortRules(protocol, ports, direction string) ([]PortRule, error) {
+	if err := validatePorts(ports); err != nil {
+		return nil, err
+	}
+
+	var protocols []string
+	switch strings.ToUpper(protocol) {
+	case "TCP", "UDP":
+		protocols = []string{strings.ToUpper(protocol)}
+	case "", "ANY":
+		protocols = []string{"TCP", "UDP"}
+	default:
+		return nil, fmt.Errorf("unsupported protocol %q (tcp, udp or any)", protocol)
+	}
+
+	var directions []string
+	switch strings.ToLower(direction) {
+	case "", "out":
+		directions = []string{"Out"}
+	case "in":
+		directions = []string{"In"}
+	case "both":
+		directions = []string{"Out", "In"}
+	default:
+		return nil, fmt.Errorf("unsupported direction %q (out, in or both)", direction)
+	}
+
+	var rules []PortRule
+	for _, p := range protocols {
+		for _, d := range directions {
+			rules = append(rules, PortRule{Protocol: p, Ports: ports, Direction: d})
+		}
+	}
+	return rules, nil
+}
+
+// validatePorts accepts comma-separated ports and ranges between 1 and 65535
+func validatePorts(ports string) error {
+	if ports == "" {
+		return fmt.Errorf("ports are required")
+	}
+	for _, item := range strings.Split(ports, ",") {
+		bounds := strings.Split(item, "-")
+		if len(bounds) > 2 {
+			return fmt.Errorf("invalid port range %q", item)
+		}
+		for _, b := range bounds {
+			n, err := strconv.Atoi(b)
+			if err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("invalid port %q", b)
+			}
+		}
+	}
+	return nil
+}
+
+func portRuleName(rule PortRule) string {
+	return portRulePrefix + rule.Protocol + "_" + rule.Ports + "_" + rule.Direction
+}
@@ -0,0 +1,53 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"syscall"
+)
+
+var (
+	powrprof = syscall.NewLazyDLL("powrprof.dll")
+
+	procSetSuspendState       = powrprof.NewProc("SetSuspendState")
+	procIsPwrSuspendAllowed   = powrprof.NewProc("IsPwrSuspendAllowed")
+	procIsPwrHibernateAllowed = powrprof.NewProc("IsPwrHibernateAllowed")
+)
+
+// CanSuspend reports whether the machine supports sleep (S1-S3) or, with
+// hibernate set, hibernation (S4, which must be enabled with powercfg)
+func CanSuspend(hibernate bool) error {
+	proc, name := procIsPwrSuspendAllowed, "sleep"
+	if hibernate {
+		proc, name = procIsPwrHibernateAllowed, "hibernate"
+	}
+	if ret, _, _ := proc.Call(); ret&0xff == 0 {
+		return fmt.Errorf("%s is not available on this machine", name)
+	}
+	return nil
+}
+
+// SuspendPC puts the machine to sleep or into hibernation. Running
+// applications keep their state and the machine can be woken normally.
+func SuspendPC(hibernate bool) error {
+	if hibernate {
+		log.Println("💤 HIBERNATE REQUESTED - Hibernating PC...")
+	} else {
+		log.Println("💤 SLEEP REQUESTED - Suspending PC...")
+	}
+
+	if err := EnableShutdownPrivilege(); err != nil {
+		return fmt.Errorf("failed to enable shutdown privilege: %w", err)
+	}
+
+	var h uintptr
+	if hibernate {
+		h = 1
+	}
+	ret, _, err := procSetSuspendState.Call(h, 0, 0)
+	if ret&0xff == 0 {
+		return fmt.Errorf("suspend failed: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package control
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	srclient = syscall.NewLazyDLL("srclient.dll")
+
+	procSRSetRestorePoint = srclient.NewProc("SRSetRestorePointW")
+)
+
+const (
+	beginSystemChange = 100
+	endSystemChange   = 101
+	modifySettings    = 12
+
+	errorServiceDisabled = 1058
+)
+
+// RESTOREPOINTINFOW; the header is byte-packed, which happens to match Go's layout
+type restorePointInfo struct {
+	EventType      uint32
+	RestorePtType  uint32
+	SequenceNumber int64
+	Description    [256]uint16
+}
+
+// RestorePoint is a System Restore point as listed by Get-ComputerRestorePoint
+type RestorePoint struct {
+	SequenceNumber int64     `json:"sequence_number"`
+	Description    string    `json:"description"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CreateRestorePoint snapshots system files and the registry so a
+// remediation can be rolled back. Windows creates at most one restore
+// point per 24 hours by default; within that window the call succeeds
+// without a new point.
+func CreateRestorePoint(description string) (int64, error) {
+	log.Printf("💾 Creating restore point: %s", description)
+
+	info := restorePointInfo{EventType: beginSystemChange, RestorePtType: modifySettings}
+	copy(info.Description[:len(info.Description)-1], syscall.StringToUTF16(description))
+
+	seq, err := setRestorePoint(&info)
+	if err != nil {
+		return 0, err
+	}
+
+	// Close the change right away; the snapshot is taken at BEGIN
+	end := restorePointInfo{EventType: endSystemChange, RestorePtType: modifySettings, SequenceNumber: seq}
+	if _, err := setRestorePoint(&end); err != nil {
+		log.Printf("⚠️ Failed to close restore point %d: %v", seq, err)
+	}
+	return seq, nil
+}
+
+func setRestorePoint(info *restorePointInfo) (int64, error) {
+	// STATEMGRSTATUS is byte-packed: DWORD status, INT64 sequence number
+	var status [12]byte
+	ret, _, _ := procSRSetRestorePoint.Call(uintptr(unsafe.Pointer(info)), uintptr(unsafe.Pointer(&status[0])))
+	code := binary.LittleEndian.Uint32(status[0:4])
+	if ret == 0 {
+		if code == errorServiceDisabled {
+			return 0, fmt.Errorf("system restore is disabled (turn on System Protection for the system drive)")
+		}
+		return 0, fmt.Errorf("SRSetRestorePoint failed: error %d", code)
+	}
+	return int64(binary.LittleEndian.Uint64(status[4:12])), nil
+}
+
+type computerRestorePoint struct {
+	SequenceNumber int64
+	Description    string
+	CreationTime   string
+}
+
+// RestorePoints lists existing System Restore points, oldest first
+func RestorePoints() ([]RestorePoint, error) {
+	query := "Get-ComputerRestorePoint | Select-Object SequenceNumber,Description,CreationTime | ConvertTo-Json -Compress"
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list restore points: %w", err)
+	}
+
+	raw := strings.TrimSpace(string(out))
+	if raw == "" {
+		return []RestorePoint{}, nil
+	}
+	if !strings.HasPrefix(raw, "[") {
+		raw = "[" + raw + "]"
+	}
+
+	var list []computerRestorePoint
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse restore points: %w", err)
+	}
+
+	points := make([]RestorePoint, 0, len(list))
+	for _, p := range list {
+		// CreationTime is a WMI datetime: yyyymmddHHMMSS.ffffff+UUU
+		created, _ := time.ParseInLocation("20060102150405", p.CreationTime[:min(14, len(p.CreationTime))], time.Local)
+		points = append(points, RestorePoint{
+			SequenceNumber: p.SequenceNumber,
+			Description:    p.Description,
+			CreatedAt:      created.UTC(),
+		})
+	}
+	return points, nil
+}
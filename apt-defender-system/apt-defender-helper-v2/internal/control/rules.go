@@ -0,0 +1,175 @@
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+const rulePrefix = "APTDefender_"
+
+// Kinds of helper-managed firewall rules, derived from the rule name
+const (
+	RuleKindAll    = "all"
+	RuleKindApp    = "app"
+	RuleKindDomain = "domain"
+	RuleKindIP     = "ip"
+	RuleKindPort   = "port"
+	RuleKindOther  = "other"
+)
+
+// FirewallRule is a Windows Firewall rule created by the helper
+type FirewallRule struct {
+	Name      string     `json:"name"`
+	Kind      string     `json:"kind"`
+	Direction string     `json:"direction"`
+	Action    string     `json:"action"`
+	Enabled   bool       `json:"enabled"`
+	Origin    string     `json:"origin,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type netFirewallRule struct {
+	DisplayName string
+	Direction   string
+	Action      string
+	Enabled     string
+	Description string
+}
+
+// ruleDescription tags a new rule with the command that created it and when,
+// since Windows Firewall records neither
+func ruleDescription(origin string) string {
+	return fmt.Sprintf("description=APTDefender origin=%s created=%s", origin, time.Now().UTC().Format(time.RFC3339))
+}
+
+// ManagedRules lists every firewall rule whose name carries the helper prefix
+func ManagedRules() ([]FirewallRule, error) {
+	query := fmt.Sprintf("Get-NetFirewallRule -DisplayName '%s*' | "+
+		"Select-Object DisplayName,@{n='Direction';e={\"$($_.Direction)\"}},@{n='Action';e={\"$($_.Action)\"}},"+
+		"@{n='Enabled';e={\"$($_.Enabled)\"}},Description | ConvertTo-Json -Compress", rulePrefix)
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list firewall rules: %w", err)
+	}
+
+	raw := strings.TrimSpace(string(out))
+	if raw == "" {
+		return []FirewallRule{}, nil
+	}
+	if !strings.HasPrefix(raw, "[") {
+		raw = "[" + raw + "]"
+	}
+
+	var list []netFirewallRule
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse firewall rules: %w", err)
+	}
+
+	rules := make([]FirewallRule, 0, len(list))
+	for _, r := range list {
+		rule := FirewallRule{
+			Name:      r.DisplayName,
+			Kind:      ruleKind(r.DisplayName),
+			Direction: r.Direction,
+			Action:    r.Action,
+			Enabled:   r.Enabled == "True",
+		}
+		for _, field := range strings.Fields(r.Description) {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "origin":
+				rule.Origin = value
+			case "created":
+				if t, err := time.Parse(time.RFC3339, value); err == nil {
+					rule.CreatedAt = &t
+				}
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules, nil
+}
+
+// RemoveManagedRules deletes helper-managed rules, optionally only one kind.
+// Removing kind "all" (or everything) lifts a full network block.
+func RemoveManagedRules(kind string) ([]string, error) {
+	rules, err := ManagedRules()
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	for _, rule := range rules {
+		if kind != "" && rule.Kind != kind {
+			continue
+		}
+		cmd := exec.Command("netsh", "advfirewall", "firewall", "delete", "rule", "name="+rule.Name)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			log.Printf("⚠️ Failed to delete firewall rule %s: %v, output: %s", rule.Name, err, output)
+			continue
+		}
+		removed = append(removed, rule.Name)
+	}
+
+	log.Printf("🧹 Removed %d helper firewall rules", len(removed))
+	return removed, nil
+}
+
+// SetRuleEnabled turns one helper-managed rule on or off by name
+func SetRuleEnabled(name string, enabled bool) error {
+	if err := checkManagedName(name); err != nil {
+		return err
+	}
+	state := map[bool]string{true: "yes", false: "no"}[enabled]
+	log.Printf("🔧 Setting firewall rule %s enable=%s", name, state)
+	cmd := exec.Command("netsh", "advfirewall", "firewall", "set", "rule", "name="+name, "new", "enable="+state)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to update firewall rule %s: %v, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RemoveRule deletes one helper-managed rule by name
+func RemoveRule(name string) error {
+	if err := checkManagedName(name); err != nil {
+		return err
+	}
+	log.Printf("🧹 Removing firewall rule %s", name)
+	cmd := exec.Command("netsh", "advfirewall", "firewall", "delete", "rule", "name="+name)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to delete firewall rule %s: %v, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// checkManagedName keeps per-rule changes to rules the helper created
+func checkManagedName(name string) error {
+	if !strings.HasPrefix(name, rulePrefix) || strings.ContainsAny(name, "\"\r\n") {
+		return fmt.Errorf("%q is not a rule created by the helper", name)
+	}
+	return nil
+}
+
+func ruleKind(name string) string {
+	switch {
+	case strings.HasPrefix(name, firewallRuleName):
+		return RuleKindAll
+	case strings.HasPrefix(name, appRulePrefix):
+		return RuleKindApp
+	case strings.HasPrefix(name, domainRulePrefix):
+		return RuleKindDomain
+	case strings.HasPrefix(name, portRulePrefix):
+		return RuleKindPort
+	case strings.HasPrefix(name, ipRulePrefix):
+		return RuleKindIP
+	default:
+		return RuleKindOther
+	}
+}
@@ -0,0 +1,138 @@
+package control
+
+import (
+	"fmt"
+	"log"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	wtsapi32 = syscall.NewLazyDLL("wtsapi32.dll")
+
+	procWTSEnumerateSessions       = wtsapi32.NewProc("WTSEnumerateSessionsW")
+	procWTSQuerySessionInformation = wtsapi32.NewProc("WTSQuerySessionInformationW")
+	procWTSLogoffSession           = wtsapi32.NewProc("WTSLogoffSession")
+	procWTSFreeMemory              = wtsapi32.NewProc("WTSFreeMemory")
+	procWTSGetActiveConsoleSession = kernel32.NewProc("WTSGetActiveConsoleSessionId")
+	procProcessIdToSessionId       = kernel32.NewProc("ProcessIdToSessionId")
+)
+
+const (
+	wtsUserName   = 5
+	wtsDomainName = 7
+
+	noConsoleSession = 0xFFFFFFFF
+)
+
+// WTS_CONNECTSTATE_CLASS
+var sessionStates = []string{
+	"active", "connected", "connect_query", "shadow", "disconnected",
+	"idle", "listen", "reset", "down", "init",
+}
+
+// Session is a Windows logon session (console or RDP)
+type Session struct {
+	ID      uint32 `json:"id"`
+	Name    string `json:"name"`
+	State   string `json:"state"`
+	User    string `json:"user,omitempty"`
+	Console bool   `json:"console"`
+}
+
+type wtsSessionInfo struct {
+	SessionID      uint32
+	WinStationName *uint16
+	State          uint32
+}
+
+// Sessions lists logon sessions that have a user attached
+func Sessions() ([]Session, error) {
+	var info *wtsSessionInfo
+	var count uint32
+	ret, _, err := procWTSEnumerateSessions.Call(0, 0, 1, uintptr(unsafe.Pointer(&info)), uintptr(unsafe.Pointer(&count)))
+	if ret == 0 {
+		return nil, fmt.Errorf("WTSEnumerateSessions failed: %v", err)
+	}
+	defer procWTSFreeMemory.Call(uintptr(unsafe.Pointer(info)))
+
+	console, _, _ := procWTSGetActiveConsoleSession.Call()
+
+	sessions := []Session{}
+	for _, entry := range unsafe.Slice(info, count) {
+		user := sessionString(entry.SessionID, wtsUserName)
+		if user == "" {
+			continue
+		}
+		if domain := sessionString(entry.SessionID, wtsDomainName); domain != "" {
+			user = domain + `\` + user
+		}
+
+		state := "unknown"
+		if int(entry.State) < len(sessionStates) {
+			state = sessionStates[entry.State]
+		}
+		sessions = append(sessions, Session{
+			ID:      entry.SessionID,
+			Name:    utf16PtrToString(entry.WinStationName),
+			State:   state,
+			User:    user,
+			Console: uintptr(entry.SessionID) == console,
+		})
+	}
+	return sessions, nil
+}
+
+// ActiveConsoleSession returns the session attached to the physical console
+func ActiveConsoleSession() (uint32, error) {
+	id, _, _ := procWTSGetActiveConsoleSession.Call()
+	if uint32(id) == noConsoleSession {
+		return 0, fmt.Errorf("no user is logged on at the console")
+	}
+	return uint32(id), nil
+}
+
+// InServiceSession reports whether the helper runs in session 0, i.e. as a
+// service with no desktop of its own
+func InServiceSession() bool {
+	var id uint32
+	ret, _, _ := procProcessIdToSessionId.Call(uintptr(syscall.Getpid()), uintptr(unsafe.Pointer(&id)))
+	return ret == 0 || id == 0
+}
+
+// LogoffSession signs out a session; unsaved work in it is lost
+func LogoffSession(id uint32) error {
+	log.Printf("🚪 LOGOFF REQUESTED - Logging off session %d...", id)
+
+	if id == 0 {
+		return fmt.Errorf("session 0 is reserved for services")
+	}
+
+	ret, _, err := procWTSLogoffSession.Call(0, uintptr(id), 0)
+	if ret == 0 {
+		return fmt.Errorf("logoff failed: %v", err)
+	}
+	return nil
+}
+
+func sessionString(id uint32, class uintptr) string {
+	var buf *uint16
+	var size uint32
+	ret, _, _ := procWTSQuerySessionInformation.Call(0, uintptr(id), class, uintptr(unsafe.Pointer(&buf)), uintptr(unsafe.Pointer(&size)))
+	if ret == 0 {
+		return ""
+	}
+	defer procWTSFreeMemory.Call(uintptr(unsafe.Pointer(buf)))
+	return utf16PtrToString(buf)
+}
+
+func utf16PtrToString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	n := 0
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; n++ {
+		ptr = unsafe.Add(ptr, 2)
+	}
+	return syscall.UTF16ToString(unsafe.Slice(p, n))
+}
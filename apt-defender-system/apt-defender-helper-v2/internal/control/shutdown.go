@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -19,6 +21,9 @@ var (
 	procLookupPrivilegeValue  = advapi32.NewProc("LookupPrivilegeValueW")
 	procAdjustTokenPrivileges = advapi32.NewProc("AdjustTokenPrivileges")
 	procGetCurrentProcess     = kernel32.NewProc("GetCurrentProcess")
+
+	procInitiateSystemShutdownEx = advapi32.NewProc("InitiateSystemShutdownExW")
+	procAbortSystemShutdown      = advapi32.NewProc("AbortSystemShutdownW")
 )
 
 const (
@@ -30,6 +35,17 @@ const (
 	TOKEN_ADJUST_PRIVILEGES = 0x0020
 	TOKEN_QUERY             = 0x0008
 	SE_PRIVILEGE_ENABLED    = 0x00000002
+
+	// SHTDN_REASON_FLAG_PLANNED | SHTDN_REASON_MINOR_SECURITY
+	shutdownReasonSecurity = 0x80000000 | 0x00000013
+
+	// MaxShutdownDelay caps delay_seconds for a scheduled shutdown
+	MaxShutdownDelay = 24 * time.Hour
+)
+
+var (
+	shutdownMutex   sync.Mutex
+	shutdownPending time.Time
 )
 
 type LUID struct {
@@ -49,6 +65,12 @@ type TOKEN_PRIVILEGES struct {
 
 // EnableShutdownPrivilege enables the necessary privilege to shutdown the system
 func EnableShutdownPrivilege() error {
+	return EnablePrivilege("SeShutdownPrivilege")
+}
+
+// EnablePrivilege enables a named privilege (e.g. SeDebugPrivilege) on the
+// helper's own token
+func EnablePrivilege(name string) error {
 	var hToken syscall.Handle
 	process, _, _ := procGetCurrentProcess.Call()
 
@@ -63,10 +85,10 @@ func EnableShutdownPrivilege() error {
 	defer syscall.CloseHandle(hToken)
 
 	var luid LUID
-	shutdownName, _ := syscall.UTF16PtrFromString("SeShutdownPrivilege")
+	privilegeName, _ := syscall.UTF16PtrFromString(name)
 	ret, _, err = procLookupPrivilegeValue.Call(
 		0,
-		uintptr(unsafe.Pointer(shutdownName)),
+		uintptr(unsafe.Pointer(privilegeName)),
 		uintptr(unsafe.Pointer(&luid)),
 	)
 	if ret == 0 {
@@ -117,6 +139,68 @@ func ShutdownPC() error {
 	return nil
 }
 
+// ScheduleShutdown starts a forced shutdown after delay. Windows shows the
+// logged-in user a countdown with message until it fires; CancelShutdown
+// aborts it.
+func ScheduleShutdown(delay time.Duration, message string) (time.Time, error) {
+	if delay <= 0 || delay > MaxShutdownDelay {
+		return time.Time{}, fmt.Errorf("delay must be between 1s and %s", MaxShutdownDelay)
+	}
+
+	log.Printf("⚠️ SHUTDOWN SCHEDULED in %s", delay)
+
+	if err := EnableShutdownPrivilege(); err != nil {
+		return time.Time{}, fmt.Errorf("failed to enable shutdown privilege: %w", err)
+	}
+
+	messagePtr, _ := syscall.UTF16PtrFromString(message)
+	ret, _, err := procInitiateSystemShutdownEx.Call(
+		0,
+		uintptr(unsafe.Pointer(messagePtr)),
+		uintptr(delay/time.Second),
+		1, // force applications closed
+		0, // shut down rather than reboot
+		shutdownReasonSecurity,
+	)
+	if ret == 0 {
+		return time.Time{}, fmt.Errorf("failed to schedule shutdown: %v", err)
+	}
+
+	shutdownMutex.Lock()
+	defer shutdownMutex.Unlock()
+	shutdownPending = time.Now().Add(delay)
+	return shutdownPending, nil
+}
+
+// CancelShutdown aborts a shutdown started by ScheduleShutdown
+func CancelShutdown() error {
+	log.Println("🛑 Cancelling scheduled shutdown")
+
+	if err := EnableShutdownPrivilege(); err != nil {
+		return fmt.Errorf("failed to enable shutdown privilege: %w", err)
+	}
+
+	ret, _, err := procAbortSystemShutdown.Call(0)
+	if ret == 0 {
+		return fmt.Errorf("no shutdown to cancel: %v", err)
+	}
+
+	shutdownMutex.Lock()
+	defer shutdownMutex.Unlock()
+	shutdownPending = time.Time{}
+	return nil
+}
+
+// PendingShutdown returns when a scheduled shutdown will fire
+func PendingShutdown() (time.Time, bool) {
+	shutdownMutex.Lock()
+	defer shutdownMutex.Unlock()
+	if shutdownPending.IsZero() || time.Now().After(shutdownPending) {
+		return time.Time{}, false
+	}
+	return shutdownPending, true
+}
+
 // RestartPC restarts the computer
 func RestartPC() error {
 	log.Println("⚠️ RESTART REQUESTED - Restarting PC...")
@@ -0,0 +1,67 @@
+package control
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	userenv = syscall.NewLazyDLL("userenv.dll")
+
+	procWTSQueryUserToken       = wtsapi32.NewProc("WTSQueryUserToken")
+	procCreateProcessAsUser     = advapi32.NewProc("CreateProcessAsUserW")
+	procCreateEnvironmentBlock  = userenv.NewProc("CreateEnvironmentBlock")
+	procDestroyEnvironmentBlock = userenv.NewProc("DestroyEnvironmentBlock")
+)
+
+const (
+	createUnicodeEnvironment = 0x00000400
+	createNoWindow           = 0x08000000
+)
+
+// RunInSession starts a command on the interactive desktop of a logged-on
+// user's session. The helper runs as a service in session 0, so anything the
+// user should see has to be launched this way.
+func RunInSession(sessionID uint32, commandLine string) error {
+	var token syscall.Token
+	ret, _, err := procWTSQueryUserToken.Call(uintptr(sessionID), uintptr(unsafe.Pointer(&token)))
+	if ret == 0 {
+		return fmt.Errorf("no user token for session %d: %v", sessionID, err)
+	}
+	defer token.Close()
+
+	var env uintptr
+	if ret, _, _ := procCreateEnvironmentBlock.Call(uintptr(unsafe.Pointer(&env)), uintptr(token), 0); ret != 0 {
+		defer procDestroyEnvironmentBlock.Call(env)
+	}
+
+	cmd, err := syscall.UTF16PtrFromString(commandLine)
+	if err != nil {
+		return err
+	}
+	desktop, _ := syscall.UTF16PtrFromString(`winsta0\default`)
+	si := syscall.StartupInfo{Desktop: desktop}
+	si.Cb = uint32(unsafe.Sizeof(si))
+	var pi syscall.ProcessInformation
+
+	ret, _, err = procCreateProcessAsUser.Call(
+		uintptr(token),
+		0,
+		uintptr(unsafe.Pointer(cmd)),
+		0,
+		0,
+		0,
+		createUnicodeEnvironment|createNoWindow,
+		env,
+		0,
+		uintptr(unsafe.Pointer(&si)),
+		uintptr(unsafe.Pointer(&pi)),
+	)
+	if ret == 0 {
+		return fmt.Errorf("CreateProcessAsUser failed: %v", err)
+	}
+	syscall.CloseHandle(pi.Thread)
+	syscall.CloseHandle(pi.Process)
+	return nil
+}
@@ -0,0 +1,89 @@
+package control
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+)
+
+const wolPort = 9
+
+// WakeOnLAN sends a magic packet for mac. With no broadcast address it goes
+// to 255.255.255.255 and to the directed broadcast of every local IPv4
+// subnet, since the limited broadcast only leaves through one interface.
+// It returns the addresses the packet was sent to.
+func WakeOnLAN(mac, broadcast string) ([]string, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return nil, fmt.Errorf("invalid MAC address: %s", mac)
+	}
+
+	packet := append(bytes.Repeat([]byte{0xff}, 6), bytes.Repeat(hw, 16)...)
+
+	targets := []net.IP{}
+	if broadcast != "" {
+		ip := net.ParseIP(broadcast).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("invalid broadcast address: %s", broadcast)
+		}
+		targets = append(targets, ip)
+	} else {
+		targets = append(targets, net.IPv4bcast)
+		targets = append(targets, subnetBroadcasts()...)
+	}
+
+	sent := []string{}
+	var lastErr error
+	for _, ip := range targets {
+		conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: ip, Port: wolPort})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_, err = conn.Write(packet)
+		conn.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		sent = append(sent, ip.String())
+	}
+	if len(sent) == 0 {
+		return nil, fmt.Errorf("failed to send magic packet: %v", lastErr)
+	}
+
+	log.Printf("⏰ Wake-on-LAN packet for %s sent to %v", hw, sent)
+	return sent, nil
+}
+
+func subnetBroadcasts() []net.IP {
+	result := []net.IP{}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return result
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || iface.Flags&net.FlagBroadcast == 0 {
+			continue
+		}
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || ipnet.IP.To4() == nil {
+				continue
+			}
+			ip := ipnet.IP.To4()
+			mask := net.IP(ipnet.Mask).To4()
+			if mask == nil {
+				continue
+			}
+			bcast := make(net.IP, 4)
+			for i := range ip {
+				bcast[i] = ip[i] | ^mask[i]
+			}
+			result = append(result, bcast)
+		}
+	}
+	return result
+}
@@ -1,13 +1,58 @@
 package dashboard
 
+// HTML is the dashboard. {{THEME}} is replaced with the theme setting.
 const HTML = `
 <!DOCTYPE html>
-<html lang="en">
+<html lang="en" data-theme-setting="{{THEME}}">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>APT Defender Helper - Dashboard</title>
+    <script>
+        // Apply the theme setting before the page renders; "system" follows Windows
+        (function() {
+            let theme = document.documentElement.dataset.themeSetting;
+            if (theme !== 'light' && theme !== 'dark') {
+                theme = window.matchMedia && window.matchMedia('(prefers-color-scheme: light)').matches ? 'light' : 'dark';
+            }
+            document.documentElement.dataset.theme = theme;
+        })();
+    </script>
     <style>
+        :root {
+            --page-bg: linear-gradient(135deg, #1e3c72 0%, #2a5298 100%);
+            --text: #fff;
+            --muted: rgba(255,255,255,0.7);
+            --surface: rgba(255,255,255,0.1);
+            --surface-strong: rgba(255,255,255,0.2);
+            --line: rgba(255,255,255,0.1);
+            --line-strong: rgba(255,255,255,0.2);
+            --inset: rgba(0,0,0,0.2);
+            --track: rgba(0,0,0,0.3);
+            --shadow: rgba(0,0,0,0.37);
+            --title-shadow: 2px 2px 4px rgba(0,0,0,0.3);
+            --accent: #74ebd5;
+            --danger-text: #f5576c;
+            --warning-text: #f39c12;
+        }
+
+        :root[data-theme="light"] {
+            --page-bg: linear-gradient(135deg, #eef2f7 0%, #dde6f3 100%);
+            --text: #1d2733;
+            --muted: rgba(0,0,0,0.6);
+            --surface: #fff;
+            --surface-strong: rgba(0,0,0,0.08);
+            --line: rgba(0,0,0,0.1);
+            --line-strong: rgba(0,0,0,0.15);
+            --inset: rgba(0,0,0,0.05);
+            --track: rgba(0,0,0,0.1);
+            --shadow: rgba(0,0,0,0.12);
+            --title-shadow: none;
+            --accent: #17707d;
+            --danger-text: #c0392b;
+            --warning-text: #b9770e;
+        }
+
         * {
             margin: 0;
             padding: 0;
@@ -16,8 +61,8 @@ const HTML = `
 
         body {
             font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
-            background: linear-gradient(135deg, #1e3c72 0%, #2a5298 100%);
-            color: #fff;
+            background: var(--page-bg);
+            color: var(--text);
             min-height: 100vh;
             padding: 20px;
         }
@@ -30,14 +75,14 @@ const HTML = `
         header {
             text-align: center;
             padding: 30px 0;
-            border-bottom: 2px solid rgba(255,255,255,0.2);
+            border-bottom: 2px solid var(--line-strong);
             margin-bottom: 30px;
         }
 
         h1 {
             font-size: 2.5em;
             margin-bottom: 10px;
-            text-shadow: 2px 2px 4px rgba(0,0,0,0.3);
+            text-shadow: var(--title-shadow);
         }
 
         .subtitle {
@@ -62,18 +107,18 @@ const HTML = `
         }
 
         .card {
-            background: rgba(255,255,255,0.1);
+            background: var(--surface);
             backdrop-filter: blur(10px);
             border-radius: 15px;
             padding: 25px;
-            border: 1px solid rgba(255,255,255,0.2);
-            box-shadow: 0 8px 32px 0 rgba(0,0,0,0.37);
+            border: 1px solid var(--line-strong);
+            box-shadow: 0 8px 32px 0 var(--shadow);
         }
 
         .card h2 {
             margin-bottom: 20px;
             font-size: 1.3em;
-            color: #74ebd5;
+            color: var(--accent);
         }
 
         .stat-row {
@@ -81,7 +126,7 @@ const HTML = `
             justify-content: space-between;
             margin: 12px 0;
             padding: 8px 0;
-            border-bottom: 1px solid rgba(255,255,255,0.1);
+            border-bottom: 1px solid var(--line);
         }
 
         .stat-label {
@@ -91,13 +136,13 @@ const HTML = `
 
         .stat-value {
             font-weight: bold;
-            color: #74ebd5;
+            color: var(--accent);
         }
 
         .progress-bar {
             width: 100%;
             height: 25px;
-            background: rgba(0,0,0,0.3);
+            background: var(--track);
             border-radius: 12px;
             overflow: hidden;
             margin-top: 10px;
@@ -165,7 +210,7 @@ const HTML = `
         }
 
         .scan-status {
-            background: rgba(0,0,0,0.2);
+            background: var(--inset);
             padding: 15px;
             border-radius: 8px;
             margin-top: 15px;
@@ -179,6 +224,312 @@ const HTML = `
         .scanning {
             animation: pulse 1.5s infinite;
         }
+
+        .scan-log {
+            list-style: none;
+            max-height: 160px;
+            overflow-y: auto;
+            font-size: 0.85em;
+        }
+
+        .scan-log li {
+            padding: 4px 0;
+            border-bottom: 1px solid var(--line);
+        }
+
+        .scan-log .threat {
+            color: var(--danger-text);
+            font-weight: bold;
+        }
+        .audit-filters {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 10px;
+            margin-bottom: 15px;
+        }
+
+        .audit-filters select, .audit-filters button {
+            padding: 8px 12px;
+            border-radius: 8px;
+            font-size: 0.9em;
+        }
+
+        .audit-table {
+            width: 100%;
+            border-collapse: collapse;
+            font-size: 0.85em;
+        }
+
+        .audit-table th, .audit-table td {
+            text-align: left;
+            padding: 6px 8px;
+            border-bottom: 1px solid var(--line);
+            word-break: break-all;
+        }
+
+        .audit-table th {
+            color: var(--accent);
+        }
+
+        .audit-table .failure {
+            color: var(--danger-text);
+            font-weight: bold;
+        }
+
+        nav.views {
+            display: flex;
+            flex-wrap: wrap;
+            justify-content: center;
+            gap: 10px;
+            margin-bottom: 30px;
+        }
+
+        nav.views button {
+            padding: 10px 18px;
+            background: var(--surface);
+            border: 1px solid var(--line-strong);
+            color: var(--text);
+        }
+
+        nav.views button.active {
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            color: white;
+        }
+
+        .view {
+            display: none;
+        }
+
+        .view.active {
+            display: block;
+        }
+
+        .audit-table td button {
+            padding: 5px 10px;
+            margin: 2px;
+            font-size: 0.85em;
+        }
+
+        .severity-high, .severity-critical {
+            color: var(--danger-text);
+            font-weight: bold;
+        }
+
+        .severity-medium {
+            color: var(--warning-text);
+            font-weight: bold;
+        }
+
+        .audit-table th.sortable {
+            cursor: pointer;
+        }
+
+        .isolation-banner {
+            display: none;
+            background: linear-gradient(135deg, #e74c3c 0%, #c0392b 100%);
+            border-radius: 15px;
+            padding: 20px 25px;
+            margin-bottom: 30px;
+            box-shadow: 0 8px 32px 0 var(--shadow);
+            color: white;
+        }
+
+        .isolation-banner h2 {
+            margin-bottom: 10px;
+        }
+
+        .isolation-banner button {
+            margin-top: 15px;
+            background: rgba(255,255,255,0.2);
+            border: 2px solid white;
+        }
+
+        .audit-filters input {
+            padding: 8px 12px;
+            border-radius: 8px;
+            border: none;
+            font-size: 0.9em;
+            min-width: 220px;
+        }
+
+        .history-chart {
+            width: 100%;
+            height: 160px;
+            display: block;
+        }
+
+        .history-chart rect {
+            fill: var(--accent);
+        }
+
+        .history-chart rect.stopped {
+            fill: #f39c12;
+        }
+
+        .history-chart text {
+            fill: var(--muted);
+            font-size: 11px;
+        }
+
+        .sparkline {
+            width: 100%;
+            height: 48px;
+            display: block;
+            margin-top: 10px;
+            background: var(--inset);
+            border-radius: 6px;
+        }
+
+        .sparkline polyline {
+            fill: none;
+            stroke: var(--accent);
+            stroke-width: 1.5;
+        }
+
+        .sparkline polyline.secondary {
+            stroke: var(--warning-text);
+        }
+
+        .sparkline text {
+            fill: var(--muted);
+            font-size: 10px;
+        }
+
+        .settings-form label {
+            display: block;
+            margin: 15px 0 5px;
+            font-weight: bold;
+        }
+
+        .settings-form .hint {
+            opacity: 0.8;
+            font-size: 0.85em;
+            margin-bottom: 5px;
+        }
+
+        .settings-form textarea, .settings-form input, .settings-form select {
+            width: 100%;
+            max-width: 600px;
+            padding: 8px 12px;
+            border-radius: 8px;
+            border: none;
+            font-size: 0.9em;
+            font-family: inherit;
+        }
+
+        .settings-form input[type="checkbox"] {
+            width: auto;
+            margin-right: 8px;
+        }
+
+        .settings-form .checkbox {
+            font-weight: normal;
+            margin: 5px 0;
+        }
+
+        .wizard-steps {
+            display: flex;
+            flex-wrap: wrap;
+            gap: 15px;
+            margin: 10px 0 15px;
+            font-size: 0.9em;
+        }
+
+        .wizard-steps span {
+            opacity: 0.6;
+        }
+
+        .wizard-steps span.current {
+            opacity: 1;
+            font-weight: bold;
+            color: var(--accent);
+        }
+
+        .timeline-day {
+            margin: 20px 0 10px;
+            color: var(--accent);
+            font-weight: bold;
+        }
+
+        .timeline-item {
+            display: flex;
+            gap: 15px;
+            padding: 10px 0 10px 15px;
+            border-left: 3px solid var(--accent);
+            border-bottom: 1px solid var(--line);
+        }
+
+        .timeline-item.failure {
+            border-left-color: var(--danger-text);
+        }
+
+        .timeline-time {
+            min-width: 70px;
+            opacity: 0.8;
+        }
+
+        .timeline-title {
+            font-weight: bold;
+        }
+
+        .timeline-meta {
+            font-size: 0.85em;
+            opacity: 0.8;
+            word-break: break-all;
+        }
+
+        .log-view {
+            position: relative;
+            height: 520px;
+            overflow-y: auto;
+            background: var(--inset);
+            border-radius: 8px;
+            font-family: Consolas, 'Courier New', monospace;
+            font-size: 0.8em;
+        }
+
+        .log-row {
+            position: absolute;
+            left: 0;
+            right: 0;
+            height: 22px;
+            line-height: 22px;
+            padding: 0 10px;
+            white-space: nowrap;
+            overflow: hidden;
+            text-overflow: ellipsis;
+        }
+
+        .log-row.level-warn {
+            color: var(--warning-text);
+        }
+
+        .log-row.level-error {
+            color: var(--danger-text);
+            font-weight: bold;
+        }
+
+        .log-row.level-debug {
+            opacity: 0.6;
+        }
+
+        .subtle-button {
+            padding: 8px 14px;
+            background: var(--surface-strong);
+            color: var(--text);
+            font-size: 0.9em;
+        }
+
+        .ip-card {
+            color: white;
+        }
+
+        .ip-address {
+            margin: 8px 0;
+            padding: 10px;
+            background: rgba(255,255,255,0.15);
+            border-radius: 8px;
+        }
     </style>
 </head>
 <body>
@@ -187,10 +538,39 @@ const HTML = `
             <h1>🛡️ APT Defender Helper</h1>
             <p class="subtitle">Advanced PC Protection & Remote Control</p>
             <span class="status" id="connectionStatus">● CHECKING...</span>
+            <span class="status" id="piLinkStatus" style="display: none; cursor: pointer;" onclick="showView('pairing')"></span>
+            <div style="margin-top: 10px;">
+                <button class="subtle-button" id="themeToggle" onclick="toggleTheme()"></button>
+                <button class="subtle-button" id="reportButton" onclick="exportReport()">📄 Export report</button>
+            </div>
+            <form id="logoutForm" method="POST" action="/dashboard/logout" style="display: none; margin-top: 10px;">
+                <button type="submit" class="subtle-button">🚪 Sign out</button>
+            </form>
         </header>
 
+        <div class="isolation-banner" id="isolationBanner">
+            <h2>🔒 Network isolation is active</h2>
+            <p>A containment action blocked all network traffic on this PC, which is why websites, email and file shares don't work. It stays this way until the isolation is lifted from the Pi Agent or here.</p>
+            <p id="isolationDetail" style="opacity: 0.9; margin-top: 8px;"></p>
+            <button onclick="liftIsolation()">Restore network access</button>
+        </div>
+
+        <nav class="views">
+            <button data-view="overview" onclick="showView('overview')">🏠 Overview</button>
+            <button data-view="commands" onclick="showView('commands')">🕑 Command History</button>
+            <button data-view="threats" onclick="showView('threats')">⚠️ Threats &amp; Quarantine</button>
+            <button data-view="processes" onclick="showView('processes')">⚙️ Processes</button>
+            <button data-view="network" onclick="showView('network')">🌐 Connections</button>
+            <button data-view="firewall" onclick="showView('firewall')">🧱 Firewall</button>
+            <button data-view="history" onclick="showView('history')">📈 Scan History</button>
+            <button data-view="pairing" onclick="showView('pairing')">🔗 Pairing</button>
+            <button data-view="logs" onclick="showView('logs')">📜 Logs</button>
+            <button data-view="settings" onclick="showView('settings')">🛠️ Settings</button>
+        </nav>
+
+        <div class="view" id="view-overview">
         <!-- IP Address Card (Prominent) -->
-        <div class="card" style="background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); margin-bottom: 30px; text-align: center;">
+        <div class="card ip-card" style="background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); margin-bottom: 30px; text-align: center;">
             <h2 style="color: white; margin-bottom: 15px;">📍 PC IP Addresses - Add to Mobile App</h2>
             <div id="ipAddresses" style="font-size: 1.5em; font-weight: bold; margin: 20px 0;"></div>
             <p style="opacity: 0.9; margin-bottom: 15px;">Use any of these IPs when adding this PC to your mobile app</p>
@@ -227,6 +607,7 @@ const HTML = `
                 <div class="progress-bar">
                     <div class="progress-fill" id="cpuProgress" style="width: 0%">0%</div>
                 </div>
+                <svg class="sparkline" id="cpuGraph"></svg>
             </div>
 
             <!-- Memory Stats -->
@@ -243,6 +624,7 @@ const HTML = `
                 <div class="progress-bar">
                     <div class="progress-fill" id="memProgress" style="width: 0%">0%</div>
                 </div>
+                <svg class="sparkline" id="memGraph"></svg>
             </div>
 
             <!-- Disk Stats -->
@@ -261,6 +643,20 @@ const HTML = `
                 </div>
             </div>
 
+            <!-- Network Stats -->
+            <div class="card">
+                <h2>📶 Network Traffic</h2>
+                <div class="stat-row">
+                    <span class="stat-label">Sending:</span>
+                    <span class="stat-value" id="netSent">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Receiving:</span>
+                    <span class="stat-value" id="netRecv">-</span>
+                </div>
+                <svg class="sparkline" id="netGraph"></svg>
+            </div>
+
             <!-- Features -->
             <div class="card">
                 <h2>🔹 Available Features</h2>
@@ -289,100 +685,2213 @@ const HTML = `
                     <span class="stat-label">Threats Found:</span>
                     <span class="stat-value" id="threatsFound">0</span>
                 </div>
+                <div class="stat-row">
+                    <span class="stat-label">Current Folder:</span>
+                    <span class="stat-value" id="scanFolder" style="word-break: break-all;">-</span>
+                </div>
+                <div class="progress-bar">
+                    <div class="progress-fill" id="scanProgress" style="width: 0%">0%</div>
+                </div>
                 <div class="actions" style="margin-top: 15px;">
                     <button onclick="startScan()">Start Scan</button>
                     <button onclick="stopScan()">Stop Scan</button>
                 </div>
+                <div class="scan-status">
+                    <ul class="scan-log" id="scanLog"></ul>
+                </div>
             </div>
         </div>
-    </div>
 
-    <script>
-        const API_BASE = window.location.origin + '/api/v1';
-        let ipAddresses = [];
+        <!-- Command Audit -->
+        <div class="card" style="margin-bottom: 30px;">
+            <h2>📜 Command Audit</h2>
+            <p style="opacity: 0.9; margin-bottom: 15px;">Every command this PC has received from the Pi Agent and other API clients</p>
+            <div class="audit-filters">
+                <select id="auditAction" onchange="fetchAudit()">
+                    <option value="">All commands</option>
+                </select>
+                <select id="auditOutcome" onchange="fetchAudit()">
+                    <option value="">Any outcome</option>
+                    <option value="success">Success</option>
+                    <option value="failure">Failure</option>
+                </select>
+                <select id="auditSince" onchange="fetchAudit()">
+                    <option value="1h">Last hour</option>
+                    <option value="24h" selected>Last 24 hours</option>
+                    <option value="168h">Last 7 days</option>
+                    <option value="720h">Last 30 days</option>
+                    <option value="">All time</option>
+                </select>
+                <button onclick="fetchAudit()">Refresh</button>
+            </div>
+            <table class="audit-table">
+                <thead>
+                    <tr><th>Time</th><th>Command</th><th>Target</th><th>Outcome</th><th>From</th><th>Token</th></tr>
+                </thead>
+                <tbody id="auditEntries"></tbody>
+            </table>
+            <p id="auditSummary" style="opacity: 0.7; margin-top: 10px; font-size: 0.85em;"></p>
+        </div>
+        </div>
 
-        // Fetch IP addresses on load
-        fetchIPAddresses();
+        <div class="view" id="view-commands">
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>🕑 Command History</h2>
+                <p style="opacity: 0.9; margin-bottom: 15px;">What this PC was told to do and by whom, newest first. If the screen just locked or the network went away, the reason is here.</p>
+                <div class="audit-filters">
+                    <select id="commandSource" onchange="fetchCommands()">
+                        <option value="">Everyone</option>
+                        <option value="pi">Pi Agent only</option>
+                        <option value="local">This PC only</option>
+                    </select>
+                    <select id="commandSince" onchange="fetchCommands()">
+                        <option value="24h">Last 24 hours</option>
+                        <option value="168h" selected>Last 7 days</option>
+                        <option value="720h">Last 30 days</option>
+                    </select>
+                    <button onclick="fetchCommands()">Refresh</button>
+                </div>
+                <div id="commandTimeline"></div>
+                <p id="commandSummary" style="opacity: 0.7; margin-top: 10px; font-size: 0.85em;"></p>
+            </div>
+        </div>
 
-        async function fetchIPAddresses() {
-            try {
-                const response = await fetch(API_BASE + '/system/info');
-                const data = await response.json();
-                
-                if (data.success && data.data.ip_addresses) {
-                    ipAddresses = data.data.ip_addresses;
-                    displayIPAddresses();
-                    
-                    // Update Pi Agent connection status
-                    const statusEl = document.getElementById('connectionStatus');
-                    if (data.data.registered_with_pi) {
-                        statusEl.textContent = '● CONNECTED TO PI AGENT';
-                        statusEl.style.background = '#2ecc71';
-                        statusEl.title = 'Registered with Pi Agent at ' + data.data.pi_agent_ip;
-                    } else {
-                        statusEl.textContent = '● NOT REGISTERED';
-                        statusEl.style.background = '#f39c12';
-                        statusEl.title = 'This PC has not been added to a Pi Agent yet';
-                    }
-                }
-            } catch (error) {
-                console.error('Failed to fetch IP addresses:', error);
-                document.getElementById('ipAddresses').innerHTML = '<span style="color: #ffcc00;">Unable to detect IPs</span>';
-                document.getElementById('connectionStatus').textContent = '● DISCONNECTED';
-                document.getElementById('connectionStatus').style.background = '#e74c3c';
-            }
-        }
+        <div class="view" id="view-threats">
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>⚠️ Detected Threats</h2>
+                <p id="threatSummary" style="opacity: 0.9; margin-bottom: 15px;"></p>
+                <table class="audit-table">
+                    <thead>
+                        <tr><th>Detected</th><th>Threat</th><th>Severity</th><th>Path</th><th>Status</th><th></th></tr>
+                    </thead>
+                    <tbody id="threatEntries"></tbody>
+                </table>
+            </div>
 
-        function displayIPAddresses() {
-            const container = document.getElementById('ipAddresses');
-            if (ipAddresses.length === 0) {
-                container.textContent = 'No network interfaces found';
-                return;
-            }
-            
-            const ipHTML = ipAddresses.map(function(ip) {
-                return '<div style="margin: 8px 0; padding: 10px; background: rgba(255,255,255,0.15); border-radius: 8px;">' + ip + '</div>';
-            }).join('');
-            container.innerHTML = ipHTML;
-        }
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>🗄️ Quarantine</h2>
+                <p id="quarantineSummary" style="opacity: 0.9; margin-bottom: 15px;"></p>
+                <table class="audit-table">
+                    <thead>
+                        <tr><th>Quarantined</th><th>Original Path</th><th>Reason</th><th>Size</th><th>SHA-256</th><th></th></tr>
+                    </thead>
+                    <tbody id="quarantineEntries"></tbody>
+                </table>
+            </div>
 
-        function copyIP() {
-            if (ipAddresses.length === 0) {
-                alert('No IP addresses available to copy');
-                return;
-            }
-            
-            const ip = ipAddresses[0]; // Copy first IP
-            navigator.clipboard.writeText(ip).then(function() {
-                alert('Copied: ' + ip + '\n\nNow open your mobile app and paste this in the "IP Address" field!');
-            }).catch(function(err) {
-                // Fallback for older browsers
-                const textArea = document.createElement('textarea');
-                textArea.value = ip;
-                document.body.appendChild(textArea);
-                textArea.select();
-                document.execCommand('copy');
-                document.body.removeChild(textArea);
-                alert('Copied: ' + ip);
-            });
-        }
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>✅ False Positives</h2>
+                <p style="opacity: 0.9; margin-bottom: 15px;">Files with these hashes are no longer reported by scans</p>
+                <table class="audit-table">
+                    <thead>
+                        <tr><th>Marked</th><th>Threat</th><th>Path</th><th>SHA-256</th><th></th></tr>
+                    </thead>
+                    <tbody id="falsePositiveEntries"></tbody>
+                </table>
+            </div>
+        </div>
 
-        // Update system stats every 2 seconds
-        setInterval(updateStats, 2000);
-        updateStats(); // Initial call
+        <div class="view" id="view-processes">
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>⚙️ Processes</h2>
+                <div class="audit-filters">
+                    <input type="search" id="processSearch" placeholder="Search by name or user" oninput="searchProcesses()">
+                    <button onclick="fetchProcesses()">Refresh</button>
+                </div>
+                <table class="audit-table">
+                    <thead>
+                        <tr>
+                            <th class="sortable" data-sort="pid" onclick="sortProcesses('pid')">PID</th>
+                            <th class="sortable" data-sort="name" onclick="sortProcesses('name')">Name</th>
+                            <th class="sortable" data-sort="user" onclick="sortProcesses('user')">User</th>
+                            <th class="sortable" data-sort="cpu" onclick="sortProcesses('cpu')">CPU</th>
+                            <th class="sortable" data-sort="memory" onclick="sortProcesses('memory')">Memory</th>
+                            <th></th>
+                        </tr>
+                    </thead>
+                    <tbody id="processEntries"></tbody>
+                </table>
+                <p id="processSummary" style="opacity: 0.7; margin-top: 10px; font-size: 0.85em;"></p>
+            </div>
+        </div>
 
-        // Update scan status every second
-        setInterval(updateScanStatus, 1000);
+        <div class="view" id="view-network">
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>🌐 Active Connections</h2>
+                <div class="audit-filters">
+                    <input type="search" id="connectionSearch" placeholder="Filter by process, address or network" oninput="showConnections()">
+                    <select id="connectionScope" onchange="showConnections()">
+                        <option value="">All remote addresses</option>
+                        <option value="public" selected>Internet only</option>
+                        <option value="private">Local network only</option>
+                    </select>
+                    <button onclick="fetchConnections()">Refresh</button>
+                </div>
+                <table class="audit-table">
+                    <thead>
+                        <tr><th>Process</th><th>Local</th><th>Remote</th><th>Owner</th><th>State</th><th></th></tr>
+                    </thead>
+                    <tbody id="connectionEntries"></tbody>
+                </table>
+                <p id="connectionSummary" style="opacity: 0.7; margin-top: 10px; font-size: 0.85em;"></p>
+            </div>
 
-        async function updateStats() {
-            try {
-                const response = await fetch(API_BASE + '/telemetry');
-                const data = await response.json();
-                
-                if (data.success) {
-                    const stats = data.data;
-                    
-                    // System info
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>🚫 Blocked Addresses</h2>
+                <table class="audit-table">
+                    <thead>
+                        <tr><th>Address</th><th>Direction</th><th></th></tr>
+                    </thead>
+                    <tbody id="blockedIPEntries"></tbody>
+                </table>
+            </div>
+        </div>
+
+        <div class="view" id="view-firewall">
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>🧱 Firewall Rules</h2>
+                <p style="opacity: 0.9; margin-bottom: 15px;">Windows Firewall rules created by APT Defender. Disabling a rule keeps it for later; removing it deletes it.</p>
+                <div class="audit-filters">
+                    <select id="firewallKind" onchange="fetchFirewall()">
+                        <option value="">All kinds</option>
+                        <option value="all">Network isolation</option>
+                        <option value="app">Applications</option>
+                        <option value="domain">Domains</option>
+                        <option value="ip">IP addresses</option>
+                        <option value="port">Ports</option>
+                        <option value="other">Other</option>
+                    </select>
+                    <button onclick="fetchFirewall()">Refresh</button>
+                </div>
+                <table class="audit-table">
+                    <thead>
+                        <tr><th>Rule</th><th>Kind</th><th>Direction</th><th>Action</th><th>Enabled</th><th>Created by</th><th>Created</th><th></th></tr>
+                    </thead>
+                    <tbody id="firewallEntries"></tbody>
+                </table>
+            </div>
+        </div>
+
+        <div class="view" id="view-history">
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>📈 Scan History</h2>
+                <p style="opacity: 0.9; margin-bottom: 15px;">Every finished scan, oldest on the left. Stopped scans are shown in orange.</p>
+                <div class="audit-filters">
+                    <select id="historyRange" onchange="fetchScanHistory()">
+                        <option value="168h">Last 7 days</option>
+                        <option value="720h" selected>Last 30 days</option>
+                        <option value="2160h">Last 90 days</option>
+                        <option value="">All time</option>
+                    </select>
+                    <button onclick="fetchScanHistory()">Refresh</button>
+                    <span id="historySummary"></span>
+                </div>
+            </div>
+            <div class="grid">
+                <div class="card">
+                    <h2>Files Scanned</h2>
+                    <svg class="history-chart" id="historyFiles"></svg>
+                </div>
+                <div class="card">
+                    <h2>Threats Found</h2>
+                    <svg class="history-chart" id="historyThreats"></svg>
+                </div>
+                <div class="card">
+                    <h2>Duration</h2>
+                    <svg class="history-chart" id="historyDuration"></svg>
+                </div>
+            </div>
+            <div class="card" style="margin-bottom: 30px;">
+                <table class="audit-table">
+                    <thead>
+                        <tr><th>Started</th><th>Type</th><th>Files scanned</th><th>Threats</th><th>Skipped</th><th>Duration</th><th>Result</th></tr>
+                    </thead>
+                    <tbody id="historyEntries"></tbody>
+                </table>
+            </div>
+        </div>
+
+        <div class="view" id="view-pairing">
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>🔗 Pi Agent Pairing</h2>
+                <div class="stat-row">
+                    <span class="stat-label">Status:</span>
+                    <span class="stat-value" id="pairingState">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Pi Agent:</span>
+                    <span class="stat-value" id="pairingAddress">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Certificate fingerprint:</span>
+                    <span class="stat-value" id="pairingFingerprint" style="word-break: break-all;">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Device ID:</span>
+                    <span class="stat-value" id="pairingDevice">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Client certificate:</span>
+                    <span class="stat-value" id="pairingClientCert">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Last contact:</span>
+                    <span class="stat-value" id="pairingLastSeen">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Last check:</span>
+                    <span class="stat-value" id="pairingLastAttempt">-</span>
+                </div>
+                <div class="stat-row" id="pairingErrorRow" style="display: none;">
+                    <span class="stat-label">Last error:</span>
+                    <span class="stat-value" id="pairingLastError" style="word-break: break-all;">-</span>
+                </div>
+                <p id="pairingAdvice" style="display: none; margin-top: 15px;"></p>
+                <p id="pairingTokenNote" style="display: none; margin-top: 15px;">⚠️ The auth token is set by an environment variable or command-line flag, so unpairing can't revoke it: the Pi Agent's credentials would work again after a restart. Remove the override, or set a new token there, to unpair.</p>
+                <div class="actions" id="pairingUnpair" style="margin-top: 20px; display: none;">
+                    <button onclick="checkPiNow()">🔄 Check now</button>
+                    <button onclick="startRepair()">🧭 Re-pair</button>
+                    <button class="danger" id="pairingUnpairButton" onclick="unpairPi()">🔓 Unpair</button>
+                </div>
+            </div>
+
+            <div class="card settings-form" id="repairWizard" style="margin-bottom: 30px; display: none;">
+                <h2>🧭 Re-pair with the Pi Agent</h2>
+                <div class="wizard-steps">
+                    <span data-step="1">1. Check the connection</span>
+                    <span data-step="2">2. Find the Pi</span>
+                    <span data-step="3">3. New pairing code</span>
+                    <span data-step="4">4. Done</span>
+                </div>
+                <div class="wizard-step" data-step="1">
+                    <p style="opacity: 0.9;">A Pi Agent that is switched off, rebooting or briefly offline comes back on its own, and re-pairing won't help. Check that it is powered on and on the network first.</p>
+                    <p id="repairCheckResult" style="margin-top: 10px;"></p>
+                    <div class="actions" style="margin-top: 20px;">
+                        <button onclick="repairCheck()">🔄 Check now</button>
+                        <button onclick="repairStep(2)">It's on, continue</button>
+                    </div>
+                </div>
+                <div class="wizard-step" data-step="2">
+                    <p style="opacity: 0.9;">If the Pi Agent moved to another address or network, find it or enter its new address.</p>
+                    <label for="repairAddress">Pi Agent address</label>
+                    <div class="hint">IP or IP:port, e.g. 192.168.1.10 or 192.168.1.10:8443</div>
+                    <input id="repairAddress" type="text" list="repairDiscovered" autocomplete="off">
+                    <datalist id="repairDiscovered"></datalist>
+                    <div class="actions" style="margin-top: 20px;">
+                        <button onclick="discoverPi('repairAddress', 'repairDiscovered', 'repairStatus')">🔎 Find Pi Agents</button>
+                        <button onclick="repairStep(3)">Continue</button>
+                    </div>
+                </div>
+                <div class="wizard-step" data-step="3">
+                    <p style="opacity: 0.9;">Generate a new pairing code in the mobile app. The current pairing is replaced once the Pi Agent accepts it.</p>
+                    <label for="repairCode">Pairing code</label>
+                    <input id="repairCode" type="text" autocomplete="off" style="text-transform: uppercase; letter-spacing: 3px;">
+                    <div class="actions" style="margin-top: 20px;">
+                        <button onclick="repairStep(2)">Back</button>
+                        <button onclick="checkRepair()">Continue</button>
+                    </div>
+                    <div id="repairConfirm" style="display: none; margin-top: 20px;">
+                        <p>The Pi Agent at <strong id="repairConfirmAddress"></strong> presented this certificate:</p>
+                        <p style="font-family: monospace; word-break: break-all; margin: 10px 0;" id="repairConfirmFingerprint"></p>
+                        <p id="repairCertChanged" style="display: none; color: var(--danger-text);">This is not the certificate this PC was paired with. That is expected if the Pi Agent was reinstalled or replaced; if it wasn't, don't continue.</p>
+                        <p>Only pair if it matches the fingerprint shown in the mobile app.</p>
+                        <div class="actions" style="margin-top: 10px;">
+                            <button onclick="confirmRepair()">✅ It matches, re-pair</button>
+                            <button class="danger" onclick="closeRepair()">It doesn't match</button>
+                        </div>
+                    </div>
+                </div>
+                <div class="wizard-step" data-step="4">
+                    <p id="repairDone"></p>
+                </div>
+                <p id="repairStatus" style="margin-top: 10px;"></p>
+                <div class="actions" style="margin-top: 10px;">
+                    <button class="subtle-button" id="repairClose" onclick="closeRepair()">Cancel</button>
+                </div>
+            </div>
+
+            <div class="card settings-form" id="pairingForm" style="margin-bottom: 30px;">
+                <h2>Pair with a Pi Agent</h2>
+                <p style="opacity: 0.9;">Generate a pairing code in the mobile app, then enter the Pi's address and the code. The Pi's certificate fingerprint is shown for you to compare with the app before the code is sent.</p>
+                <label for="pairAddress">Pi Agent address</label>
+                <div class="hint">IP or IP:port, e.g. 192.168.1.10 or 192.168.1.10:8443</div>
+                <input id="pairAddress" type="text" list="pairDiscovered" autocomplete="off">
+                <datalist id="pairDiscovered"></datalist>
+                <label for="pairCode">Pairing code</label>
+                <input id="pairCode" type="text" autocomplete="off" style="text-transform: uppercase; letter-spacing: 3px;">
+                <div class="actions" style="margin-top: 20px;">
+                    <button onclick="checkPairing()">Continue</button>
+                    <button onclick="discoverPi('pairAddress', 'pairDiscovered', 'pairStatus')">🔎 Find Pi Agents</button>
+                </div>
+                <div id="pairConfirm" style="display: none; margin-top: 20px;">
+                    <p>The Pi Agent at <strong id="pairConfirmAddress"></strong> presented this certificate:</p>
+                    <p style="font-family: monospace; word-break: break-all; margin: 10px 0;" id="pairConfirmFingerprint"></p>
+                    <p>Only pair if it matches the fingerprint shown in the mobile app.</p>
+                    <div class="actions" style="margin-top: 10px;">
+                        <button onclick="confirmPairing()">✅ It matches, pair</button>
+                        <button class="danger" onclick="cancelPairing()">It doesn't match</button>
+                    </div>
+                </div>
+                <p id="pairStatus" style="margin-top: 10px;"></p>
+            </div>
+        </div>
+
+        <div class="view" id="view-logs">
+            <div class="card" style="margin-bottom: 30px;">
+                <h2>📜 Logs</h2>
+                <p style="opacity: 0.9; margin-bottom: 15px;">The helper's log file, newest at the bottom. Hover over a line to see all of it.</p>
+                <div class="audit-filters">
+                    <select id="logLevel" onchange="fetchLogs()">
+                        <option value="debug">Everything</option>
+                        <option value="info" selected>Info and above</option>
+                        <option value="warn">Warnings and errors</option>
+                        <option value="error">Errors only</option>
+                    </select>
+                    <select id="logComponent" onchange="fetchLogs()">
+                        <option value="">All components</option>
+                    </select>
+                    <input id="logSearch" type="search" placeholder="Search messages" oninput="searchLogs()">
+                    <label class="checkbox"><input id="logFollow" type="checkbox" checked>Follow</label>
+                    <button onclick="copyLogs()">Copy</button>
+                    <button onclick="fetchLogs()">Refresh</button>
+                </div>
+                <div class="log-view" id="logView" onscroll="renderLogs()">
+                    <div id="logSpacer"></div>
+                </div>
+                <p id="logSummary" style="opacity: 0.7; margin-top: 10px; font-size: 0.85em;"></p>
+            </div>
+        </div>
+
+        <div class="view" id="view-settings">
+            <div class="card settings-form" style="margin-bottom: 30px;">
+                <h2>🛠️ Settings</h2>
+                <p style="opacity: 0.9;">Changes are checked, applied right away and saved to the helper's config file. Settings fixed by environment variables or command-line flags can't be changed here.</p>
+
+                <h3 style="margin-top: 20px;">Scanning</h3>
+                <label for="settingScanPaths">Folders to scan</label>
+                <div class="hint">One folder per line, e.g. C:\Users\you\Downloads</div>
+                <textarea id="settingScanPaths" rows="4"></textarea>
+
+                <label for="settingExclusions">Exclusions</label>
+                <div class="hint">One per line: a folder or file (C:\VMs) or a name pattern (*.iso)</div>
+                <textarea id="settingExclusions" rows="4"></textarea>
+
+                <label for="settingInterval">Scheduled scan</label>
+                <select id="settingInterval">
+                    <option value="0">Off</option>
+                    <option value="6">Every 6 hours</option>
+                    <option value="12">Every 12 hours</option>
+                    <option value="24">Every day</option>
+                    <option value="72">Every 3 days</option>
+                    <option value="168">Every week</option>
+                </select>
+
+                <label for="settingSkipWarn">Warn when a scan can't read more than (%) of files</label>
+                <input id="settingSkipWarn" type="number" min="1" max="100">
+
+                <h3 style="margin-top: 20px;">Notifications</h3>
+                <label class="checkbox"><input id="settingNotifyThreats" type="checkbox">Show a notification on this PC when a scan finds threats</label>
+                <label class="checkbox"><input id="settingNotifyScans" type="checkbox">Show a notification on this PC when any scan finishes</label>
+                <label class="checkbox"><input id="settingNotifyCommands" type="checkbox">Tell the user when this PC is locked, shut down, restarted or cut off the network remotely</label>
+                <label>Report to the Pi Agent as they happen</label>
+                <label class="checkbox"><input type="checkbox" class="setting-pi-alert" value="scan.threat">Threats found by scans</label>
+                <label class="checkbox"><input type="checkbox" class="setting-pi-alert" value="fim.change">Changes to watched files</label>
+                <label class="checkbox"><input type="checkbox" class="setting-pi-alert" value="playbook.executed">Automatic response actions</label>
+
+                <h3 style="margin-top: 20px;">Appearance</h3>
+                <label for="settingTheme">Dashboard theme</label>
+                <select id="settingTheme">
+                    <option value="system">Same as Windows</option>
+                    <option value="light">Light</option>
+                    <option value="dark">Dark</option>
+                </select>
+
+                <h3 style="margin-top: 20px;">Startup</h3>
+                <label class="checkbox"><input id="settingStartAtLogin" type="checkbox">Start the helper when I sign in to Windows</label>
+                <label class="checkbox"><input id="settingStartMinimized" type="checkbox">Start in the tray without opening the dashboard</label>
+                <div class="hint">The dashboard still opens on the pairing page until this PC is paired with a Pi Agent.</div>
+
+                <h3 style="margin-top: 20px;">Logging</h3>
+                <label for="settingLogLevel">Log detail</label>
+                <select id="settingLogLevel">
+                    <option value="debug">Debug (everything)</option>
+                    <option value="info">Info</option>
+                    <option value="warn">Warnings and errors</option>
+                    <option value="error">Errors only</option>
+                </select>
+
+                <div class="actions" style="margin-top: 20px;">
+                    <button onclick="saveSettings()">Save</button>
+                    <button onclick="fetchSettings()">Undo changes</button>
+                </div>
+                <p id="settingsStatus" style="margin-top: 10px;"></p>
+            </div>
+
+            <div class="card" style="margin-top: 20px;">
+                <h2>⬆️ Updates</h2>
+                <div class="stat-row">
+                    <span class="stat-label">Installed:</span>
+                    <span class="stat-value" id="updateCurrent">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Available:</span>
+                    <span class="stat-value" id="updateAvailable">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Last checked:</span>
+                    <span class="stat-value" id="updateChecked">-</span>
+                </div>
+                <div class="stat-row">
+                    <span class="stat-label">Source:</span>
+                    <span class="stat-value" id="updateSource">-</span>
+                </div>
+                <p id="updateNotes" style="opacity: 0.9; margin-top: 10px; white-space: pre-wrap;"></p>
+                <div class="actions" style="margin-top: 15px;">
+                    <button onclick="checkForUpdate()">Check for updates</button>
+                    <button id="updateInstall" onclick="installUpdate()" style="display: none;">Install and restart</button>
+                </div>
+                <p id="updateStatus" style="margin-top: 10px;"></p>
+            </div>
+        </div>
+    </div>
+
+    <script>
+        const API_BASE = window.location.origin + '/api/v1';
+        let ipAddresses = [];
+        let piAgentIP = '';
+
+        // Fetch IP addresses on load
+        fetchIPAddresses();
+
+        async function fetchIPAddresses() {
+            try {
+                const response = await fetch(API_BASE + '/system/info');
+                const data = await response.json();
+                
+                if (data.success && data.data.ip_addresses) {
+                    ipAddresses = data.data.ip_addresses;
+                    displayIPAddresses();
+                    
+                    // Whether the Pi answers is shown by the piLinkStatus badge
+                    piAgentIP = data.data.registered_with_pi ? data.data.pi_agent_ip : '';
+                }
+            } catch (error) {
+                console.error('Failed to fetch IP addresses:', error);
+                document.getElementById('ipAddresses').innerHTML = '<span style="color: #ffcc00;">Unable to detect IPs</span>';
+                document.getElementById('connectionStatus').textContent = '● DISCONNECTED';
+                document.getElementById('connectionStatus').style.background = '#e74c3c';
+            }
+        }
+
+        function displayIPAddresses() {
+            const container = document.getElementById('ipAddresses');
+            if (ipAddresses.length === 0) {
+                container.textContent = 'No network interfaces found';
+                return;
+            }
+            
+            const ipHTML = ipAddresses.map(function(ip) {
+                return '<div class="ip-address">' + ip + '</div>';
+            }).join('');
+            container.innerHTML = ipHTML;
+        }
+
+        function copyIP() {
+            if (ipAddresses.length === 0) {
+                alert('No IP addresses available to copy');
+                return;
+            }
+            
+            const ip = ipAddresses[0]; // Copy first IP
+            navigator.clipboard.writeText(ip).then(function() {
+                alert('Copied: ' + ip + '\n\nNow open your mobile app and paste this in the "IP Address" field!');
+            }).catch(function(err) {
+                // Fallback for older browsers
+                const textArea = document.createElement('textarea');
+                textArea.value = ip;
+                document.body.appendChild(textArea);
+                textArea.select();
+                document.execCommand('copy');
+                document.body.removeChild(textArea);
+                alert('Copied: ' + ip);
+            });
+        }
+
+        // The theme is a helper setting, so every browser on this PC agrees.
+        // It follows Windows until one is picked here or in Settings.
+        function showThemeToggle() {
+            const light = document.documentElement.dataset.theme === 'light';
+            document.getElementById('themeToggle').textContent = light ? '🌙 Dark theme' : '☀️ Light theme';
+        }
+
+        function applyTheme(setting) {
+            document.documentElement.dataset.themeSetting = setting;
+            let theme = setting;
+            if (theme !== 'light' && theme !== 'dark') {
+                theme = window.matchMedia && window.matchMedia('(prefers-color-scheme: light)').matches ? 'light' : 'dark';
+            }
+            document.documentElement.dataset.theme = theme;
+            showThemeToggle();
+        }
+
+        async function toggleTheme() {
+            const theme = document.documentElement.dataset.theme === 'light' ? 'dark' : 'light';
+            const cfg = await tokenCall('PATCH', '/config', { theme: theme });
+            if (cfg) {
+                applyTheme(cfg.theme);
+            }
+        }
+
+        if (window.matchMedia) {
+            window.matchMedia('(prefers-color-scheme: light)').addEventListener('change', function() {
+                if (document.documentElement.dataset.themeSetting === 'system') {
+                    applyTheme('system');
+                }
+            });
+        }
+        showThemeToggle();
+
+        // The incident report bundle is fetched first so failures can be shown
+        async function exportReport() {
+            const button = document.getElementById('reportButton');
+            button.disabled = true;
+            button.textContent = '📄 Preparing report...';
+            try {
+                const response = await fetch(API_BASE + '/report');
+                if (!response.ok) {
+                    const data = await response.json();
+                    alert('Failed: ' + data.error);
+                    return;
+                }
+                const disposition = response.headers.get('Content-Disposition') || '';
+                const match = disposition.match(/filename="([^"]+)"/);
+                const link = document.createElement('a');
+                link.href = URL.createObjectURL(await response.blob());
+                link.download = match ? match[1] : 'incident-report.zip';
+                document.body.appendChild(link);
+                link.click();
+                link.remove();
+                setTimeout(function() { URL.revokeObjectURL(link.href); }, 10000);
+            } catch (error) {
+                alert('Error: ' + error.message);
+            } finally {
+                button.disabled = false;
+                button.textContent = '📄 Export report';
+            }
+        }
+
+        // Views are picked from the nav bar and kept in the URL fragment
+        const viewLoaders = {
+            commands: fetchCommands,
+            threats: fetchThreats,
+            processes: fetchProcesses,
+            network: fetchConnections,
+            firewall: fetchFirewall,
+            history: fetchScanHistory,
+            pairing: fetchPairing,
+            logs: fetchLogs,
+            settings: fetchSettings
+        };
+
+        function showView(name) {
+            if (!document.getElementById('view-' + name)) {
+                name = 'overview';
+            }
+            document.querySelectorAll('.view').forEach(function(el) {
+                el.classList.toggle('active', el.id === 'view-' + name);
+            });
+            document.querySelectorAll('nav.views button').forEach(function(el) {
+                el.classList.toggle('active', el.dataset.view === name);
+            });
+            history.replaceState(null, '', '#' + name);
+            if (viewLoaders[name]) {
+                viewLoaders[name]();
+            }
+        }
+
+        function activeView() {
+            const el = document.querySelector('.view.active');
+            return el ? el.id.replace('view-', '') : '';
+        }
+
+        // apiCall sends a state-changing request and reports failures to the user
+        async function apiCall(method, path, body, headers) {
+            const options = { method: method, headers: headers || {} };
+            if (body !== undefined) {
+                options.headers['Content-Type'] = 'application/json';
+                options.body = JSON.stringify(body);
+            }
+            try {
+                const response = await fetch(API_BASE + path, options);
+                const data = await response.json();
+                if (!data.success) {
+                    alert('Failed: ' + data.error);
+                    return null;
+                }
+                return data.data;
+            } catch (error) {
+                alert('Error: ' + error.message);
+                return null;
+            }
+        }
+
+        // tokenCall sends an action that needs the auth token even from this
+        // PC, asking for it every time rather than keeping it in the page.
+        // The request is signed for replay protection (X-Signature).
+        async function tokenCall(method, path, body, token) {
+            token = token || askToken();
+            if (!token) return null;
+            const timestamp = String(Math.floor(Date.now() / 1000));
+            const nonce = toHex(crypto.getRandomValues(new Uint8Array(16)));
+            const url = new URL(API_BASE + path, window.location.href);
+            const headers = {
+                'Authorization': 'Bearer ' + token,
+                'X-Timestamp': timestamp,
+                'X-Nonce': nonce
+            };
+            // crypto.subtle only exists on secure origins such as localhost;
+            // elsewhere the helper sees an unsigned request
+            if (window.crypto && crypto.subtle) {
+                const encoder = new TextEncoder();
+                const bodyText = body !== undefined ? JSON.stringify(body) : '';
+                const bodyHash = toHex(await crypto.subtle.digest('SHA-256', encoder.encode(bodyText)));
+                const key = await crypto.subtle.importKey('raw', encoder.encode(token), { name: 'HMAC', hash: 'SHA-256' }, false, ['sign']);
+                const signed = [timestamp, nonce, method, url.pathname + url.search, bodyHash].join('|');
+                headers['X-Signature'] = toHex(await crypto.subtle.sign('HMAC', key, encoder.encode(signed)));
+            }
+            return apiCall(method, path, body, headers);
+        }
+
+        function toHex(buffer) {
+            return Array.from(new Uint8Array(buffer), function(b) {
+                return b.toString(16).padStart(2, '0');
+            }).join('');
+        }
+
+        function askToken() {
+            const token = prompt('This needs the helper\'s auth_token (printed at startup and kept in its config file):');
+            return token ? token.trim() : '';
+        }
+
+        function actionButton(label, onClick, danger) {
+            const button = document.createElement('button');
+            button.textContent = label;
+            button.onclick = onClick;
+            if (danger) {
+                button.className = 'danger';
+            }
+            return button;
+        }
+
+        function formatBytes(bytes) {
+            if (bytes >= 1048576) return (bytes / 1048576).toFixed(1) + ' MB';
+            if (bytes >= 1024) return (bytes / 1024).toFixed(1) + ' KB';
+            return bytes + ' B';
+        }
+
+        async function fetchThreats() {
+            try {
+                const [threats, quarantine] = await Promise.all([
+                    fetch(API_BASE + '/threats').then(function(r) { return r.json(); }),
+                    fetch(API_BASE + '/quarantine').then(function(r) { return r.json(); })
+                ]);
+                if (threats.success) {
+                    showThreats(threats.data);
+                    showFalsePositives(threats.data.false_positives);
+                }
+                if (quarantine.success) {
+                    showQuarantine(quarantine.data);
+                }
+            } catch (error) {
+                console.error('Failed to fetch threats:', error);
+            }
+        }
+
+        function showThreats(result) {
+            const body = document.getElementById('threatEntries');
+            body.innerHTML = '';
+            result.threats.forEach(function(threat) {
+                const row = body.insertRow();
+                row.insertCell().textContent = new Date(threat.detected_at).toLocaleString();
+                row.insertCell().textContent = threat.type;
+                const severity = row.insertCell();
+                severity.textContent = threat.severity;
+                severity.className = 'severity-' + threat.severity;
+                row.insertCell().textContent = threat.path;
+                row.insertCell().textContent = threat.status;
+                const actions = row.insertCell();
+                if (threat.status === 'active') {
+                    actions.appendChild(actionButton('Quarantine', function() { quarantineThreat(threat); }, true));
+                }
+                if (threat.status === 'quarantined') {
+                    actions.appendChild(actionButton('Restore', function() {
+                        restoreQuarantined({ id: threat.quarantine_id, original_path: threat.path });
+                    }));
+                }
+                if (threat.status !== 'quarantined') {
+                    actions.appendChild(actionButton('False positive', function() { markFalsePositive(threat); }));
+                }
+                if (isLocalDashboard) {
+                    actions.appendChild(actionButton('📂 Open folder', function() { revealFile(threat.path); }));
+                }
+            });
+            let summary = result.count + ' threats in the latest scan';
+            if (result.scan_active) {
+                summary += ' (scan in progress)';
+            } else if (result.scan_type) {
+                summary += ' (' + result.scan_type + ' scan started ' + new Date(result.scan_started).toLocaleString() + ')';
+            }
+            document.getElementById('threatSummary').textContent = summary;
+        }
+
+        function showQuarantine(result) {
+            const body = document.getElementById('quarantineEntries');
+            body.innerHTML = '';
+            result.items.forEach(function(item) {
+                const row = body.insertRow();
+                row.insertCell().textContent = new Date(item.quarantined_at).toLocaleString();
+                row.insertCell().textContent = item.original_path;
+                row.insertCell().textContent = item.reason || '';
+                row.insertCell().textContent = formatBytes(item.size);
+                const hash = row.insertCell();
+                hash.textContent = item.sha256.slice(0, 16) + '…';
+                hash.title = item.sha256;
+                const actions = row.insertCell();
+                actions.appendChild(actionButton('Restore', function() { restoreQuarantined(item); }));
+                if (isLocalDashboard) {
+                    actions.appendChild(actionButton('📂 Open folder', function() { revealFile(item.original_path); }));
+                }
+                actions.appendChild(actionButton('Delete', function() { deleteQuarantined(item); }, true));
+            });
+            document.getElementById('quarantineSummary').textContent = result.count + ' files, ' + formatBytes(result.total_size) +
+                (result.retention.max_age_days > 0 ? ', kept for ' + result.retention.max_age_days + ' days' : '');
+        }
+
+        function showFalsePositives(entries) {
+            const body = document.getElementById('falsePositiveEntries');
+            body.innerHTML = '';
+            entries.forEach(function(entry) {
+                const row = body.insertRow();
+                row.insertCell().textContent = new Date(entry.marked_at).toLocaleString();
+                row.insertCell().textContent = entry.type;
+                row.insertCell().textContent = entry.path;
+                const hash = row.insertCell();
+                hash.textContent = entry.sha256.slice(0, 16) + '…';
+                hash.title = entry.sha256;
+                row.insertCell().appendChild(actionButton('Unmark', function() { unmarkFalsePositive(entry); }));
+            });
+        }
+
+        async function quarantineThreat(threat) {
+            if (!confirm('Move ' + threat.path + ' to quarantine?')) return;
+            if (await apiCall('POST', '/files/quarantine', { path: threat.path, reason: threat.type })) {
+                fetchThreats();
+            }
+        }
+
+        async function markFalsePositive(threat) {
+            if (!confirm('Mark ' + threat.path + ' as a false positive? Files with the same hash will no longer be reported.')) return;
+            if (await apiCall('POST', '/threats/false-positives', { path: threat.path })) {
+                fetchThreats();
+                updateScanStatus();
+            }
+        }
+
+        async function unmarkFalsePositive(entry) {
+            if (await apiCall('DELETE', '/threats/false-positives?sha256=' + encodeURIComponent(entry.sha256))) {
+                fetchThreats();
+            }
+        }
+
+        async function restoreQuarantined(item) {
+            if (!confirm('Restore ' + item.original_path + ' from quarantine?')) return;
+            if (await apiCall('POST', '/files/restore', { id: item.id })) {
+                fetchThreats();
+            }
+        }
+
+        // Explorer opens on this PC's screen, so the button only shows here
+        const isLocalDashboard = ['localhost', '127.0.0.1', '[::1]'].includes(location.hostname);
+
+        async function revealFile(path) {
+            await apiCall('POST', '/files/reveal', { path: path });
+        }
+
+        async function deleteQuarantined(item) {
+            if (!confirm('Permanently delete the quarantined copy of ' + item.original_path + '?')) return;
+            if (await apiCall('DELETE', '/quarantine?id=' + encodeURIComponent(item.id))) {
+                fetchThreats();
+            }
+        }
+
+        // Process list, sorted and searched by the helper; CPU and memory sort highest first
+        let processSort = 'cpu';
+        let processOrder = 'desc';
+        let processSearchTimer = null;
+
+        function sortProcesses(column) {
+            if (processSort === column) {
+                processOrder = processOrder === 'desc' ? 'asc' : 'desc';
+            } else {
+                processSort = column;
+                processOrder = (column === 'cpu' || column === 'memory') ? 'desc' : 'asc';
+            }
+            fetchProcesses();
+        }
+
+        function searchProcesses() {
+            clearTimeout(processSearchTimer);
+            processSearchTimer = setTimeout(fetchProcesses, 300);
+        }
+
+        async function fetchProcesses() {
+            const search = document.getElementById('processSearch').value.trim();
+            const params = new URLSearchParams({ sort: processSort, order: processOrder });
+            try {
+                // The search box matches either the process name or its user
+                const requests = [fetch(API_BASE + '/processes?' + params.toString() + (search ? '&name=' + encodeURIComponent(search) : ''))];
+                if (search) {
+                    requests.push(fetch(API_BASE + '/processes?' + params.toString() + '&user=' + encodeURIComponent(search)));
+                }
+                const results = await Promise.all(requests.map(function(p) { return p.then(function(r) { return r.json(); }); }));
+                if (!results.every(function(d) { return d.success; })) {
+                    return;
+                }
+                const seen = {};
+                const processes = [];
+                results.forEach(function(d) {
+                    d.data.processes.forEach(function(p) {
+                        if (!seen[p.pid]) {
+                            seen[p.pid] = true;
+                            processes.push(p);
+                        }
+                    });
+                });
+                showProcesses(processes, search);
+            } catch (error) {
+                console.error('Failed to fetch processes:', error);
+            }
+        }
+
+        function showProcesses(processes, search) {
+            const keys = { pid: 'pid', name: 'name', user: 'user', cpu: 'cpu_percent', memory: 'memory_bytes' };
+            const key = keys[processSort];
+            processes.sort(function(a, b) {
+                const x = typeof a[key] === 'string' ? (a[key] || '').toLowerCase() : a[key];
+                const y = typeof b[key] === 'string' ? (b[key] || '').toLowerCase() : b[key];
+                const c = x < y ? -1 : (x > y ? 1 : a.pid - b.pid);
+                return processOrder === 'desc' ? -c : c;
+            });
+
+            document.querySelectorAll('#view-processes th.sortable').forEach(function(th) {
+                th.textContent = th.textContent.replace(/ [▲▼]$/, '');
+                if (th.dataset.sort === processSort) {
+                    th.textContent += processOrder === 'desc' ? ' ▼' : ' ▲';
+                }
+            });
+
+            const body = document.getElementById('processEntries');
+            body.innerHTML = '';
+            processes.forEach(function(p) {
+                const row = body.insertRow();
+                row.insertCell().textContent = p.pid;
+                const name = row.insertCell();
+                name.textContent = p.name;
+                name.title = p.image_path || '';
+                row.insertCell().textContent = p.user || '';
+                row.insertCell().textContent = p.cpu_percent.toFixed(1) + '%';
+                row.insertCell().textContent = formatBytes(p.memory_bytes);
+                const actions = row.insertCell();
+                actions.appendChild(actionButton('Kill', function() { killProcess(p); }, true));
+                if (p.image_path) {
+                    actions.appendChild(actionButton('Block network', function() { blockProcessNetwork(p); }));
+                }
+            });
+            document.getElementById('processSummary').textContent = processes.length + (search ? ' matching processes' : ' processes');
+        }
+
+        async function killProcess(p) {
+            if (!confirm('Kill ' + p.name + ' (PID ' + p.pid + ')? Unsaved work in it is lost.')) return;
+            if (await tokenCall('POST', '/process/' + p.pid + '/kill')) {
+                fetchProcesses();
+            }
+        }
+
+        async function blockProcessNetwork(p) {
+            if (!confirm('Block all network access for ' + p.image_path + '? This adds firewall rules for the executable.')) return;
+            if (await apiCall('POST', '/network/block-app', { path: p.image_path })) {
+                alert('Network access blocked for ' + p.name);
+            }
+        }
+
+        setInterval(function() {
+            if (activeView() === 'processes' && !document.hidden) {
+                fetchProcesses();
+            }
+        }, 5000);
+
+        // Connections with who the remote end probably is. Hints for new
+        // addresses are looked up in the background and appear on a later refresh.
+        let connectionData = { connections: [], hints: {} };
+
+        async function fetchConnections() {
+            try {
+                const [connections, blocked] = await Promise.all([
+                    fetch(API_BASE + '/network/connections?hints=true').then(function(r) { return r.json(); }),
+                    fetch(API_BASE + '/network/blocked-ips').then(function(r) { return r.json(); })
+                ]);
+                if (connections.success) {
+                    connectionData = connections.data;
+                    showConnections();
+                }
+                if (blocked.success) {
+                    showBlockedIPs(blocked.data.rules);
+                }
+            } catch (error) {
+                console.error('Failed to fetch connections:', error);
+            }
+        }
+
+        function countryFlag(code) {
+            if (!/^[A-Z]{2}$/.test(code || '')) return '';
+            return String.fromCodePoint(code.charCodeAt(0) + 127397, code.charCodeAt(1) + 127397) + ' ';
+        }
+
+        function describeHint(hint) {
+            if (!hint) return '';
+            switch (hint.scope) {
+                case 'public':
+                    break;
+                case 'private':
+                    return 'Local network';
+                case 'cgnat':
+                    return 'Carrier-grade NAT';
+                case 'link_local':
+                    return 'Link-local';
+                default:
+                    return hint.scope;
+            }
+            if (hint.pending) return 'Looking up…';
+            const parts = [];
+            if (hint.as_name || hint.asn) parts.push(hint.as_name || hint.asn);
+            if (hint.hostname) parts.push(hint.hostname);
+            return (countryFlag(hint.country) + parts.join(' · ')) || 'Internet';
+        }
+
+        function showConnections() {
+            const search = document.getElementById('connectionSearch').value.trim().toLowerCase();
+            const scope = document.getElementById('connectionScope').value;
+            const body = document.getElementById('connectionEntries');
+            body.innerHTML = '';
+            let shown = 0;
+            connectionData.connections.forEach(function(c) {
+                const hint = connectionData.hints[c.remote_address] || {};
+                if (scope === 'public' && hint.scope !== 'public') return;
+                if (scope === 'private' && hint.scope !== 'private' && hint.scope !== 'link_local') return;
+                const owner = describeHint(hint);
+                const text = [c.process_name, c.pid, c.remote_address, owner, hint.asn || ''].join(' ').toLowerCase();
+                if (search && !text.includes(search)) return;
+                shown++;
+
+                const row = body.insertRow();
+                row.insertCell().textContent = (c.process_name || '?') + ' (' + c.pid + ')';
+                row.insertCell().textContent = c.local_address + ':' + c.local_port;
+                row.insertCell().textContent = c.remote_address + ':' + c.remote_port;
+                const ownerCell = row.insertCell();
+                ownerCell.textContent = owner;
+                ownerCell.title = [hint.asn, hint.prefix, hint.country].filter(Boolean).join(' ');
+                row.insertCell().textContent = c.state;
+                const actions = row.insertCell();
+                if (hint.scope && hint.scope !== 'loopback' && hint.scope !== 'invalid') {
+                    actions.appendChild(actionButton('Block IP', function() { blockRemoteIP(c.remote_address, owner); }, true));
+                }
+            });
+            document.getElementById('connectionSummary').textContent = shown + ' of ' + connectionData.connections.length + ' connections';
+        }
+
+        function showBlockedIPs(rules) {
+            const byAddress = {};
+            rules.forEach(function(rule) {
+                (byAddress[rule.address] = byAddress[rule.address] || []).push(rule.direction);
+            });
+            const body = document.getElementById('blockedIPEntries');
+            body.innerHTML = '';
+            Object.keys(byAddress).sort().forEach(function(address) {
+                const row = body.insertRow();
+                row.insertCell().textContent = address;
+                row.insertCell().textContent = byAddress[address].join(', ');
+                row.insertCell().appendChild(actionButton('Unblock', function() { unblockRemoteIP(address); }));
+            });
+            if (rules.length === 0) {
+                body.insertRow().insertCell().textContent = 'No addresses blocked';
+            }
+        }
+
+        async function blockRemoteIP(address, owner) {
+            if (!confirm('Block all traffic to and from ' + address + (owner ? ' (' + owner + ')' : '') + '?')) return;
+            if (await apiCall('POST', '/network/block-ip', { address: address })) {
+                fetchConnections();
+            }
+        }
+
+        async function unblockRemoteIP(address) {
+            if (await apiCall('POST', '/network/unblock-ip', { address: address })) {
+                fetchConnections();
+            }
+        }
+
+        setInterval(function() {
+            if (activeView() === 'network' && !document.hidden) {
+                fetchConnections();
+            }
+        }, 5000);
+
+        // Helper firewall rules; the isolation banner is refreshed from the same list
+        async function fetchFirewall() {
+            const kind = document.getElementById('firewallKind').value;
+            try {
+                const response = await fetch(API_BASE + '/network/rules' + (kind ? '?kind=' + encodeURIComponent(kind) : ''));
+                const data = await response.json();
+                if (data.success) {
+                    showIsolation(data.data.isolated, data.data.rules);
+                    showFirewall(data.data.rules);
+                }
+            } catch (error) {
+                console.error('Failed to fetch firewall rules:', error);
+            }
+        }
+
+        function showIsolation(isolated, rules) {
+            document.getElementById('isolationBanner').style.display = isolated ? 'block' : 'none';
+            const rule = rules.find(function(r) { return r.kind === 'all' && r.enabled; });
+            document.getElementById('isolationDetail').textContent = rule && rule.created_at
+                ? 'Isolated since ' + new Date(rule.created_at).toLocaleString() + (rule.origin ? ' by ' + rule.origin : '')
+                : '';
+        }
+
+        function showFirewall(rules) {
+            const kinds = { all: 'Network isolation', app: 'Application', domain: 'Domain', ip: 'IP address', port: 'Port', other: 'Other' };
+            const body = document.getElementById('firewallEntries');
+            body.innerHTML = '';
+            rules.forEach(function(rule) {
+                const row = body.insertRow();
+                row.insertCell().textContent = rule.name;
+                row.insertCell().textContent = kinds[rule.kind] || rule.kind;
+                row.insertCell().textContent = rule.direction;
+                row.insertCell().textContent = rule.action;
+                const enabled = row.insertCell();
+                enabled.textContent = rule.enabled ? 'Yes' : 'No';
+                if (rule.enabled && rule.kind === 'all') {
+                    enabled.className = 'failure';
+                }
+                row.insertCell().textContent = rule.origin || '';
+                row.insertCell().textContent = rule.created_at ? new Date(rule.created_at).toLocaleString() : '';
+                const actions = row.insertCell();
+                if (rule.enabled) {
+                    actions.appendChild(actionButton('Disable', function() { toggleFirewallRule(rule, false); }));
+                } else {
+                    actions.appendChild(actionButton('Enable', function() { toggleFirewallRule(rule, true); }));
+                }
+                actions.appendChild(actionButton('Remove', function() { removeFirewallRule(rule); }, true));
+            });
+            if (rules.length === 0) {
+                body.insertRow().insertCell().textContent = 'No rules';
+            }
+        }
+
+        async function toggleFirewallRule(rule, enable) {
+            if (enable && rule.kind === 'all' && !confirm('Enabling ' + rule.name + ' cuts this PC off the network again. Continue?')) return;
+            if (await tokenCall('POST', '/network/rules/' + (enable ? 'enable' : 'disable'), { name: rule.name })) {
+                fetchFirewall();
+            }
+        }
+
+        async function removeFirewallRule(rule) {
+            if (!confirm('Delete firewall rule ' + rule.name + '?')) return;
+            if (await apiCall('DELETE', '/network/rules?name=' + encodeURIComponent(rule.name))) {
+                fetchFirewall();
+            }
+        }
+
+        async function liftIsolation() {
+            if (!confirm('Restore network access? Only do this once the threat that triggered the isolation has been dealt with.')) return;
+            if (await tokenCall('POST', '/network/unblock')) {
+                fetchFirewall();
+            }
+        }
+
+        setInterval(function() {
+            if (!document.hidden) {
+                fetchFirewall();
+            }
+        }, 15000);
+
+        // Finished scans from the persistent history, charted oldest first
+        async function fetchScanHistory() {
+            const since = document.getElementById('historyRange').value;
+            try {
+                const response = await fetch(API_BASE + '/scan/history?limit=500' + (since ? '&since=' + since : ''));
+                const data = await response.json();
+                if (data.success) {
+                    showScanHistory(data.data);
+                }
+            } catch (error) {
+                console.error('Failed to fetch scan history:', error);
+            }
+        }
+
+        function formatDuration(seconds) {
+            if (seconds >= 3600) return (seconds / 3600).toFixed(1) + ' h';
+            if (seconds >= 60) return (seconds / 60).toFixed(1) + ' min';
+            return seconds.toFixed(1) + ' s';
+        }
+
+        function showScanHistory(result) {
+            const scans = result.scans.slice().reverse();
+            let summary = result.count + ' scans';
+            if (result.total > result.count) {
+                summary = 'Latest ' + result.count + ' of ' + result.total + ' scans';
+            }
+            document.getElementById('historySummary').textContent = summary;
+
+            drawHistoryChart('historyFiles', scans, function(scan) { return scan.scanned_files; }, function(v) { return Math.round(v).toLocaleString(); });
+            drawHistoryChart('historyThreats', scans, function(scan) { return scan.threats_found; }, function(v) { return String(Math.round(v)); });
+            drawHistoryChart('historyDuration', scans, function(scan) { return scan.duration_seconds; }, formatDuration);
+
+            const body = document.getElementById('historyEntries');
+            body.innerHTML = '';
+            result.scans.forEach(function(scan) {
+                const row = body.insertRow();
+                row.insertCell().textContent = new Date(scan.started_at).toLocaleString();
+                row.insertCell().textContent = scan.scan_type;
+                row.insertCell().textContent = scan.scanned_files + ' / ' + scan.total_files;
+                const threats = row.insertCell();
+                threats.textContent = scan.threats_found;
+                if (scan.threats_found > 0) {
+                    threats.className = 'failure';
+                }
+                row.insertCell().textContent = scan.skipped.total;
+                row.insertCell().textContent = formatDuration(scan.duration_seconds);
+                row.insertCell().textContent = scan.stopped ? 'Stopped' : 'Completed';
+            });
+            if (result.scans.length === 0) {
+                body.insertRow().insertCell().textContent = 'No scans yet';
+            }
+        }
+
+        // drawHistoryChart renders one bar per scan into an SVG, scaled to the largest value
+        function drawHistoryChart(id, scans, value, format) {
+            const svg = document.getElementById(id);
+            const ns = 'http://www.w3.org/2000/svg';
+            const width = svg.clientWidth || 300;
+            const height = svg.clientHeight || 160;
+            const top = 16, bottom = 16;
+            svg.innerHTML = '';
+            svg.setAttribute('viewBox', '0 0 ' + width + ' ' + height);
+
+            function label(text, x, y, anchor) {
+                const el = document.createElementNS(ns, 'text');
+                el.setAttribute('x', x);
+                el.setAttribute('y', y);
+                el.setAttribute('text-anchor', anchor);
+                el.textContent = text;
+                svg.appendChild(el);
+            }
+
+            if (scans.length === 0) {
+                label('No data', width / 2, height / 2, 'middle');
+                return;
+            }
+
+            const values = scans.map(value);
+            const max = Math.max.apply(null, values) || 1;
+            const slot = width / scans.length;
+            const barWidth = Math.max(1, slot * 0.8);
+            scans.forEach(function(scan, i) {
+                const barHeight = (values[i] / max) * (height - top - bottom);
+                const bar = document.createElementNS(ns, 'rect');
+                bar.setAttribute('x', i * slot + (slot - barWidth) / 2);
+                bar.setAttribute('y', height - bottom - barHeight);
+                bar.setAttribute('width', barWidth);
+                bar.setAttribute('height', Math.max(barHeight, values[i] > 0 ? 1 : 0));
+                if (scan.stopped) {
+                    bar.setAttribute('class', 'stopped');
+                }
+                const title = document.createElementNS(ns, 'title');
+                title.textContent = new Date(scan.started_at).toLocaleString() + ': ' + format(values[i]);
+                bar.appendChild(title);
+                svg.appendChild(bar);
+            });
+
+            label('max ' + format(max), 0, 11, 'start');
+            label(new Date(scans[0].started_at).toLocaleDateString(), 0, height - 3, 'start');
+            if (scans.length > 1) {
+                label(new Date(scans[scans.length - 1].started_at).toLocaleDateString(), width, height - 3, 'end');
+            }
+        }
+
+        // Settings are written through the config API, which validates them.
+        // Only changed keys are sent, so ones fixed by overrides don't block a save.
+        let loadedSettings = {};
+
+        function settingLines(id) {
+            return document.getElementById(id).value.split('\n')
+                .map(function(line) { return line.trim(); })
+                .filter(function(line) { return line !== ''; });
+        }
+
+        async function fetchSettings() {
+            try {
+                const response = await fetch(API_BASE + '/config');
+                const data = await response.json();
+                if (data.success) {
+                    showSettings(data.data);
+                }
+            } catch (error) {
+                console.error('Failed to fetch settings:', error);
+            }
+            fetchUpdate();
+        }
+
+        async function fetchUpdate() {
+            try {
+                const response = await fetch(API_BASE + '/update');
+                const data = await response.json();
+                if (data.success) {
+                    showUpdate(data.data);
+                }
+            } catch (error) {
+                console.error('Failed to fetch update status:', error);
+            }
+        }
+
+        function showUpdate(update) {
+            document.getElementById('updateCurrent').textContent = update.current;
+            document.getElementById('updateAvailable').textContent = update.available ?
+                update.available.version : (update.last_checked ? 'Up to date' : 'Not checked yet');
+            document.getElementById('updateChecked').textContent = update.last_checked ?
+                new Date(update.last_checked).toLocaleString() : 'Never';
+            document.getElementById('updateSource').textContent = update.source;
+            document.getElementById('updateNotes').textContent = update.available ? (update.available.notes || '') : '';
+            document.getElementById('updateInstall').style.display =
+                update.available && update.can_install && update.state !== 'installed' ? '' : 'none';
+
+            const status = document.getElementById('updateStatus');
+            status.className = '';
+            if (update.state === 'failed') {
+                status.className = 'failure';
+                status.textContent = update.error;
+            } else if (update.available && !update.can_install) {
+                status.textContent = 'Set update_public_key in the config file to install updates.';
+            } else if (update.state === 'installed') {
+                status.textContent = 'Installed; restarting the helper...';
+            } else {
+                status.textContent = '';
+            }
+        }
+
+        async function checkForUpdate() {
+            document.getElementById('updateStatus').textContent = 'Checking...';
+            const update = await apiCall('POST', '/update/check');
+            if (update) {
+                showUpdate(update);
+            } else {
+                fetchUpdate();
+            }
+        }
+
+        async function installUpdate() {
+            const version = document.getElementById('updateAvailable').textContent;
+            if (!confirm('Install version ' + version + ' and restart the helper? The dashboard reconnects when it is back.')) return;
+            document.getElementById('updateStatus').textContent = 'Downloading and verifying ' + version + '...';
+            const update = await apiCall('POST', '/update/install');
+            if (!update) {
+                fetchUpdate();
+                return;
+            }
+            showUpdate(update);
+            // The page is reloaded from the new build once it answers
+            const wait = setInterval(async function() {
+                try {
+                    const response = await fetch(API_BASE + '/health');
+                    const health = await response.json();
+                    if (health.data && health.data.version === version) {
+                        clearInterval(wait);
+                        location.reload();
+                    }
+                } catch (error) {
+                    // Still restarting
+                }
+            }, 3000);
+        }
+
+        function showSettings(cfg) {
+            document.getElementById('settingScanPaths').value = (cfg.scan_paths || []).join('\n');
+            document.getElementById('settingExclusions').value = (cfg.scan_exclusions || []).join('\n');
+            const interval = document.getElementById('settingInterval');
+            if (!Array.from(interval.options).some(function(o) { return o.value === String(cfg.scan_interval); })) {
+                const option = document.createElement('option');
+                option.value = cfg.scan_interval;
+                option.textContent = 'Every ' + cfg.scan_interval + ' hours';
+                interval.appendChild(option);
+            }
+            interval.value = String(cfg.scan_interval);
+            document.getElementById('settingSkipWarn').value = cfg.scan_skip_warn;
+            document.getElementById('settingNotifyThreats').checked = cfg.notify_threats;
+            document.getElementById('settingNotifyScans').checked = cfg.notify_scans;
+            document.getElementById('settingNotifyCommands').checked = cfg.notify_commands;
+            document.getElementById('settingStartAtLogin').checked = cfg.start_at_login;
+            document.getElementById('settingStartMinimized').checked = cfg.start_minimized;
+            document.getElementById('settingTheme').value = cfg.theme || 'system';
+            applyTheme(cfg.theme || 'system');
+            document.querySelectorAll('.setting-pi-alert').forEach(function(el) {
+                el.checked = (cfg.pi_alerts || []).includes(el.value);
+            });
+            document.getElementById('settingLogLevel').value = cfg.log_level || 'info';
+            document.getElementById('settingsStatus').textContent = '';
+            loadedSettings = cfg;
+        }
+
+        async function saveSettings() {
+            const piAlerts = [];
+            document.querySelectorAll('.setting-pi-alert').forEach(function(el) {
+                if (el.checked) piAlerts.push(el.value);
+            });
+            const edited = {
+                scan_paths: settingLines('settingScanPaths'),
+                scan_exclusions: settingLines('settingExclusions'),
+                scan_interval: parseInt(document.getElementById('settingInterval').value, 10),
+                scan_skip_warn: parseInt(document.getElementById('settingSkipWarn').value, 10),
+                notify_threats: document.getElementById('settingNotifyThreats').checked,
+                notify_scans: document.getElementById('settingNotifyScans').checked,
+                notify_commands: document.getElementById('settingNotifyCommands').checked,
+                pi_alerts: piAlerts,
+                start_at_login: document.getElementById('settingStartAtLogin').checked,
+                start_minimized: document.getElementById('settingStartMinimized').checked,
+                theme: document.getElementById('settingTheme').value,
+                log_level: document.getElementById('settingLogLevel').value
+            };
+            const patch = {};
+            Object.keys(edited).forEach(function(key) {
+                if (JSON.stringify(edited[key]) !== JSON.stringify(loadedSettings[key])) {
+                    patch[key] = edited[key];
+                }
+            });
+            if (Object.keys(patch).length === 0) {
+                document.getElementById('settingsStatus').textContent = 'Nothing changed';
+                return;
+            }
+            const cfg = await tokenCall('PATCH', '/config', patch);
+            if (cfg) {
+                showSettings(cfg);
+                document.getElementById('settingsStatus').textContent = 'Saved ' + new Date().toLocaleTimeString();
+            }
+        }
+
+        // The log view only creates rows for the lines in sight, so a day of
+        // records scrolls as smoothly as a minute of them
+        const LOG_ROW_HEIGHT = 22;
+        const LOG_KEEP = 5000;
+        let logRecords = [];
+        let logOffset = 0;
+        let logMore = false;
+        let logSearchTimer = null;
+
+        function logQuery(after) {
+            const params = new URLSearchParams({ level: document.getElementById('logLevel').value, limit: LOG_KEEP });
+            const component = document.getElementById('logComponent').value;
+            const search = document.getElementById('logSearch').value.trim();
+            if (component) params.set('component', component);
+            if (search) params.set('q', search);
+            if (after) params.set('after', after);
+            return API_BASE + '/logs?' + params.toString();
+        }
+
+        async function fetchLogs() {
+            try {
+                const response = await fetch(logQuery(0));
+                const data = await response.json();
+                if (data.success) {
+                    logRecords = data.data.records;
+                    logOffset = data.data.offset;
+                    logMore = data.data.more;
+                    showLogs(true);
+                }
+            } catch (error) {
+                console.error('Failed to fetch logs:', error);
+            }
+        }
+
+        // tailLogs appends what was written since the last fetch
+        async function tailLogs() {
+            try {
+                const response = await fetch(logQuery(logOffset));
+                const data = await response.json();
+                if (!data.success) return;
+                if (data.data.reset) {
+                    logRecords = [];
+                }
+                logOffset = data.data.offset;
+                if (data.data.records.length === 0 && !data.data.reset) return;
+                const view = document.getElementById('logView');
+                const atBottom = view.scrollTop + view.clientHeight >= view.scrollHeight - LOG_ROW_HEIGHT;
+                logRecords = logRecords.concat(data.data.records);
+                if (logRecords.length > LOG_KEEP) {
+                    logRecords = logRecords.slice(logRecords.length - LOG_KEEP);
+                    logMore = true;
+                }
+                showLogs(atBottom);
+            } catch (error) {
+                console.error('Failed to follow logs:', error);
+            }
+        }
+
+        function searchLogs() {
+            clearTimeout(logSearchTimer);
+            logSearchTimer = setTimeout(fetchLogs, 300);
+        }
+
+        function logLine(record) {
+            const parts = [new Date(record.time).toLocaleString(), (record.level || '').padEnd(5)];
+            if (record.component) parts.push('[' + record.component + ']');
+            parts.push(record.msg);
+            Object.keys(record.attrs || {}).forEach(function(key) {
+                const value = record.attrs[key];
+                parts.push(key + '=' + (typeof value === 'object' ? JSON.stringify(value) : value));
+            });
+            return parts.join(' ');
+        }
+
+        function showLogs(scrollToEnd) {
+            const picker = document.getElementById('logComponent');
+            const components = new Set(Array.from(picker.options).map(function(o) { return o.value; }));
+            logRecords.forEach(function(record) {
+                if (record.component && !components.has(record.component)) {
+                    components.add(record.component);
+                    const option = document.createElement('option');
+                    option.value = record.component;
+                    option.textContent = record.component;
+                    picker.appendChild(option);
+                }
+            });
+
+            const view = document.getElementById('logView');
+            document.getElementById('logSpacer').style.height = (logRecords.length * LOG_ROW_HEIGHT) + 'px';
+            if (scrollToEnd) {
+                view.scrollTop = view.scrollHeight;
+            }
+            renderLogs();
+            document.getElementById('logSummary').textContent = logRecords.length === 0 ?
+                'No matching log lines' :
+                logRecords.length + ' lines' + (logMore ? ' (older lines not shown)' : '');
+        }
+
+        function renderLogs() {
+            const view = document.getElementById('logView');
+            const spacer = document.getElementById('logSpacer');
+            view.querySelectorAll('.log-row').forEach(function(row) { row.remove(); });
+            const first = Math.floor(view.scrollTop / LOG_ROW_HEIGHT);
+            const last = Math.min(logRecords.length, first + Math.ceil(view.clientHeight / LOG_ROW_HEIGHT) + 1);
+            for (let i = first; i < last; i++) {
+                const record = logRecords[i];
+                const row = document.createElement('div');
+                row.className = 'log-row level-' + (record.level || '').toLowerCase();
+                row.style.top = (i * LOG_ROW_HEIGHT) + 'px';
+                row.textContent = logLine(record);
+                row.title = row.textContent;
+                view.insertBefore(row, spacer);
+            }
+        }
+
+        function copyLogs() {
+            const text = logRecords.map(logLine).join('\n');
+            const done = function() {
+                document.getElementById('logSummary').textContent = 'Copied ' + logRecords.length + ' lines';
+            };
+            // The clipboard API is only there on localhost or HTTPS
+            (navigator.clipboard ? navigator.clipboard.writeText(text) : Promise.reject()).then(done).catch(function() {
+                const textArea = document.createElement('textarea');
+                textArea.value = text;
+                document.body.appendChild(textArea);
+                textArea.select();
+                document.execCommand('copy');
+                document.body.removeChild(textArea);
+                done();
+            });
+        }
+
+        setInterval(function() {
+            if (activeView() === 'logs' && !document.hidden && document.getElementById('logFollow').checked) {
+                tailLogs();
+            }
+        }, 2000);
+
+        // Pairing mirrors --pair: the Pi's fingerprint is confirmed before the code is sent
+        let pendingPair = null;
+        let currentPairing = null;
+
+        async function fetchPairing() {
+            try {
+                const response = await fetch(API_BASE + '/pairing');
+                const data = await response.json();
+                if (data.success) {
+                    showPairing(data.data);
+                }
+            } catch (error) {
+                console.error('Failed to fetch pairing:', error);
+            }
+        }
+
+        function showPairing(pairing) {
+            currentPairing = pairing;
+            const states = {
+                unknown: 'Paired, checking the Pi Agent...',
+                connected: '● Connected',
+                reconnecting: '● Not answering, retrying',
+                unreachable: '● Unreachable',
+                unpaired: 'Not paired'
+            };
+            const failing = pairing.paired && (pairing.link.state === 'reconnecting' || pairing.link.state === 'unreachable');
+            const stateEl = document.getElementById('pairingState');
+            stateEl.textContent = pairing.paired ?
+                (states[pairing.link.state] || pairing.link.state || 'Paired') : 'Not paired';
+            stateEl.style.color = failing ? 'var(--danger-text)' : '';
+            document.getElementById('pairingAddress').textContent = pairing.paired ?
+                pairing.pi_agent_ip + (pairing.pi_agent_port ? ':' + pairing.pi_agent_port : '') : '-';
+            document.getElementById('pairingFingerprint').textContent = pairing.fingerprint || '-';
+            document.getElementById('pairingDevice').textContent = pairing.device_id || '-';
+            document.getElementById('pairingClientCert').textContent = pairing.paired ?
+                (pairing.client_certificate ? 'Issued by the Pi Agent' : 'None') : '-';
+            const lastSeen = linkTime(pairing.link.last_seen);
+            const lastAttempt = linkTime(pairing.link.last_attempt);
+            document.getElementById('pairingLastSeen').textContent = lastSeen ?
+                lastSeen.toLocaleString() + ' (' + timeAgo(lastSeen) + ')' : (pairing.paired ? 'None since the helper started' : '-');
+            document.getElementById('pairingLastAttempt').textContent = pairing.paired && lastAttempt ? lastAttempt.toLocaleString() : '-';
+            document.getElementById('pairingLastError').textContent = pairing.link.last_error || '-';
+            document.getElementById('pairingErrorRow').style.display = failing && pairing.link.last_error ? 'flex' : 'none';
+
+            const advice = document.getElementById('pairingAdvice');
+            advice.textContent = 'The Pi Agent has not answered ' + (lastSeen ? 'since ' + lastSeen.toLocaleString() : 'since the helper started') +
+                '. If it was reset, replaced or moved to another network, re-pair it.';
+            advice.style.display = failing ? 'block' : 'none';
+
+            document.getElementById('pairingUnpair').style.display = pairing.paired ? 'grid' : 'none';
+            document.getElementById('pairingUnpairButton').disabled = pairing.token_overridden;
+            document.getElementById('pairingTokenNote').style.display = pairing.paired && pairing.token_overridden ? 'block' : 'none';
+            document.getElementById('pairingForm').style.display = pairing.paired ? 'none' : 'block';
+            if (!pairing.paired) {
+                closeRepair();
+            }
+        }
+
+        // Times the Pi link hasn't reached yet come back as Go's zero time
+        function linkTime(value) {
+            return value && !value.startsWith('0001') ? new Date(value) : null;
+        }
+
+        function timeAgo(date) {
+            const seconds = Math.max(0, (Date.now() - date.getTime()) / 1000);
+            if (seconds < 60) return 'just now';
+            if (seconds < 3600) return Math.floor(seconds / 60) + ' min ago';
+            if (seconds < 86400) return Math.floor(seconds / 3600) + ' h ago';
+            return Math.floor(seconds / 86400) + ' d ago';
+        }
+
+        // Contacts the Pi Agent now rather than at the next heartbeat
+        async function checkPiNow() {
+            const link = await apiCall('POST', '/pi/check');
+            if (link) {
+                showPiLink(link);
+                fetchPairing();
+            }
+            return link;
+        }
+
+        async function discoverPi(inputId, listId, statusId) {
+            const status = document.getElementById(statusId);
+            status.textContent = 'Looking for Pi Agents on the network...';
+            const found = await apiCall('GET', '/discovery/pi');
+            if (!found) {
+                status.textContent = '';
+                return;
+            }
+            const list = document.getElementById(listId);
+            list.innerHTML = '';
+            (found.services || []).forEach(function(service) {
+                const option = document.createElement('option');
+                option.value = service.ip + ':' + service.port;
+                list.appendChild(option);
+            });
+            if (found.count > 0 && !document.getElementById(inputId).value) {
+                document.getElementById(inputId).value = list.options[0].value;
+            }
+            status.textContent = found.count === 0 ? 'No Pi Agents found; enter the address by hand' :
+                'Found ' + found.count + ' Pi Agent' + (found.count === 1 ? '' : 's');
+        }
+
+        async function checkPairing() {
+            const address = document.getElementById('pairAddress').value.trim();
+            const code = document.getElementById('pairCode').value.trim();
+            if (!address || !code) {
+                document.getElementById('pairStatus').textContent = 'Enter the Pi Agent address and the pairing code';
+                return;
+            }
+            const token = askToken();
+            if (!token) return;
+            document.getElementById('pairStatus').textContent = 'Fetching the Pi Agent certificate...';
+            const result = await tokenCall('POST', '/pair', { pi_address: address }, token);
+            document.getElementById('pairStatus').textContent = '';
+            if (!result) return;
+            pendingPair = { address: result.pi_address, code: code, fingerprint: result.fingerprint, token: token };
+            document.getElementById('pairConfirmAddress').textContent = result.pi_address;
+            document.getElementById('pairConfirmFingerprint').textContent = result.fingerprint;
+            document.getElementById('pairConfirm').style.display = 'block';
+        }
+
+        async function confirmPairing() {
+            if (!pendingPair) return;
+            document.getElementById('pairStatus').textContent = 'Pairing...';
+            const result = await tokenCall('POST', '/pair', {
+                pi_address: pendingPair.address,
+                pairing_code: pendingPair.code,
+                fingerprint: pendingPair.fingerprint
+            }, pendingPair.token);
+            cancelPairing();
+            if (result) {
+                document.getElementById('pairCode').value = '';
+                document.getElementById('pairStatus').textContent = 'Paired with the Pi Agent at ' + result.pi_agent_ip;
+                fetchPairing();
+                fetchIPAddresses();
+            }
+        }
+
+        function cancelPairing() {
+            pendingPair = null;
+            document.getElementById('pairConfirm').style.display = 'none';
+            document.getElementById('pairStatus').textContent = '';
+        }
+
+        async function unpairPi() {
+            if (!confirm('Unpair from the Pi Agent? It will no longer be able to control this PC, and you will need a new pairing code to pair again.')) return;
+            if (await apiCall('DELETE', '/pairing')) {
+                fetchPairing();
+                fetchIPAddresses();
+            }
+        }
+
+        // The re-pair wizard rules out a Pi that is just off, then one that
+        // moved, before asking for a new pairing code
+        let pendingRepair = null;
+
+        function startRepair() {
+            document.getElementById('repairAddress').value = currentPairing && currentPairing.pi_agent_ip ?
+                currentPairing.pi_agent_ip + (currentPairing.pi_agent_port ? ':' + currentPairing.pi_agent_port : '') : '';
+            document.getElementById('repairCheckResult').textContent = '';
+            document.getElementById('repairWizard').style.display = 'block';
+            repairStep(1);
+            document.getElementById('repairWizard').scrollIntoView({ behavior: 'smooth' });
+        }
+
+        function repairStep(step) {
+            if (step === 3 && !document.getElementById('repairAddress').value.trim()) {
+                document.getElementById('repairStatus').textContent = 'Enter the Pi Agent address';
+                return;
+            }
+            document.querySelectorAll('#repairWizard .wizard-step').forEach(function(el) {
+                el.style.display = Number(el.dataset.step) === step ? 'block' : 'none';
+            });
+            document.querySelectorAll('#repairWizard .wizard-steps span').forEach(function(el) {
+                el.classList.toggle('current', Number(el.dataset.step) === step);
+            });
+            document.getElementById('repairConfirm').style.display = 'none';
+            document.getElementById('repairStatus').textContent = '';
+            document.getElementById('repairClose').textContent = step === 4 ? 'Close' : 'Cancel';
+        }
+
+        async function repairCheck() {
+            const result = document.getElementById('repairCheckResult');
+            result.textContent = 'Contacting the Pi Agent...';
+            const link = await checkPiNow();
+            if (!link) {
+                result.textContent = '';
+            } else if (link.state === 'connected') {
+                result.textContent = '✅ The Pi Agent answered. Only re-pair if the mobile app no longer lists this PC.';
+            } else {
+                result.textContent = 'Still no answer (' + (link.last_error || 'no response') + '). If the Pi Agent is on, continue.';
+            }
+        }
+
+        async function checkRepair() {
+            const address = document.getElementById('repairAddress').value.trim();
+            const code = document.getElementById('repairCode').value.trim();
+            if (!code) {
+                document.getElementById('repairStatus').textContent = 'Enter the pairing code from the mobile app';
+                return;
+            }
+            const token = askToken();
+            if (!token) return;
+            document.getElementById('repairStatus').textContent = 'Fetching the Pi Agent certificate...';
+            const result = await tokenCall('POST', '/pair', { pi_address: address }, token);
+            document.getElementById('repairStatus').textContent = '';
+            if (!result) return;
+            pendingRepair = { address: result.pi_address, code: code, fingerprint: result.fingerprint, token: token };
+            const pinned = currentPairing ? currentPairing.fingerprint : '';
+            const changed = pinned && normalizeFingerprint(pinned) !== normalizeFingerprint(result.fingerprint);
+            document.getElementById('repairConfirmAddress').textContent = result.pi_address;
+            document.getElementById('repairConfirmFingerprint').textContent = result.fingerprint;
+            document.getElementById('repairCertChanged').style.display = changed ? 'block' : 'none';
+            document.getElementById('repairConfirm').style.display = 'block';
+        }
+
+        function normalizeFingerprint(fingerprint) {
+            return fingerprint.toLowerCase().replace(/[^0-9a-f]/g, '');
+        }
+
+        async function confirmRepair() {
+            if (!pendingRepair) return;
+            document.getElementById('repairStatus').textContent = 'Pairing...';
+            const result = await tokenCall('POST', '/pair', {
+                pi_address: pendingRepair.address,
+                pairing_code: pendingRepair.code,
+                fingerprint: pendingRepair.fingerprint
+            }, pendingRepair.token);
+            pendingRepair = null;
+            if (!result) {
+                document.getElementById('repairConfirm').style.display = 'none';
+                document.getElementById('repairStatus').textContent = '';
+                return;
+            }
+            document.getElementById('repairCode').value = '';
+            repairStep(4);
+            document.getElementById('repairDone').textContent = '✅ Re-paired with the Pi Agent at ' + result.pi_agent_ip +
+                '. The helper is contacting it now; the status above turns green once it answers.';
+            fetchPairing();
+            fetchIPAddresses();
+            setTimeout(fetchPiLink, 2000);
+        }
+
+        function closeRepair() {
+            pendingRepair = null;
+            document.getElementById('repairCode').value = '';
+            document.getElementById('repairWizard').style.display = 'none';
+        }
+
+        // Sessions from other machines can sign out; on this PC there is no session
+        if (!isLocalDashboard) {
+            document.getElementById('logoutForm').style.display = 'block';
+        }
+
+        // Update system stats every 2 seconds
+        setInterval(updateStats, 2000);
+        updateStats(); // Initial call
+
+        // Scan lifecycle is pushed over server-sent events
+        updateScanStatus();
+        connectScanEvents();
+        connectPiEvents();
+        fetchPiLink();
+        fetchAudit();
+        showView(location.hash.slice(1));
+        if (activeView() !== 'firewall') {
+            fetchFirewall();
+        }
+
+        // Pairing changes (unpaired by the Pi, Pi moved, lost and regained)
+        function connectPiEvents() {
+            const source = new EventSource(API_BASE + '/pi/events');
+
+            source.addEventListener('pi.unpaired', function(e) {
+                const ev = JSON.parse(e.data);
+                if (activeView() === 'pairing') {
+                    fetchPairing();
+                }
+                showPiLink({ state: 'unpaired' });
+                if (ev.data.by === 'pc') {
+                    appendScanLog('Unpaired from the Pi Agent at ' + ev.data.pi_agent_ip + ' on this PC; auth token rotated');
+                    return;
+                }
+                const statusEl = document.getElementById('piLinkStatus');
+                statusEl.textContent = '● UNPAIRED BY PI AGENT';
+                statusEl.style.background = '#e74c3c';
+                statusEl.title = 'The Pi Agent at ' + ev.data.pi_agent_ip + ' revoked this pairing';
+                appendScanLog('Pi Agent at ' + ev.data.pi_agent_ip + ' revoked the pairing; auth token rotated', 'threat');
+            });
+
+            source.addEventListener('pi.address_changed', function(e) {
+                const ev = JSON.parse(e.data);
+                appendScanLog('Pi Agent moved from ' + ev.data.old_ip + ' to ' + ev.data.new_ip);
+                fetchIPAddresses();
+            });
+
+            source.addEventListener('pi.reconnecting', function(e) {
+                const ev = JSON.parse(e.data);
+                showPiLink(ev.data);
+                if (activeView() === 'pairing') {
+                    fetchPairing();
+                }
+                appendScanLog('Lost contact with the Pi Agent at ' + ev.data.pi_agent_ip + ': ' + ev.data.last_error, 'threat');
+            });
+
+            source.addEventListener('pi.unreachable', function(e) {
+                const ev = JSON.parse(e.data);
+                showPiLink(ev.data);
+                appendScanLog('Pi Agent at ' + ev.data.pi_agent_ip + ' unreachable after ' + ev.data.consecutive_failures + ' attempts; still retrying', 'threat');
+            });
+
+            source.addEventListener('pi.cert_mismatch', function(e) {
+                const ev = JSON.parse(e.data);
+                piCertMismatch = ev.data;
+                showPiLink(piLink || { state: 'reconnecting' });
+                appendScanLog('Refused ' + ev.data.pi_agent_ip + ': certificate ' + ev.data.fingerprint + ' does not match the pinned Pi Agent certificate', 'threat');
+            });
+
+            source.addEventListener('pi.cert_pinned', function(e) {
+                const ev = JSON.parse(e.data);
+                appendScanLog('Pinned Pi Agent certificate ' + ev.data.fingerprint);
+            });
+
+            source.addEventListener('pi.reconnected', function(e) {
+                const ev = JSON.parse(e.data);
+                showPiLink(ev.data);
+                if (activeView() === 'pairing') {
+                    fetchPairing();
+                }
+                appendScanLog('Reconnected to Pi Agent at ' + ev.data.pi_agent_ip);
+            });
+        }
+
+        async function fetchPiLink() {
+            try {
+                const response = await fetch(API_BASE + '/pi/status');
+                const data = await response.json();
+                if (data.success) {
+                    showPiLink(data.data);
+                }
+            } catch (error) {
+                console.error('Failed to fetch Pi Agent link status:', error);
+            }
+        }
+
+        // Pairing health badge: green while heartbeats reach the Pi Agent,
+        // red as soon as one fails, with the time of the last contact
+        let piLink = null;
+        let piCertMismatch = null;
+
+        function showPiLink(link) {
+            piLink = link;
+            const el = document.getElementById('piLinkStatus');
+            const lastSeen = linkTime(link.last_seen);
+            const contact = lastSeen ? 'last contact ' + timeAgo(lastSeen) : 'no contact since the helper started';
+            const retry = linkTime(link.next_attempt);
+            el.style.display = 'inline-block';
+            if (link.state === 'connected') {
+                piCertMismatch = null;
+            }
+            switch (link.state) {
+            case 'connected':
+                el.textContent = '● PI AGENT CONNECTED';
+                el.style.background = '#2ecc71';
+                el.title = 'Pi Agent at ' + link.pi_agent_ip + ', ' + contact;
+                break;
+            case 'reconnecting':
+            case 'unreachable':
+                el.textContent = (piCertMismatch ? '● PI AGENT CERTIFICATE MISMATCH' :
+                    link.state === 'unreachable' ? '● PI AGENT UNREACHABLE' : '● PI AGENT NOT ANSWERING') + ' · ' + contact;
+                el.style.background = '#e74c3c';
+                el.title = piCertMismatch ? 'Presented ' + piCertMismatch.fingerprint + ', pinned ' + piCertMismatch.pinned :
+                    (link.last_error || '') + (retry ? ' (next retry ' + retry.toLocaleTimeString() + ')' : '');
+                break;
+            case 'unpaired':
+                el.textContent = '● NOT PAIRED';
+                el.style.background = '#f39c12';
+                el.title = 'This PC has not been paired with a Pi Agent yet';
+                break;
+            default:
+                el.textContent = '● CHECKING PI AGENT...';
+                el.style.background = '#95a5a6';
+                el.title = '';
+            }
+        }
+
+        // Keeps "last contact" current between heartbeats
+        setInterval(function() {
+            if (!document.hidden) {
+                fetchPiLink();
+            }
+        }, 15000);
+
+        async function fetchAudit() {
+            const params = new URLSearchParams({ limit: 100 });
+            const action = document.getElementById('auditAction').value;
+            const outcome = document.getElementById('auditOutcome').value;
+            const since = document.getElementById('auditSince').value;
+            if (action) params.set('action', action);
+            if (outcome) params.set('outcome', outcome);
+            if (since) params.set('since', since);
+
+            try {
+                const response = await fetch(API_BASE + '/audit?' + params.toString());
+                const data = await response.json();
+                if (data.success) {
+                    showAuditActions(data.data.actions, action);
+                    showAudit(data.data);
+                }
+            } catch (error) {
+                console.error('Failed to fetch audit log:', error);
+            }
+        }
+
+        function showAuditActions(actions, selected) {
+            const select = document.getElementById('auditAction');
+            select.length = 1;
+            actions.forEach(function(action) {
+                select.add(new Option(action, action, false, action === selected));
+            });
+        }
+
+        // Cells are filled with textContent; targets and errors come from remote callers
+        function showAudit(result) {
+            const body = document.getElementById('auditEntries');
+            body.innerHTML = '';
+            result.entries.forEach(function(entry) {
+                const row = body.insertRow();
+                row.insertCell().textContent = new Date(entry.time).toLocaleString();
+                row.insertCell().textContent = entry.action;
+                row.insertCell().textContent = entry.target || '';
+                const outcome = row.insertCell();
+                outcome.textContent = entry.outcome + (entry.error ? ': ' + entry.error : '');
+                outcome.className = entry.outcome;
+                row.insertCell().textContent = (entry.remote || '').replace(/:\d+$/, '');
+                row.insertCell().textContent = entry.token || '';
+            });
+            document.getElementById('auditSummary').textContent = result.count < result.total
+                ? 'Showing the newest ' + result.count + ' of ' + result.total + ' entries'
+                : result.total + ' entries';
+        }
+
+        // Command history reads the same audit log as a timeline, in plain words
+        const commandTitles = {
+            'system.lock': '🔒 Locked this PC',
+            'system.logoff': '🚪 Signed the user out',
+            'system.shutdown': '⏻ Scheduled a shutdown',
+            'system.shutdown_cancel': '⏻ Cancelled a scheduled shutdown',
+            'system.restart': '🔄 Restarted this PC',
+            'system.sleep': '💤 Put this PC to sleep',
+            'system.hibernate': '💤 Hibernated this PC',
+            'system.notify': '💬 Showed a message',
+            'system.remote_access_enable': '🖥️ Turned on remote access',
+            'system.remote_access_disable': '🖥️ Turned off remote access',
+            'system.restore_point': '💾 Created a restore point',
+            'network.isolate': '🔒 Cut this PC off the network',
+            'network.restore': '🌐 Restored network access',
+            'network.block_app': '🚫 Blocked an app from the network',
+            'network.block_domain': '🚫 Blocked a website',
+            'network.unblock_domain': '✅ Unblocked a website',
+            'network.block_ip': '🚫 Blocked an address',
+            'network.unblock_ip': '✅ Unblocked an address',
+            'network.block_port': '🚫 Blocked a port',
+            'network.unblock_port': '✅ Unblocked a port',
+            'network.kill_connection': '✂️ Closed a connection',
+            'network.adapter_disable': '🔌 Turned off a network adapter',
+            'network.adapter_enable': '🔌 Turned on a network adapter',
+            'network.rule_enable': '🧱 Enabled a firewall rule',
+            'network.rule_disable': '🧱 Disabled a firewall rule',
+            'network.rule_remove': '🧱 Removed a firewall rule',
+            'process.kill': '⛔ Stopped a program',
+            'files.quarantine': '📦 Quarantined a file',
+            'files.restore': '📦 Restored a file from quarantine',
+            'files.lock': '🔒 Locked a file',
+            'files.unlock': '🔓 Unlocked a file',
+            'quarantine.delete': '🗑️ Deleted a quarantined file',
+            'scan.start': '🔍 Started a scan',
+            'scan.stop': '🔍 Stopped a scan',
+            'helper.exit': '👋 Closed the helper',
+            'helper.update': '⬆️ Updated the helper',
+            'defender.scan': '🛡️ Started a Microsoft Defender scan',
+            'defender.enable_realtime': '🛡️ Turned on Defender real-time protection',
+            'persistence.remove': '🧹 Removed a startup entry',
+            'config.update': '🛠️ Changed settings',
+            'report.export': '📄 Exported an incident report',
+            'config.rollback': '🛠️ Restored earlier settings',
+            'pair': '🔗 Paired with a Pi Agent',
+            'unpair': '🔗 Unpaired from the Pi Agent'
+        };
+
+        async function fetchCommands() {
+            const params = new URLSearchParams({ limit: 500 });
+            params.set('since', document.getElementById('commandSince').value);
+            try {
+                const response = await fetch(API_BASE + '/audit?' + params.toString());
+                const data = await response.json();
+                if (data.success) {
+                    showCommands(data.data.entries.filter(function(entry) {
+                        return entry.action !== 'dashboard.login';
+                    }));
+                }
+            } catch (error) {
+                console.error('Failed to fetch command history:', error);
+            }
+        }
+
+        function remoteHost(remote) {
+            return (remote || '').replace(/:\d+$/, '').replace(/^\[(.*)\]$/, '$1');
+        }
+
+        // commandSource names who sent a command: the paired Pi, this PC or another caller
+        function commandSource(entry) {
+            if (entry.details && entry.details.via === 'tray') {
+                return { kind: 'local', label: 'This PC (tray icon)' };
+            }
+            if (entry.details && entry.details.via === 'auto') {
+                return { kind: 'local', label: 'This PC (automatic update)' };
+            }
+            const host = remoteHost(entry.remote);
+            if (piAgentIP && host === piAgentIP) {
+                return { kind: 'pi', label: 'Pi Agent (' + host + ')' };
+            }
+            if (host === '127.0.0.1' || host === '::1') {
+                return { kind: 'local', label: entry.token ? 'This PC, token ' + entry.token : 'This PC (dashboard)' };
+            }
+            return { kind: 'other', label: host + (entry.token ? ', token ' + entry.token : ', dashboard') };
+        }
+
+        function showCommands(entries) {
+            const filter = document.getElementById('commandSource').value;
+            const timeline = document.getElementById('commandTimeline');
+            timeline.innerHTML = '';
+            let shown = 0;
+            let day = '';
+            entries.forEach(function(entry) {
+                const source = commandSource(entry);
+                if (filter && source.kind !== filter) return;
+                shown++;
+
+                const time = new Date(entry.time);
+                if (time.toDateString() !== day) {
+                    day = time.toDateString();
+                    const heading = document.createElement('div');
+                    heading.className = 'timeline-day';
+                    heading.textContent = time.toLocaleDateString(undefined, { weekday: 'long', year: 'numeric', month: 'long', day: 'numeric' });
+                    timeline.appendChild(heading);
+                }
+
+                const item = document.createElement('div');
+                item.className = 'timeline-item' + (entry.outcome === 'failure' ? ' failure' : '');
+                const when = document.createElement('div');
+                when.className = 'timeline-time';
+                when.textContent = time.toLocaleTimeString();
+                const body = document.createElement('div');
+                const title = document.createElement('div');
+                title.className = 'timeline-title';
+                title.textContent = (commandTitles[entry.action] || entry.action) +
+                    (entry.outcome === 'failure' ? ' (failed)' : '');
+                body.appendChild(title);
+                const meta = [];
+                if (entry.target) meta.push(entry.target);
+                meta.push('by ' + source.label);
+                if (entry.error) meta.push(entry.error);
+                const details = document.createElement('div');
+                details.className = 'timeline-meta';
+                details.textContent = meta.join(' · ');
+                body.appendChild(details);
+                item.appendChild(when);
+                item.appendChild(body);
+                timeline.appendChild(item);
+            });
+            if (shown === 0) {
+                timeline.textContent = 'No commands in this period';
+            }
+            document.getElementById('commandSummary').textContent = shown + ' commands';
+        }
+
+        setInterval(function() {
+            if (activeView() === 'commands' && !document.hidden) {
+                fetchCommands();
+            }
+        }, 15000);
+
+        function connectScanEvents() {
+            const source = new EventSource(API_BASE + '/scan/events');
+
+            source.addEventListener('open', updateScanStatus);
+
+            source.addEventListener('scan.started', function(e) {
+                const ev = JSON.parse(e.data);
+                document.getElementById('scanStatus').textContent = 'Scanning...';
+                document.getElementById('filesScanned').textContent = 0;
+                document.getElementById('threatsFound').textContent = 0;
+                showScanProgress(0, 0, '-');
+                appendScanLog('Scan started (' + ev.data.scan_type + ')');
+            });
+
+            source.addEventListener('scan.progress', function(e) {
+                const ev = JSON.parse(e.data);
+                document.getElementById('filesScanned').textContent = ev.data.scanned_files;
+                showScanProgress(ev.data.scanned_files, ev.data.total_files, ev.data.current_folder);
+            });
+
+            source.addEventListener('scan.threat', function(e) {
+                const ev = JSON.parse(e.data);
+                const found = document.getElementById('threatsFound');
+                found.textContent = parseInt(found.textContent, 10) + 1;
+                appendScanLog('Threat: ' + ev.data.type + ' - ' + ev.data.path, 'threat');
+                if (activeView() === 'threats') {
+                    fetchThreats();
+                }
+            });
+
+            source.addEventListener('scan.completed', function(e) {
+                const ev = JSON.parse(e.data);
+                const summary = ev.data;
+                document.getElementById('scanStatus').textContent = 'Idle';
+                document.getElementById('filesScanned').textContent = summary.scanned_files;
+                document.getElementById('threatsFound').textContent = summary.threats_found;
+                showScanProgress(summary.scanned_files, summary.total_files, '-');
+                appendScanLog((summary.stopped ? 'Scan stopped: ' : 'Scan completed: ') +
+                    summary.scanned_files + ' files, ' + summary.threats_found + ' threats, ' +
+                    summary.skipped.total + ' skipped in ' + summary.duration_seconds.toFixed(1) + 's');
+                if (activeView() === 'history') {
+                    fetchScanHistory();
+                }
+            });
+
+            source.addEventListener('scan.warning', function(e) {
+                const ev = JSON.parse(e.data);
+                const reasons = Object.entries(ev.data.skipped.by_reason)
+                    .map(function(r) { return r[0] + ': ' + r[1]; }).join(', ');
+                appendScanLog('Warning: ' + (ev.data.skip_ratio * 100).toFixed(1) +
+                    '% of files could not be read (' + reasons + ')', 'threat');
+            });
+        }
+
+        // Progress is files scanned out of those counted before the scan started
+        function showScanProgress(scanned, total, folder) {
+            updateProgress('scanProgress', total > 0 ? Math.min(100, scanned * 100 / total) : 0);
+            document.getElementById('scanFolder').textContent = folder || '-';
+        }
+
+        function appendScanLog(message, cssClass) {
+            const log = document.getElementById('scanLog');
+            const item = document.createElement('li');
+            item.textContent = new Date().toLocaleTimeString() + '  ' + message;
+            if (cssClass) {
+                item.className = cssClass;
+            }
+            log.insertBefore(item, log.firstChild);
+            while (log.children.length > 50) {
+                log.removeChild(log.lastChild);
+            }
+        }
+
+        async function updateStats() {
+            try {
+                const response = await fetch(API_BASE + '/telemetry');
+                const data = await response.json();
+                
+                if (data.success) {
+                    const stats = data.data;
+                    
+                    // System info
                     document.getElementById('hostname').textContent = stats.system.hostname;
                     document.getElementById('os').textContent = stats.system.os + ' ' + stats.system.platform;
                     document.getElementById('uptime').textContent = formatUptime(stats.system.uptime_seconds);
@@ -411,6 +2920,86 @@ const HTML = `
             }
         }
 
+        // The usage graphs cover the last few minutes, sampled by the helper
+        // every two seconds even while the dashboard is closed
+        const USAGE_KEEP = 180;
+        let usageSamples = [];
+
+        async function updateUsageGraphs() {
+            const last = usageSamples.length ? usageSamples[usageSamples.length - 1].time : '';
+            try {
+                const response = await fetch(API_BASE + '/telemetry/history' + (last ? '?since=' + encodeURIComponent(last) : ''));
+                const data = await response.json();
+                if (!data.success) return;
+                usageSamples = usageSamples.concat(data.data).slice(-USAGE_KEEP);
+            } catch (error) {
+                console.error('Failed to fetch usage history:', error);
+                return;
+            }
+
+            drawSparkline('cpuGraph', [usageSamples.map(function(s) { return s.cpu_percent; })], 100, function(v) { return v.toFixed(0) + '%'; });
+            drawSparkline('memGraph', [usageSamples.map(function(s) { return s.memory_percent; })], 100, function(v) { return v.toFixed(0) + '%'; });
+            drawSparkline('netGraph', [
+                usageSamples.map(function(s) { return s.recv_bytes_per_sec; }),
+                usageSamples.map(function(s) { return s.sent_bytes_per_sec; })
+            ], 0, formatRate);
+            if (usageSamples.length) {
+                const latest = usageSamples[usageSamples.length - 1];
+                document.getElementById('netSent').textContent = formatRate(latest.sent_bytes_per_sec);
+                document.getElementById('netRecv').textContent = formatRate(latest.recv_bytes_per_sec);
+            }
+        }
+
+        function formatRate(bytes) {
+            const units = ['B/s', 'KB/s', 'MB/s', 'GB/s'];
+            let i = 0;
+            while (bytes >= 1024 && i < units.length - 1) {
+                bytes /= 1024;
+                i++;
+            }
+            return bytes.toFixed(i === 0 ? 0 : 1) + ' ' + units[i];
+        }
+
+        // drawSparkline draws each series as a line, the first in the accent
+        // colour; max 0 scales to the largest value
+        function drawSparkline(id, series, max, format) {
+            const svg = document.getElementById(id);
+            const ns = 'http://www.w3.org/2000/svg';
+            const width = svg.clientWidth || 300;
+            const height = svg.clientHeight || 48;
+            svg.innerHTML = '';
+            svg.setAttribute('viewBox', '0 0 ' + width + ' ' + height);
+
+            const top = Math.max(max, Math.max.apply(null, [].concat.apply([], series))) || 1;
+            series.forEach(function(values, n) {
+                if (values.length < 2) return;
+                const step = width / (USAGE_KEEP - 1);
+                const offset = width - (values.length - 1) * step;
+                const points = values.map(function(v, i) {
+                    return (offset + i * step).toFixed(1) + ',' + (height - 2 - (v / top) * (height - 14)).toFixed(1);
+                });
+                const line = document.createElementNS(ns, 'polyline');
+                line.setAttribute('points', points.join(' '));
+                if (n > 0) {
+                    line.setAttribute('class', 'secondary');
+                }
+                svg.appendChild(line);
+            });
+
+            const label = document.createElementNS(ns, 'text');
+            label.setAttribute('x', 4);
+            label.setAttribute('y', 10);
+            label.textContent = 'max ' + format(top);
+            svg.appendChild(label);
+        }
+
+        setInterval(function() {
+            if (activeView() === 'overview' && !document.hidden) {
+                updateUsageGraphs();
+            }
+        }, 2000);
+        updateUsageGraphs();
+
         async function updateScanStatus() {
             try {
                 const response = await fetch(API_BASE + '/scan/status');
@@ -421,6 +3010,7 @@ const HTML = `
                     document.getElementById('scanStatus').textContent = status.active ? 'Scanning...' : 'Idle';
                     document.getElementById('filesScanned').textContent = status.scanned_files;
                     document.getElementById('threatsFound').textContent = status.threats_found;
+                    showScanProgress(status.scanned_files, status.total_files, status.active ? status.current_folder : '-');
                 }
             } catch (error) {
                 // Silently fail if scan status not available
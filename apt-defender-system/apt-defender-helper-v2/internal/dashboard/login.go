@@ -0,0 +1,121 @@
+package dashboard
+
+// LoginHTML is shown to browsers on other machines when dashboard_pin is
+// set. {{ERROR}} is replaced with an escaped message, or nothing, and
+// {{THEME}} with the theme setting.
+const LoginHTML = `
+<!DOCTYPE html>
+<html lang="en" data-theme-setting="{{THEME}}">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>APT Defender Helper - Sign in</title>
+    <script>
+        // Same theme as the dashboard
+        (function() {
+            let theme = document.documentElement.dataset.themeSetting;
+            if (theme !== 'light' && theme !== 'dark') {
+                theme = window.matchMedia && window.matchMedia('(prefers-color-scheme: light)').matches ? 'light' : 'dark';
+            }
+            document.documentElement.dataset.theme = theme;
+        })();
+    </script>
+    <style>
+        * {
+            margin: 0;
+            padding: 0;
+            box-sizing: border-box;
+        }
+
+        body {
+            font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif;
+            background: linear-gradient(135deg, #1e3c72 0%, #2a5298 100%);
+            color: #fff;
+            min-height: 100vh;
+            display: flex;
+            align-items: center;
+            justify-content: center;
+            padding: 20px;
+        }
+
+        .card {
+            background: rgba(255,255,255,0.1);
+            border-radius: 15px;
+            padding: 30px;
+            width: 100%;
+            max-width: 380px;
+            box-shadow: 0 8px 32px rgba(0,0,0,0.3);
+        }
+
+        h1 {
+            font-size: 1.6em;
+            margin-bottom: 10px;
+        }
+
+        p {
+            opacity: 0.85;
+            margin-bottom: 20px;
+        }
+
+        input {
+            width: 100%;
+            padding: 12px;
+            border: none;
+            border-radius: 8px;
+            font-size: 1.2em;
+            letter-spacing: 4px;
+            margin-bottom: 15px;
+        }
+
+        button {
+            width: 100%;
+            background: #27ae60;
+            color: white;
+            border: none;
+            padding: 12px;
+            border-radius: 8px;
+            font-size: 1em;
+            cursor: pointer;
+        }
+
+        .error {
+            background: rgba(231,76,60,0.8);
+            border-radius: 8px;
+            padding: 10px;
+            margin-bottom: 15px;
+        }
+
+        .error:empty {
+            display: none;
+        }
+
+        :root[data-theme="light"] body {
+            background: linear-gradient(135deg, #eef2f7 0%, #dde6f3 100%);
+            color: #1d2733;
+        }
+
+        :root[data-theme="light"] .card {
+            background: #fff;
+            box-shadow: 0 8px 32px rgba(0,0,0,0.12);
+        }
+
+        :root[data-theme="light"] input {
+            border: 1px solid rgba(0,0,0,0.15);
+        }
+
+        :root[data-theme="light"] .error {
+            color: white;
+        }
+    </style>
+</head>
+<body>
+    <form class="card" method="POST" action="/dashboard/login">
+        <h1>🛡️ APT Defender Helper</h1>
+        <p>Enter the dashboard PIN to continue.</p>
+        <div class="error">{{ERROR}}</div>
+        <input type="password" name="pin" inputmode="numeric" autocomplete="current-password" autofocus required>
+        <button type="submit">Sign in</button>
+    </form>
+</body>
+</html>
+`
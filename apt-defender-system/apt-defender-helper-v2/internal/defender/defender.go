@@ -0,0 +1,172 @@
+package defender
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Status is Microsoft Defender's protection state, from Get-MpComputerStatus
+// and the exclusions configured in Get-MpPreference
+type Status struct {
+	ServiceEnabled       bool       `json:"service_enabled"`
+	AntivirusEnabled     bool       `json:"antivirus_enabled"`
+	RealTimeProtection   bool       `json:"real_time_protection"`
+	BehaviorMonitor      bool       `json:"behavior_monitor"`
+	OnAccessProtection   bool       `json:"on_access_protection"`
+	TamperProtected      bool       `json:"tamper_protected"`
+	RunningMode          string     `json:"running_mode"`
+	SignatureVersion     string     `json:"signature_version"`
+	SignatureUpdated     *time.Time `json:"signature_updated,omitempty"`
+	SignatureAgeDays     int        `json:"signature_age_days"`
+	QuickScanAgeDays     int        `json:"quick_scan_age_days"`
+	LastQuickScan        *time.Time `json:"last_quick_scan,omitempty"`
+	ExclusionPaths       []string   `json:"exclusion_paths"`
+	ExclusionExtensions  []string   `json:"exclusion_extensions"`
+	ExclusionProcesses   []string   `json:"exclusion_processes"`
+	ExclusionIPAddresses []string   `json:"exclusion_ip_addresses"`
+}
+
+type mpStatus struct {
+	AMServiceEnabled              bool
+	AntivirusEnabled              bool
+	RealTimeProtectionEnabled     bool
+	BehaviorMonitorEnabled        bool
+	OnAccessProtectionEnabled     bool
+	IsTamperProtected             bool
+	AMRunningMode                 string
+	AntivirusSignatureVersion     string
+	AntivirusSignatureLastUpdated string
+	AntivirusSignatureAge         int
+	QuickScanAge                  int
+	QuickScanEndTime              string
+	ExclusionPath                 []string
+	ExclusionExtension            []string
+	ExclusionProcess              []string
+	ExclusionIpAddress            []string
+}
+
+// Dates are converted in PowerShell; Windows PowerShell 5.1 would otherwise
+// serialize them as \/Date(...)\/
+const statusQuery = `$s = Get-MpComputerStatus; $p = Get-MpPreference
+[pscustomobject]@{
+  AMServiceEnabled = $s.AMServiceEnabled; AntivirusEnabled = $s.AntivirusEnabled
+  RealTimeProtectionEnabled = $s.RealTimeProtectionEnabled; BehaviorMonitorEnabled = $s.BehaviorMonitorEnabled
+  OnAccessProtectionEnabled = $s.OnAccessProtectionEnabled; IsTamperProtected = $s.IsTamperProtected
+  AMRunningMode = [string]$s.AMRunningMode; AntivirusSignatureVersion = $s.AntivirusSignatureVersion
+  AntivirusSignatureLastUpdated = $(if ($s.AntivirusSignatureLastUpdated) { $s.AntivirusSignatureLastUpdated.ToUniversalTime().ToString('o') } else { '' })
+  AntivirusSignatureAge = $s.AntivirusSignatureAge; QuickScanAge = $s.QuickScanAge
+  QuickScanEndTime = $(if ($s.QuickScanEndTime) { $s.QuickScanEndTime.ToUniversalTime().ToString('o') } else { '' })
+  ExclusionPath = @($p.ExclusionPath); ExclusionExtension = @($p.ExclusionExtension)
+  ExclusionProcess = @($p.ExclusionProcess); ExclusionIpAddress = @($p.ExclusionIpAddress)
+} | ConvertTo-Json -Compress`
+
+// GetStatus reports real-time protection, signature age and exclusions
+func GetStatus() (*Status, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", statusQuery).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Defender (is it installed and running?): %w", err)
+	}
+
+	var s mpStatus
+	if err := json.Unmarshal(out, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse Defender status: %w", err)
+	}
+
+	return &Status{
+		ServiceEnabled:       s.AMServiceEnabled,
+		AntivirusEnabled:     s.AntivirusEnabled,
+		RealTimeProtection:   s.RealTimeProtectionEnabled,
+		BehaviorMonitor:      s.BehaviorMonitorEnabled,
+		OnAccessProtection:   s.OnAccessProtectionEnabled,
+		TamperProtected:      s.IsTamperProtected,
+		RunningMode:          s.AMRunningMode,
+		SignatureVersion:     s.AntivirusSignatureVersion,
+		SignatureUpdated:     parseTime(s.AntivirusSignatureLastUpdated),
+		SignatureAgeDays:     s.AntivirusSignatureAge,
+		QuickScanAgeDays:     s.QuickScanAge,
+		LastQuickScan:        parseTime(s.QuickScanEndTime),
+		ExclusionPaths:       nonEmpty(s.ExclusionPath),
+		ExclusionExtensions:  nonEmpty(s.ExclusionExtension),
+		ExclusionProcesses:   nonEmpty(s.ExclusionProcess),
+		ExclusionIPAddresses: nonEmpty(s.ExclusionIpAddress),
+	}, nil
+}
+
+// StartScan launches a Defender quick or full scan in the background; its
+// progress shows up in QuickScanAge/LastQuickScan and the Defender event log
+func StartScan(scanType string) error {
+	arg := ""
+	switch scanType {
+	case "", "quick":
+		arg = "1"
+	case "full":
+		arg = "2"
+	default:
+		return fmt.Errorf("unknown scan type %q (quick or full)", scanType)
+	}
+
+	cmd := exec.Command(mpCmdRun(), "-Scan", "-ScanType", arg)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start Defender scan: %w", err)
+	}
+	log.Printf("🛡️ Defender %s scan started (pid %d)", scanType, cmd.Process.Pid)
+
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			// MpCmdRun exits with 2 when the scan found threats
+			log.Printf("⚠️ Defender scan finished: %v", err)
+			return
+		}
+		log.Println("✅ Defender scan finished clean")
+	}()
+	return nil
+}
+
+// EnableRealTimeProtection turns real-time monitoring back on, e.g. after
+// malware disabled it. Fails if a policy or Tamper Protection prevents it.
+func EnableRealTimeProtection() error {
+	log.Println("🛡️ Re-enabling Defender real-time protection")
+
+	query := "Set-MpPreference -DisableRealtimeMonitoring $false -DisableBehaviorMonitoring $false -DisableIOAVProtection $false"
+	if output, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to enable real-time protection: %v, output: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	status, err := GetStatus()
+	if err != nil {
+		return err
+	}
+	if !status.RealTimeProtection {
+		return fmt.Errorf("real-time protection is still off; check for a policy (HKLM\\SOFTWARE\\Policies\\Microsoft\\Windows Defender) overriding it")
+	}
+	return nil
+}
+
+func mpCmdRun() string {
+	return filepath.Join(os.Getenv("ProgramFiles"), "Windows Defender", "MpCmdRun.exe")
+}
+
+func parseTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// nonEmpty drops the "N/A" placeholders and nulls PowerShell emits
+func nonEmpty(list []string) []string {
+	result := []string{}
+	for _, v := range list {
+		if v != "" && !strings.HasPrefix(v, "N/A") {
+			result = append(result, v)
+		}
+	}
+	return result
+}
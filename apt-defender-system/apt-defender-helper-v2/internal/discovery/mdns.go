@@ -0,0 +1,257 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ServiceType is what the Pi Agent advertises over mDNS
+const ServiceType = "_aptdefender._tcp.local."
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+const (
+	typeA   = 1
+	typePTR = 12
+	typeTXT = 16
+	typeSRV = 33
+	classIN = 1
+)
+
+// Service is a Pi Agent found on the LAN
+type Service struct {
+	Instance string            `json:"instance"`
+	Host     string            `json:"host"`
+	IP       string            `json:"ip"`
+	Port     int               `json:"port"`
+	TXT      map[string]string `json:"txt,omitempty"`
+}
+
+// Browse queries the LAN for Pi Agents and collects answers until timeout.
+// The query is sent from an ephemeral port, so responders answer it
+// directly (RFC 6762 legacy unicast) and port 5353 doesn't need to be free.
+func Browse(timeout time.Duration) ([]Service, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(query(ServiceType, typePTR), mdnsGroup); err != nil {
+		return nil, fmt.Errorf("failed to send mDNS query: %w", err)
+	}
+
+	found := map[string]*Service{}
+	hosts := map[string]string{}
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 9000)
+	for {
+		conn.SetReadDeadline(deadline)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		records, err := parse(buf[:n])
+		if err != nil {
+			continue
+		}
+		collect(records, from.IP, found, hosts)
+	}
+
+	services := []Service{}
+	for _, svc := range found {
+		if svc.IP == "" {
+			svc.IP = hosts[svc.Host]
+		}
+		if svc.IP != "" && svc.Port != 0 {
+			services = append(services, *svc)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Instance < services[j].Instance })
+	return services, nil
+}
+
+// collect folds one response into the services found so far. A records may
+// arrive in a different packet than the SRV that names the host.
+func collect(records []record, sender net.IP, found map[string]*Service, hosts map[string]string) {
+	service := func(name string) *Service {
+		if found[name] == nil {
+			found[name] = &Service{Instance: strings.TrimSuffix(name, "."+ServiceType)}
+		}
+		return found[name]
+	}
+
+	for _, rr := range records {
+		switch rr.Type {
+		case typePTR:
+			if strings.EqualFold(rr.Name, ServiceType) {
+				service(rr.Target)
+			}
+		case typeSRV:
+			if strings.HasSuffix(strings.ToLower(rr.Name), ServiceType) {
+				svc := service(rr.Name)
+				svc.Host, svc.Port = rr.Target, rr.Port
+			}
+		case typeTXT:
+			if strings.HasSuffix(strings.ToLower(rr.Name), ServiceType) {
+				service(rr.Name).TXT = rr.TXT
+			}
+		case typeA:
+			hosts[rr.Name] = rr.IP.String()
+		}
+	}
+
+	// Responders that leave out the A record are reachable at their source address
+	for _, svc := range found {
+		if svc.Host != "" && hosts[svc.Host] == "" && sender != nil {
+			hosts[svc.Host] = sender.String()
+		}
+	}
+}
+
+type record struct {
+	Name   string
+	Type   uint16
+	Target string // PTR and SRV
+	Port   int    // SRV
+	IP     net.IP // A
+	TXT    map[string]string
+}
+
+// query builds a single-question DNS query
+func query(name string, qtype uint16) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+	msg = appendName(msg, name)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	return binary.BigEndian.AppendUint16(msg, classIN)
+}
+
+func appendName(msg []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0)
+}
+
+// parse decodes the answer, authority and additional records of a response
+func parse(msg []byte) ([]record, error) {
+	if len(msg) < 12 {
+		return nil, fmt.Errorf("short message")
+	}
+	if msg[2]&0x80 == 0 {
+		return nil, fmt.Errorf("not a response")
+	}
+	qd := int(binary.BigEndian.Uint16(msg[4:]))
+	rrCount := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := 12
+	for i := 0; i < qd; i++ {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		off = next + 4
+	}
+
+	records := []record{}
+	for i := 0; i < rrCount; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		if next+10 > len(msg) {
+			return nil, fmt.Errorf("truncated record")
+		}
+		rr := record{Name: name, Type: binary.BigEndian.Uint16(msg[next:])}
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		data := next + 10
+		if data+length > len(msg) {
+			return nil, fmt.Errorf("truncated record data")
+		}
+
+		switch rr.Type {
+		case typePTR:
+			rr.Target, _, err = readName(msg, data)
+		case typeSRV:
+			if length < 7 {
+				return nil, fmt.Errorf("short SRV record")
+			}
+			rr.Port = int(binary.BigEndian.Uint16(msg[data+4:]))
+			rr.Target, _, err = readName(msg, data+6)
+		case typeA:
+			if length == 4 {
+				rr.IP = net.IP(append([]byte{}, msg[data:data+4]...))
+			}
+		case typeTXT:
+			rr.TXT = parseTXT(msg[data : data+length])
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rr)
+		off = data + length
+	}
+	return records, nil
+}
+
+// readName decodes a possibly compressed domain name starting at off and
+// returns it with the offset just past it
+func readName(msg []byte, off int) (string, int, error) {
+	labels := []string{}
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, fmt.Errorf("name out of range")
+		}
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case length&0xC0 == 0xC0:
+			if off+1 >= len(msg) || jumps > 10 {
+				return "", 0, fmt.Errorf("bad name pointer")
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+			jumps++
+		default:
+			if off+1+length > len(msg) {
+				return "", 0, fmt.Errorf("label out of range")
+			}
+			labels = append(labels, string(msg[off+1:off+1+length]))
+			off += 1 + length
+		}
+	}
+}
+
+func parseTXT(data []byte) map[string]string {
+	txt := map[string]string{}
+	for len(data) > 0 {
+		n := int(data[0])
+		if 1+n > len(data) {
+			break
+		}
+		key, value, _ := strings.Cut(string(data[1:1+n]), "=")
+		if key != "" {
+			txt[key] = value
+		}
+		data = data[1+n:]
+	}
+	return txt
+}
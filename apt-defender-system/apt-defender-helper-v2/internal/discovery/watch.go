@@ -0,0 +1,84 @@
+package discovery
+
+import (
+	"log"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/piclient"
+)
+
+const browseTimeout = 3 * time.Second
+
+// Moved is called when the paired Pi Agent shows up at a new address
+type Moved func(ip string, port int)
+
+// Watcher follows the paired Pi Agent across DHCP address changes. A Pi
+// found over mDNS is only accepted if it presents the pinned certificate,
+// so a spoofed announcement can't redirect the helper.
+type Watcher struct {
+	config     *config.Config
+	moved      Moved
+	stopSignal chan struct{}
+}
+
+func NewWatcher(cfg *config.Config, moved Moved) *Watcher {
+	return &Watcher{config: cfg, moved: moved}
+}
+
+// Start checks the Pi's address every interval in the background
+func (w *Watcher) Start(interval time.Duration) {
+	w.stopSignal = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.Check()
+			case <-w.stopSignal:
+				return
+			}
+		}
+	}()
+}
+
+func (w *Watcher) Stop() {
+	if w.stopSignal != nil {
+		close(w.stopSignal)
+	}
+}
+
+// Check looks for the paired Pi Agent once, calling moved if it has a new address
+func (w *Watcher) Check() {
+	pin := piclient.NormalizeFingerprint(w.config.PiCertFingerprint)
+	if !w.config.RegisteredWithPi || pin == "" {
+		return
+	}
+
+	port := w.config.PiAgentPort
+	if port == 0 {
+		port = 8443
+	}
+	if fp, err := piclient.Fingerprint(w.config.PiAgentIP, port); err == nil && piclient.NormalizeFingerprint(fp) == pin {
+		return
+	}
+
+	services, err := Browse(browseTimeout)
+	if err != nil {
+		log.Printf("⚠️ Pi Agent rediscovery failed: %v", err)
+		return
+	}
+	for _, svc := range services {
+		if svc.IP == w.config.PiAgentIP && svc.Port == port {
+			continue
+		}
+		fp, err := piclient.Fingerprint(svc.IP, svc.Port)
+		if err != nil || piclient.NormalizeFingerprint(fp) != pin {
+			continue
+		}
+		log.Printf("🔎 Paired Pi Agent moved from %s:%d to %s:%d", w.config.PiAgentIP, port, svc.IP, svc.Port)
+		w.moved(svc.IP, svc.Port)
+		return
+	}
+}
@@ -0,0 +1,115 @@
+package dns
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	minDGALabelLength = 10
+	entropyThreshold  = 3.5
+	digitRatioLimit   = 0.3
+	consonantRunLimit = 5
+	ruleVersion       = "1"
+)
+
+// evaluate returns the reasons a domain looks malicious, if any
+func (m *Monitor) evaluate(domain string) []string {
+	var reasons []string
+
+	if domain == "" {
+		return reasons
+	}
+
+	if m.isBlocklisted(domain) {
+		reasons = append(reasons, "blocklist")
+	}
+
+	reasons = append(reasons, dgaReasons(domain)...)
+	return reasons
+}
+
+// ruleID names the rule behind a flagged query; blocklist hits win over heuristics
+func ruleID(reasons []string) string {
+	if len(reasons) == 0 {
+		return ""
+	}
+	rule, _, _ := strings.Cut(reasons[0], ":")
+	return "dns." + rule
+}
+
+// isBlocklisted matches the domain itself or any parent domain
+func (m *Monitor) isBlocklisted(domain string) bool {
+	for _, blocked := range m.blocklist {
+		if domain == blocked || strings.HasSuffix(domain, "."+blocked) {
+			return true
+		}
+	}
+	return false
+}
+
+// dgaReasons applies simple lexical heuristics to the registrable label
+func dgaReasons(domain string) []string {
+	var reasons []string
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return reasons
+	}
+	label := labels[len(labels)-2]
+
+	if len(label) < minDGALabelLength {
+		return reasons
+	}
+
+	if shannonEntropy(label) >= entropyThreshold {
+		reasons = append(reasons, "dga:high-entropy")
+	}
+
+	digits := 0
+	for _, c := range label {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	if float64(digits)/float64(len(label)) > digitRatioLimit {
+		reasons = append(reasons, "dga:digit-heavy")
+	}
+
+	if longestConsonantRun(label) >= consonantRunLimit {
+		reasons = append(reasons, "dga:consonant-run")
+	}
+
+	return reasons
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+
+	entropy := 0.0
+	length := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
+
+func longestConsonantRun(s string) int {
+	longest, current := 0, 0
+	for _, c := range s {
+		if c >= 'a' && c <= 'z' && !strings.ContainsRune("aeiouy", c) {
+			current++
+			if current > longest {
+				longest = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
@@ -0,0 +1,285 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/events"
+	"github.com/apt-defender/helper-v2/internal/process"
+)
+
+const (
+	eventChannel = "Microsoft-Windows-DNS-Client/Operational"
+	maxQueries   = 5000
+	batchSize    = 500
+)
+
+type Query struct {
+	Timestamp   time.Time      `json:"timestamp"`
+	Domain      string         `json:"domain"`
+	QueryType   string         `json:"query_type"`
+	PID         uint32         `json:"pid"`
+	ProcessName string         `json:"process_name"`
+	Flagged     bool           `json:"flagged"`
+	Reasons     []string       `json:"reasons,omitempty"`
+	Source      *events.Source `json:"source,omitempty"`
+}
+
+type Monitor struct {
+	mutex        sync.RWMutex
+	queries      []Query
+	blocklist    []string
+	lastRecordID uint64
+	stopSignal   chan struct{}
+}
+
+// dnsEvent mirrors the subset of the DNS-Client ETW event we care about
+type dnsEvent struct {
+	System struct {
+		EventID       int    `xml:"EventID"`
+		EventRecordID uint64 `xml:"EventRecordID"`
+		TimeCreated   struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+		Execution struct {
+			ProcessID uint32 `xml:"ProcessID,attr"`
+		} `xml:"Execution"`
+	} `xml:"System"`
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"EventData"`
+}
+
+func New(blocklist []string) *Monitor {
+	normalized := make([]string, 0, len(blocklist))
+	for _, domain := range blocklist {
+		if d := normalizeDomain(domain); d != "" {
+			normalized = append(normalized, d)
+		}
+	}
+
+	return &Monitor{
+		blocklist: normalized,
+		queries:   []Query{},
+	}
+}
+
+// Start enables the DNS-Client ETW channel and polls it for new lookups
+func (m *Monitor) Start(interval time.Duration) {
+	m.mutex.Lock()
+	if m.stopSignal != nil {
+		m.mutex.Unlock()
+		return
+	}
+	m.stopSignal = make(chan struct{})
+	stop := m.stopSignal
+	m.mutex.Unlock()
+
+	cmd := exec.Command("wevtutil", "sl", eventChannel, "/e:true")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		log.Printf("⚠️ Could not enable DNS client logging: %v, output: %s", err, output)
+	}
+
+	log.Printf("🌐 DNS monitor started (%d blocklisted domains)", len(m.blocklist))
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := m.poll(); err != nil {
+					log.Printf("DNS monitor poll error: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop halts background polling
+func (m *Monitor) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.stopSignal != nil {
+		close(m.stopSignal)
+		m.stopSignal = nil
+	}
+}
+
+// GetQueries returns recorded lookups, newest first
+func (m *Monitor) GetQueries(flaggedOnly bool, pid uint32, limit int) []Query {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := []Query{}
+	for i := len(m.queries) - 1; i >= 0; i-- {
+		q := m.queries[i]
+		if flaggedOnly && !q.Flagged {
+			continue
+		}
+		if pid != 0 && q.PID != pid {
+			continue
+		}
+		result = append(result, q)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+
+	return result
+}
+
+// poll reads the lookups logged since the last poll, oldest first, a batch
+// at a time until none are left, so a burst of more than one batch isn't
+// skipped over
+func (m *Monitor) poll() error {
+	for {
+		n, err := m.pollBatch()
+		if err != nil || n < batchSize {
+			return err
+		}
+	}
+}
+
+// pollBatch records up to batchSize lookups after lastRecordID and returns
+// how many events wevtutil returned
+func (m *Monitor) pollBatch() (int, error) {
+	m.mutex.RLock()
+	lastID := m.lastRecordID
+	m.mutex.RUnlock()
+
+	query := fmt.Sprintf("*[System[(EventID=3006) and (EventRecordID>%d)]]", lastID)
+	cmd := exec.Command("wevtutil", "qe", eventChannel,
+		"/q:"+query,
+		"/f:xml",
+		"/rd:false",
+		fmt.Sprintf("/c:%d", batchSize),
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("wevtutil query failed: %v, output: %s", err, output)
+	}
+
+	events, err := parseEvents(output)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	// wevtutil returns oldest first; keep it that way even if it didn't
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].System.EventRecordID < events[j].System.EventRecordID
+	})
+
+	names := process.NameMap()
+	queries := make([]Query, 0, len(events))
+	for _, ev := range events {
+		q := m.buildQuery(ev, names)
+		if q.Domain == "" {
+			continue
+		}
+		if q.Flagged {
+			log.Printf("🚩 Suspicious DNS lookup: %s by %s (PID %d) [%s]",
+				q.Domain, q.ProcessName, q.PID, strings.Join(q.Reasons, ", "))
+		}
+		queries = append(queries, q)
+	}
+
+	m.mutex.Lock()
+	m.lastRecordID = events[len(events)-1].System.EventRecordID
+	m.queries = append(m.queries, queries...)
+	if len(m.queries) > maxQueries {
+		m.queries = m.queries[len(m.queries)-maxQueries:]
+	}
+	m.mutex.Unlock()
+
+	return len(events), nil
+}
+
+func (m *Monitor) buildQuery(ev dnsEvent, names map[uint32]string) Query {
+	q := Query{
+		PID:         ev.System.Execution.ProcessID,
+		ProcessName: names[ev.System.Execution.ProcessID],
+	}
+
+	if ts, err := time.Parse(time.RFC3339Nano, ev.System.TimeCreated.SystemTime); err == nil {
+		q.Timestamp = ts
+	} else {
+		q.Timestamp = time.Now()
+	}
+
+	for _, d := range ev.EventData.Data {
+		switch d.Name {
+		case "QueryName":
+			q.Domain = normalizeDomain(d.Value)
+		case "QueryType":
+			q.QueryType = queryTypeName(d.Value)
+		}
+	}
+
+	q.Reasons = m.evaluate(q.Domain)
+	q.Flagged = len(q.Reasons) > 0
+	if q.Flagged {
+		source := events.NewSource(events.ModuleDNS, ruleID(q.Reasons), ruleVersion)
+		q.Source = &source
+	}
+
+	return q
+}
+
+// parseEvents decodes the sequence of <Event> elements wevtutil prints
+func parseEvents(data []byte) ([]dnsEvent, error) {
+	var events []dnsEvent
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		var ev dnsEvent
+		if err := decoder.Decode(&ev); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return events, fmt.Errorf("failed to parse DNS events: %w", err)
+		}
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
+
+func queryTypeName(value string) string {
+	types := map[int]string{
+		1: "A", 2: "NS", 5: "CNAME", 6: "SOA", 12: "PTR",
+		15: "MX", 16: "TXT", 28: "AAAA", 33: "SRV", 65: "HTTPS",
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return value
+	}
+	if name, ok := types[n]; ok {
+		return name
+	}
+	return value
+}
+
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const subscriberBuffer = 64
+
+type Event struct {
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+// Broker fans out events to any number of subscribers
+type Broker struct {
+	mutex       sync.RWMutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Subscribe returns a channel of future events and a function to unsubscribe
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mutex.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mutex.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscribers, ch)
+			b.mutex.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, cancel
+}
+
+// Publish delivers an event to every subscriber without blocking; slow
+// subscribers drop events rather than stalling the publisher
+func (b *Broker) Publish(eventType string, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	ev := Event{
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			log.Printf("⚠️ Event subscriber lagging, dropped %s", eventType)
+		}
+	}
+}
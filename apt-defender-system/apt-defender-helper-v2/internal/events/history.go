@@ -0,0 +1,99 @@
+package events
+
+import "sync"
+
+// Numbered is an event with its position in a History
+type Numbered struct {
+	ID uint64 `json:"id"`
+	Event
+}
+
+// History numbers the broker's events and keeps the most recent ones, so a
+// stream client that reconnects can pick up from the last event it saw
+// instead of polling for what it missed
+type History struct {
+	mutex       sync.RWMutex
+	size        int
+	next        uint64
+	recent      []Numbered
+	subscribers map[chan Numbered]struct{}
+}
+
+// NewHistory records broker events matching keep, holding the newest size
+func NewHistory(broker *Broker, size int, keep func(Event) bool) *History {
+	h := &History{
+		size:        size,
+		next:        1,
+		subscribers: make(map[chan Numbered]struct{}),
+	}
+
+	ch, _ := broker.Subscribe()
+	go func() {
+		for ev := range ch {
+			if keep(ev) {
+				h.add(ev)
+			}
+		}
+	}()
+	return h
+}
+
+func (h *History) add(ev Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	n := Numbered{ID: h.next, Event: ev}
+	h.next++
+	h.recent = append(h.recent, n)
+	if len(h.recent) > h.size {
+		h.recent = append([]Numbered{}, h.recent[len(h.recent)-h.size:]...)
+	}
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+// Since returns the recorded events after id, oldest first
+func (h *History) Since(id uint64) []Numbered {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	events := []Numbered{}
+	for _, n := range h.recent {
+		if n.ID > id {
+			events = append(events, n)
+		}
+	}
+	return events
+}
+
+// Subscribe returns a channel of future numbered events and a function to unsubscribe
+func (h *History) Subscribe() (<-chan Numbered, func()) {
+	ch := make(chan Numbered, subscriberBuffer)
+
+	h.mutex.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mutex.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mutex.Lock()
+			delete(h.subscribers, ch)
+			h.mutex.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// Last returns the ID of the newest recorded event, 0 when there is none
+func (h *History) Last() uint64 {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.next - 1
+}
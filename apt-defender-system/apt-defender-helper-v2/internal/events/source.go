@@ -0,0 +1,30 @@
+package events
+
+import "github.com/apt-defender/helper-v2/internal/version"
+
+// Detection modules that raise alerts
+const (
+	ModuleScan    = "scan"
+	ModuleFIM     = "fim"
+	ModuleDNS     = "dns_monitor"
+	ModuleNetwork = "network_monitor"
+)
+
+// Source attributes an alert to the module and rule that produced it so
+// noisy sources can be tuned per machine on the Pi
+type Source struct {
+	Module        string `json:"module"`
+	RuleID        string `json:"rule_id"`
+	RuleVersion   string `json:"rule_version"`
+	EngineVersion string `json:"engine_version"`
+}
+
+// NewSource stamps an alert source with the running engine version
+func NewSource(module, ruleID, ruleVersion string) Source {
+	return Source{
+		Module:        module,
+		RuleID:        ruleID,
+		RuleVersion:   ruleVersion,
+		EngineVersion: version.Version,
+	}
+}
@@ -0,0 +1,313 @@
+package fim
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/events"
+)
+
+const (
+	maxHashSize  = 100 * 1024 * 1024 // larger files are compared by size and mtime only
+	baselineFile = "fim-baseline.json"
+	ruleVersion  = "1"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeModified = "modified"
+	ChangeDeleted  = "deleted"
+)
+
+// Entry is the recorded state of a single file
+type Entry struct {
+	Hash    string    `json:"hash,omitempty"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// Change describes how a file differs from the baseline
+type Change struct {
+	Path       string        `json:"path"`
+	Type       string        `json:"type"`
+	OldHash    string        `json:"old_hash,omitempty"`
+	NewHash    string        `json:"new_hash,omitempty"`
+	Source     events.Source `json:"source"`
+	DetectedAt time.Time     `json:"detected_at"`
+}
+
+type baseline struct {
+	CreatedAt time.Time        `json:"created_at"`
+	Entries   map[string]Entry `json:"entries"`
+}
+
+type Monitor struct {
+	mutex       sync.RWMutex
+	paths       []string
+	storePath   string
+	baseline    *baseline
+	changes     map[string]Change
+	lastChecked time.Time
+	events      *events.Broker
+	stopSignal  chan struct{}
+}
+
+func New(paths []string, broker *events.Broker) *Monitor {
+	expanded := make([]string, 0, len(paths))
+	for _, p := range paths {
+		expanded = append(expanded, filepath.Clean(config.ExpandPath(p)))
+	}
+
+	return &Monitor{
+		paths:     expanded,
+		storePath: filepath.Join(config.GetDataDir(), baselineFile),
+		changes:   make(map[string]Change),
+		events:    broker,
+	}
+}
+
+// Start loads (or creates) the baseline and re-checks it every interval
+func (m *Monitor) Start(interval time.Duration) {
+	m.mutex.Lock()
+	if m.stopSignal != nil {
+		m.mutex.Unlock()
+		return
+	}
+	m.stopSignal = make(chan struct{})
+	stop := m.stopSignal
+	m.mutex.Unlock()
+
+	go func() {
+		if err := m.loadBaseline(); err != nil {
+			log.Printf("FIM: no stored baseline (%v), creating one", err)
+			if err := m.Rebaseline(); err != nil {
+				log.Printf("⚠️ FIM baseline failed: %v", err)
+			}
+		} else {
+			m.Check()
+		}
+
+		log.Printf("🧾 File integrity monitor started (%d paths, every %s)", len(m.paths), interval)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				m.Check()
+			}
+		}
+	}()
+}
+
+// Stop halts periodic re-checks
+func (m *Monitor) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.stopSignal != nil {
+		close(m.stopSignal)
+		m.stopSignal = nil
+	}
+}
+
+// Rebaseline accepts the current state of all watched paths as trusted
+func (m *Monitor) Rebaseline() error {
+	entries := m.snapshot()
+
+	m.mutex.Lock()
+	m.baseline = &baseline{CreatedAt: time.Now(), Entries: entries}
+	m.changes = make(map[string]Change)
+	m.lastChecked = time.Now()
+	m.mutex.Unlock()
+
+	log.Printf("🧾 FIM baseline recorded: %d files", len(entries))
+	return m.saveBaseline()
+}
+
+// Check compares the current state against the baseline
+func (m *Monitor) Check() {
+	m.mutex.RLock()
+	base := m.baseline
+	m.mutex.RUnlock()
+
+	if base == nil {
+		return
+	}
+
+	current := m.snapshot()
+	now := time.Now()
+	found := make(map[string]Change)
+
+	for path, old := range base.Entries {
+		cur, ok := current[path]
+		if !ok {
+			found[path] = Change{Path: path, Type: ChangeDeleted, OldHash: old.Hash}
+			continue
+		}
+		if entryChanged(old, cur) {
+			found[path] = Change{Path: path, Type: ChangeModified, OldHash: old.Hash, NewHash: cur.Hash}
+		}
+	}
+	for path, cur := range current {
+		if _, ok := base.Entries[path]; !ok {
+			found[path] = Change{Path: path, Type: ChangeAdded, NewHash: cur.Hash}
+		}
+	}
+
+	m.mutex.Lock()
+	var newChanges []Change
+	for path, change := range found {
+		// Keep the original detection time for changes we already reported
+		if prev, ok := m.changes[path]; ok && prev.Type == change.Type && prev.NewHash == change.NewHash {
+			change.DetectedAt = prev.DetectedAt
+			change.Source = prev.Source
+		} else {
+			change.DetectedAt = now
+			change.Source = events.NewSource(events.ModuleFIM, "fim."+change.Type, ruleVersion)
+			newChanges = append(newChanges, change)
+		}
+		found[path] = change
+	}
+	m.changes = found
+	m.lastChecked = now
+	m.mutex.Unlock()
+
+	for _, change := range newChanges {
+		log.Printf("🚩 FIM: %s %s", change.Type, change.Path)
+		m.events.Publish("fim.change", change)
+	}
+}
+
+// GetChanges returns all differences from the baseline, newest first
+func (m *Monitor) GetChanges() []Change {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make([]Change, 0, len(m.changes))
+	for _, c := range m.changes {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].DetectedAt.Equal(result[j].DetectedAt) {
+			return result[i].Path < result[j].Path
+		}
+		return result[i].DetectedAt.After(result[j].DetectedAt)
+	})
+
+	return result
+}
+
+// GetStatus reports baseline metadata
+func (m *Monitor) GetStatus() map[string]interface{} {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	status := map[string]interface{}{
+		"paths":        m.paths,
+		"last_checked": m.lastChecked,
+		"changes":      len(m.changes),
+	}
+	if m.baseline != nil {
+		status["baseline_created_at"] = m.baseline.CreatedAt
+		status["baseline_files"] = len(m.baseline.Entries)
+	}
+
+	return status
+}
+
+// snapshot hashes every file under the watched paths
+func (m *Monitor) snapshot() map[string]Entry {
+	entries := make(map[string]Entry)
+
+	for _, root := range m.paths {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+
+			entry := Entry{Size: info.Size(), ModTime: info.ModTime().UTC()}
+			if info.Size() <= maxHashSize {
+				if hash, err := hashFile(path); err == nil {
+					entry.Hash = hash
+				}
+			}
+			entries[path] = entry
+			return nil
+		})
+	}
+
+	return entries
+}
+
+func entryChanged(old, cur Entry) bool {
+	if old.Hash != "" && cur.Hash != "" {
+		return old.Hash != cur.Hash
+	}
+	return old.Size != cur.Size || !old.ModTime.Equal(cur.ModTime)
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func (m *Monitor) loadBaseline() error {
+	data, err := os.ReadFile(m.storePath)
+	if err != nil {
+		return err
+	}
+
+	var base baseline
+	if err := json.Unmarshal(data, &base); err != nil {
+		return fmt.Errorf("failed to parse baseline: %w", err)
+	}
+	if base.Entries == nil {
+		base.Entries = make(map[string]Entry)
+	}
+
+	m.mutex.Lock()
+	m.baseline = &base
+	m.mutex.Unlock()
+
+	return nil
+}
+
+func (m *Monitor) saveBaseline() error {
+	m.mutex.RLock()
+	data, err := json.Marshal(m.baseline)
+	m.mutex.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal baseline: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.storePath), 0700); err != nil {
+		return fmt.Errorf("failed to create data dir: %w", err)
+	}
+	if err := os.WriteFile(m.storePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+
+	return nil
+}
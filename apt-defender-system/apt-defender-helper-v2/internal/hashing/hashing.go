@@ -0,0 +1,124 @@
+package hashing
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// Supported algorithm names
+const (
+	MD5    = "md5"
+	SHA1   = "sha1"
+	SHA256 = "sha256"
+	SSDeep = "ssdeep"
+)
+
+// maxFuzzySize caps the files ssdeep is computed for, since it needs the
+// whole content in memory and may take several passes
+const maxFuzzySize = 64 * 1024 * 1024
+
+// Hashes holds whichever digests were requested
+type Hashes struct {
+	MD5    string `json:"md5,omitempty"`
+	SHA1   string `json:"sha1,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	SSDeep string `json:"ssdeep,omitempty"`
+}
+
+// All lists every algorithm in output order
+var All = []string{MD5, SHA1, SHA256, SSDeep}
+
+// ParseAlgorithms turns a comma-separated list into validated algorithm names
+func ParseAlgorithms(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return []string{SHA256}, nil
+	}
+
+	var algorithms []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case MD5, SHA1, SHA256, SSDeep:
+			algorithms = append(algorithms, name)
+		case "all":
+			return All, nil
+		default:
+			return nil, fmt.Errorf("unknown hash algorithm: %s", name)
+		}
+	}
+	return algorithms, nil
+}
+
+// File computes the requested digests of a file. Cryptographic hashes share
+// a single read; ssdeep is skipped for files over 64MB.
+func File(path string, algorithms []string) (*Hashes, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	want := make(map[string]bool, len(algorithms))
+	for _, a := range algorithms {
+		want[a] = true
+	}
+
+	digests := make(map[string]hash.Hash)
+	if want[MD5] {
+		digests[MD5] = md5.New()
+	}
+	if want[SHA1] {
+		digests[SHA1] = sha1.New()
+	}
+	if want[SHA256] {
+		digests[SHA256] = sha256.New()
+	}
+
+	result := &Hashes{}
+
+	if len(digests) > 0 {
+		writers := make([]io.Writer, 0, len(digests))
+		for _, h := range digests {
+			writers = append(writers, h)
+		}
+		if _, err := io.Copy(io.MultiWriter(writers...), f); err != nil {
+			return nil, err
+		}
+		for name, h := range digests {
+			sum := fmt.Sprintf("%x", h.Sum(nil))
+			switch name {
+			case MD5:
+				result.MD5 = sum
+			case SHA1:
+				result.SHA1 = sum
+			case SHA256:
+				result.SHA256 = sum
+			}
+		}
+	}
+
+	if want[SSDeep] {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() <= maxFuzzySize {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return nil, err
+			}
+			data, err := io.ReadAll(f)
+			if err != nil {
+				return nil, err
+			}
+			result.SSDeep = FuzzyHash(data)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,112 @@
+package hashing
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Context-triggered piecewise hashing as implemented by spamsum/ssdeep, so
+// digests are comparable with the standard ssdeep tool
+const (
+	rollingWindow = 7
+	minBlockSize  = 3
+	spamSumLength = 64
+	hashPrime     = 0x01000193
+	hashInit      = 0x28021967
+)
+
+const b64 = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+type rollingHash struct {
+	window     [rollingWindow]byte
+	h1, h2, h3 uint32
+	n          uint32
+}
+
+func (r *rollingHash) roll(c byte) uint32 {
+	r.h2 -= r.h1
+	r.h2 += rollingWindow * uint32(c)
+
+	r.h1 += uint32(c)
+	r.h1 -= uint32(r.window[r.n%rollingWindow])
+
+	r.window[r.n%rollingWindow] = c
+	r.n++
+
+	r.h3 <<= 5
+	r.h3 ^= uint32(c)
+
+	return r.h1 + r.h2 + r.h3
+}
+
+func sumHash(c byte, h uint32) uint32 {
+	return (h * hashPrime) ^ uint32(c)
+}
+
+// FuzzyHash returns the ssdeep digest ("blocksize:hash1:hash2") of data
+func FuzzyHash(data []byte) string {
+	blockSize := uint32(minBlockSize)
+	for blockSize*spamSumLength < uint32(len(data)) {
+		blockSize *= 2
+	}
+
+	for {
+		var roll rollingHash
+		var p1 [spamSumLength]byte
+		var p2 [spamSumLength / 2]byte
+		j, k := 0, 0
+		set1, set2 := false, false
+		h1, h2 := uint32(hashInit), uint32(hashInit)
+		var rh uint32
+
+		for _, c := range data {
+			h1 = sumHash(c, h1)
+			h2 = sumHash(c, h2)
+			rh = roll.roll(c)
+
+			// The last slot keeps being overwritten once the digest is full
+			if rh%blockSize == blockSize-1 {
+				p1[j], set1 = b64[h1%64], true
+				if j < spamSumLength-1 {
+					h1 = hashInit
+					j++
+					set1 = false
+				}
+			}
+			if rh%(blockSize*2) == blockSize*2-1 {
+				p2[k], set2 = b64[h2%64], true
+				if k < spamSumLength/2-1 {
+					h2 = hashInit
+					k++
+					set2 = false
+				}
+			}
+		}
+
+		if rh != 0 {
+			p1[j], set1 = b64[h1%64], true
+			p2[k], set2 = b64[h2%64], true
+		}
+
+		// Retry with a smaller block size when too few pieces were produced
+		if blockSize > minBlockSize && j < spamSumLength/2 {
+			blockSize /= 2
+			continue
+		}
+
+		if set1 {
+			j++
+		}
+		if set2 {
+			k++
+		}
+
+		var sb strings.Builder
+		sb.WriteString(strconv.FormatUint(uint64(blockSize), 10))
+		sb.WriteByte(':')
+		sb.Write(p1[:j])
+		sb.WriteByte(':')
+		sb.Write(p2[:k])
+		return sb.String()
+	}
+}
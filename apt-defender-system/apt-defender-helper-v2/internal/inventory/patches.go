@@ -0,0 +1,125 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const currentVersionKey = `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+
+// OSBuild identifies the installed Windows release down to the cumulative
+// update revision (e.g. 19045.4291)
+type OSBuild struct {
+	ProductName    string `json:"product_name"`
+	DisplayVersion string `json:"display_version"`
+	Build          string `json:"build"`
+	Revision       uint64 `json:"revision"`
+	FullBuild      string `json:"full_build"`
+}
+
+// Hotfix is an installed KB as reported by Win32_QuickFixEngineering
+type Hotfix struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	InstalledOn *time.Time `json:"installed_on,omitempty"`
+	InstalledBy string     `json:"installed_by,omitempty"`
+}
+
+// Patches is the patch level of this machine
+type Patches struct {
+	OS         OSBuild    `json:"os"`
+	Hotfixes   []Hotfix   `json:"hotfixes"`
+	LastUpdate *time.Time `json:"last_update,omitempty"`
+}
+
+type quickFix struct {
+	HotFixID    string
+	Description string
+	InstalledOn string
+	InstalledBy string
+}
+
+const hotfixQuery = `Get-CimInstance Win32_QuickFixEngineering | ForEach-Object {
+  [pscustomobject]@{
+    HotFixID = $_.HotFixID; Description = $_.Description; InstalledBy = $_.InstalledBy
+    InstalledOn = $(if ($_.InstalledOn) { $_.InstalledOn.ToString('yyyy-MM-dd') } else { '' })
+  }
+} | ConvertTo-Json -Compress`
+
+// Windows Update history covers updates Win32_QuickFixEngineering misses
+// (Defender platform, drivers, store-delivered servicing stack)
+const lastUpdateQuery = `$s = (New-Object -ComObject Microsoft.Update.Session).CreateUpdateSearcher()
+if ($s.GetTotalHistoryCount() -gt 0) { $s.QueryHistory(0, 1) | ForEach-Object { $_.Date.ToUniversalTime().ToString('o') } }`
+
+// GetPatches returns the OS build, installed KBs (newest first) and the time
+// the last update was installed
+func GetPatches() (*Patches, error) {
+	result := &Patches{OS: osBuild(), Hotfixes: []Hotfix{}}
+
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", hotfixQuery).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hotfixes: %w", err)
+	}
+	raw := strings.TrimSpace(string(out))
+	if raw != "" {
+		if !strings.HasPrefix(raw, "[") {
+			raw = "[" + raw + "]"
+		}
+		var list []quickFix
+		if err := json.Unmarshal([]byte(raw), &list); err != nil {
+			return nil, fmt.Errorf("failed to parse hotfixes: %w", err)
+		}
+		for _, q := range list {
+			h := Hotfix{ID: q.HotFixID, Description: q.Description, InstalledBy: q.InstalledBy}
+			if t, err := time.Parse("2006-01-02", q.InstalledOn); err == nil {
+				h.InstalledOn = &t
+			}
+			result.Hotfixes = append(result.Hotfixes, h)
+		}
+	}
+
+	sort.SliceStable(result.Hotfixes, func(i, j int) bool {
+		a, b := result.Hotfixes[i].InstalledOn, result.Hotfixes[j].InstalledOn
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.After(*b)
+	})
+
+	if out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", lastUpdateQuery).Output(); err == nil {
+		if t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(out))); err == nil {
+			result.LastUpdate = &t
+		}
+	}
+	// Fall back to the newest hotfix date when update history is unavailable
+	if result.LastUpdate == nil && len(result.Hotfixes) > 0 {
+		result.LastUpdate = result.Hotfixes[0].InstalledOn
+	}
+
+	return result, nil
+}
+
+func osBuild() OSBuild {
+	var b OSBuild
+	key, err := winreg.Open(currentVersionKey)
+	if err != nil {
+		return b
+	}
+	defer key.Close()
+
+	b.ProductName, _ = key.GetString("ProductName")
+	b.DisplayVersion, _ = key.GetString("DisplayVersion")
+	if b.DisplayVersion == "" {
+		b.DisplayVersion, _ = key.GetString("ReleaseId")
+	}
+	b.Build, _ = key.GetString("CurrentBuild")
+	b.Revision, _ = key.GetUint("UBR")
+	b.FullBuild = fmt.Sprintf("%s.%d", b.Build, b.Revision)
+	return b
+}
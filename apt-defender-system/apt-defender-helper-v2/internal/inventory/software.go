@@ -0,0 +1,118 @@
+package inventory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const uninstallPath = `SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall`
+
+// Uninstall key locations; per-user installs live under each loaded HKU hive
+var uninstallRoots = []struct {
+	Path         string
+	Architecture string
+}{
+	{`HKLM\` + uninstallPath, "x64"},
+	{`HKLM\SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall`, "x86"},
+}
+
+// Program is an installed application from the Uninstall registry keys
+type Program struct {
+	Name            string     `json:"name"`
+	Version         string     `json:"version"`
+	Publisher       string     `json:"publisher"`
+	InstallDate     *time.Time `json:"install_date,omitempty"`
+	InstallLocation string     `json:"install_location,omitempty"`
+	UninstallString string     `json:"uninstall_string,omitempty"`
+	Architecture    string     `json:"architecture"`
+	Scope           string     `json:"scope"` // "machine" or the user SID
+	MSI             bool       `json:"msi"`
+	ProductCode     string     `json:"product_code,omitempty"`
+	SystemComponent bool       `json:"system_component"`
+}
+
+// Software lists installed programs sorted by name. System components and
+// updates that Programs and Features hides are left out unless includeSystem
+// is set.
+func Software(includeSystem bool) []Program {
+	programs := []Program{}
+	seen := map[string]bool{}
+
+	add := func(path, architecture, scope string) {
+		root, err := winreg.Open(path)
+		if err != nil {
+			return
+		}
+		defer root.Close()
+
+		for _, name := range root.SubKeys() {
+			key, err := winreg.Open(path + `\` + name)
+			if err != nil {
+				continue
+			}
+			p, ok := readProgram(key, name, architecture, scope)
+			key.Close()
+			if !ok || (p.SystemComponent && !includeSystem) {
+				continue
+			}
+
+			id := strings.ToLower(p.Name + "|" + p.Version + "|" + p.Scope)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			programs = append(programs, p)
+		}
+	}
+
+	for _, r := range uninstallRoots {
+		add(r.Path, r.Architecture, "machine")
+	}
+	if users, err := winreg.Open(`HKU\`); err == nil {
+		for _, sid := range users.SubKeys() {
+			if strings.HasSuffix(sid, "_Classes") || sid == ".DEFAULT" {
+				continue
+			}
+			add(`HKU\`+sid+`\`+uninstallPath, "", sid)
+		}
+		users.Close()
+	}
+
+	sort.Slice(programs, func(i, j int) bool {
+		return strings.ToLower(programs[i].Name) < strings.ToLower(programs[j].Name)
+	})
+	return programs
+}
+
+func readProgram(key *winreg.Key, keyName, architecture, scope string) (Program, bool) {
+	p := Program{Architecture: architecture, Scope: scope}
+
+	p.Name, _ = key.GetString("DisplayName")
+	if p.Name == "" {
+		return p, false
+	}
+	p.Version, _ = key.GetString("DisplayVersion")
+	p.Publisher, _ = key.GetString("Publisher")
+	p.InstallLocation, _ = key.GetString("InstallLocation")
+	p.UninstallString, _ = key.GetString("UninstallString")
+
+	if v, err := key.GetString("InstallDate"); err == nil {
+		if t, err := time.Parse("20060102", strings.TrimSpace(v)); err == nil {
+			p.InstallDate = &t
+		}
+	}
+	if v, err := key.GetUint("WindowsInstaller"); err == nil && v == 1 {
+		p.MSI = true
+		if strings.HasPrefix(keyName, "{") {
+			p.ProductCode = keyName
+		}
+	}
+
+	system, _ := key.GetUint("SystemComponent")
+	parent, _ := key.GetString("ParentKeyName")
+	p.SystemComponent = system == 1 || parent != ""
+	return p, true
+}
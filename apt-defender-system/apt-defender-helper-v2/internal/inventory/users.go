@@ -0,0 +1,167 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const (
+	sidAdministrators = "S-1-5-32-544"
+
+	// Accounts set to 0 here are hidden from the logon screen
+	specialAccountsKey = `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\Winlogon\SpecialAccounts\UserList`
+)
+
+// User is a local account with the properties attackers tend to abuse
+type User struct {
+	Name                 string     `json:"name"`
+	SID                  string     `json:"sid"`
+	FullName             string     `json:"full_name,omitempty"`
+	Description          string     `json:"description,omitempty"`
+	Enabled              bool       `json:"enabled"`
+	Groups               []string   `json:"groups"`
+	Admin                bool       `json:"admin"`
+	LastLogon            *time.Time `json:"last_logon,omitempty"`
+	PasswordLastSet      *time.Time `json:"password_last_set,omitempty"`
+	PasswordNeverExpires bool       `json:"password_never_expires"`
+	PasswordRequired     bool       `json:"password_required"`
+	CreatedAt            *time.Time `json:"created_at,omitempty"`
+	RecentlyCreated      bool       `json:"recently_created"`
+	Hidden               bool       `json:"hidden"`
+}
+
+type localAccounts struct {
+	Users []struct {
+		Name             string
+		SID              string
+		FullName         string
+		Description      string
+		Enabled          bool
+		LastLogon        string
+		PasswordLastSet  string
+		PasswordExpires  string
+		PasswordRequired bool
+	}
+	Groups []struct {
+		Name    string
+		SID     string
+		Members []string
+	}
+	Created []struct {
+		SID  string
+		Time string
+	}
+}
+
+// Account creation time isn't stored with the account, so it comes from
+// Security event 4720 (when the log still holds it)
+const accountsQuery = `function iso($t) { if ($t) { $t.ToUniversalTime().ToString('o') } else { '' } }
+$users = @(Get-LocalUser | ForEach-Object { [pscustomobject]@{
+  Name = $_.Name; SID = $_.SID.Value; FullName = $_.FullName; Description = $_.Description; Enabled = $_.Enabled
+  LastLogon = iso $_.LastLogon; PasswordLastSet = iso $_.PasswordLastSet; PasswordExpires = iso $_.PasswordExpires
+  PasswordRequired = $_.PasswordRequired } })
+$groups = @(Get-LocalGroup | ForEach-Object {
+  $members = @(); try { $members = @(Get-LocalGroupMember -SID $_.SID -ErrorAction Stop | ForEach-Object { $_.SID.Value }) } catch {}
+  [pscustomobject]@{ Name = $_.Name; SID = $_.SID.Value; Members = $members } })
+$created = @(Get-WinEvent -FilterHashtable @{LogName='Security'; Id=4720} -ErrorAction SilentlyContinue | ForEach-Object {
+  [pscustomobject]@{ SID = $_.Properties[2].Value.Value; Time = iso $_.TimeCreated } })
+[pscustomobject]@{ Users = $users; Groups = $groups; Created = $created } | ConvertTo-Json -Compress -Depth 4`
+
+// Users lists local accounts with group memberships, flagging admins,
+// non-expiring passwords, accounts hidden from the logon screen and accounts
+// created within recentDays
+func Users(recentDays int) ([]User, error) {
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", accountsQuery).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list local accounts: %w", err)
+	}
+
+	var accounts localAccounts
+	if err := json.Unmarshal(out, &accounts); err != nil {
+		return nil, fmt.Errorf("failed to parse local accounts: %w", err)
+	}
+
+	groups := map[string][]string{}
+	admins := map[string]bool{}
+	for _, g := range accounts.Groups {
+		for _, sid := range g.Members {
+			groups[sid] = append(groups[sid], g.Name)
+			if g.SID == sidAdministrators {
+				admins[sid] = true
+			}
+		}
+	}
+
+	created := map[string]time.Time{}
+	for _, c := range accounts.Created {
+		if t := parseISO(c.Time); t != nil {
+			if prev, ok := created[c.SID]; !ok || t.After(prev) {
+				created[c.SID] = *t
+			}
+		}
+	}
+
+	hidden := hiddenAccounts()
+	cutoff := time.Now().AddDate(0, 0, -recentDays)
+
+	users := make([]User, 0, len(accounts.Users))
+	for _, a := range accounts.Users {
+		u := User{
+			Name:                 a.Name,
+			SID:                  a.SID,
+			FullName:             a.FullName,
+			Description:          a.Description,
+			Enabled:              a.Enabled,
+			Groups:               groups[a.SID],
+			Admin:                admins[a.SID],
+			LastLogon:            parseISO(a.LastLogon),
+			PasswordLastSet:      parseISO(a.PasswordLastSet),
+			PasswordNeverExpires: a.PasswordExpires == "",
+			PasswordRequired:     a.PasswordRequired,
+			Hidden:               strings.HasSuffix(a.Name, "$") || hidden[strings.ToLower(a.Name)],
+		}
+		if u.Groups == nil {
+			u.Groups = []string{}
+		}
+		if t, ok := created[a.SID]; ok {
+			u.CreatedAt = &t
+			u.RecentlyCreated = t.After(cutoff)
+		}
+		users = append(users, u)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return strings.ToLower(users[i].Name) < strings.ToLower(users[j].Name)
+	})
+	return users, nil
+}
+
+func hiddenAccounts() map[string]bool {
+	result := map[string]bool{}
+	key, err := winreg.Open(specialAccountsKey)
+	if err != nil {
+		return result
+	}
+	defer key.Close()
+
+	for _, v := range key.Values() {
+		if v.Data == "0" {
+			result[strings.ToLower(v.Name)] = true
+		}
+	}
+	return result
+}
+
+func parseISO(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
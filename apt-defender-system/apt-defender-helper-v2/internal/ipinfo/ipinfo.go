@@ -0,0 +1,213 @@
+package ipinfo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Address scopes
+const (
+	ScopeLoopback  = "loopback"
+	ScopePrivate   = "private"
+	ScopeLinkLocal = "link_local"
+	ScopeCGNAT     = "cgnat"
+	ScopeMulticast = "multicast"
+	ScopePublic    = "public"
+	ScopeInvalid   = "invalid"
+)
+
+const (
+	hitTTL        = 24 * time.Hour
+	missTTL       = time.Hour
+	lookupTimeout = 3 * time.Second
+	maxLookups    = 4    // concurrent background lookups
+	maxEntries    = 5000 // cached addresses
+)
+
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Hint is what is known about a remote address. ASN, AS name, country and
+// hostname stay empty until the background lookup finishes.
+type Hint struct {
+	Scope    string `json:"scope"`
+	Hostname string `json:"hostname,omitempty"`
+	ASN      string `json:"asn,omitempty"`
+	ASName   string `json:"as_name,omitempty"`
+	Country  string `json:"country,omitempty"` // ISO 3166 code of the registered prefix
+	Prefix   string `json:"prefix,omitempty"`
+	Pending  bool   `json:"pending,omitempty"`
+}
+
+type entry struct {
+	hint    Hint
+	expires time.Time
+}
+
+// Resolver tells who a remote address probably belongs to: its scope (LAN,
+// loopback, carrier NAT...) and, for public addresses, the reverse DNS name
+// and the origin ASN and country from Team Cymru's IP-to-ASN DNS zone.
+// Lookups run in the background and are cached, so callers never wait on DNS.
+type Resolver struct {
+	mutex   sync.Mutex
+	cache   map[string]entry
+	pending map[string]bool
+	slots   chan struct{}
+	lookups bool
+}
+
+// New creates a resolver. With lookups off only the scope is reported and
+// no DNS queries are made.
+func New(lookups bool) *Resolver {
+	return &Resolver{
+		cache:   make(map[string]entry),
+		pending: make(map[string]bool),
+		slots:   make(chan struct{}, maxLookups),
+		lookups: lookups,
+	}
+}
+
+// Scope classifies an address without any lookups
+func Scope(address string) string {
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil || ip.IsUnspecified():
+		return ScopeInvalid
+	case ip.IsLoopback():
+		return ScopeLoopback
+	case ip.IsPrivate():
+		return ScopePrivate
+	case ip.IsLinkLocalUnicast():
+		return ScopeLinkLocal
+	case ip.IsMulticast() || ip.IsLinkLocalMulticast() || ip.Equal(net.IPv4bcast):
+		return ScopeMulticast
+	case cgnat.Contains(ip):
+		return ScopeCGNAT
+	default:
+		return ScopePublic
+	}
+}
+
+// Lookup returns the cached hint for an address, starting a background
+// lookup for public addresses that aren't cached yet
+func (r *Resolver) Lookup(address string) Hint {
+	scope := Scope(address)
+	if scope != ScopePublic || !r.lookups {
+		return Hint{Scope: scope}
+	}
+
+	now := time.Now()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if e, ok := r.cache[address]; ok && now.Before(e.expires) {
+		return e.hint
+	}
+	if !r.pending[address] && len(r.pending) < maxEntries {
+		r.pending[address] = true
+		go r.resolve(address)
+	}
+	return Hint{Scope: scope, Pending: true}
+}
+
+func (r *Resolver) resolve(address string) {
+	r.slots <- struct{}{}
+	defer func() { <-r.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	hint := Hint{Scope: ScopePublic}
+	if names, err := net.DefaultResolver.LookupAddr(ctx, address); err == nil && len(names) > 0 {
+		hint.Hostname = strings.TrimSuffix(names[0], ".")
+	}
+	if err := originASN(ctx, address, &hint); err == nil && hint.ASN != "" {
+		asName(ctx, &hint)
+	}
+
+	ttl := hitTTL
+	if hint.ASN == "" && hint.Hostname == "" {
+		ttl = missTTL
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.pending, address)
+	if len(r.cache) >= maxEntries {
+		now := time.Now()
+		for k, e := range r.cache {
+			if now.After(e.expires) {
+				delete(r.cache, k)
+			}
+		}
+		if len(r.cache) >= maxEntries {
+			r.cache = make(map[string]entry)
+		}
+	}
+	r.cache[address] = entry{hint: hint, expires: time.Now().Add(ttl)}
+}
+
+// originASN queries <reversed address>.origin[6].asn.cymru.com, answered as
+// "13335 | 1.1.1.0/24 | AU | apnic | 2011-08-11"
+func originASN(ctx context.Context, address string, hint *Hint) error {
+	name, err := originName(net.ParseIP(address))
+	if err != nil {
+		return err
+	}
+	records, err := net.DefaultResolver.LookupTXT(ctx, name)
+	if err != nil || len(records) == 0 {
+		return fmt.Errorf("no origin record for %s", address)
+	}
+	fields := splitRecord(records[0])
+	if len(fields) < 3 {
+		return fmt.Errorf("malformed origin record %q", records[0])
+	}
+	// Prefixes announced by several ASes list them all; the first is enough for a hint
+	if asns := strings.Fields(fields[0]); len(asns) > 0 {
+		hint.ASN = "AS" + asns[0]
+	}
+	hint.Prefix, hint.Country = fields[1], fields[2]
+	return nil
+}
+
+// asName queries AS<n>.asn.cymru.com, answered as
+// "13335 | US | arin | 2010-07-14 | CLOUDFLARENET, US"
+func asName(ctx context.Context, hint *Hint) {
+	records, err := net.DefaultResolver.LookupTXT(ctx, hint.ASN+".asn.cymru.com")
+	if err != nil || len(records) == 0 {
+		return
+	}
+	if fields := splitRecord(records[0]); len(fields) >= 5 {
+		hint.ASName = fields[4]
+	}
+}
+
+func originName(ip net.IP) (string, error) {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.origin.asn.cymru.com", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+	if ip == nil {
+		return "", fmt.Errorf("invalid address")
+	}
+	const hexDigits = "0123456789abcdef"
+	var b strings.Builder
+	for i := len(ip) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[ip[i]&0x0f])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[ip[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("origin6.asn.cymru.com")
+	return b.String(), nil
+}
+
+func splitRecord(record string) []string {
+	fields := strings.Split(record, "|")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+// File is the JSON log main writes, relative to the working directory
+const File = "apt-defender-v2.log"
+
+// tailBytes bounds how much of the end of the file one Read looks at, so a
+// log that has grown for weeks is still quick to open
+const tailBytes = 8 << 20
+
+// Record is one line of the JSON log file. Attributes other than the
+// standard ones are kept in Attrs.
+type Record struct {
+	Time      time.Time              `json:"time"`
+	Level     string                 `json:"level"`
+	Message   string                 `json:"msg"`
+	Component string                 `json:"component,omitempty"`
+	Source    string                 `json:"source,omitempty"`
+	Attrs     map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// Filter selects records from the log file; zero fields match everything
+type Filter struct {
+	Level     slog.Level // minimum level
+	Component string
+	Search    string // case-insensitive text in the message or attributes
+	After     int64  // Offset from an earlier Page; 0 reads the end of the file
+	Limit     int
+}
+
+// Page is the result of a Read
+type Page struct {
+	Records []Record `json:"records"`
+	Offset  int64    `json:"offset"` // pass as after to get the records written since
+	Reset   bool     `json:"reset"`  // the file was truncated or replaced since after
+	More    bool     `json:"more"`   // older matches were left out by limit
+}
+
+// Read returns the newest records in the log file at path that match f,
+// oldest first. With f.After it returns only what was written since that
+// offset, unless the file has since shrunk.
+func Read(path string, f Filter) (Page, error) {
+	page := Page{Records: []Record{}}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return page, nil
+	}
+	if err != nil {
+		return page, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return page, err
+	}
+	size := info.Size()
+
+	start := f.After
+	if start > size {
+		page.Reset = true
+		start = 0
+	}
+	if start == 0 || size-start > tailBytes {
+		start = max(0, size-tailBytes)
+	}
+	data := make([]byte, size-start)
+	if _, err := file.ReadAt(data, start); err != nil && err != io.EOF {
+		return page, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	// Drop a line cut by starting mid-file, and one still being written
+	if start > 0 && start != f.After {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	end := bytes.LastIndexByte(data, '\n') + 1
+	page.Offset = size - int64(len(data)-end)
+	data = data[:end]
+
+	search := strings.ToLower(f.Search)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		record, ok := parseRecord(line)
+		if !ok || !f.matches(record, search) {
+			continue
+		}
+		page.Records = append(page.Records, record)
+	}
+	if f.Limit > 0 && len(page.Records) > f.Limit {
+		page.Records = page.Records[len(page.Records)-f.Limit:]
+		page.More = true
+	}
+	return page, nil
+}
+
+func (f Filter) matches(r Record, search string) bool {
+	var lvl slog.Level
+	if lvl.UnmarshalText([]byte(r.Level)) == nil && lvl < f.Level {
+		return false
+	}
+	if f.Component != "" && r.Component != f.Component {
+		return false
+	}
+	if search == "" {
+		return true
+	}
+	if strings.Contains(strings.ToLower(r.Message), search) {
+		return true
+	}
+	for k, v := range r.Attrs {
+		if strings.Contains(strings.ToLower(fmt.Sprintf("%s=%v", k, v)), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRecord reads one JSON line written by the file handler
+func parseRecord(line []byte) (Record, bool) {
+	var fields map[string]interface{}
+	if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &fields) != nil {
+		return Record{}, false
+	}
+	var r Record
+	if v, ok := fields[slog.TimeKey].(string); ok {
+		r.Time, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	r.Level, _ = fields[slog.LevelKey].(string)
+	r.Message, _ = fields[slog.MessageKey].(string)
+	r.Component, _ = fields["component"].(string)
+	r.Source, _ = fields["source"].(string)
+	for _, key := range []string{slog.TimeKey, slog.LevelKey, slog.MessageKey, "component", "source"} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		r.Attrs = fields
+	}
+	return r, true
+}
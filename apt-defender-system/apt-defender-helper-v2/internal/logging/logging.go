@@ -0,0 +1,229 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+var (
+	level    = new(slog.LevelVar)
+	deviceID atomic.Int64
+	root     *fanout
+)
+
+// Setup sends slog and the standard logger to the console as text and,
+// when file is non-nil, to file as one JSON record per line for the Pi and
+// other tooling to parse. Records below levelName are dropped.
+func Setup(levelName string, file io.Writer) {
+	if err := SetLevel(levelName); err != nil {
+		level.Set(slog.LevelInfo)
+		defer slog.Warn("invalid log_level, using info", "error", err)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	handlers := []slog.Handler{slog.NewTextHandler(os.Stderr, opts)}
+	if file != nil {
+		handlers = append(handlers, slog.NewJSONHandler(file, opts))
+	}
+	handler := &fanout{handlers: handlers}
+	root = handler
+
+	// SetDefault points the log package at slog too, so the bridge has to be
+	// installed after it
+	slog.SetDefault(slog.New(handler))
+	log.SetFlags(log.Llongfile)
+	log.SetOutput(&bridge{handler: handler})
+}
+
+// SetLevel changes the minimum level at runtime (debug, info, warn, error)
+func SetLevel(name string) error {
+	l, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
+// ParseLevel maps a log_level value to a slog level
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug", "trace":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+// SetDeviceID tags every record with the device ID the Pi Agent assigned at pairing
+func SetDeviceID(id int) {
+	deviceID.Store(int64(id))
+}
+
+// AddSink sends a copy of every record, as one JSON object per Write, to w
+func AddSink(w io.Writer) {
+	if root == nil {
+		return
+	}
+	root.mutex.Lock()
+	defer root.mutex.Unlock()
+	root.handlers = append(root.handlers, slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// Component returns a logger whose records carry the given component
+func Component(name string) *slog.Logger {
+	return slog.Default().With("component", name)
+}
+
+// fanout hands each record to every handler that wants it
+type fanout struct {
+	mutex    sync.RWMutex
+	handlers []slog.Handler
+}
+
+func (f *fanout) Enabled(ctx context.Context, l slog.Level) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	for _, h := range f.handlers {
+		if h.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fanout) Handle(ctx context.Context, r slog.Record) error {
+	if id := deviceID.Load(); id != 0 {
+		r.AddAttrs(slog.Int64("device_id", id))
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	for _, h := range f.handlers {
+		if h.Enabled(ctx, r.Level) {
+			h.Handle(ctx, r.Clone())
+		}
+	}
+	return nil
+}
+
+func (f *fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	next := &fanout{}
+	for _, h := range f.handlers {
+		next.handlers = append(next.handlers, h.WithAttrs(attrs))
+	}
+	return next
+}
+
+func (f *fanout) WithGroup(name string) slog.Handler {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	next := &fanout{}
+	for _, h := range f.handlers {
+		next.handlers = append(next.handlers, h.WithGroup(name))
+	}
+	return next
+}
+
+// bridge turns lines from the standard logger into slog records. The level
+// is read from the message's leading marker (⚠️ warn, ❌/🚨 error) and the
+// component from the package that logged it, so existing log.Printf calls
+// produce the same structured records as new slog code.
+type bridge struct {
+	handler slog.Handler
+}
+
+func (b *bridge) Write(p []byte) (int, error) {
+	source, msg := splitSource(strings.TrimRight(string(p), "\n"))
+	lvl, msg := classify(msg)
+	if !b.handler.Enabled(context.Background(), lvl) {
+		return len(p), nil
+	}
+
+	r := slog.NewRecord(time.Now(), lvl, msg, 0)
+	if source != "" {
+		r.AddAttrs(slog.String("component", component(source)), slog.String("source", shortSource(source)))
+	}
+	b.handler.Handle(context.Background(), r)
+	return len(p), nil
+}
+
+// splitSource separates the "path/file.go:123: " prefix added by Llongfile
+func splitSource(line string) (string, string) {
+	idx := strings.Index(line, ".go:")
+	if idx < 0 {
+		return "", line
+	}
+	rest := line[idx+len(".go:"):]
+	colon := strings.Index(rest, ": ")
+	if colon < 0 {
+		return "", line
+	}
+	if _, err := strconv.Atoi(rest[:colon]); err != nil {
+		return "", line
+	}
+	return line[:idx+len(".go:")+colon], rest[colon+2:]
+}
+
+// component is the package a source path belongs to: internal/<component>/x.go, or main
+func component(source string) string {
+	path := filepath.ToSlash(source)
+	if i := strings.LastIndex(path, "/internal/"); i >= 0 {
+		if name, _, ok := strings.Cut(path[i+len("/internal/"):], "/"); ok {
+			return name
+		}
+	}
+	return "main"
+}
+
+func shortSource(source string) string {
+	path := filepath.ToSlash(source)
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		if j := strings.LastIndex(path[:i], "/"); j >= 0 {
+			return path[j+1:]
+		}
+	}
+	return path
+}
+
+var (
+	errorMarkers = []string{"❌", "🚨", "ERROR", "Error:", "Server error"}
+	warnMarkers  = []string{"⚠️", "⚠", "Warning", "WARNING", "Failed"}
+)
+
+// classify infers a level from the message's leading marker and strips
+// the emoji, which structured consumers don't want
+func classify(msg string) (slog.Level, string) {
+	lvl := slog.LevelInfo
+	for _, m := range errorMarkers {
+		if strings.HasPrefix(msg, m) {
+			lvl = slog.LevelError
+		}
+	}
+	for _, m := range warnMarkers {
+		if strings.HasPrefix(msg, m) {
+			lvl = slog.LevelWarn
+		}
+	}
+	return lvl, strings.TrimLeftFunc(msg, func(r rune) bool {
+		return unicode.Is(unicode.So, r) || unicode.Is(unicode.Sk, r) || unicode.IsSpace(r) ||
+			r == '\uFE0F' || r == '\u200D' || unicode.Is(unicode.Mn, r)
+	})
+}
@@ -0,0 +1,230 @@
+package logship
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/events"
+)
+
+const (
+	batchSize     = 200
+	flushInterval = 10 * time.Second
+	queueSize     = 5000
+)
+
+// Sender delivers a batch of JSON records to the log destination
+type Sender func(records []json.RawMessage) error
+
+// Status reports what the forwarder has shipped and what is waiting
+type Status struct {
+	Destination string    `json:"destination"`
+	Shipped     int64     `json:"shipped"`
+	Queued      int       `json:"queued"`
+	SpooledKB   int64     `json:"spooled_kb"`
+	Dropped     int64     `json:"dropped"`
+	LastShipped time.Time `json:"last_shipped,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Forwarder batches the helper's log records and security events and ships
+// them off the endpoint, so an investigation doesn't depend on a log file
+// on a machine that may be compromised. Batches that can't be delivered are
+// spooled to disk and sent first once the destination is back.
+type Forwarder struct {
+	destination string
+	send        Sender
+	spool       *spool
+	queue       chan json.RawMessage
+
+	mutex      sync.Mutex
+	status     Status
+	stopSignal chan struct{}
+	done       chan struct{}
+}
+
+func New(destination string, send Sender, spoolDir string, spoolMB int) *Forwarder {
+	return &Forwarder{
+		destination: destination,
+		send:        send,
+		spool:       newSpool(spoolDir, int64(spoolMB)*1024*1024),
+		queue:       make(chan json.RawMessage, queueSize),
+		status:      Status{Destination: destination},
+	}
+}
+
+// Write takes one JSON record, as written by a slog JSON handler. It never
+// blocks the caller; records are dropped when the queue is full.
+func (f *Forwarder) Write(p []byte) (int, error) {
+	record := json.RawMessage(bytes.TrimSpace(append([]byte{}, p...)))
+	select {
+	case f.queue <- record:
+	default:
+		f.mutex.Lock()
+		f.status.Dropped++
+		f.mutex.Unlock()
+	}
+	return len(p), nil
+}
+
+// Start ships queued records in the background and forwards broker events
+func (f *Forwarder) Start(broker *events.Broker) {
+	f.mutex.Lock()
+	if f.stopSignal != nil {
+		f.mutex.Unlock()
+		return
+	}
+	f.stopSignal = make(chan struct{})
+	f.done = make(chan struct{})
+	stop, done := f.stopSignal, f.done
+	f.mutex.Unlock()
+
+	if broker != nil {
+		ch, unsubscribe := broker.Subscribe()
+		go func() {
+			defer unsubscribe()
+			for {
+				select {
+				case <-stop:
+					return
+				case ev, ok := <-ch:
+					if !ok {
+						return
+					}
+					f.event(ev)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+
+		batch := []json.RawMessage{}
+		for {
+			select {
+			case <-stop:
+				f.flush(f.drain(batch))
+				return
+			case record := <-f.queue:
+				batch = append(batch, record)
+				if len(batch) >= batchSize {
+					f.flush(batch)
+					batch = []json.RawMessage{}
+				}
+			case <-ticker.C:
+				f.flush(batch)
+				batch = []json.RawMessage{}
+			}
+		}
+	}()
+}
+
+// Stop ships what is queued (or spools it) and halts the forwarder
+func (f *Forwarder) Stop() {
+	f.mutex.Lock()
+	stop, done := f.stopSignal, f.done
+	f.stopSignal = nil
+	f.mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Status returns the forwarder's counters
+func (f *Forwarder) Status() Status {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	status := f.status
+	status.Queued = len(f.queue)
+	status.SpooledKB = f.spool.size() / 1024
+	return status
+}
+
+// event turns a broker event into a log record. Progress events are only
+// useful live and are left out.
+func (f *Forwarder) event(ev events.Event) {
+	if strings.HasSuffix(ev.Type, ".progress") {
+		return
+	}
+	record, err := json.Marshal(map[string]interface{}{
+		"time":      ev.Timestamp,
+		"level":     "INFO",
+		"msg":       ev.Type,
+		"component": "events",
+		"event":     ev.Data,
+	})
+	if err == nil {
+		f.Write(record)
+	}
+}
+
+// drain empties the queue into batch
+func (f *Forwarder) drain(batch []json.RawMessage) []json.RawMessage {
+	for {
+		select {
+		case record := <-f.queue:
+			batch = append(batch, record)
+		default:
+			return batch
+		}
+	}
+}
+
+// flush sends anything spooled, then batch. Whatever can't be sent is
+// spooled, so records are delivered in order once the destination is back.
+func (f *Forwarder) flush(batch []json.RawMessage) {
+	replayed, err := f.spool.replay(f.send, batchSize)
+	f.shipped(replayed)
+	if err == nil && len(batch) > 0 {
+		err = f.send(batch)
+		if err == nil {
+			f.shipped(len(batch))
+		}
+	}
+
+	f.mutex.Lock()
+	wasFailing := f.status.LastError != ""
+	if err != nil {
+		f.status.LastError = err.Error()
+	} else {
+		f.status.LastError = ""
+	}
+	f.mutex.Unlock()
+
+	if err == nil {
+		if wasFailing {
+			log.Printf("📤 Log shipping to %s resumed", f.destination)
+		}
+		return
+	}
+	// Logged once per outage; this record is itself queued for shipping
+	if !wasFailing {
+		log.Printf("⚠️ Log shipping to %s failed, spooling to disk: %v", f.destination, err)
+	}
+	if len(batch) > 0 {
+		if err := f.spool.append(batch); err != nil {
+			f.mutex.Lock()
+			f.status.Dropped += int64(len(batch))
+			f.mutex.Unlock()
+		}
+	}
+}
+
+func (f *Forwarder) shipped(n int) {
+	if n == 0 {
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.status.Shipped += int64(n)
+	f.status.LastShipped = time.Now()
+}
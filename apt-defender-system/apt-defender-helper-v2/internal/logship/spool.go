@@ -0,0 +1,136 @@
+package logship
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// spool keeps undelivered records on disk as JSON lines. When it would
+// grow past max the oldest records are discarded.
+type spool struct {
+	mutex sync.Mutex
+	path  string
+	max   int64
+}
+
+func newSpool(dir string, max int64) *spool {
+	return &spool{path: filepath.Join(dir, "spool.jsonl"), max: max}
+}
+
+func (s *spool) size() int64 {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (s *spool) append(batch []json.RawMessage) error {
+	if s.max <= 0 {
+		return fmt.Errorf("log spooling is disabled")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var buf bytes.Buffer
+	for _, record := range batch {
+		buf.Write(record)
+		buf.WriteByte('\n')
+	}
+
+	if s.size()+int64(buf.Len()) > s.max {
+		records, err := s.read()
+		if err != nil {
+			return err
+		}
+		records = append(records, batch...)
+		total := int64(0)
+		for _, r := range records {
+			total += int64(len(r)) + 1
+		}
+		for len(records) > 0 && total > s.max {
+			total -= int64(len(records[0])) + 1
+			records = records[1:]
+		}
+		return s.write(records)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(buf.Bytes())
+	return err
+}
+
+// replay sends the spooled records in chunks, keeping whatever wasn't
+// delivered, and returns how many were sent
+func (s *spool) replay(send Sender, chunk int) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	records, err := s.read()
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+
+	sent := 0
+	for sent < len(records) {
+		end := min(sent+chunk, len(records))
+		if err := send(records[sent:end]); err != nil {
+			if sent > 0 {
+				s.write(records[sent:])
+			}
+			return sent, err
+		}
+		sent = end
+	}
+	return sent, os.Remove(s.path)
+}
+
+func (s *spool) read() ([]json.RawMessage, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := []json.RawMessage{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			records = append(records, json.RawMessage(append([]byte{}, line...)))
+		}
+	}
+	return records, scanner.Err()
+}
+
+func (s *spool) write(records []json.RawMessage) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, record := range records {
+		buf.Write(record)
+		buf.WriteByte('\n')
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,71 @@
+package logship
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const facilityLocal0 = 16
+
+// Syslog returns a Sender that writes each record as an RFC 5424 message
+// with the JSON record as its body. address is host:port for UDP or
+// tcp://host:port for TCP (octet-counted framing).
+func Syslog(address string) Sender {
+	network := "udp"
+	if rest, ok := strings.CutPrefix(address, "tcp://"); ok {
+		network, address = "tcp", rest
+	}
+	address = strings.TrimPrefix(address, "udp://")
+	hostname, _ := os.Hostname()
+
+	return func(records []json.RawMessage) error {
+		if address == "" {
+			return fmt.Errorf("syslog_address is not set")
+		}
+		conn, err := net.DialTimeout(network, address, 10*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to reach syslog server %s: %w", address, err)
+		}
+		defer conn.Close()
+		conn.SetWriteDeadline(time.Now().Add(30 * time.Second))
+
+		for _, record := range records {
+			msg := syslogMessage(hostname, record)
+			if network == "tcp" {
+				msg = fmt.Sprintf("%d %s", len(msg), msg)
+			}
+			if _, err := conn.Write([]byte(msg)); err != nil {
+				return fmt.Errorf("failed to write to syslog server %s: %w", address, err)
+			}
+		}
+		return nil
+	}
+}
+
+func syslogMessage(hostname string, record json.RawMessage) string {
+	var fields struct {
+		Time  time.Time `json:"time"`
+		Level string    `json:"level"`
+	}
+	json.Unmarshal(record, &fields)
+	if fields.Time.IsZero() {
+		fields.Time = time.Now()
+	}
+
+	severity := 6 // informational
+	switch strings.ToUpper(fields.Level) {
+	case "DEBUG":
+		severity = 7
+	case "WARN":
+		severity = 4
+	case "ERROR":
+		severity = 3
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s apt-defender-helper %d - - %s",
+		facilityLocal0*8+severity, fields.Time.UTC().Format(time.RFC3339Nano), hostname, os.Getpid(), record)
+}
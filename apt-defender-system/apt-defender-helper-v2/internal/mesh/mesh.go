@@ -0,0 +1,205 @@
+package mesh
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/events"
+)
+
+const (
+	failureThreshold = 3
+	pingTimeout      = 5 * time.Second
+)
+
+const (
+	StatusUnknown = "unknown"
+	StatusUp      = "up"
+	StatusDown    = "down"
+)
+
+// Peer is another helper on the LAN whose health this helper watches
+type Peer struct {
+	Address             string    `json:"address"`
+	Status              string    `json:"status"`
+	LastSeen            time.Time `json:"last_seen,omitempty"`
+	LastChecked         time.Time `json:"last_checked,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// Reporter forwards mesh events to the Pi Agent
+type Reporter func(eventType string, data interface{}) error
+
+type Monitor struct {
+	mutex      sync.RWMutex
+	peers      map[string]*Peer
+	http       *http.Client
+	report     Reporter
+	events     *events.Broker
+	stopSignal chan struct{}
+}
+
+func New(addresses []string, report Reporter, broker *events.Broker) *Monitor {
+	m := &Monitor{
+		peers:  make(map[string]*Peer),
+		http:   &http.Client{Timeout: pingTimeout},
+		report: report,
+		events: broker,
+	}
+	m.SetPeers(addresses)
+	return m
+}
+
+// SetPeers replaces the watched peer list, keeping state for peers that remain
+func (m *Monitor) SetPeers(addresses []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	peers := make(map[string]*Peer, len(addresses))
+	for _, addr := range addresses {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if existing, ok := m.peers[addr]; ok {
+			peers[addr] = existing
+		} else {
+			peers[addr] = &Peer{Address: addr, Status: StatusUnknown}
+		}
+	}
+	m.peers = peers
+}
+
+// GetPeers returns the current view of every peer
+func (m *Monitor) GetPeers() []Peer {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make([]Peer, 0, len(m.peers))
+	for _, p := range m.peers {
+		result = append(result, *p)
+	}
+	return result
+}
+
+// Start pings every peer each interval
+func (m *Monitor) Start(interval time.Duration) {
+	m.mutex.Lock()
+	if m.stopSignal != nil {
+		m.mutex.Unlock()
+		return
+	}
+	m.stopSignal = make(chan struct{})
+	stop := m.stopSignal
+	m.mutex.Unlock()
+
+	log.Printf("🕸️ LAN health mesh started (%d peers, every %s)", len(m.GetPeers()), interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				m.checkAll()
+			}
+		}
+	}()
+}
+
+// Stop halts peer checks
+func (m *Monitor) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.stopSignal != nil {
+		close(m.stopSignal)
+		m.stopSignal = nil
+	}
+}
+
+func (m *Monitor) checkAll() {
+	var wg sync.WaitGroup
+	for _, p := range m.GetPeers() {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			m.check(addr)
+		}(p.Address)
+	}
+	wg.Wait()
+}
+
+func (m *Monitor) check(addr string) {
+	err := m.ping(addr)
+	now := time.Now()
+
+	m.mutex.Lock()
+	peer, ok := m.peers[addr]
+	if !ok {
+		m.mutex.Unlock()
+		return
+	}
+
+	peer.LastChecked = now
+	previous := peer.Status
+	if err == nil {
+		peer.Status = StatusUp
+		peer.LastSeen = now
+		peer.ConsecutiveFailures = 0
+		peer.LastError = ""
+	} else {
+		peer.ConsecutiveFailures++
+		peer.LastError = err.Error()
+		if peer.ConsecutiveFailures >= failureThreshold {
+			peer.Status = StatusDown
+		}
+	}
+	snapshot := *peer
+	m.mutex.Unlock()
+
+	switch {
+	case snapshot.Status == StatusDown && previous != StatusDown:
+		log.Printf("🚨 Peer helper %s is DOWN: %s", addr, snapshot.LastError)
+		m.notify("peer.down", snapshot)
+	case snapshot.Status == StatusUp && previous == StatusDown:
+		log.Printf("✅ Peer helper %s recovered", addr)
+		m.notify("peer.recovered", snapshot)
+	}
+}
+
+func (m *Monitor) notify(eventType string, peer Peer) {
+	m.events.Publish(eventType, peer)
+	if m.report == nil {
+		return
+	}
+	if err := m.report(eventType, peer); err != nil {
+		log.Printf("⚠️ Could not report %s for %s to Pi Agent: %v", eventType, peer.Address, err)
+	}
+}
+
+func (m *Monitor) ping(addr string) error {
+	url := addr
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "http://" + url
+	}
+
+	resp, err := m.http.Get(strings.TrimSuffix(url, "/") + "/api/v1/health")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health returned %d", resp.StatusCode)
+	}
+	return nil
+}
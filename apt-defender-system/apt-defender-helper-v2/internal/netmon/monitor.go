@@ -0,0 +1,314 @@
+package netmon
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/events"
+	"github.com/apt-defender/helper-v2/internal/telemetry"
+)
+
+const (
+	maxHistory        = 5000
+	maxSessionsPerKey = 20
+	maxFindings       = 500
+	sessionWindow     = 24 * time.Hour // sessions and findings not seen for this long are forgotten
+	minBeaconSamples  = 5
+	maxBeaconJitter   = 0.25 // stddev / mean of connection intervals
+	minBeaconInterval = 5 * time.Second
+	ruleVersion       = "1"
+)
+
+// Observation is a connection tracked across polls
+type Observation struct {
+	telemetry.Connection
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Finding describes a periodic, beacon-like connection pattern
+type Finding struct {
+	ID              string        `json:"id"`
+	Type            string        `json:"type"`
+	PID             uint32        `json:"pid"`
+	ProcessName     string        `json:"process_name"`
+	RemoteAddress   string        `json:"remote_address"`
+	RemotePort      uint16        `json:"remote_port"`
+	IntervalSeconds float64       `json:"interval_seconds"`
+	Jitter          float64       `json:"jitter"`
+	Samples         int           `json:"samples"`
+	FirstSeen       time.Time     `json:"first_seen"`
+	LastSeen        time.Time     `json:"last_seen"`
+	Source          events.Source `json:"source"`
+}
+
+type Monitor struct {
+	mutex      sync.RWMutex
+	active     map[string]*Observation
+	history    []Observation
+	sessions   map[string][]time.Time
+	findings   map[string]*Finding
+	stopSignal chan struct{}
+}
+
+func New() *Monitor {
+	return &Monitor{
+		active:   make(map[string]*Observation),
+		history:  []Observation{},
+		sessions: make(map[string][]time.Time),
+		findings: make(map[string]*Finding),
+	}
+}
+
+// Start polls the connection table every interval
+func (m *Monitor) Start(interval time.Duration) {
+	m.mutex.Lock()
+	if m.stopSignal != nil {
+		m.mutex.Unlock()
+		return
+	}
+	m.stopSignal = make(chan struct{})
+	stop := m.stopSignal
+	m.mutex.Unlock()
+
+	log.Printf("📶 Network connection monitor started (every %s)", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		m.poll()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				m.poll()
+			}
+		}
+	}()
+}
+
+// Stop halts background polling
+func (m *Monitor) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.stopSignal != nil {
+		close(m.stopSignal)
+		m.stopSignal = nil
+	}
+}
+
+// GetActive returns connections present in the latest poll
+func (m *Monitor) GetActive() []Observation {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make([]Observation, 0, len(m.active))
+	for _, obs := range m.active {
+		result = append(result, *obs)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FirstSeen.After(result[j].FirstSeen)
+	})
+
+	return result
+}
+
+// GetHistory returns closed connections, newest first
+func (m *Monitor) GetHistory(limit int) []Observation {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := []Observation{}
+	for i := len(m.history) - 1; i >= 0; i-- {
+		result = append(result, m.history[i])
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+
+	return result
+}
+
+// GetFindings returns all beacon findings
+func (m *Monitor) GetFindings() []Finding {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make([]Finding, 0, len(m.findings))
+	for _, f := range m.findings {
+		result = append(result, *f)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeen.After(result[j].LastSeen)
+	})
+
+	return result
+}
+
+func (m *Monitor) poll() {
+	connections, err := telemetry.GetNetworkConnections()
+	if err != nil {
+		log.Printf("Network monitor poll error: %v", err)
+		return
+	}
+
+	now := time.Now()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	seen := make(map[string]bool, len(connections))
+	for _, conn := range connections {
+		if conn.State == "LISTEN" {
+			continue
+		}
+
+		key := connectionKey(conn)
+		seen[key] = true
+
+		if obs, ok := m.active[key]; ok {
+			obs.LastSeen = now
+			obs.State = conn.State
+			continue
+		}
+
+		m.active[key] = &Observation{Connection: conn, FirstSeen: now, LastSeen: now}
+		if isExternal(conn.RemoteAddress) {
+			m.recordSession(conn, now)
+		}
+	}
+
+	// Move connections that disappeared into history
+	for key, obs := range m.active {
+		if seen[key] {
+			continue
+		}
+		m.history = append(m.history, *obs)
+		delete(m.active, key)
+	}
+	if len(m.history) > maxHistory {
+		m.history = m.history[len(m.history)-maxHistory:]
+	}
+
+	m.prune(now)
+}
+
+// prune forgets process/IP pairs that haven't connected within the session
+// window and keeps the newest maxFindings findings, so short-lived
+// processes don't grow the maps for as long as the helper runs
+func (m *Monitor) prune(now time.Time) {
+	cutoff := now.Add(-sessionWindow)
+	for key, sessions := range m.sessions {
+		if sessions[len(sessions)-1].Before(cutoff) {
+			delete(m.sessions, key)
+		}
+	}
+	for key, f := range m.findings {
+		if f.LastSeen.Before(cutoff) {
+			delete(m.findings, key)
+		}
+	}
+
+	if len(m.findings) <= maxFindings {
+		return
+	}
+	keys := make([]string, 0, len(m.findings))
+	for key := range m.findings {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return m.findings[keys[i]].LastSeen.After(m.findings[keys[j]].LastSeen)
+	})
+	for _, key := range keys[maxFindings:] {
+		delete(m.findings, key)
+	}
+}
+
+// recordSession tracks new connections per process and remote IP and checks
+// whether their start times are suspiciously regular
+func (m *Monitor) recordSession(conn telemetry.Connection, at time.Time) {
+	key := fmt.Sprintf("%d|%s", conn.PID, conn.RemoteAddress)
+
+	sessions := append(m.sessions[key], at)
+	if len(sessions) > maxSessionsPerKey {
+		sessions = sessions[len(sessions)-maxSessionsPerKey:]
+	}
+	m.sessions[key] = sessions
+
+	if len(sessions) < minBeaconSamples {
+		return
+	}
+
+	mean, jitter := intervalStats(sessions)
+	if mean < minBeaconInterval.Seconds() || jitter > maxBeaconJitter {
+		return
+	}
+
+	finding, exists := m.findings[key]
+	if !exists {
+		finding = &Finding{
+			ID:            fmt.Sprintf("beacon-%d-%s", conn.PID, conn.RemoteAddress),
+			Type:          "beacon",
+			PID:           conn.PID,
+			ProcessName:   conn.ProcessName,
+			RemoteAddress: conn.RemoteAddress,
+			RemotePort:    conn.RemotePort,
+			FirstSeen:     sessions[0],
+			Source:        events.NewSource(events.ModuleNetwork, "netmon.beacon", ruleVersion),
+		}
+		m.findings[key] = finding
+		log.Printf("🚩 Beacon-like traffic: %s (PID %d) -> %s:%d every %.0fs",
+			conn.ProcessName, conn.PID, conn.RemoteAddress, conn.RemotePort, mean)
+	}
+
+	finding.IntervalSeconds = mean
+	finding.Jitter = jitter
+	finding.Samples = len(sessions)
+	finding.LastSeen = at
+}
+
+// intervalStats returns the mean interval in seconds and its relative stddev
+func intervalStats(times []time.Time) (float64, float64) {
+	intervals := make([]float64, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		intervals = append(intervals, times[i].Sub(times[i-1]).Seconds())
+	}
+
+	sum := 0.0
+	for _, v := range intervals {
+		sum += v
+	}
+	mean := sum / float64(len(intervals))
+	if mean == 0 {
+		return 0, math.Inf(1)
+	}
+
+	variance := 0.0
+	for _, v := range intervals {
+		variance += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(intervals)))
+
+	return mean, stddev / mean
+}
+
+func connectionKey(c telemetry.Connection) string {
+	return fmt.Sprintf("%d|%s:%d|%s:%d", c.PID, c.LocalAddress, c.LocalPort, c.RemoteAddress, c.RemotePort)
+}
+
+func isExternal(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsMulticast()
+}
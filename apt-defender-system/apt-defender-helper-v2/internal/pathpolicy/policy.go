@@ -0,0 +1,201 @@
+package pathpolicy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+)
+
+const fileAttributeReparsePoint = 0x400
+
+// Policy decides which filesystem paths remote file operations may touch
+type Policy struct {
+	denied    []string
+	overrides []string
+}
+
+// New builds a policy from the built-in protected paths plus any configured
+// extras. Overrides carve explicit exceptions out of the denied set.
+func New(extraDenied, overrides []string) *Policy {
+	p := &Policy{}
+
+	for _, path := range append(defaultDenied(), extraDenied...) {
+		if clean := cleanPath(path); clean != "" {
+			p.denied = append(p.denied, clean)
+		}
+	}
+	for _, path := range overrides {
+		if clean := cleanPath(path); clean != "" {
+			p.overrides = append(p.overrides, clean)
+		}
+	}
+
+	return p
+}
+
+// Check canonicalizes the path and returns it if the policy permits access
+func (p *Policy) Check(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	// Reject namespace prefixes that bypass Win32 path normalization
+	if strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) ||
+		strings.HasPrefix(path, `//?/`) || strings.HasPrefix(path, `//./`) {
+		return "", fmt.Errorf("device and extended-length paths are not allowed")
+	}
+	if strings.HasPrefix(path, `\\`) || strings.HasPrefix(path, `//`) {
+		return "", fmt.Errorf("UNC paths are not allowed")
+	}
+	if !filepath.IsAbs(path) {
+		return "", fmt.Errorf("path must be absolute")
+	}
+
+	// A colon anywhere past the drive letter addresses an alternate data stream
+	if strings.Contains(path[2:], ":") {
+		return "", fmt.Errorf("alternate data streams are not allowed")
+	}
+
+	canonical, err := canonicalize(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	if p.isDenied(canonical) {
+		return "", fmt.Errorf("access to protected path denied: %s", canonical)
+	}
+
+	return canonical, nil
+}
+
+// canonicalize expands short names in the longest part of path that exists
+// and appends the rest, so a path that doesn't exist yet (a restore target,
+// folders MkdirAll is about to create) is judged by where it would land. A
+// reparse point anywhere on that existing part could redirect the rest into
+// a protected folder, so any of them is refused.
+func canonicalize(path string) (string, error) {
+	existing := path
+	var rest []string
+	for {
+		if _, err := getAttributes(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+
+	long := expandShortName(existing)
+	for dir := long; ; dir = filepath.Dir(dir) {
+		if attrs, err := getAttributes(dir); err == nil && attrs&fileAttributeReparsePoint != 0 {
+			return "", fmt.Errorf("reparse points (symlinks/junctions) are not allowed: %s", dir)
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	return filepath.Join(append([]string{long}, rest...)...), nil
+}
+
+func (p *Policy) isDenied(path string) bool {
+	for _, override := range p.overrides {
+		if isWithin(path, override) {
+			return false
+		}
+	}
+	for _, denied := range p.denied {
+		if isWithin(path, denied) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultDenied lists system-critical locations and the helper's own files
+func defaultDenied() []string {
+	systemRoot := os.Getenv("SystemRoot")
+	if systemRoot == "" {
+		systemRoot = `C:\Windows`
+	}
+	systemDrive := os.Getenv("SystemDrive")
+	if systemDrive == "" {
+		systemDrive = "C:"
+	}
+
+	denied := []string{
+		systemRoot,
+		systemDrive + `\bootmgr`,
+		systemDrive + `\Boot`,
+		systemDrive + `\Recovery`,
+		systemDrive + `\System Volume Information`,
+		systemDrive + `\pagefile.sys`,
+		systemDrive + `\hiberfil.sys`,
+		systemDrive + `\swapfile.sys`,
+		config.GetDataDir(),
+	}
+
+	// The install folder holds the binary, its update staging files and logs
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		denied = append(denied, filepath.Dir(exe))
+	}
+
+	return denied
+}
+
+// isWithin reports whether path equals dir or lives beneath it (case-insensitive)
+func isWithin(path, dir string) bool {
+	if strings.EqualFold(path, dir) {
+		return true
+	}
+	prefix := strings.TrimSuffix(dir, `\`) + `\`
+	return len(path) > len(prefix) && strings.EqualFold(path[:len(prefix)], prefix)
+}
+
+func cleanPath(path string) string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return ""
+	}
+	return filepath.Clean(config.ExpandPath(path))
+}
+
+// expandShortName converts 8.3 names (PROGRA~1) to their long form
+func expandShortName(path string) string {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return path
+	}
+
+	buf := make([]uint16, syscall.MAX_PATH)
+	n, err := syscall.GetLongPathName(pathPtr, &buf[0], uint32(len(buf)))
+	if err != nil || n == 0 {
+		return path
+	}
+	if n > uint32(len(buf)) {
+		buf = make([]uint16, n)
+		n, err = syscall.GetLongPathName(pathPtr, &buf[0], uint32(len(buf)))
+		if err != nil || n == 0 {
+			return path
+		}
+	}
+
+	return syscall.UTF16ToString(buf[:n])
+}
+
+func getAttributes(path string) (uint32, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	return syscall.GetFileAttributes(pathPtr)
+}
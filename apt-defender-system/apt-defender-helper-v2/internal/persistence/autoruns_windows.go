@@ -0,0 +1,343 @@
+package persistence
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/authenticode"
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const (
+	codecKey        = `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\Drivers32`
+	codecKeyWow64   = `HKLM\SOFTWARE\WOW6432Node\Microsoft\Windows NT\CurrentVersion\Drivers32`
+	lsaKey          = `HKLM\SYSTEM\CurrentControlSet\Control\Lsa`
+	lsaOSConfigKey  = `HKLM\SYSTEM\CurrentControlSet\Control\Lsa\OSConfig`
+	bhoKey          = `HKLM\SOFTWARE\Microsoft\Windows\CurrentVersion\Explorer\Browser Helper Objects`
+	bhoKeyWow64     = `HKLM\SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Explorer\Browser Helper Objects`
+	printMonitorKey = `HKLM\SYSTEM\CurrentControlSet\Control\Print\Monitors`
+	appInitKey      = `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\Windows`
+	appInitKeyWow64 = `HKLM\SOFTWARE\WOW6432Node\Microsoft\Windows NT\CurrentVersion\Windows`
+	netshKey        = `HKLM\SOFTWARE\Microsoft\NetSh`
+	timeProviderKey = `HKLM\SYSTEM\CurrentControlSet\Services\W32Time\TimeProviders`
+)
+
+var lsaPackageValues = []string{"Authentication Packages", "Notification Packages", "Security Packages"}
+
+// autorunSources are the deeper load points Sysinternals Autoruns covers
+var autorunSources = []func() []Entry{
+	drivers,
+	codecs,
+	lsaProviders,
+	browserHelperObjects,
+	printMonitors,
+	appInitDLLs,
+	netshHelpers,
+	timeProviders,
+}
+
+// GetAutoruns returns the persistence listing plus the deeper load points
+// Sysinternals Autoruns covers, with Authenticode status for every image
+func GetAutoruns() ([]Entry, error) {
+	var entries []Entry
+	err := EachAutorun(func(batch []Entry) error {
+		entries = append(entries, batch...)
+		return nil
+	})
+	return entries, err
+}
+
+// EachAutorun hands fn the autoruns one location at a time, signatures
+// already checked, so callers can stream them as they are found. It stops
+// at the first error fn returns.
+func EachAutorun(fn func([]Entry) error) error {
+	sources := append(append([]func() []Entry{}, persistenceSources...), autorunSources...)
+	for _, source := range sources {
+		batch := source()
+		if len(batch) == 0 {
+			continue
+		}
+		applySignatures(batch)
+		if err := fn(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func drivers() []Entry {
+	var entries []Entry
+
+	root, err := winreg.Open(servicesKey)
+	if err != nil {
+		return entries
+	}
+	defer root.Close()
+
+	for _, name := range root.SubKeys() {
+		key, err := winreg.Open(servicesKey + `\` + name)
+		if err != nil {
+			continue
+		}
+		svcType, _ := key.GetUint("Type")
+		imagePath, _ := key.GetString("ImagePath")
+		start, _ := key.GetUint("Start")
+		key.Close()
+
+		// Kernel and file system drivers; disabled ones never load
+		if svcType&0x3 == 0 || start == 4 {
+			continue
+		}
+		if imagePath == "" {
+			imagePath = `System32\drivers\` + name + ".sys"
+		}
+
+		e := newEntry(CategoryDriver, servicesKey, name, imagePath)
+		e.Details = map[string]string{"start_type": startTypeName(start)}
+		e.setImage(ImageFromCommand(imagePath))
+		if e.ImagePath != "" && !isStandardLocation(e.ImagePath) {
+			e.flag("driver outside system directories")
+		}
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func codecs() []Entry {
+	var entries []Entry
+
+	for _, path := range []string{codecKey, codecKeyWow64} {
+		key, err := winreg.Open(path)
+		if err != nil {
+			continue
+		}
+		for _, v := range key.Values() {
+			if v.Data == "" {
+				continue
+			}
+			e := newEntry(CategoryCodec, key.Path, v.Name, v.Data)
+			e.setImage(resolveSystemDLL(v.Data))
+			entries = append(entries, e)
+		}
+		key.Close()
+	}
+
+	return entries
+}
+
+func lsaProviders() []Entry {
+	var entries []Entry
+
+	for _, path := range []string{lsaKey, lsaOSConfigKey} {
+		key, err := winreg.Open(path)
+		if err != nil {
+			continue
+		}
+		for _, valueName := range lsaPackageValues {
+			value, err := key.GetString(valueName)
+			if err != nil {
+				continue
+			}
+			for _, pkg := range strings.Split(value, "; ") {
+				pkg = strings.Trim(strings.TrimSpace(pkg), `"`)
+				if pkg == "" {
+					continue
+				}
+				e := newEntry(CategoryLSAProvider, key.Path, valueName+": "+pkg, pkg)
+				e.setImage(resolveSystemDLL(pkg))
+				entries = append(entries, e)
+			}
+		}
+		key.Close()
+	}
+
+	return entries
+}
+
+func browserHelperObjects() []Entry {
+	var entries []Entry
+
+	for _, path := range []string{bhoKey, bhoKeyWow64} {
+		root, err := winreg.Open(path)
+		if err != nil {
+			continue
+		}
+		for _, clsid := range root.SubKeys() {
+			server := clsidServer(clsid)
+			e := newEntry(CategoryBHO, root.Path, clsid, server)
+			e.setImage(ImageFromCommand(server))
+			assessCommand(&e)
+			entries = append(entries, e)
+		}
+		root.Close()
+	}
+
+	return entries
+}
+
+func printMonitors() []Entry {
+	var entries []Entry
+
+	root, err := winreg.Open(printMonitorKey)
+	if err != nil {
+		return entries
+	}
+	defer root.Close()
+
+	for _, name := range root.SubKeys() {
+		key, err := winreg.Open(printMonitorKey + `\` + name)
+		if err != nil {
+			continue
+		}
+		driver, err := key.GetString("Driver")
+		key.Close()
+		if err != nil || driver == "" {
+			continue
+		}
+		e := newEntry(CategoryPrintMonitor, printMonitorKey, name, driver)
+		e.setImage(resolveSystemDLL(driver))
+		if e.ImagePath != "" && !isStandardLocation(e.ImagePath) {
+			e.flag("print monitor outside system directories")
+		}
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func appInitDLLs() []Entry {
+	var entries []Entry
+
+	for _, path := range []string{appInitKey, appInitKeyWow64} {
+		key, err := winreg.Open(path)
+		if err != nil {
+			continue
+		}
+		value, _ := key.GetString("AppInit_DLLs")
+		enabled, _ := key.GetUint("LoadAppInit_DLLs")
+		key.Close()
+
+		for _, dll := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			e := newEntry(CategoryAppInit, path, dll, dll)
+			e.setImage(ImageFromCommand(dll))
+			if enabled != 0 {
+				e.flag("AppInit DLL loaded into every GUI process")
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+func netshHelpers() []Entry {
+	var entries []Entry
+
+	key, err := winreg.Open(netshKey)
+	if err != nil {
+		return entries
+	}
+	defer key.Close()
+
+	for _, v := range key.Values() {
+		e := newEntry(CategoryNetshHelper, key.Path, v.Name, v.Data)
+		e.setImage(resolveSystemDLL(v.Data))
+		if e.ImagePath != "" && !isStandardLocation(e.ImagePath) {
+			e.flag("netsh helper outside system directories")
+		}
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func timeProviders() []Entry {
+	var entries []Entry
+
+	root, err := winreg.Open(timeProviderKey)
+	if err != nil {
+		return entries
+	}
+	defer root.Close()
+
+	for _, name := range root.SubKeys() {
+		key, err := winreg.Open(timeProviderKey + `\` + name)
+		if err != nil {
+			continue
+		}
+		dll, _ := key.GetString("DllName")
+		key.Close()
+		if dll == "" {
+			continue
+		}
+		e := newEntry(CategoryTimeProvider, timeProviderKey, name, dll)
+		e.setImage(ImageFromCommand(dll))
+		if e.ImagePath != "" && !isStandardLocation(e.ImagePath) {
+			e.flag("time provider outside system directories")
+		}
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+// clsidServer returns the in-process or local server registered for a CLSID
+func clsidServer(clsid string) string {
+	for _, server := range comServerKeys {
+		key, err := winreg.Open(machineCLSID + `\` + clsid + `\` + server)
+		if err != nil {
+			continue
+		}
+		value, err := key.GetString("")
+		key.Close()
+		if err == nil && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// resolveSystemDLL maps bare module names (e.g. "msv1_0") to System32
+func resolveSystemDLL(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	if filepath.Ext(name) == "" {
+		name += ".dll"
+	}
+	if filepath.IsAbs(name) || strings.ContainsAny(name, `%\`) {
+		return ImageFromCommand(name)
+	}
+
+	candidate := filepath.Join(systemRoot(), "System32", name)
+	if _, err := os.Stat(candidate); err == nil {
+		return candidate
+	}
+	return ImageFromCommand(name)
+}
+
+// applySignatures records the Authenticode status of every image
+func applySignatures(entries []Entry) {
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		paths = append(paths, e.ImagePath)
+	}
+	results := authenticode.Check(paths)
+
+	for i := range entries {
+		r, ok := results[strings.ToLower(entries[i].ImagePath)]
+		if !ok {
+			continue
+		}
+		entries[i].Signature = r.Status
+		entries[i].Signer = r.Signer
+		if r.Status == authenticode.StatusHashMismatch {
+			entries[i].flag("Authenticode hash mismatch")
+		} else if r.Status == authenticode.StatusNotSigned && !isStandardLocation(entries[i].ImagePath) {
+			entries[i].flag("unsigned image outside system/program directories")
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package persistence
+
+import (
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const machineCLSID = `HKLM\SOFTWARE\Classes\CLSID`
+
+var comServerKeys = []string{"InprocServer32", "LocalServer32"}
+
+// comHijacks finds per-user CLSID registrations that shadow a machine-wide
+// class. COM resolves HKCU before HKLM, so these silently redirect every
+// process of that user that instantiates the class.
+func comHijacks() []Entry {
+	var entries []Entry
+
+	users, err := winreg.Open(`HKU\`)
+	if err != nil {
+		return entries
+	}
+	defer users.Close()
+
+	for _, hive := range users.SubKeys() {
+		if !strings.HasSuffix(hive, "_Classes") {
+			continue
+		}
+		root, err := winreg.Open(`HKU\` + hive + `\CLSID`)
+		if err != nil {
+			continue
+		}
+
+		for _, clsid := range root.SubKeys() {
+			for _, server := range comServerKeys {
+				key, err := winreg.Open(root.Path + `\` + clsid + `\` + server)
+				if err != nil {
+					continue
+				}
+				command, err := key.GetString("")
+				key.Close()
+				if err != nil || command == "" {
+					continue
+				}
+
+				e := newEntry(CategoryCOMHijack, root.Path+`\`+clsid, server, command)
+				e.setImage(ImageFromCommand(command))
+				assessCommand(&e)
+
+				if machine, err := winreg.Open(machineCLSID + `\` + clsid + `\` + server); err == nil {
+					if original, err := machine.GetString(""); err == nil {
+						e.Details = map[string]string{"machine_server": original}
+					}
+					machine.Close()
+					e.flag("per-user CLSID overrides machine registration")
+				}
+
+				// Plain per-user registrations (OneDrive, Teams, ...) are only
+				// reported when something about them looks wrong
+				if e.Suspicious {
+					entries = append(entries, e)
+				}
+			}
+		}
+		root.Close()
+	}
+
+	return entries
+}
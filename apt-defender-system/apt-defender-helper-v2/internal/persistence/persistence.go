@@ -0,0 +1,102 @@
+package persistence
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Persistence categories shared by every platform so the Pi can treat
+// entries uniformly
+const (
+	CategoryRegistryRun   = "registry_run"
+	CategoryStartupFolder = "startup_folder"
+	CategoryScheduledTask = "scheduled_task"
+	CategoryService       = "service"
+	CategoryWinlogon      = "winlogon"
+	CategoryIFEO          = "ifeo"
+	CategoryWMI           = "wmi_subscription"
+	CategoryCOMHijack     = "com_hijack"
+	CategoryDriver        = "driver"
+	CategoryCodec         = "codec"
+	CategoryLSAProvider   = "lsa_provider"
+	CategoryBHO           = "browser_helper_object"
+	CategoryPrintMonitor  = "print_monitor"
+	CategoryAppInit       = "appinit_dll"
+	CategoryNetshHelper   = "netsh_helper"
+	CategoryTimeProvider  = "time_provider"
+)
+
+// Entry is a single autostart location and what it launches
+type Entry struct {
+	ID         string            `json:"id"`
+	Category   string            `json:"category"`
+	Location   string            `json:"location"`
+	Name       string            `json:"name"`
+	Command    string            `json:"command"`
+	ImagePath  string            `json:"image_path,omitempty"`
+	SHA256     string            `json:"sha256,omitempty"`
+	Signature  string            `json:"signature,omitempty"` // Authenticode status, autoruns listing only
+	Signer     string            `json:"signer,omitempty"`
+	Suspicious bool              `json:"suspicious"`
+	Reason     string            `json:"reason,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// newEntry builds an entry with a stable ID derived from where it lives
+func newEntry(category, location, name, command string) Entry {
+	sum := sha1.Sum([]byte(category + "|" + location + "|" + name))
+	return Entry{
+		ID:       fmt.Sprintf("%x", sum[:8]),
+		Category: category,
+		Location: location,
+		Name:     name,
+		Command:  command,
+	}
+}
+
+// flag marks an entry suspicious, accumulating reasons
+func (e *Entry) flag(reason string) {
+	e.Suspicious = true
+	if e.Reason == "" {
+		e.Reason = reason
+	} else {
+		e.Reason += "; " + reason
+	}
+}
+
+// setImage records the launched binary and its hash when it exists on disk
+func (e *Entry) setImage(path string) {
+	if path == "" {
+		return
+	}
+	e.ImagePath = path
+
+	info, err := os.Stat(path)
+	if err != nil {
+		e.flag("image file missing")
+		return
+	}
+	if info.IsDir() {
+		return
+	}
+	if hash, err := hashFile(path); err == nil {
+		e.SHA256 = hash
+	}
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
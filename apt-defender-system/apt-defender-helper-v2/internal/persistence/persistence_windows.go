@@ -0,0 +1,496 @@
+package persistence
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const (
+	winlogonKey = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Winlogon`
+	ifeoKey     = `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\Image File Execution Options`
+	silentKey   = `HKLM\SOFTWARE\Microsoft\Windows NT\CurrentVersion\SilentProcessExit`
+	servicesKey = `HKLM\SYSTEM\CurrentControlSet\Services`
+)
+
+var runKeySuffixes = []string{
+	`SOFTWARE\Microsoft\Windows\CurrentVersion\Run`,
+	`SOFTWARE\Microsoft\Windows\CurrentVersion\RunOnce`,
+	`SOFTWARE\Microsoft\Windows\CurrentVersion\Policies\Explorer\Run`,
+	`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Run`,
+	`SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\RunOnce`,
+}
+
+// persistenceSources are the Windows autostart locations, in listing order
+var persistenceSources = []func() []Entry{
+	runKeys,
+	startupFolders,
+	scheduledTasks,
+	services,
+	winlogonHooks,
+	ifeoHooks,
+	wmiSubscriptions,
+	comHijacks,
+}
+
+// GetPersistence enumerates Windows autostart locations
+func GetPersistence() ([]Entry, error) {
+	var entries []Entry
+	for _, source := range persistenceSources {
+		entries = append(entries, source()...)
+	}
+	return entries, nil
+}
+
+func runKeys() []Entry {
+	var entries []Entry
+
+	for _, hive := range hivePrefixes() {
+		for _, suffix := range runKeySuffixes {
+			key, err := winreg.Open(hive + `\` + suffix)
+			if err != nil {
+				continue
+			}
+			for _, v := range key.Values() {
+				e := newEntry(CategoryRegistryRun, key.Path, v.Name, v.Data)
+				e.setImage(ImageFromCommand(v.Data))
+				assessCommand(&e)
+				entries = append(entries, e)
+			}
+			key.Close()
+		}
+	}
+
+	return entries
+}
+
+func startupFolders() []Entry {
+	var entries []Entry
+
+	for _, dir := range startupDirs() {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			if f.IsDir() || strings.EqualFold(f.Name(), "desktop.ini") {
+				continue
+			}
+			path := filepath.Join(dir, f.Name())
+			e := newEntry(CategoryStartupFolder, dir, f.Name(), path)
+			// Shortcut targets need COM to resolve; hash the startup item itself
+			e.setImage(path)
+			assessCommand(&e)
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// startupDirs returns the machine-wide and per-user Startup folders
+func startupDirs() []string {
+	programData := os.Getenv("ProgramData")
+	if programData == "" {
+		programData = `C:\ProgramData`
+	}
+	dirs := []string{filepath.Join(programData, `Microsoft\Windows\Start Menu\Programs\StartUp`)}
+
+	for _, profile := range userProfiles() {
+		dirs = append(dirs, filepath.Join(profile, `AppData\Roaming\Microsoft\Windows\Start Menu\Programs\Startup`))
+	}
+
+	return dirs
+}
+
+type taskXML struct {
+	RegistrationInfo struct {
+		Author string `xml:"Author"`
+		URI    string `xml:"URI"`
+	} `xml:"RegistrationInfo"`
+	Settings struct {
+		Enabled string `xml:"Enabled"`
+		Hidden  string `xml:"Hidden"`
+	} `xml:"Settings"`
+	Principals struct {
+		Principal []struct {
+			UserID   string `xml:"UserId"`
+			RunLevel string `xml:"RunLevel"`
+		} `xml:"Principal"`
+	} `xml:"Principals"`
+	Actions struct {
+		Exec []struct {
+			Command   string `xml:"Command"`
+			Arguments string `xml:"Arguments"`
+		} `xml:"Exec"`
+		ComHandler []struct {
+			ClassID string `xml:"ClassId"`
+		} `xml:"ComHandler"`
+	} `xml:"Actions"`
+}
+
+func scheduledTasks() []Entry {
+	var entries []Entry
+	root := filepath.Join(systemRoot(), "System32", "Tasks")
+
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+
+		task, err := parseTaskFile(path)
+		if err != nil {
+			return nil
+		}
+
+		name := strings.TrimPrefix(path, root)
+		details := map[string]string{
+			"author":  task.RegistrationInfo.Author,
+			"enabled": boolDefault(task.Settings.Enabled, "true"),
+			"hidden":  boolDefault(task.Settings.Hidden, "false"),
+		}
+		if len(task.Principals.Principal) > 0 {
+			details["user"] = task.Principals.Principal[0].UserID
+			details["run_level"] = task.Principals.Principal[0].RunLevel
+		}
+
+		for _, ex := range task.Actions.Exec {
+			command := strings.TrimSpace(ex.Command + " " + ex.Arguments)
+			e := newEntry(CategoryScheduledTask, root, name, command)
+			e.Details = details
+			e.setImage(ImageFromCommand(ex.Command))
+			assessCommand(&e)
+			if details["hidden"] == "true" {
+				e.flag("hidden task")
+			}
+			entries = append(entries, e)
+		}
+		for _, com := range task.Actions.ComHandler {
+			e := newEntry(CategoryScheduledTask, root, name, "COM "+com.ClassID)
+			e.Details = details
+			entries = append(entries, e)
+		}
+		return nil
+	})
+
+	return entries
+}
+
+// parseTaskFile decodes a Task Scheduler XML definition (usually UTF-16)
+func parseTaskFile(path string) (*taskXML, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data := toUTF8(raw)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil // already converted
+	}
+
+	var task taskXML
+	if err := decoder.Decode(&task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func services() []Entry {
+	var entries []Entry
+
+	root, err := winreg.Open(servicesKey)
+	if err != nil {
+		return entries
+	}
+	defer root.Close()
+
+	for _, name := range root.SubKeys() {
+		key, err := winreg.Open(servicesKey + `\` + name)
+		if err != nil {
+			continue
+		}
+
+		svcType, _ := key.GetUint("Type")
+		imagePath, _ := key.GetString("ImagePath")
+		start, _ := key.GetUint("Start")
+		account, _ := key.GetString("ObjectName")
+		key.Close()
+
+		// Only user-mode services; drivers are reported by the autoruns listing
+		if svcType&0x30 == 0 || imagePath == "" {
+			continue
+		}
+
+		image := ImageFromCommand(imagePath)
+		if isStandardLocation(image) {
+			continue
+		}
+
+		e := newEntry(CategoryService, servicesKey, name, imagePath)
+		e.Details = map[string]string{
+			"start_type": startTypeName(start),
+			"account":    account,
+		}
+		e.setImage(image)
+		e.flag("service image outside system/program directories")
+		assessCommand(&e)
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func winlogonHooks() []Entry {
+	var entries []Entry
+
+	expected := map[string]string{
+		"Shell":    "explorer.exe",
+		"Userinit": strings.ToLower(filepath.Join(systemRoot(), "system32", "userinit.exe")) + ",",
+	}
+
+	for _, hive := range hivePrefixes() {
+		key, err := winreg.Open(hive + `\` + winlogonKey)
+		if err != nil {
+			continue
+		}
+		for _, name := range []string{"Shell", "Userinit", "Taskman", "AppSetup"} {
+			value, err := key.GetString(name)
+			if err != nil || value == "" {
+				continue
+			}
+			e := newEntry(CategoryWinlogon, key.Path, name, value)
+			e.setImage(ImageFromCommand(value))
+			if want, ok := expected[name]; ok && hive == "HKLM" {
+				if strings.ToLower(strings.TrimSpace(value)) != want {
+					e.flag("non-default " + name + " value")
+				}
+			} else {
+				// Per-user Shell/Userinit overrides and Taskman/AppSetup are unusual
+				e.flag("uncommon Winlogon hook")
+			}
+			entries = append(entries, e)
+		}
+		key.Close()
+	}
+
+	return entries
+}
+
+func ifeoHooks() []Entry {
+	var entries []Entry
+
+	if root, err := winreg.Open(ifeoKey); err == nil {
+		for _, name := range root.SubKeys() {
+			key, err := winreg.Open(ifeoKey + `\` + name)
+			if err != nil {
+				continue
+			}
+			if debugger, err := key.GetString("Debugger"); err == nil && debugger != "" {
+				e := newEntry(CategoryIFEO, key.Path, name, debugger)
+				e.setImage(ImageFromCommand(debugger))
+				e.flag("IFEO debugger hijack")
+				entries = append(entries, e)
+			}
+			key.Close()
+		}
+		root.Close()
+	}
+
+	if root, err := winreg.Open(silentKey); err == nil {
+		for _, name := range root.SubKeys() {
+			key, err := winreg.Open(silentKey + `\` + name)
+			if err != nil {
+				continue
+			}
+			if monitor, err := key.GetString("MonitorProcess"); err == nil && monitor != "" {
+				e := newEntry(CategoryIFEO, key.Path, name, monitor)
+				e.setImage(ImageFromCommand(monitor))
+				e.flag("SilentProcessExit monitor process")
+				entries = append(entries, e)
+			}
+			key.Close()
+		}
+		root.Close()
+	}
+
+	return entries
+}
+
+// hivePrefixes returns HKLM plus every loaded user hive under HKU
+func hivePrefixes() []string {
+	prefixes := []string{"HKLM"}
+
+	users, err := winreg.Open(`HKU\`)
+	if err != nil {
+		return prefixes
+	}
+	defer users.Close()
+
+	for _, sid := range users.SubKeys() {
+		if strings.HasSuffix(sid, "_Classes") || sid == ".DEFAULT" {
+			continue
+		}
+		prefixes = append(prefixes, `HKU\`+sid)
+	}
+
+	return prefixes
+}
+
+func userProfiles() []string {
+	var profiles []string
+	usersDir := filepath.Join(os.Getenv("SystemDrive")+`\`, "Users")
+
+	dirs, err := os.ReadDir(usersDir)
+	if err != nil {
+		return profiles
+	}
+	for _, d := range dirs {
+		if d.IsDir() {
+			profiles = append(profiles, filepath.Join(usersDir, d.Name()))
+		}
+	}
+
+	return profiles
+}
+
+// ImageFromCommand extracts the executable path from a command line or
+// service ImagePath, expanding environment variables and NT prefixes
+func ImageFromCommand(command string) string {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return ""
+	}
+
+	var image string
+	if strings.HasPrefix(command, `"`) {
+		if end := strings.Index(command[1:], `"`); end >= 0 {
+			image = command[1 : end+1]
+		} else {
+			image = strings.Trim(command, `"`)
+		}
+	} else {
+		lower := strings.ToLower(command)
+		image = command
+		for _, ext := range []string{".exe", ".com", ".bat", ".cmd", ".dll", ".scr"} {
+			if idx := strings.Index(lower, ext); idx >= 0 {
+				image = command[:idx+len(ext)]
+				break
+			}
+		}
+		if image == command {
+			image, _, _ = strings.Cut(command, " ")
+		}
+	}
+
+	image = config.ExpandPath(image)
+	image = strings.TrimPrefix(image, `\??\`)
+	lower := strings.ToLower(image)
+	switch {
+	case strings.HasPrefix(lower, `\systemroot\`):
+		image = filepath.Join(systemRoot(), image[len(`\systemroot\`):])
+	case strings.HasPrefix(lower, `system32\`):
+		image = filepath.Join(systemRoot(), image)
+	}
+
+	// Bare names (e.g. "explorer.exe") are resolved against the Windows directories
+	if !filepath.IsAbs(image) && !strings.ContainsAny(image, `\/`) {
+		for _, dir := range []string{filepath.Join(systemRoot(), "System32"), systemRoot()} {
+			candidate := filepath.Join(dir, image)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+		}
+	}
+
+	return image
+}
+
+// isStandardLocation reports whether an image lives in an OS or program directory
+func isStandardLocation(image string) bool {
+	lower := strings.ToLower(image)
+	standard := []string{
+		strings.ToLower(systemRoot()) + `\`,
+		strings.ToLower(os.Getenv("ProgramFiles")) + `\`,
+		strings.ToLower(os.Getenv("ProgramFiles(x86)")) + `\`,
+		strings.ToLower(os.Getenv("ProgramData")) + `\microsoft\windows defender\`,
+	}
+	for _, prefix := range standard {
+		if prefix != `\` && strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// assessCommand applies generic red flags to any autostart command
+func assessCommand(e *Entry) {
+	lower := strings.ToLower(e.Command)
+	image := strings.ToLower(e.ImagePath)
+
+	for _, dir := range []string{`\appdata\local\temp\`, `\windows\temp\`, `\users\public\`, `\$recycle.bin\`} {
+		if strings.Contains(image, dir) {
+			e.flag("runs from user-writable temp location")
+			break
+		}
+	}
+	if strings.Contains(lower, "powershell") && (strings.Contains(lower, " -enc") || strings.Contains(lower, " -e ")) {
+		e.flag("encoded PowerShell command")
+	}
+	for _, lolbin := range []string{"mshta", "regsvr32", "rundll32", "wscript", "cscript", "certutil", "bitsadmin"} {
+		if strings.Contains(lower, lolbin) && strings.Contains(lower, "http") {
+			e.flag("LOLBin with remote URL")
+			break
+		}
+	}
+}
+
+func startTypeName(start uint64) string {
+	switch start {
+	case 0:
+		return "boot"
+	case 1:
+		return "system"
+	case 2:
+		return "automatic"
+	case 3:
+		return "manual"
+	case 4:
+		return "disabled"
+	}
+	return "unknown"
+}
+
+func boolDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return strings.ToLower(value)
+}
+
+func systemRoot() string {
+	if root := os.Getenv("SystemRoot"); root != "" {
+		return root
+	}
+	return `C:\Windows`
+}
+
+// toUTF8 converts UTF-16 (with BOM) task files to UTF-8
+func toUTF8(raw []byte) []byte {
+	if len(raw) >= 2 && raw[0] == 0xFF && raw[1] == 0xFE {
+		u := make([]uint16, (len(raw)-2)/2)
+		for i := range u {
+			u[i] = binary.LittleEndian.Uint16(raw[2+i*2:])
+		}
+		return []byte(string(utf16.Decode(u)))
+	}
+	return bytes.TrimPrefix(raw, []byte{0xEF, 0xBB, 0xBF})
+}
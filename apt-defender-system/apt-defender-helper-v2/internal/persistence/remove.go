@@ -0,0 +1,36 @@
+package persistence
+
+import "fmt"
+
+// Backup stores a copy of a persistence artefact (usually in quarantine)
+// and returns an ID that can later be used to restore it
+type Backup func(path, reason string) (string, error)
+
+// Removal describes what was removed and where its backup lives
+type Removal struct {
+	Entry    Entry  `json:"entry"`
+	BackupID string `json:"backup_id"`
+	Method   string `json:"method"`
+}
+
+// Remove deletes the autostart entry with the given ID after backing it up
+func Remove(id string, backup Backup) (*Removal, error) {
+	entries, err := GetPersistence()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.ID != id {
+			continue
+		}
+		removal, err := remove(e, backup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove %s %q: %w", e.Category, e.Name, err)
+		}
+		removal.Entry = e
+		return removal, nil
+	}
+
+	return nil, fmt.Errorf("persistence entry not found: %s", id)
+}
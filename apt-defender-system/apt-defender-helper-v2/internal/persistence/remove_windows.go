@@ -0,0 +1,99 @@
+package persistence
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/tasks"
+)
+
+func remove(e Entry, backup Backup) (*Removal, error) {
+	switch e.Category {
+	case CategoryRegistryRun:
+		return removeRegistryValue(e, backup)
+	case CategoryScheduledTask:
+		return removeScheduledTask(e, backup)
+	case CategoryStartupFolder:
+		// Quarantining the shortcut is the removal
+		id, err := backup(filepath.Join(e.Location, e.Name), "Persistence removal: "+e.Name)
+		if err != nil {
+			return nil, err
+		}
+		return &Removal{BackupID: id, Method: "quarantine_file"}, nil
+	}
+	return nil, fmt.Errorf("removal of %s entries is not supported", e.Category)
+}
+
+// removeRegistryValue exports the key to a .reg file, quarantines it and
+// deletes the single value. Restoring is a "reg import" of the backup.
+func removeRegistryValue(e Entry, backup Backup) (*Removal, error) {
+	tmp, err := tempPath("persistence-*.reg")
+	if err != nil {
+		return nil, err
+	}
+
+	if out, err := exec.Command("reg", "export", e.Location, tmp, "/y").CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("reg export failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	id, err := backup(tmp, "Persistence removal: "+e.Location+`\`+e.Name)
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	args := []string{"delete", e.Location, "/f"}
+	if e.Name == "" {
+		args = append(args, "/ve")
+	} else {
+		args = append(args, "/v", e.Name)
+	}
+	if out, err := exec.Command("reg", args...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("reg delete failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return &Removal{BackupID: id, Method: "registry_export"}, nil
+}
+
+// removeScheduledTask saves the task XML to quarantine and unregisters the task.
+// Restoring is "schtasks /Create /XML <backup> /TN <name>".
+func removeScheduledTask(e Entry, backup Backup) (*Removal, error) {
+	xml, err := tasks.Export(e.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := tempPath("persistence-*.xml")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(tmp, xml, 0600); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("failed to write task backup: %w", err)
+	}
+
+	id, err := backup(tmp, "Persistence removal: task "+e.Name)
+	if err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	if err := tasks.Delete(e.Name); err != nil {
+		return nil, err
+	}
+
+	return &Removal{BackupID: id, Method: "task_xml_export"}, nil
+}
+
+func tempPath(pattern string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create backup file: %w", err)
+	}
+	f.Close()
+	return f.Name(), nil
+}
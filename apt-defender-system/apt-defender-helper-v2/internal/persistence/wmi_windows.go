@@ -0,0 +1,110 @@
+package persistence
+
+import (
+	"encoding/json"
+	"os/exec"
+	"strings"
+)
+
+// wmiQuery lists permanent event subscriptions as one JSON document
+const wmiQuery = `$ns='root/subscription';` +
+	`$f=@(Get-CimInstance -Namespace $ns -ClassName __EventFilter | Select-Object Name,Query,EventNamespace);` +
+	`$c=@(Get-CimInstance -Namespace $ns -ClassName __EventConsumer | Select-Object Name,@{n='Class';e={$_.CimClass.CimClassName}},CommandLineTemplate,ExecutablePath,ScriptingEngine,ScriptText,ScriptFileName);` +
+	`$b=@(Get-CimInstance -Namespace $ns -ClassName __FilterToConsumerBinding | Select-Object @{n='Filter';e={$_.Filter.Name}},@{n='Consumer';e={$_.Consumer.Name}});` +
+	`@{filters=$f;consumers=$c;bindings=$b} | ConvertTo-Json -Depth 3 -Compress`
+
+type wmiFilter struct {
+	Name           string
+	Query          string
+	EventNamespace string
+}
+
+type wmiConsumer struct {
+	Name                string
+	Class               string
+	CommandLineTemplate string
+	ExecutablePath      string
+	ScriptingEngine     string
+	ScriptText          string
+	ScriptFileName      string
+}
+
+type wmiBinding struct {
+	Filter   string
+	Consumer string
+}
+
+type wmiSubscriptionSet struct {
+	Filters   []wmiFilter   `json:"filters"`
+	Consumers []wmiConsumer `json:"consumers"`
+	Bindings  []wmiBinding  `json:"bindings"`
+}
+
+// wmiSubscriptions reports every WMI event consumer with the filters bound to it
+func wmiSubscriptions() []Entry {
+	var entries []Entry
+
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", wmiQuery).Output()
+	if err != nil {
+		return entries
+	}
+
+	var set wmiSubscriptionSet
+	if err := json.Unmarshal(out, &set); err != nil {
+		return entries
+	}
+
+	filters := make(map[string]wmiFilter, len(set.Filters))
+	for _, f := range set.Filters {
+		filters[f.Name] = f
+	}
+
+	for _, c := range set.Consumers {
+		command := c.CommandLineTemplate
+		if command == "" {
+			command = c.ExecutablePath
+		}
+		if command == "" && c.ScriptFileName != "" {
+			command = c.ScriptFileName
+		}
+		if command == "" {
+			command = c.ScriptText
+		}
+
+		e := newEntry(CategoryWMI, `root\subscription`, c.Name, command)
+		e.Details = map[string]string{"consumer_class": c.Class}
+		if c.ScriptingEngine != "" {
+			e.Details["scripting_engine"] = c.ScriptingEngine
+		}
+
+		var bound []string
+		for _, b := range set.Bindings {
+			if b.Consumer != c.Name {
+				continue
+			}
+			bound = append(bound, b.Filter)
+			if f, ok := filters[b.Filter]; ok {
+				e.Details["query"] = f.Query
+			}
+		}
+		if len(bound) > 0 {
+			e.Details["filters"] = strings.Join(bound, "; ")
+		}
+
+		switch c.Class {
+		case "CommandLineEventConsumer":
+			e.setImage(ImageFromCommand(command))
+			if len(bound) > 0 {
+				e.flag("WMI subscription runs a command")
+			}
+		case "ActiveScriptEventConsumer":
+			if len(bound) > 0 {
+				e.flag("WMI subscription runs a script")
+			}
+		}
+		assessCommand(&e)
+		entries = append(entries, e)
+	}
+
+	return entries
+}
@@ -0,0 +1,217 @@
+package piclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+)
+
+// Client sends notifications from this helper to the Pi Agent it is registered with
+type Client struct {
+	config *config.Config
+	http   *http.Client
+	upload *http.Client
+	outbox *outbox
+
+	pinMutex     sync.Mutex
+	pinAlert     PinAlert
+	lastMismatch string
+}
+
+const (
+	PinFirstUse = "first_use" // no pin was stored; the presented certificate is now pinned
+	PinMismatch = "mismatch"  // the Pi's address answered with a different certificate
+)
+
+// PinAlert is told when the Pi Agent's certificate is pinned on first use
+// or when a certificate fails the pin
+type PinAlert func(kind, fingerprint string)
+
+func New(cfg *config.Config) *Client {
+	c := &Client{config: cfg, outbox: newOutbox()}
+	transport := &http.Transport{
+		// The Pi Agent serves a self-signed certificate, so it is checked
+		// against the fingerprint pinned at pairing instead of a CA
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify:    true,
+			VerifyPeerCertificate: c.verifyPin,
+			GetClientCertificate:  c.clientCertificate,
+		},
+	}
+	c.http = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	// Artifacts can be large; rely on the per-read deadline of the transport instead
+	c.upload = &http.Client{Transport: transport}
+	return c
+}
+
+// OnPin registers the callback for first-use pins and pin mismatches
+func (c *Client) OnPin(alert PinAlert) {
+	c.pinMutex.Lock()
+	defer c.pinMutex.Unlock()
+	c.pinAlert = alert
+}
+
+// verifyPin rejects a Pi Agent whose certificate doesn't match the pinned
+// fingerprint, so a host that takes over the Pi's IP (ARP spoofing, a DHCP
+// reassignment) can't pose as the controller. Helpers registered before
+// pinning existed have no pin; the first certificate they see is pinned.
+func (c *Client) verifyPin(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("Pi Agent presented no certificate")
+	}
+
+	c.pinMutex.Lock()
+	defer c.pinMutex.Unlock()
+
+	presented := FormatFingerprint(rawCerts[0])
+	pin := NormalizeFingerprint(c.config.PiCertFingerprint)
+	if pin == "" {
+		c.config.PiCertFingerprint = NormalizeFingerprint(presented)
+		log.Printf("📌 Pinned Pi Agent certificate %s on first use", presented)
+		// Saved right away, so a restart doesn't open another first-use window
+		if err := c.config.Save(config.GetConfigPath()); err != nil {
+			log.Printf("⚠️ Failed to save config after pinning the Pi Agent certificate: %v", err)
+		}
+		if c.pinAlert != nil {
+			go c.pinAlert(PinFirstUse, presented)
+		}
+		return nil
+	}
+
+	err := checkPin(rawCerts, pin)
+	if err == nil {
+		c.lastMismatch = ""
+	} else if presented != c.lastMismatch {
+		c.lastMismatch = presented
+		log.Printf("🚨 Refused Pi Agent at %s: %v", c.config.PiAgentIP, err)
+		if c.pinAlert != nil {
+			go c.pinAlert(PinMismatch, presented)
+		}
+	}
+	return err
+}
+
+// Available reports whether a Pi Agent is configured to receive notifications
+func (c *Client) Available() bool {
+	return c.config.RegisteredWithPi && c.config.PiAgentIP != ""
+}
+
+// BaseURL returns the Pi Agent API root
+func (c *Client) BaseURL() string {
+	port := c.config.PiAgentPort
+	if port == 0 {
+		port = 8443
+	}
+	return fmt.Sprintf("https://%s:%d/api/v1", c.config.PiAgentIP, port)
+}
+
+// PostEvent reports a helper-side event to the Pi Agent
+func (c *Client) PostEvent(eventType string, data interface{}) error {
+	if !c.Available() {
+		return fmt.Errorf("not registered with a Pi Agent")
+	}
+
+	hostname, _ := os.Hostname()
+	payload := map[string]interface{}{
+		"hostname":  hostname,
+		"type":      eventType,
+		"timestamp": time.Now(),
+		"data":      data,
+	}
+
+	return c.post("/devices/events", payload)
+}
+
+// UploadArtifact streams a staged file to the Pi Agent as multipart form data
+func (c *Client) UploadArtifact(path, kind, sha256 string) error {
+	if !c.Available() {
+		return fmt.Errorf("not registered with a Pi Agent")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hostname, _ := os.Hostname()
+	reader, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	go func() {
+		form.WriteField("hostname", hostname)
+		form.WriteField("kind", kind)
+		form.WriteField("sha256", sha256)
+		part, err := form.CreateFormFile("file", filepath.Base(path))
+		if err == nil {
+			_, err = io.Copy(part, f)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL()+"/devices/artifacts", reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+c.config.AuthToken)
+
+	resp, err := c.upload.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload to Pi Agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Pi Agent returned %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
+
+func (c *Client) post(endpoint string, payload interface{}) error {
+	return c.postAs(c.config.AuthToken, endpoint, payload)
+}
+
+// postAs posts payload with token as the bearer credential
+func (c *Client) postAs(token, endpoint string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL()+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Pi Agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{Code: resp.StatusCode, Body: string(msg)}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package piclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+)
+
+// newClientKey generates the key pair for the helper's mTLS certificate and
+// a CSR for the Pi Agent to sign. The private key stays on this PC.
+func newClientKey(hostname string) (keyPEM, csrPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate client key: %w", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: hostname, Organization: []string{"APT Defender"}},
+	}, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CSR: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}), nil
+}
+
+// storeClientCert saves the certificate the Pi Agent issued, with its key
+// and the Pi's device CA, under the data dir and enables mTLS in cfg
+func storeClientCert(cfg *config.Config, keyPEM, certPEM, caPEM []byte) error {
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return fmt.Errorf("issued certificate doesn't match the client key: %w", err)
+	}
+
+	dir := filepath.Join(config.GetDataDir(), "certs")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	files := []struct {
+		path string
+		data []byte
+		perm os.FileMode
+	}{
+		{filepath.Join(dir, "pi-client.key"), keyPEM, 0600},
+		{filepath.Join(dir, "pi-client.crt"), certPEM, 0644},
+		{filepath.Join(dir, "pi-ca.crt"), caPEM, 0644},
+	}
+	for _, f := range files {
+		if len(f.data) == 0 {
+			continue
+		}
+		if err := os.WriteFile(f.path, f.data, f.perm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.path, err)
+		}
+	}
+
+	cfg.ClientKeyFile = files[0].path
+	cfg.ClientCertFile = files[1].path
+	if len(caPEM) > 0 {
+		cfg.PiCAFile = files[2].path
+	}
+	cfg.EnableMTLS = true
+	return nil
+}
+
+// RemoveClientCert deletes files storeClientCert wrote, once the config no
+// longer points at them. Files elsewhere were set up by hand and are kept.
+func RemoveClientCert(paths ...string) {
+	dir := filepath.Join(config.GetDataDir(), "certs")
+	for _, path := range paths {
+		if path == "" || !strings.EqualFold(filepath.Dir(filepath.Clean(path)), dir) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️ Failed to delete %s: %v", path, err)
+		}
+	}
+}
+
+// clientCertificate presents the certificate issued at pairing when the Pi
+// Agent asks for one. It is read on each handshake so a re-pair takes
+// effect without a restart; without one the handshake goes on bare.
+func (c *Client) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if !c.config.EnableMTLS || c.config.ClientCertFile == "" || c.config.ClientKeyFile == "" {
+		return &tls.Certificate{}, nil
+	}
+	cert, err := tls.LoadX509KeyPair(config.ExpandPath(c.config.ClientCertFile), config.ExpandPath(c.config.ClientKeyFile))
+	if err != nil {
+		log.Printf("⚠️ Failed to load client certificate for mTLS: %v", err)
+		return &tls.Certificate{}, nil
+	}
+	return &cert, nil
+}
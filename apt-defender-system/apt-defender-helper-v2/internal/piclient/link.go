@@ -0,0 +1,294 @@
+package piclient
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+	"github.com/apt-defender/helper-v2/internal/events"
+)
+
+const (
+	retryBase        = 5 * time.Second
+	retryMax         = 5 * time.Minute
+	unreachableAfter = 5 // failed attempts before the dashboard is alerted
+)
+
+const (
+	LinkUnknown      = "unknown"
+	LinkConnected    = "connected"
+	LinkReconnecting = "reconnecting"
+	LinkUnreachable  = "unreachable"
+	LinkUnpaired     = "unpaired"
+)
+
+// LinkStatus is the helper's view of its connection to the Pi Agent
+type LinkStatus struct {
+	State               string    `json:"state"`
+	PiAgentIP           string    `json:"pi_agent_ip,omitempty"`
+	LastSeen            time.Time `json:"last_seen,omitempty"`
+	LastAttempt         time.Time `json:"last_attempt,omitempty"`
+	LastRegistered      time.Time `json:"last_registered,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	NextAttempt         time.Time `json:"next_attempt,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	Queued              int       `json:"queued"` // notifications waiting in the outbox
+}
+
+// Link keeps a paired helper connected to its Pi Agent. While the Pi
+// answers it is checked every interval; once it stops answering the helper
+// retries with exponential backoff, looks for it on the LAN in case its
+// address changed, and registers again as soon as it is back.
+type Link struct {
+	client     *Client
+	events     *events.Broker
+	rediscover func()
+
+	mutex      sync.Mutex
+	status     LinkStatus
+	wake       chan struct{}
+	stopSignal chan struct{}
+}
+
+// NewLink supervises client's connection. rediscover is called when the Pi
+// can't be reached at its configured address and may update that address.
+func NewLink(client *Client, broker *events.Broker, rediscover func()) *Link {
+	return &Link{
+		client:     client,
+		events:     broker,
+		rediscover: rediscover,
+		status:     LinkStatus{State: LinkUnknown},
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Start checks the Pi Agent right away and then every interval while it is up
+func (l *Link) Start(interval time.Duration) {
+	l.mutex.Lock()
+	if l.stopSignal != nil {
+		l.mutex.Unlock()
+		return
+	}
+	l.stopSignal = make(chan struct{})
+	stop := l.stopSignal
+	l.mutex.Unlock()
+
+	go func() {
+		delay := time.Duration(0)
+		for {
+			timer := time.NewTimer(delay)
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-l.wake:
+				timer.Stop()
+			case <-timer.C:
+			}
+			delay = l.attempt(interval)
+		}
+	}()
+}
+
+// Stop halts the reconnection loop
+func (l *Link) Stop() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.stopSignal != nil {
+		close(l.stopSignal)
+		l.stopSignal = nil
+	}
+}
+
+// Kick retries immediately, e.g. after pairing or when the Pi has moved
+func (l *Link) Kick() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// CheckNow retries immediately and waits up to timeout for the result, so a
+// user asking whether the Pi is back gets an answer rather than a promise
+func (l *Link) CheckNow(timeout time.Duration) LinkStatus {
+	started := time.Now()
+	l.Kick()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if status := l.Status(); !status.LastAttempt.Before(started) {
+			return status
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return l.Status()
+}
+
+// Status returns the current connection state
+func (l *Link) Status() LinkStatus {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	status := l.status
+	status.Queued = l.client.Queued()
+	return status
+}
+
+// attempt checks the Pi Agent once and returns the delay before the next check
+func (l *Link) attempt(interval time.Duration) time.Duration {
+	if !l.client.Available() {
+		l.mutex.Lock()
+		l.status = LinkStatus{State: LinkUnpaired, LastAttempt: time.Now()}
+		l.mutex.Unlock()
+		return interval
+	}
+
+	err := l.client.Ping()
+	if err != nil && l.rediscover != nil {
+		before := l.client.config.PiAgentIP
+		l.rediscover()
+		if l.client.config.PiAgentIP != before {
+			err = l.client.Ping()
+		}
+	}
+
+	l.mutex.Lock()
+	previous := l.status
+	now := time.Now()
+	l.status.PiAgentIP = l.client.config.PiAgentIP
+	l.status.LastAttempt = now
+
+	if err == nil {
+		l.status.State = LinkConnected
+		l.status.LastSeen = now
+		l.status.ConsecutiveFailures = 0
+		l.status.NextAttempt = now.Add(interval)
+		l.status.LastError = ""
+		l.mutex.Unlock()
+
+		// Anything but a steady connection means the Pi may have rebooted
+		// and lost track of this helper, so register again
+		if previous.State != LinkConnected {
+			l.register(previous)
+		}
+		l.refreshToken()
+		l.flushOutbox()
+		return interval
+	}
+
+	l.status.ConsecutiveFailures++
+	l.status.LastError = err.Error()
+	delay := backoff(l.status.ConsecutiveFailures)
+	l.status.NextAttempt = now.Add(delay)
+	failures := l.status.ConsecutiveFailures
+	if failures >= unreachableAfter {
+		l.status.State = LinkUnreachable
+	} else {
+		l.status.State = LinkReconnecting
+	}
+	status := l.status
+	l.mutex.Unlock()
+
+	if failures == 1 {
+		log.Printf("📡 Lost contact with Pi Agent at %s: %v", status.PiAgentIP, err)
+		l.events.Publish("pi.reconnecting", status)
+	}
+	if failures == unreachableAfter {
+		log.Printf("🚨 Pi Agent at %s unreachable after %d attempts, still retrying", status.PiAgentIP, failures)
+		l.events.Publish("pi.unreachable", status)
+	}
+	return delay
+}
+
+// register re-announces this helper to the Pi Agent after a reconnect
+func (l *Link) register(previous LinkStatus) {
+	err := l.client.Register()
+
+	l.mutex.Lock()
+	if err == nil {
+		l.status.LastRegistered = time.Now()
+	} else {
+		l.status.LastError = err.Error()
+	}
+	status := l.status
+	l.mutex.Unlock()
+
+	if err != nil {
+		log.Printf("⚠️ Reconnected to Pi Agent at %s but re-registration failed: %v", status.PiAgentIP, err)
+	} else if previous.State != LinkUnknown {
+		log.Printf("✅ Reconnected to Pi Agent at %s after %d failed attempts", status.PiAgentIP, previous.ConsecutiveFailures)
+	}
+	if previous.State == LinkReconnecting || previous.State == LinkUnreachable {
+		l.events.Publish("pi.reconnected", status)
+	}
+}
+
+// flushOutbox replays notifications queued while the Pi was unreachable
+func (l *Link) flushOutbox() {
+	if l.client.Queued() == 0 {
+		return
+	}
+	delivered, err := l.client.FlushOutbox()
+	if delivered > 0 {
+		log.Printf("📬 Delivered %d notifications queued while the Pi Agent was unreachable", delivered)
+	}
+	if err != nil {
+		log.Printf("⚠️ Replaying the Pi outbox stopped, %d still queued: %v", l.client.Queued(), err)
+	}
+}
+
+// refreshToken keeps the Pi Agent device token from expiring while the Pi is reachable
+func (l *Link) refreshToken() {
+	refreshed, err := l.client.RefreshAccessToken()
+	if err != nil {
+		log.Printf("⚠️ Failed to refresh Pi Agent device token: %v", err)
+		return
+	}
+	if refreshed {
+		if err := l.client.config.Save(config.GetConfigPath()); err != nil {
+			log.Printf("⚠️ Failed to save config after refreshing the Pi Agent device token: %v", err)
+		}
+	}
+}
+
+// backoff doubles the retry delay with every failure up to retryMax, with
+// jitter so helpers that lost the same Pi don't retry in lockstep
+func backoff(failures int) time.Duration {
+	delay := retryMax
+	if failures < 16 {
+		delay = min(retryBase<<(failures-1), retryMax)
+	}
+	return delay - time.Duration(rand.Int63n(int64(delay/5)))
+}
+
+// Ping checks that the Pi Agent answers and still presents the pinned certificate
+func (c *Client) Ping() error {
+	if !c.Available() {
+		return fmt.Errorf("not registered with a Pi Agent")
+	}
+
+	resp, err := c.http.Get(strings.TrimSuffix(c.BaseURL(), "/api/v1") + "/health")
+	if err != nil {
+		return fmt.Errorf("failed to reach Pi Agent: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Pi Agent health check returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Register tells the Pi Agent where this helper is listening
+func (c *Client) Register() error {
+	return c.PostEvent("device.registered", map[string]interface{}{
+		"helper_port": c.config.Port,
+		"mtls":        c.config.EnableMTLS,
+	})
+}
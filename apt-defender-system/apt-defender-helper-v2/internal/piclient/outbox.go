@@ -0,0 +1,253 @@
+package piclient
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+)
+
+const (
+	outboxFile = "pi-outbox.jsonl"
+	outboxMax  = 5000 // oldest notifications are dropped beyond this
+)
+
+// Notification is an event for the Pi Agent. It keeps the time it was
+// raised, not the time it was finally delivered, and an ID the Pi can use
+// to drop duplicates if a replay is interrupted.
+type Notification struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// StatusError is a response from the Pi Agent outside the 2xx range
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Pi Agent returned %d: %s", e.Code, e.Body)
+}
+
+// permanent reports whether retrying can't help: the Pi answered and refused
+// the request. Network errors and 5xx are worth retrying.
+func permanent(err error) bool {
+	var status *StatusError
+	return errors.As(err, &status) && status.Code >= 400 && status.Code < 500 &&
+		status.Code != http.StatusRequestTimeout && status.Code != http.StatusTooManyRequests
+}
+
+// outbox is the on-disk queue of notifications waiting for the Pi Agent
+type outbox struct {
+	mutex sync.Mutex // held while delivering so notifications go out in order
+	path  string
+	count atomic.Int64
+}
+
+func newOutbox() *outbox {
+	o := &outbox{path: filepath.Join(config.GetDataDir(), outboxFile)}
+	if data, err := os.ReadFile(o.path); err == nil {
+		o.count.Store(int64(bytes.Count(data, []byte("\n"))))
+	}
+	return o
+}
+
+// Notify delivers an event to the Pi Agent. When the Pi can't be reached,
+// or earlier notifications are still queued, it goes to the outbox instead
+// and is replayed in order once the Pi is back, so nothing raised during a
+// network outage or Pi reboot is lost. Only a paired helper queues.
+func (c *Client) Notify(eventType string, data interface{}) error {
+	if !c.Available() {
+		return fmt.Errorf("not registered with a Pi Agent")
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	n := Notification{ID: newNotificationID(), Type: eventType, Timestamp: time.Now(), Data: raw}
+
+	c.outbox.mutex.Lock()
+	defer c.outbox.mutex.Unlock()
+
+	if c.outbox.size() == 0 {
+		err := c.deliver(n)
+		if err == nil {
+			return nil
+		}
+		if permanent(err) {
+			return err
+		}
+		log.Printf("📮 Pi Agent unavailable, queued %s for later delivery: %v", eventType, err)
+	}
+	return c.outbox.append(n)
+}
+
+// FlushOutbox replays queued notifications in order until one fails, and
+// returns how many were delivered. Notifications the Pi refuses outright
+// are dropped so they can't block the queue.
+func (c *Client) FlushOutbox() (int, error) {
+	c.outbox.mutex.Lock()
+	defer c.outbox.mutex.Unlock()
+
+	pending, err := c.outbox.load()
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	delivered := 0
+	for i, n := range pending {
+		if err := c.deliver(n); err != nil {
+			if !permanent(err) {
+				if saveErr := c.outbox.save(pending[i:]); saveErr != nil {
+					log.Printf("⚠️ Failed to update Pi outbox: %v", saveErr)
+				}
+				return delivered, err
+			}
+			log.Printf("⚠️ Pi Agent refused queued %s from %s, dropping it: %v", n.Type, n.Timestamp.Format(time.RFC3339), err)
+			continue
+		}
+		delivered++
+	}
+	return delivered, c.outbox.save(nil)
+}
+
+// DiscardOutbox drops everything queued, e.g. when the Pi unpairs this helper
+func (c *Client) DiscardOutbox() error {
+	c.outbox.mutex.Lock()
+	defer c.outbox.mutex.Unlock()
+	return c.outbox.save(nil)
+}
+
+// Queued returns the number of notifications waiting in the outbox
+func (c *Client) Queued() int {
+	return c.outbox.size()
+}
+
+// deliver posts one notification, authenticated with the Pi-issued device
+// token when the helper has one so the Pi knows which device sent it
+func (c *Client) deliver(n Notification) error {
+	token := c.config.PiAccessToken
+	if token == "" {
+		token = c.config.AuthToken
+	}
+	hostname, _ := os.Hostname()
+	return c.postAs(token, "/devices/events", map[string]interface{}{
+		"id":        n.ID,
+		"hostname":  hostname,
+		"type":      n.Type,
+		"timestamp": n.Timestamp,
+		"data":      n.Data,
+	})
+}
+
+func newNotificationID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (o *outbox) append(n Notification) error {
+	line, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	if o.size() >= outboxMax {
+		pending, err := o.load()
+		if err != nil {
+			return err
+		}
+		dropped := max(len(pending)-outboxMax+1, 0)
+		log.Printf("⚠️ Pi outbox full, dropping %d oldest notifications", dropped)
+		if err := o.save(pending[dropped:]); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(o.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(o.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open Pi outbox: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	o.count.Add(1)
+	return nil
+}
+
+func (o *outbox) load() ([]Notification, error) {
+	f, err := os.Open(o.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pending []Notification
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var n Notification
+		if len(bytes.TrimSpace(scanner.Bytes())) > 0 && json.Unmarshal(scanner.Bytes(), &n) == nil {
+			pending = append(pending, n)
+		}
+	}
+	return pending, scanner.Err()
+}
+
+// save replaces the outbox with pending, removing it when empty
+func (o *outbox) save(pending []Notification) error {
+	if len(pending) == 0 {
+		if err := os.Remove(o.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		o.count.Store(0)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, n := range pending {
+		line, err := json.Marshal(n)
+		if err != nil {
+			continue
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := o.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, o.path); err != nil {
+		return err
+	}
+	o.count.Store(int64(len(pending)))
+	return nil
+}
+
+func (o *outbox) size() int {
+	return int(o.count.Load())
+}
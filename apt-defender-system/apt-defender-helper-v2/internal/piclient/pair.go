@@ -0,0 +1,292 @@
+package piclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+)
+
+const defaultPiPort = 8443
+
+// PairResult describes a completed pairing
+type PairResult struct {
+	PiAgentIP   string `json:"pi_agent_ip"`
+	PiAgentHost string `json:"pi_agent_host,omitempty"` // The name paired by, when it wasn't an IP
+	PiAgentPort int    `json:"pi_agent_port"`
+	Fingerprint string `json:"fingerprint"`
+	DeviceID    int    `json:"device_id"`
+	ClientCert  bool   `json:"client_certificate"` // An mTLS client certificate was issued and stored
+}
+
+// SplitAddress parses "host" or "host:port", defaulting to the Pi Agent port
+func SplitAddress(addr string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, defaultPiPort, nil
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port in %s", addr)
+	}
+	return host, port, nil
+}
+
+// Fingerprint connects to the Pi Agent and returns the SHA-256 fingerprint
+// of the certificate it serves, for the user to compare with the one shown
+// on the Pi
+func Fingerprint(host string, port int) (string, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, strconv.Itoa(port)), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to Pi Agent: %w", err)
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", fmt.Errorf("Pi Agent presented no certificate")
+	}
+	return FormatFingerprint(certs[0].Raw), nil
+}
+
+// FormatFingerprint renders the SHA-256 of a DER certificate as AB:CD:...
+func FormatFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return DisplayFingerprint(hex.EncodeToString(sum[:]))
+}
+
+// DisplayFingerprint renders a fingerprint as colon-separated upper-case
+// byte pairs, the way openssl and the Pi show it
+func DisplayFingerprint(fp string) string {
+	fp = strings.ToUpper(NormalizeFingerprint(fp))
+	parts := []string{}
+	for i := 0; i+1 < len(fp); i += 2 {
+		parts = append(parts, fp[i:i+2])
+	}
+	return strings.Join(parts, ":")
+}
+
+// NormalizeFingerprint reduces a fingerprint in any common notation
+// ("SHA256:ab cd", "AB:CD", "abcd") to lower-case hex
+func NormalizeFingerprint(fp string) string {
+	fp = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(fp)), "SHA256:")
+	fp = strings.NewReplacer(":", "", " ", "", "-", "").Replace(fp)
+	return strings.ToLower(fp)
+}
+
+// pinnedTLS accepts only a certificate with the given fingerprint. The Pi
+// Agent's certificate is self-signed, so the pin replaces chain validation.
+func pinnedTLS(fingerprint string) *tls.Config {
+	want := NormalizeFingerprint(fingerprint)
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return checkPin(rawCerts, want)
+		},
+	}
+}
+
+func checkPin(rawCerts [][]byte, want string) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("Pi Agent presented no certificate")
+	}
+	sum := sha256.Sum256(rawCerts[0])
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return fmt.Errorf("Pi Agent certificate fingerprint %s does not match the pinned %s", FormatFingerprint(rawCerts[0]), DisplayFingerprint(want))
+	}
+	return nil
+}
+
+// pairProofLabel domain-separates the pairing HMACs; the Pi uses the same label
+const pairProofLabel = "aptd-pair-v1"
+
+// Pair pairs with the Pi Agent over HTTPS, refusing to talk to it unless
+// its certificate matches fingerprint. The pairing code never leaves the PC:
+// the helper answers a nonce from the Pi with an HMAC keyed by the code, and
+// checks the Pi's reply under the session key both sides derive from it.
+// A CSR rides along with the proof and the Pi returns a signed client
+// certificate for mTLS. On success the Pi's address, fingerprint and client
+// certificate are stored in cfg; the caller saves it. A host name is
+// resolved once here and the helper talks to that IP from then on, since
+// source checks and unpair compare callers with pi_agent_ip.
+func Pair(cfg *config.Config, host string, port int, code, fingerprint string) (*PairResult, error) {
+	if NormalizeFingerprint(fingerprint) == "" {
+		return nil, fmt.Errorf("the Pi Agent certificate fingerprint is required")
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil, fmt.Errorf("pairing code is required")
+	}
+
+	name := host
+	host, err := resolveHost(host)
+	if err != nil {
+		return nil, err
+	}
+	if name == host {
+		name = ""
+	}
+
+	client := &http.Client{
+		Timeout:   15 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: pinnedTLS(fingerprint)},
+	}
+	base := fmt.Sprintf("https://%s/api/v1/auth/pair", net.JoinHostPort(host, strconv.Itoa(port)))
+
+	var challenge struct {
+		ChallengeID string `json:"challenge_id"`
+		Nonce       string `json:"nonce"`
+	}
+	if err := pairRequest(client, base+"/challenge", map[string]string{}, &challenge); err != nil {
+		return nil, err
+	}
+	if challenge.ChallengeID == "" || challenge.Nonce == "" {
+		return nil, fmt.Errorf("Pi Agent sent an empty pairing challenge")
+	}
+
+	clientNonce := make([]byte, 32)
+	if _, err := rand.Read(clientNonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	nonce := hex.EncodeToString(clientNonce)
+
+	hostname, _ := os.Hostname()
+	keyPEM, csrPEM, err := newClientKey(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	var verified struct {
+		AccessToken       string `json:"access_token"`
+		DeviceID          int    `json:"device_id"`
+		ServerProof       string `json:"server_proof"`
+		ClientCertificate string `json:"client_certificate"`
+		CACertificate     string `json:"ca_certificate"`
+	}
+	err = pairRequest(client, base+"/verify", map[string]string{
+		"challenge_id":    challenge.ChallengeID,
+		"client_nonce":    nonce,
+		"proof":           pairMAC([]byte(code), "client", challenge.Nonce, nonce, hostname),
+		"device_hostname": hostname,
+		"device_ip":       localAddressFor(host, port),
+		"device_os":       runtime.GOOS,
+		"csr":             string(csrPEM),
+	}, &verified)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionKey, _ := hex.DecodeString(pairMAC([]byte(code), "session", challenge.Nonce, nonce))
+	want := pairMAC(sessionKey, "server", verified.AccessToken, strconv.Itoa(verified.DeviceID))
+	if !hmac.Equal([]byte(want), []byte(strings.ToLower(verified.ServerProof))) {
+		return nil, fmt.Errorf("Pi Agent could not prove it knows the pairing code")
+	}
+
+	cfg.PiAgentIP = host
+	cfg.PiAgentHost = name
+	cfg.PiAgentPort = port
+	cfg.PiCertFingerprint = NormalizeFingerprint(fingerprint)
+	cfg.RegisteredWithPi = true
+	cfg.PiDeviceID = verified.DeviceID
+	cfg.PiAccessToken = verified.AccessToken
+
+	// Pi Agents that predate certificate issuance pair without one
+	issued := false
+	if verified.ClientCertificate != "" {
+		if err := storeClientCert(cfg, keyPEM, []byte(verified.ClientCertificate), []byte(verified.CACertificate)); err != nil {
+			log.Printf("⚠️ Paired, but the client certificate could not be stored: %v", err)
+		} else {
+			issued = true
+		}
+	}
+
+	return &PairResult{
+		PiAgentIP:   host,
+		PiAgentHost: name,
+		PiAgentPort: port,
+		Fingerprint: DisplayFingerprint(fingerprint),
+		DeviceID:    verified.DeviceID,
+		ClientCert:  issued,
+	}, nil
+}
+
+// pairMAC is HMAC-SHA256 over the label and |-joined parts, hex encoded
+func pairMAC(key []byte, parts ...string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join(append([]string{pairProofLabel}, parts...), "|")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// pairRequest posts one step of the pairing handshake and decodes its data
+func pairRequest(client *http.Client, url string, payload map[string]string, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to reach Pi Agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Detail string          `json:"detail"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result)
+	if resp.StatusCode >= 300 {
+		if result.Detail == "" {
+			result.Detail = resp.Status
+		}
+		return fmt.Errorf("Pi Agent refused pairing: %s", result.Detail)
+	}
+	if err := json.Unmarshal(result.Data, out); err != nil {
+		return fmt.Errorf("unexpected pairing response from Pi Agent: %w", err)
+	}
+	return nil
+}
+
+// resolveHost returns host's IP, preferring IPv4; an IP is returned as is
+func resolveHost(host string) (string, error) {
+	host = strings.Trim(host, "[]")
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return "", fmt.Errorf("failed to resolve Pi Agent %s: %v", host, err)
+	}
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			return ip.String(), nil
+		}
+	}
+	return ips[0].String(), nil
+}
+
+// localAddressFor returns the local IP this PC uses to reach host
+func localAddressFor(host string, port int) string {
+	conn, err := net.Dial("udp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String()
+}
@@ -0,0 +1,92 @@
+package piclient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// refreshWindow is how long before expiry the device token is renewed
+const refreshWindow = 12 * time.Hour
+
+// PostLogs ships a batch of JSON log records to the Pi Agent, authenticated
+// with the device token the Pi issued at pairing
+func (c *Client) PostLogs(records []json.RawMessage) error {
+	if !c.Available() {
+		return fmt.Errorf("not registered with a Pi Agent")
+	}
+	if c.config.PiAccessToken == "" {
+		return fmt.Errorf("no Pi Agent device token; pair again to enable log shipping")
+	}
+
+	hostname, _ := os.Hostname()
+	return c.postAs(c.config.PiAccessToken, "/devices/logs", map[string]interface{}{
+		"hostname": hostname,
+		"records":  records,
+	})
+}
+
+// RefreshAccessToken renews the Pi Agent device token when it expires
+// within refreshWindow. It reports whether the token changed, in which case
+// the caller saves the config.
+func (c *Client) RefreshAccessToken() (bool, error) {
+	token := c.config.PiAccessToken
+	if !c.Available() || token == "" {
+		return false, nil
+	}
+	if exp := tokenExpiry(token); !exp.IsZero() && time.Until(exp) > refreshWindow {
+		return false, nil
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL()+"/auth/refresh", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to reach Pi Agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return false, fmt.Errorf("Pi Agent refused token refresh (%d): %s", resp.StatusCode, msg)
+	}
+	var result struct {
+		Data struct {
+			AccessToken string `json:"access_token"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result); err != nil || result.Data.AccessToken == "" {
+		return false, fmt.Errorf("unexpected token refresh response from Pi Agent")
+	}
+	c.config.PiAccessToken = result.Data.AccessToken
+	return true, nil
+}
+
+// tokenExpiry reads the exp claim of a JWT without verifying it; the Pi
+// verifies its own tokens, the helper only needs to know when to renew
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
@@ -0,0 +1,86 @@
+package piclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// HelperUpdate asks the Pi Agent for the newest helper build it holds for
+// arch. It returns the manifest as sent, or nil when the Pi has none.
+func (c *Client) HelperUpdate(current, arch string) (json.RawMessage, error) {
+	if !c.Available() {
+		return nil, fmt.Errorf("not registered with a Pi Agent")
+	}
+	if c.config.PiAccessToken == "" {
+		return nil, fmt.Errorf("no Pi Agent device token; pair again to get updates from the Pi")
+	}
+
+	query := url.Values{"version": {current}, "arch": {arch}}
+	req, err := http.NewRequest(http.MethodGet, c.BaseURL()+"/devices/helper-update?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.PiAccessToken)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Pi Agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, &StatusError{Code: resp.StatusCode, Body: string(msg)}
+	}
+	var result struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("unexpected update response from Pi Agent")
+	}
+	if len(result.Data) == 0 || string(result.Data) == "null" {
+		return nil, nil
+	}
+	return result.Data, nil
+}
+
+// Download streams a file the Pi Agent serves into w. fileURL may be a
+// path on the Pi, such as one from HelperUpdate.
+func (c *Client) Download(fileURL string, w io.Writer) error {
+	if !c.Available() {
+		return fmt.Errorf("not registered with a Pi Agent")
+	}
+	base, err := url.Parse(c.BaseURL())
+	if err != nil {
+		return err
+	}
+	ref, err := url.Parse(fileURL)
+	if err != nil {
+		return fmt.Errorf("invalid download URL %q: %w", fileURL, err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, base.ResolveReference(ref).String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.PiAccessToken)
+
+	resp, err := c.upload.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Pi Agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{Code: resp.StatusCode, Body: string(msg)}
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
@@ -0,0 +1,307 @@
+package playbook
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/events"
+)
+
+const (
+	storeFile     = "playbooks.json"
+	maxExecutions = 200
+)
+
+// Actions are the response primitives the engine can invoke. They are
+// supplied by the API layer so path policy and logging stay in one place.
+type Actions struct {
+	Quarantine      func(path, reason string) error
+	BlockDomain     func(domain string) error
+	Notify          func(title, message string) error
+	IsolateNetwork  func() error
+	LockWorkstation func() error
+}
+
+// Execution records one playbook run
+type Execution struct {
+	PlaybookID string       `json:"playbook_id"`
+	Event      string       `json:"event"`
+	Path       string       `json:"path,omitempty"`
+	Steps      []StepResult `json:"steps"`
+	ExecutedAt time.Time    `json:"executed_at"`
+}
+
+type StepResult struct {
+	Action  string `json:"action"`
+	Success bool   `json:"success"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+type Engine struct {
+	mutex      sync.RWMutex
+	playbooks  map[string]Playbook
+	executions []Execution
+	storePath  string
+	actions    Actions
+	events     *events.Broker
+}
+
+func NewEngine(dataDir string, actions Actions, broker *events.Broker) *Engine {
+	e := &Engine{
+		playbooks: make(map[string]Playbook),
+		storePath: filepath.Join(dataDir, storeFile),
+		actions:   actions,
+		events:    broker,
+	}
+
+	if err := e.load(); err != nil && !os.IsNotExist(err) {
+		log.Printf("⚠️ Failed to load playbooks: %v", err)
+	}
+
+	return e
+}
+
+// Start evaluates playbooks against every published event
+func (e *Engine) Start() {
+	ch, _ := e.events.Subscribe()
+
+	log.Printf("📘 Playbook engine started (%d playbooks)", len(e.List()))
+
+	go func() {
+		for ev := range ch {
+			if strings.HasPrefix(ev.Type, "playbook.") {
+				continue
+			}
+			e.evaluate(ev)
+		}
+	}()
+}
+
+// List returns all stored playbooks
+func (e *Engine) List() []Playbook {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	result := make([]Playbook, 0, len(e.playbooks))
+	for _, p := range e.playbooks {
+		result = append(result, p)
+	}
+	return result
+}
+
+// Put adds or replaces playbooks and persists them
+func (e *Engine) Put(playbooks []Playbook) error {
+	for i := range playbooks {
+		if err := playbooks[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	e.mutex.Lock()
+	for _, p := range playbooks {
+		e.playbooks[p.ID] = p
+	}
+	e.mutex.Unlock()
+
+	return e.save()
+}
+
+// Delete removes a playbook by ID
+func (e *Engine) Delete(id string) error {
+	e.mutex.Lock()
+	if _, ok := e.playbooks[id]; !ok {
+		e.mutex.Unlock()
+		return fmt.Errorf("playbook not found: %s", id)
+	}
+	delete(e.playbooks, id)
+	e.mutex.Unlock()
+
+	return e.save()
+}
+
+// GetExecutions returns recent playbook runs, newest first
+func (e *Engine) GetExecutions() []Execution {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	result := make([]Execution, 0, len(e.executions))
+	for i := len(e.executions) - 1; i >= 0; i-- {
+		result = append(result, e.executions[i])
+	}
+	return result
+}
+
+func (e *Engine) evaluate(ev events.Event) {
+	fields := eventFields(ev.Data)
+
+	for _, p := range e.List() {
+		if !p.Enabled || !p.Trigger.Matches(ev.Type, fields) {
+			continue
+		}
+		e.run(p, ev.Type, fields)
+	}
+}
+
+func (e *Engine) run(p Playbook, eventType string, fields map[string]interface{}) {
+	path, _ := fields["path"].(string)
+	log.Printf("📘 Running playbook %s for %s %s", p.ID, eventType, path)
+
+	// Read the download origin before quarantine moves the file
+	origin := ""
+	if path != "" {
+		origin = originDomain(path)
+	}
+
+	execution := Execution{PlaybookID: p.ID, Event: eventType, Path: path, ExecutedAt: time.Now()}
+
+	for _, action := range p.Actions {
+		step := StepResult{Action: action.Type}
+		err := e.runAction(action, path, origin, p, fields)
+		if err != nil {
+			step.Detail = err.Error()
+			log.Printf("⚠️ Playbook %s action %s failed: %v", p.ID, action.Type, err)
+		} else {
+			step.Success = true
+		}
+		if action.Type == ActionBlockOriginDomain && origin != "" {
+			step.Detail = strings.TrimSpace(origin + " " + step.Detail)
+		}
+		execution.Steps = append(execution.Steps, step)
+	}
+
+	e.mutex.Lock()
+	e.executions = append(e.executions, execution)
+	if len(e.executions) > maxExecutions {
+		e.executions = e.executions[len(e.executions)-maxExecutions:]
+	}
+	e.mutex.Unlock()
+
+	e.events.Publish("playbook.executed", execution)
+}
+
+func (e *Engine) runAction(action Action, path, origin string, p Playbook, fields map[string]interface{}) error {
+	switch action.Type {
+	case ActionQuarantine:
+		if path == "" {
+			return fmt.Errorf("event has no file path")
+		}
+		return e.actions.Quarantine(path, "Playbook "+p.ID)
+	case ActionBlockOriginDomain:
+		if origin == "" {
+			return fmt.Errorf("no download origin recorded for file")
+		}
+		return e.actions.BlockDomain(origin)
+	case ActionNotify:
+		title := action.Title
+		if title == "" {
+			title = "APT Defender"
+		}
+		message := action.Message
+		if message == "" {
+			threatType, _ := fields["type"].(string)
+			message = fmt.Sprintf("A threat (%s) was detected and handled: %s", threatType, path)
+		}
+		return e.actions.Notify(title, message)
+	case ActionIsolateNetwork:
+		return e.actions.IsolateNetwork()
+	case ActionLockWorkstation:
+		return e.actions.LockWorkstation()
+	}
+	return fmt.Errorf("unknown action %q", action.Type)
+}
+
+// eventFields flattens an event payload into a generic map for matching
+func eventFields(data interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(raw, &fields)
+	return fields
+}
+
+// originDomain reads the Mark-of-the-Web stream to find where a file was downloaded from
+func originDomain(path string) string {
+	f, err := os.Open(path + ":Zone.Identifier")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	var referrer string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "hosturl":
+			if host := hostOf(value); host != "" {
+				return host
+			}
+		case "referrerurl":
+			referrer = hostOf(value)
+		}
+	}
+
+	return referrer
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func (e *Engine) load() error {
+	data, err := os.ReadFile(e.storePath)
+	if err != nil {
+		return err
+	}
+
+	var playbooks []Playbook
+	if err := json.Unmarshal(data, &playbooks); err != nil {
+		return fmt.Errorf("failed to parse playbooks: %w", err)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for _, p := range playbooks {
+		if err := p.Validate(); err != nil {
+			log.Printf("⚠️ Skipping invalid stored playbook: %v", err)
+			continue
+		}
+		e.playbooks[p.ID] = p
+	}
+
+	return nil
+}
+
+func (e *Engine) save() error {
+	data, err := json.MarshalIndent(e.List(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal playbooks: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(e.storePath), 0700); err != nil {
+		return fmt.Errorf("failed to create data dir: %w", err)
+	}
+	if err := os.WriteFile(e.storePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write playbooks: %w", err)
+	}
+
+	return nil
+}
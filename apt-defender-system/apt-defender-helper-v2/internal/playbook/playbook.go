@@ -0,0 +1,110 @@
+package playbook
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/config"
+)
+
+// Action types a playbook may run
+const (
+	ActionQuarantine        = "quarantine"
+	ActionBlockOriginDomain = "block_origin_domain"
+	ActionNotify            = "notify"
+	ActionIsolateNetwork    = "isolate_network"
+	ActionLockWorkstation   = "lock_workstation"
+)
+
+var severityRank = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// Playbook is a declarative "when X happens, do Y" rule pushed by the Pi
+type Playbook struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Enabled bool     `json:"enabled"`
+	Trigger Trigger  `json:"trigger"`
+	Actions []Action `json:"actions"`
+}
+
+// Trigger selects which events a playbook reacts to
+type Trigger struct {
+	Event        string `json:"event"`                  // e.g. "scan.threat"
+	MinSeverity  string `json:"min_severity,omitempty"` // low, medium, high, critical
+	PathPrefix   string `json:"path_prefix,omitempty"`  // supports %VAR% references
+	PathContains string `json:"path_contains,omitempty"`
+	ThreatType   string `json:"threat_type,omitempty"` // substring match on the threat type
+}
+
+// Action is a single response step
+type Action struct {
+	Type    string `json:"type"`
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Validate checks a playbook is well-formed before it is accepted
+func (p *Playbook) Validate() error {
+	if strings.TrimSpace(p.ID) == "" {
+		return fmt.Errorf("playbook id is required")
+	}
+	if p.Trigger.Event == "" {
+		return fmt.Errorf("playbook %s: trigger.event is required", p.ID)
+	}
+	if p.Trigger.MinSeverity != "" {
+		if _, ok := severityRank[strings.ToLower(p.Trigger.MinSeverity)]; !ok {
+			return fmt.Errorf("playbook %s: unknown severity %q", p.ID, p.Trigger.MinSeverity)
+		}
+	}
+	if len(p.Actions) == 0 {
+		return fmt.Errorf("playbook %s: at least one action is required", p.ID)
+	}
+	for _, a := range p.Actions {
+		switch a.Type {
+		case ActionQuarantine, ActionBlockOriginDomain, ActionNotify, ActionIsolateNetwork, ActionLockWorkstation:
+		default:
+			return fmt.Errorf("playbook %s: unknown action %q", p.ID, a.Type)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether an event satisfies the trigger
+func (t *Trigger) Matches(eventType string, fields map[string]interface{}) bool {
+	if t.Event != eventType {
+		return false
+	}
+
+	if t.MinSeverity != "" {
+		severity, _ := fields["severity"].(string)
+		if severityRank[strings.ToLower(severity)] < severityRank[strings.ToLower(t.MinSeverity)] {
+			return false
+		}
+	}
+
+	path, _ := fields["path"].(string)
+	if t.PathPrefix != "" {
+		prefix := filepath.Clean(config.ExpandPath(t.PathPrefix))
+		if !strings.HasPrefix(strings.ToLower(path), strings.ToLower(prefix)) {
+			return false
+		}
+	}
+	if t.PathContains != "" && !strings.Contains(strings.ToLower(path), strings.ToLower(t.PathContains)) {
+		return false
+	}
+
+	if t.ThreatType != "" {
+		threatType, _ := fields["type"].(string)
+		if !strings.Contains(strings.ToLower(threatType), strings.ToLower(t.ThreatType)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,64 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+const (
+	processQueryInformation = 0x0400
+	processVMRead           = 0x0010
+
+	miniDumpWithFullMemory      = 0x00000002
+	miniDumpWithHandleData      = 0x00000004
+	miniDumpWithUnloadedModules = 0x00000020
+	miniDumpWithFullMemoryInfo  = 0x00000800
+	miniDumpWithThreadInfo      = 0x00001000
+	fullMemoryDumpType          = miniDumpWithFullMemory | miniDumpWithHandleData | miniDumpWithUnloadedModules | miniDumpWithFullMemoryInfo | miniDumpWithThreadInfo
+	credentialProcessName       = "lsass.exe"
+)
+
+var (
+	dbghelp               = syscall.NewLazyDLL("dbghelp.dll")
+	procMiniDumpWriteDump = dbghelp.NewProc("MiniDumpWriteDump")
+)
+
+// Dump writes a full-memory minidump of a process to path
+func Dump(pid uint32, path string) error {
+	if name := NameMap()[pid]; strings.EqualFold(name, credentialProcessName) {
+		// An LSASS dump is a credential dump; never produce one remotely
+		return fmt.Errorf("refusing to dump %s", name)
+	}
+
+	handle, err := syscall.OpenProcess(processQueryInformation|processVMRead, false, pid)
+	if err != nil {
+		return fmt.Errorf("failed to open process %d: %w", pid, err)
+	}
+	defer syscall.CloseHandle(handle)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create dump file: %w", err)
+	}
+
+	ret, _, callErr := procMiniDumpWriteDump.Call(
+		uintptr(handle),
+		uintptr(pid),
+		f.Fd(),
+		fullMemoryDumpType,
+		0, 0, 0,
+	)
+	closeErr := f.Close()
+
+	if ret == 0 {
+		os.Remove(path)
+		return fmt.Errorf("MiniDumpWriteDump failed for PID %d: %v", pid, callErr)
+	}
+	if closeErr != nil {
+		os.Remove(path)
+		return closeErr
+	}
+	return nil
+}
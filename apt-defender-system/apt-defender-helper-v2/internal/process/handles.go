@@ -0,0 +1,216 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	systemExtendedHandleInformation = 64
+	objectNameInformation           = 1
+	objectTypeInformation           = 2
+	statusInfoLengthMismatch        = 0xC0000004
+	processDupHandle                = 0x0040
+	duplicateSameAccess             = 0x2
+	fileTypeDisk                    = 1
+)
+
+var (
+	ntdll                         = syscall.NewLazyDLL("ntdll.dll")
+	procNtQuerySystemInformation  = ntdll.NewProc("NtQuerySystemInformation")
+	procNtQueryObject             = ntdll.NewProc("NtQueryObject")
+	procGetFinalPathNameByHandleW = kernel32.NewProc("GetFinalPathNameByHandleW")
+	procGetCurrentProcess         = kernel32.NewProc("GetCurrentProcess")
+)
+
+type handleEntryEx struct {
+	Object                uintptr
+	UniqueProcessID       uintptr
+	HandleValue           uintptr
+	GrantedAccess         uint32
+	CreatorBackTraceIndex uint16
+	ObjectTypeIndex       uint16
+	HandleAttributes      uint32
+	Reserved              uint32
+}
+
+type unicodeString struct {
+	Length        uint16
+	MaximumLength uint16
+	Buffer        *uint16
+}
+
+// Registry roots as the kernel names them
+var registryRoots = []struct{ kernel, short string }{
+	{`\REGISTRY\MACHINE`, "HKLM"},
+	{`\REGISTRY\USER`, "HKU"},
+}
+
+// Handle is an open file or registry key handle
+type Handle struct {
+	PID         uint32 `json:"pid"`
+	ProcessName string `json:"process_name"`
+	Handle      string `json:"handle"`
+	Type        string `json:"type"` // File or Key
+	Name        string `json:"name"`
+}
+
+// Handles returns the open file and registry handles of one process
+func Handles(pid uint32) ([]Handle, error) {
+	return collectHandles(func(owner uint32) bool { return owner == pid }, "")
+}
+
+// FindHandles returns every handle, in any process, whose name contains the
+// given path, e.g. to find who holds a malicious file open
+func FindHandles(path string) ([]Handle, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	return collectHandles(func(uint32) bool { return true }, strings.ToLower(path))
+}
+
+func collectHandles(wantPID func(uint32) bool, nameFilter string) ([]Handle, error) {
+	entries, err := systemHandles()
+	if err != nil {
+		return nil, err
+	}
+
+	names := NameMap()
+	self, _, _ := procGetCurrentProcess.Call()
+	typeNames := make(map[uint16]string)
+	processes := make(map[uint32]syscall.Handle)
+	defer func() {
+		for _, h := range processes {
+			syscall.CloseHandle(h)
+		}
+	}()
+
+	result := []Handle{}
+	for _, e := range entries {
+		pid := uint32(e.UniqueProcessID)
+		if pid == 0 || pid == 4 || !wantPID(pid) {
+			continue
+		}
+
+		proc, ok := processes[pid]
+		if !ok {
+			proc, err = syscall.OpenProcess(processDupHandle, false, pid)
+			if err != nil {
+				proc = 0
+			}
+			processes[pid] = proc
+		}
+		if proc == 0 {
+			continue
+		}
+
+		var dup syscall.Handle
+		if err := syscall.DuplicateHandle(proc, syscall.Handle(e.HandleValue), syscall.Handle(self), &dup, 0, false, duplicateSameAccess); err != nil {
+			continue
+		}
+
+		typeName, known := typeNames[e.ObjectTypeIndex]
+		if !known {
+			typeName = queryObjectString(dup, objectTypeInformation)
+			typeNames[e.ObjectTypeIndex] = typeName
+		}
+
+		var name string
+		switch typeName {
+		case "File":
+			name = diskFileName(dup)
+		case "Key":
+			name = registryName(queryObjectString(dup, objectNameInformation))
+		}
+		syscall.CloseHandle(dup)
+
+		if name == "" || (nameFilter != "" && !strings.Contains(strings.ToLower(name), nameFilter)) {
+			continue
+		}
+
+		result = append(result, Handle{
+			PID:         pid,
+			ProcessName: names[pid],
+			Handle:      fmt.Sprintf("0x%x", e.HandleValue),
+			Type:        typeName,
+			Name:        name,
+		})
+	}
+
+	return result, nil
+}
+
+// systemHandles snapshots the system-wide handle table
+func systemHandles() ([]handleEntryEx, error) {
+	size := uint32(1 << 20)
+	for attempt := 0; attempt < 8; attempt++ {
+		buf := make([]byte, size)
+		var needed uint32
+		status, _, _ := procNtQuerySystemInformation.Call(
+			systemExtendedHandleInformation,
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(size),
+			uintptr(unsafe.Pointer(&needed)),
+		)
+		if status == statusInfoLengthMismatch {
+			// The table grows while we allocate; leave some headroom
+			size = needed + needed/4 + 4096
+			continue
+		}
+		if status != 0 {
+			return nil, fmt.Errorf("NtQuerySystemInformation failed: 0x%x", status)
+		}
+
+		count := *(*uintptr)(unsafe.Pointer(&buf[0]))
+		first := unsafe.Pointer(&buf[2*unsafe.Sizeof(uintptr(0))])
+		entries := make([]handleEntryEx, count)
+		copy(entries, unsafe.Slice((*handleEntryEx)(first), count))
+		return entries, nil
+	}
+	return nil, fmt.Errorf("handle table kept growing")
+}
+
+// queryObjectString reads the UNICODE_STRING at the start of an
+// object type or name information block
+func queryObjectString(h syscall.Handle, class uintptr) string {
+	buf := make([]byte, 4096)
+	var needed uint32
+	status, _, _ := procNtQueryObject.Call(uintptr(h), class,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), uintptr(unsafe.Pointer(&needed)))
+	if status != 0 {
+		return ""
+	}
+	us := (*unicodeString)(unsafe.Pointer(&buf[0]))
+	if us.Buffer == nil || us.Length == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(unsafe.Slice(us.Buffer, us.Length/2))
+}
+
+// diskFileName resolves file handles that refer to files on disk. Pipes and
+// other devices are skipped because querying their names can block.
+func diskFileName(h syscall.Handle) string {
+	if t, err := syscall.GetFileType(h); err != nil || t != fileTypeDisk {
+		return ""
+	}
+
+	buf := make([]uint16, syscall.MAX_LONG_PATH)
+	n, _, _ := procGetFinalPathNameByHandleW.Call(uintptr(h), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), 0)
+	if n == 0 || int(n) > len(buf) {
+		return ""
+	}
+	return strings.TrimPrefix(syscall.UTF16ToString(buf[:n]), `\\?\`)
+}
+
+// registryName converts \REGISTRY\MACHINE\... to HKLM\...
+func registryName(name string) string {
+	upper := strings.ToUpper(name)
+	for _, root := range registryRoots {
+		if strings.HasPrefix(upper, root.kernel) {
+			return root.short + name[len(root.kernel):]
+		}
+	}
+	return name
+}
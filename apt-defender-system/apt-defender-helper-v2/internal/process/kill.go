@@ -0,0 +1,214 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	processTerminate               = 0x0001
+	processQueryLimitedInformation = 0x1000
+
+	processProtectionLevelInfo = 7          // PROCESS_INFORMATION_CLASS for GetProcessInformation
+	protectionLevelNone        = 0xFFFFFFFE // PROTECTION_LEVEL_NONE
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procQueryFullProcessImageNameW = kernel32.NewProc("QueryFullProcessImageNameW")
+	procIsProcessCritical          = kernel32.NewProc("IsProcessCritical")
+	procGetProcessInformation      = kernel32.NewProc("GetProcessInformation")
+)
+
+// criticalProcesses must never be terminated when they run from System32;
+// killing them bluescreens the machine. The name alone proves nothing, since
+// malware often calls itself lsass.exe or csrss.exe from another folder.
+var criticalProcesses = map[string]bool{
+	"system":       true,
+	"smss.exe":     true,
+	"csrss.exe":    true,
+	"wininit.exe":  true,
+	"winlogon.exe": true,
+	"services.exe": true,
+	"lsass.exe":    true,
+}
+
+// Match is a process selected by a kill pattern and what happened to it
+type Match struct {
+	PID       uint32 `json:"pid"`
+	Name      string `json:"name"`
+	ImagePath string `json:"image_path,omitempty"`
+	Killed    bool   `json:"killed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ImagePath returns the full path of a process executable
+func ImagePath(pid uint32) (string, error) {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, pid)
+	if err != nil {
+		return "", err
+	}
+	defer syscall.CloseHandle(handle)
+	return imagePath(handle)
+}
+
+func imagePath(handle syscall.Handle) (string, error) {
+	buf := make([]uint16, syscall.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	ret, _, err := procQueryFullProcessImageNameW.Call(uintptr(handle), 0,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)))
+	if ret == 0 {
+		return "", err
+	}
+	return syscall.UTF16ToString(buf[:size]), nil
+}
+
+// Kill terminates a single process
+func Kill(pid uint32) error {
+	handle, err := syscall.OpenProcess(processTerminate, false, pid)
+	if err != nil {
+		return fmt.Errorf("failed to open process %d: %w", pid, err)
+	}
+	defer syscall.CloseHandle(handle)
+
+	if err := syscall.TerminateProcess(handle, 1); err != nil {
+		return fmt.Errorf("failed to terminate process %d: %w", pid, err)
+	}
+	return nil
+}
+
+// KillPID terminates one process by ID, refusing the ones KillMatching
+// never touches (critical system processes and the helper itself)
+func KillPID(pid uint32) (Match, error) {
+	processes, err := List()
+	if err != nil {
+		return Match{}, err
+	}
+	for _, p := range processes {
+		if p.PID != pid {
+			continue
+		}
+		m := Match{PID: p.PID, Name: p.Name}
+		image, reason := guard(p)
+		m.ImagePath = image
+		if reason != "" {
+			return m, fmt.Errorf("%s (PID %d) is %s", p.Name, p.PID, reason)
+		}
+		if err := Kill(p.PID); err != nil {
+			m.Error = err.Error()
+			return m, err
+		}
+		m.Killed = true
+		return m, nil
+	}
+	return Match{PID: pid}, fmt.Errorf("no process with PID %d", pid)
+}
+
+// guard returns p's image path and why it must not be killed, or "" when it
+// may be: the idle and System processes and the helper itself, processes
+// Windows marks critical or protected (PPL), the criticalProcesses running
+// from System32, and any process whose image can't be read to tell.
+func guard(p Process) (string, string) {
+	if p.PID == 0 || p.PID == 4 || p.PID == uint32(os.Getpid()) {
+		return "", "a protected process"
+	}
+
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, p.PID)
+	if err != nil {
+		return "", "a process that can't be inspected"
+	}
+	defer syscall.CloseHandle(handle)
+
+	image, err := imagePath(handle)
+	if err != nil {
+		return "", "a process whose image can't be read"
+	}
+	switch {
+	case isCritical(handle):
+		return image, "a critical process"
+	case isProtected(handle):
+		return image, "a protected process (PPL)"
+	case criticalProcesses[strings.ToLower(p.Name)] && inSystem32(image):
+		return image, "a critical system process"
+	}
+	return image, ""
+}
+
+// isCritical reports whether terminating the process would stop Windows
+func isCritical(handle syscall.Handle) bool {
+	var critical int32
+	ret, _, _ := procIsProcessCritical.Call(uintptr(handle), uintptr(unsafe.Pointer(&critical)))
+	return ret != 0 && critical != 0
+}
+
+// isProtected reports whether the process runs as a protected process or PPL
+func isProtected(handle syscall.Handle) bool {
+	var level uint32
+	ret, _, _ := procGetProcessInformation.Call(uintptr(handle), processProtectionLevelInfo,
+		uintptr(unsafe.Pointer(&level)), unsafe.Sizeof(level))
+	return ret != 0 && level != protectionLevelNone
+}
+
+// inSystem32 reports whether image lives under %SystemRoot%\System32
+func inSystem32(image string) bool {
+	root := os.Getenv("SystemRoot")
+	if root == "" {
+		root = `C:\Windows`
+	}
+	dir := filepath.Join(root, "System32") + `\`
+	return len(image) > len(dir) && strings.EqualFold(image[:len(dir)], dir)
+}
+
+// KillMatching terminates every process whose name (or, when the pattern
+// contains a path separator, full image path) matches the glob. With dryRun
+// set nothing is killed and the matches are only reported.
+func KillMatching(pattern string, dryRun bool) ([]Match, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is required")
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	byPath := strings.ContainsAny(pattern, `\/`)
+
+	processes, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []Match{}
+	for _, p := range processes {
+		image, reason := guard(p)
+		if reason != "" {
+			continue
+		}
+
+		subject := strings.ToLower(p.Name)
+		if byPath {
+			if image == "" {
+				continue
+			}
+			subject = strings.ToLower(image)
+		}
+		if ok, _ := filepath.Match(pattern, subject); !ok {
+			continue
+		}
+
+		m := Match{PID: p.PID, Name: p.Name, ImagePath: image}
+		if !dryRun {
+			if err := Kill(p.PID); err != nil {
+				m.Error = err.Error()
+			} else {
+				m.Killed = true
+			}
+		}
+		matches = append(matches, m)
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,74 @@
+package process
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	th32csSnapModule   = 0x00000008
+	th32csSnapModule32 = 0x00000010
+)
+
+var (
+	procModule32FirstW = kernel32.NewProc("Module32FirstW")
+	procModule32NextW  = kernel32.NewProc("Module32NextW")
+)
+
+type moduleEntry32 struct {
+	Size         uint32
+	ModuleID     uint32
+	ProcessID    uint32
+	GlblcntUsage uint32
+	ProccntUsage uint32
+	ModBaseAddr  uintptr
+	ModBaseSize  uint32
+	HModule      uintptr
+	Module       [256]uint16
+	ExePath      [syscall.MAX_PATH]uint16
+}
+
+// Module is a DLL (or the main image) mapped into a process
+type Module struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	BaseAddress string `json:"base_address"`
+	Size        uint32 `json:"size"`
+	Signature   string `json:"signature,omitempty"`
+	Signer      string `json:"signer,omitempty"`
+}
+
+// Modules lists the modules loaded into a process
+func Modules(pid uint32) ([]Module, error) {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(th32csSnapModule|th32csSnapModule32, pid)
+	if err != nil {
+		return nil, fmt.Errorf("CreateToolhelp32Snapshot failed for PID %d: %w", pid, err)
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	var entry moduleEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+
+	ret, _, err := procModule32FirstW.Call(uintptr(snapshot), uintptr(unsafe.Pointer(&entry)))
+	if ret == 0 {
+		return nil, fmt.Errorf("Module32First failed for PID %d: %w", pid, err)
+	}
+
+	var modules []Module
+	for {
+		modules = append(modules, Module{
+			Name:        syscall.UTF16ToString(entry.Module[:]),
+			Path:        syscall.UTF16ToString(entry.ExePath[:]),
+			BaseAddress: fmt.Sprintf("0x%x", entry.ModBaseAddr),
+			Size:        entry.ModBaseSize,
+		})
+
+		ret, _, _ = procModule32NextW.Call(uintptr(snapshot), uintptr(unsafe.Pointer(&entry)))
+		if ret == 0 {
+			break
+		}
+	}
+
+	return modules, nil
+}
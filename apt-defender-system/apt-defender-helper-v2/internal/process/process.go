@@ -0,0 +1,62 @@
+package process
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+type Process struct {
+	PID     uint32 `json:"pid"`
+	PPID    uint32 `json:"ppid"`
+	Name    string `json:"name"`
+	Threads uint32 `json:"threads"`
+}
+
+// List returns a snapshot of all running processes
+func List() ([]Process, error) {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, fmt.Errorf("CreateToolhelp32Snapshot failed: %w", err)
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+
+	if err := syscall.Process32First(snapshot, &entry); err != nil {
+		return nil, fmt.Errorf("Process32First failed: %w", err)
+	}
+
+	var processes []Process
+	for {
+		processes = append(processes, Process{
+			PID:     entry.ProcessID,
+			PPID:    entry.ParentProcessID,
+			Name:    syscall.UTF16ToString(entry.ExeFile[:]),
+			Threads: entry.Threads,
+		})
+
+		if err := syscall.Process32Next(snapshot, &entry); err != nil {
+			break
+		}
+	}
+
+	return processes, nil
+}
+
+// NameMap returns a PID to executable name lookup table
+func NameMap() map[uint32]string {
+	names := make(map[uint32]string)
+
+	processes, err := List()
+	if err != nil {
+		return names
+	}
+
+	for _, p := range processes {
+		names[p.PID] = p.Name
+	}
+
+	return names
+}
@@ -0,0 +1,172 @@
+package process
+
+import (
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// cpuSampleWindow is how long ListDetailed waits to measure CPU usage when
+// it has no recent sample to compare against
+const cpuSampleWindow = 300 * time.Millisecond
+
+var procK32GetProcessMemoryInfo = kernel32.NewProc("K32GetProcessMemoryInfo")
+
+type processMemoryCounters struct {
+	CB                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+// Info is a process with its owner and resource usage. User is empty and
+// the counters zero for processes the helper can't open (protected ones).
+type Info struct {
+	Process
+	ImagePath   string  `json:"image_path,omitempty"`
+	User        string  `json:"user,omitempty"`
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryBytes uint64  `json:"memory_bytes"` // working set
+}
+
+type cpuSample struct {
+	total time.Duration
+	at    time.Time
+}
+
+var (
+	cpuMutex   sync.Mutex
+	cpuSamples = map[uint32]cpuSample{}
+	accounts   sync.Map // SID string -> DOMAIN\user
+)
+
+// ListDetailed returns every running process with owner, CPU and memory.
+// CPU is the share of all cores used since the previous call, or over a
+// short sampling window when there was none in the last minute.
+func ListDetailed() ([]Info, error) {
+	processes, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]Info, len(processes))
+	handles := make([]syscall.Handle, len(processes))
+	for i, p := range processes {
+		infos[i].Process = p
+		h, err := syscall.OpenProcess(processQueryLimitedInformation, false, p.PID)
+		if err != nil {
+			continue
+		}
+		handles[i] = h
+		infos[i].ImagePath, _ = imagePath(h)
+		infos[i].User = processUser(h)
+		infos[i].MemoryBytes = workingSet(h)
+	}
+	defer func() {
+		for _, h := range handles {
+			if h != 0 {
+				syscall.CloseHandle(h)
+			}
+		}
+	}()
+
+	cpuMutex.Lock()
+	defer cpuMutex.Unlock()
+
+	if stale := len(cpuSamples) == 0 || time.Since(newestSample()) > time.Minute; stale {
+		for i, h := range handles {
+			if h != 0 {
+				cpuSamples[infos[i].PID] = cpuSample{total: cpuTime(h), at: time.Now()}
+			}
+		}
+		time.Sleep(cpuSampleWindow)
+	}
+
+	cores := float64(runtime.NumCPU())
+	next := make(map[uint32]cpuSample, len(handles))
+	for i, h := range handles {
+		if h == 0 {
+			continue
+		}
+		sample := cpuSample{total: cpuTime(h), at: time.Now()}
+		if prev, ok := cpuSamples[infos[i].PID]; ok && sample.total >= prev.total {
+			if elapsed := sample.at.Sub(prev.at); elapsed > 0 {
+				infos[i].CPUPercent = float64(sample.total-prev.total) / float64(elapsed) / cores * 100
+			}
+		}
+		next[infos[i].PID] = sample
+	}
+	cpuSamples = next
+	return infos, nil
+}
+
+func newestSample() time.Time {
+	var newest time.Time
+	for _, s := range cpuSamples {
+		if s.at.After(newest) {
+			newest = s.at
+		}
+	}
+	return newest
+}
+
+// cpuTime is the kernel plus user time a process has used
+func cpuTime(h syscall.Handle) time.Duration {
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(h, &creation, &exit, &kernel, &user); err != nil {
+		return 0
+	}
+	ticks := int64(kernel.HighDateTime)<<32 | int64(kernel.LowDateTime)
+	ticks += int64(user.HighDateTime)<<32 | int64(user.LowDateTime)
+	return time.Duration(ticks * 100)
+}
+
+func workingSet(h syscall.Handle) uint64 {
+	var counters processMemoryCounters
+	counters.CB = uint32(unsafe.Sizeof(counters))
+	ret, _, _ := procK32GetProcessMemoryInfo.Call(uintptr(h), uintptr(unsafe.Pointer(&counters)), uintptr(counters.CB))
+	if ret == 0 {
+		return 0
+	}
+	return uint64(counters.WorkingSetSize)
+}
+
+// processUser resolves the account a process runs as, e.g. NT AUTHORITY\SYSTEM
+func processUser(h syscall.Handle) string {
+	var token syscall.Token
+	if err := syscall.OpenProcessToken(h, syscall.TOKEN_QUERY, &token); err != nil {
+		return ""
+	}
+	defer token.Close()
+
+	tu, err := token.GetTokenUser()
+	if err != nil {
+		return ""
+	}
+	sid, err := tu.User.Sid.String()
+	if err != nil {
+		return ""
+	}
+	if name, ok := accounts.Load(sid); ok {
+		return name.(string)
+	}
+
+	account, domain, _, err := tu.User.Sid.LookupAccount("")
+	if err != nil {
+		return sid
+	}
+	name := account
+	if domain != "" {
+		name = domain + `\` + account
+	}
+	accounts.Store(sid, name)
+	return name
+}
@@ -0,0 +1,25 @@
+package quarantine
+
+import "syscall"
+
+// getAttributes returns the Windows file attributes, or 0 if unavailable
+func getAttributes(path string) uint32 {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0
+	}
+	attrs, err := syscall.GetFileAttributes(pathPtr)
+	if err != nil {
+		return 0
+	}
+	return attrs
+}
+
+// setAttributes restores recorded Windows file attributes
+func setAttributes(path string, attrs uint32) error {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	return syscall.SetFileAttributes(pathPtr, attrs)
+}
@@ -0,0 +1,169 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Retention bounds how much the quarantine may hold; zero disables a limit
+type Retention struct {
+	MaxAge   time.Duration `json:"max_age"`
+	MaxBytes int64         `json:"max_bytes"`
+}
+
+// List returns every quarantined item, newest first
+func (s *Store) List() ([]Item, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Item{}, nil
+		}
+		return nil, err
+	}
+
+	items := []Item{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), metaSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var item Item
+		if err := json.Unmarshal(data, &item); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].QuarantinedAt.After(items[j].QuarantinedAt) })
+	return items, nil
+}
+
+// Delete permanently removes a quarantined file and its metadata
+func (s *Store) Delete(id string) (*Item, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.remove(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// Enforce deletes items older than MaxAge, then the oldest items until the
+// total size fits MaxBytes
+func (s *Store) Enforce(policy Retention) ([]Item, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	items, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, item := range items {
+		total += item.Size
+	}
+
+	var removed []Item
+	// Walk oldest first
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		expired := policy.MaxAge > 0 && time.Since(item.QuarantinedAt) > policy.MaxAge
+		oversize := policy.MaxBytes > 0 && total > policy.MaxBytes
+		if !expired && !oversize {
+			continue
+		}
+
+		if err := s.remove(&item); err != nil {
+			log.Printf("⚠️ Quarantine retention could not remove %s: %v", item.ID, err)
+			continue
+		}
+		total -= item.Size
+		removed = append(removed, item)
+	}
+
+	return removed, nil
+}
+
+// StartJanitor enforces the retention policy every interval
+func (s *Store) StartJanitor(policy Retention, interval time.Duration) {
+	s.mutex.Lock()
+	if s.stopSignal != nil {
+		s.mutex.Unlock()
+		return
+	}
+	s.stopSignal = make(chan struct{})
+	stop := s.stopSignal
+	s.retention = policy
+	s.mutex.Unlock()
+
+	log.Printf("🧹 Quarantine janitor started (max age %s, max %d bytes)", policy.MaxAge, policy.MaxBytes)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.runJanitor(policy)
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// StopJanitor halts background retention enforcement
+func (s *Store) StopJanitor() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopSignal != nil {
+		close(s.stopSignal)
+		s.stopSignal = nil
+	}
+}
+
+// Retention returns the policy the janitor enforces
+func (s *Store) Retention() Retention {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.retention
+}
+
+func (s *Store) runJanitor(policy Retention) {
+	removed, err := s.Enforce(policy)
+	if err != nil {
+		log.Printf("⚠️ Quarantine retention failed: %v", err)
+		return
+	}
+	for _, item := range removed {
+		log.Printf("🧹 Quarantine retention removed %s (%s, quarantined %s)",
+			item.ID, item.OriginalPath, item.QuarantinedAt.Format(time.RFC3339))
+	}
+}
+
+func (s *Store) remove(item *Item) error {
+	data := filepath.Join(s.dir, item.ID+dataSuffix)
+	os.Chmod(data, 0666)
+	if err := os.Remove(data); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete quarantined file: %w", err)
+	}
+	return os.Remove(filepath.Join(s.dir, item.ID+metaSuffix))
+}
@@ -0,0 +1,273 @@
+package quarantine
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	dataSuffix = ".quar"
+	metaSuffix = ".json"
+)
+
+// Item is the metadata recorded for every quarantined file
+type Item struct {
+	ID             string    `json:"id"`
+	OriginalPath   string    `json:"original_path"`
+	QuarantinePath string    `json:"quarantine_path"`
+	Reason         string    `json:"reason"`
+	SHA256         string    `json:"sha256"`
+	Size           int64     `json:"size"`
+	Attributes     uint32    `json:"attributes"`
+	QuarantinedAt  time.Time `json:"quarantined_at"`
+}
+
+// Store keeps quarantined files under a single directory, each stored under
+// a unique ID with a JSON metadata sidecar
+type Store struct {
+	mutex      sync.Mutex
+	dir        string
+	retention  Retention
+	stopSignal chan struct{}
+}
+
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the quarantine directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Quarantine moves a file into the store and records its metadata
+func (s *Store) Quarantine(path, reason string) (*Item, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("cannot quarantine a directory")
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create quarantine dir: %w", err)
+	}
+
+	hash, err := hashFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	item := &Item{
+		ID:             id,
+		OriginalPath:   path,
+		QuarantinePath: filepath.Join(s.dir, id+dataSuffix),
+		Reason:         reason,
+		SHA256:         hash,
+		Size:           info.Size(),
+		Attributes:     getAttributes(path),
+		QuarantinedAt:  time.Now(),
+	}
+
+	// Clear read-only so the original can be removed after the move
+	os.Chmod(path, 0666)
+	if err := moveFile(path, item.QuarantinePath); err != nil {
+		return nil, fmt.Errorf("failed to move file to quarantine: %w", err)
+	}
+	os.Chmod(item.QuarantinePath, 0444)
+
+	// Without its metadata the blob is invisible to List and Restore, so put
+	// the file back rather than lose track of it
+	if err := s.writeMeta(item); err != nil {
+		os.Chmod(item.QuarantinePath, 0666)
+		if moveErr := moveFile(item.QuarantinePath, path); moveErr != nil {
+			os.Remove(item.QuarantinePath)
+			return nil, fmt.Errorf("%w; moving the file back also failed (%v), so it was deleted", err, moveErr)
+		}
+		if item.Attributes != 0 {
+			setAttributes(path, item.Attributes)
+		}
+		return nil, err
+	}
+
+	log.Printf("☣️ Quarantined %s as %s", path, id)
+	return item, nil
+}
+
+// Get returns the metadata of a quarantined item
+func (s *Store) Get(id string) (*Item, error) {
+	if !validID(id) {
+		return nil, fmt.Errorf("invalid quarantine ID %q", id)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, id+metaSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("quarantine item not found: %s", id)
+	}
+
+	var item Item
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine metadata: %w", err)
+	}
+	return &item, nil
+}
+
+// Restore moves a quarantined file back to its original path and reapplies
+// its attributes. An existing file at that path is only replaced when
+// overwrite is set; it is set aside first and put back if the restore fails.
+func (s *Store) Restore(id string, overwrite bool) (*Item, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := hashFile(item.QuarantinePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantined file: %w", err)
+	}
+	if hash != item.SHA256 {
+		return nil, fmt.Errorf("quarantined file %s does not match its recorded hash", id)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to recreate original folder: %w", err)
+	}
+
+	backup := ""
+	if _, err := os.Stat(item.OriginalPath); err == nil {
+		if !overwrite {
+			return nil, fmt.Errorf("%s already exists (set overwrite to replace it)", item.OriginalPath)
+		}
+		backup = item.OriginalPath + ".aptd-restore-" + id
+		if err := os.Rename(item.OriginalPath, backup); err != nil {
+			return nil, fmt.Errorf("failed to set aside existing file: %w", err)
+		}
+	}
+
+	os.Chmod(item.QuarantinePath, 0666)
+	if err := moveFile(item.QuarantinePath, item.OriginalPath); err != nil {
+		os.Chmod(item.QuarantinePath, 0444)
+		if backup != "" {
+			if undoErr := os.Rename(backup, item.OriginalPath); undoErr != nil {
+				log.Printf("⚠️ Failed to put back %s, it is at %s: %v", item.OriginalPath, backup, undoErr)
+			}
+		}
+		return nil, fmt.Errorf("failed to restore file: %w", err)
+	}
+	if backup != "" {
+		os.Chmod(backup, 0666)
+		if err := os.Remove(backup); err != nil {
+			log.Printf("⚠️ Failed to delete the replaced file %s: %v", backup, err)
+		}
+	}
+	if item.Attributes != 0 {
+		if err := setAttributes(item.OriginalPath, item.Attributes); err != nil {
+			log.Printf("⚠️ Failed to restore attributes of %s: %v", item.OriginalPath, err)
+		}
+	}
+
+	os.Remove(filepath.Join(s.dir, id+metaSuffix))
+
+	log.Printf("♻️ Restored %s from quarantine to %s", id, item.OriginalPath)
+	return item, nil
+}
+
+func (s *Store) writeMeta(item *Item) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal quarantine metadata: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, item.ID+metaSuffix), data, 0600); err != nil {
+		return fmt.Errorf("failed to write quarantine metadata: %w", err)
+	}
+	return nil
+}
+
+// moveFile renames when possible and falls back to copy+delete across volumes
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		in.Close()
+		return err
+	}
+
+	_, copyErr := io.Copy(out, in)
+	closeErr := out.Close()
+	in.Close()
+
+	if copyErr != nil {
+		os.Remove(dst)
+		return copyErr
+	}
+	if closeErr != nil {
+		os.Remove(dst)
+		return closeErr
+	}
+
+	return os.Remove(src)
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// validID rejects anything that could escape the quarantine directory
+func validID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate quarantine ID: %w", err)
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(buf), nil
+}
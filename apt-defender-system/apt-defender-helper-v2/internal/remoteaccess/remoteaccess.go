@@ -0,0 +1,199 @@
+package remoteaccess
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/control"
+	"github.com/apt-defender/helper-v2/internal/services"
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const (
+	stateFile       = "remote-access-state.json"
+	terminalServer  = `HKLM\System\CurrentControlSet\Control\Terminal Server`
+	denyConnections = "fDenyTSConnections"
+)
+
+// Remote access services and the inbound ports blocked alongside them
+var targets = []struct {
+	Service  string
+	Protocol string
+	Ports    string
+}{
+	{"TermService", "any", "3389"},
+	{"WinRM", "tcp", "5985,5986"},
+	{"sshd", "tcp", "22"},
+}
+
+// ServiceState is what a service looked like before lockdown
+type ServiceState struct {
+	Name      string `json:"name"`
+	StartType string `json:"start_type"`
+	Running   bool   `json:"running"`
+}
+
+// State records the pre-lockdown configuration so Enable can put it back
+type State struct {
+	DisabledAt        time.Time          `json:"disabled_at"`
+	DenyTSConnections uint64             `json:"deny_ts_connections"`
+	Services          []ServiceState     `json:"services"`
+	FirewallRules     []control.PortRule `json:"firewall_rules"`
+	Warnings          []string           `json:"warnings,omitempty"`
+}
+
+var mutex sync.Mutex
+
+// Disable turns off RDP, WinRM and SSH: RDP connections are denied in the
+// registry, the services are stopped and disabled, and their inbound ports
+// are blocked. Calling it again while locked down returns the saved state.
+func Disable(dataDir string) (*State, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if state, err := load(dataDir); err == nil {
+		return state, nil
+	}
+
+	log.Println("🔒 DISABLING REMOTE ACCESS (RDP/WinRM/SSH)")
+
+	state := &State{DisabledAt: time.Now()}
+	if key, err := winreg.Open(terminalServer); err == nil {
+		state.DenyTSConnections, _ = key.GetUint(denyConnections)
+		key.Close()
+	}
+	if err := setDenyConnections(1); err != nil {
+		return nil, err
+	}
+
+	for _, t := range targets {
+		svc, err := services.Get(t.Service)
+		if err == nil {
+			state.Services = append(state.Services, ServiceState{
+				Name:      svc.Name,
+				StartType: svc.StartType,
+				Running:   svc.State == "running",
+			})
+			if svc.State == "running" {
+				if err := services.Stop(t.Service); err != nil {
+					state.Warnings = append(state.Warnings, err.Error())
+				}
+			}
+			if err := services.Disable(t.Service); err != nil {
+				state.Warnings = append(state.Warnings, err.Error())
+			}
+		}
+
+		rules, err := control.BlockPorts(t.Protocol, t.Ports, "in")
+		if err != nil {
+			state.Warnings = append(state.Warnings, err.Error())
+			continue
+		}
+		state.FirewallRules = append(state.FirewallRules, rules...)
+	}
+
+	if err := save(dataDir, state); err != nil {
+		return state, err
+	}
+
+	log.Printf("✅ Remote access disabled (%d warnings)", len(state.Warnings))
+	return state, nil
+}
+
+// Enable restores the configuration recorded by Disable
+func Enable(dataDir string) (*State, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	state, err := load(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("remote access is not disabled by the helper")
+	}
+
+	log.Println("🔓 RESTORING REMOTE ACCESS")
+
+	var warnings []string
+	for _, rule := range state.FirewallRules {
+		if _, err := control.UnblockPorts(rule.Protocol, rule.Ports, rule.Direction); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+
+	for _, svc := range state.Services {
+		if err := services.SetStartType(svc.Name, svc.StartType); err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		if svc.Running {
+			if err := services.Start(svc.Name); err != nil {
+				warnings = append(warnings, err.Error())
+			}
+		}
+	}
+
+	if err := setDenyConnections(state.DenyTSConnections); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	if err := os.Remove(filepath.Join(dataDir, stateFile)); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	state.Warnings = warnings
+	log.Printf("✅ Remote access restored (%d warnings)", len(warnings))
+	return state, nil
+}
+
+// Status returns the saved lockdown state, or nil when remote access is not
+// disabled by the helper
+func Status(dataDir string) *State {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	state, err := load(dataDir)
+	if err != nil {
+		return nil
+	}
+	return state
+}
+
+func setDenyConnections(value uint64) error {
+	cmd := exec.Command("reg", "add", terminalServer, "/v", denyConnections, "/t", "REG_DWORD", "/d", fmt.Sprint(value), "/f")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set %s: %v, output: %s", denyConnections, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func load(dataDir string) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, stateFile))
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse remote access state: %w", err)
+	}
+	return &state, nil
+}
+
+func save(dataDir string, state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal remote access state: %w", err)
+	}
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return fmt.Errorf("failed to create data dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, stateFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write remote access state: %w", err)
+	}
+	return nil
+}
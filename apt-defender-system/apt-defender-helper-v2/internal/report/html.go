@@ -0,0 +1,107 @@
+package report
+
+import (
+	"html/template"
+	"time"
+)
+
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"when": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Local().Format("2006-01-02 15:04:05")
+	},
+	"whenPtr": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Local().Format("2006-01-02 15:04:05")
+	},
+	"uptime": func(seconds uint64) string {
+		return (time.Duration(seconds) * time.Second).String()
+	},
+}).Parse(pageHTML))
+
+// pageHTML is report.html. Everything from the PC is escaped by html/template.
+const pageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>Incident report - {{.Hostname}} - {{when .GeneratedAt}}</title>
+<style>
+    body { font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; color: #1d2733; margin: 30px; }
+    h1 { margin-bottom: 5px; }
+    h2 { margin-top: 30px; border-bottom: 2px solid #17707d; color: #17707d; }
+    table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+    th, td { text-align: left; padding: 5px 8px; border-bottom: 1px solid #ddd; vertical-align: top; word-break: break-all; }
+    th { background: #f2f5f9; }
+    .meta { color: #555; }
+    .alert { background: #fdecea; border-left: 4px solid #c0392b; padding: 10px 15px; margin: 15px 0; }
+    .failure { color: #c0392b; font-weight: bold; }
+    .empty { color: #777; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>🛡️ Incident report: {{.Hostname}}</h1>
+<p class="meta">Generated {{when .GeneratedAt}} by APT Defender Helper {{.AgentVersion}}{{if .PiAgentIP}}, paired with the Pi Agent at {{.PiAgentIP}}{{end}}</p>
+
+{{if .Isolated}}<div class="alert">Network isolation is active: all traffic on this PC is blocked.</div>{{end}}
+{{if .Errors}}<div class="alert">Some sections could not be collected:
+<ul>{{range $section, $err := .Errors}}<li>{{$section}}: {{$err}}</li>{{end}}</ul></div>{{end}}
+
+<h2>System</h2>
+{{with .Telemetry}}
+<table>
+<tr><th>Hostname</th><td>{{.System.Hostname}}</td></tr>
+<tr><th>OS</th><td>{{.System.OS}} {{.System.Platform}}</td></tr>
+<tr><th>Uptime</th><td>{{uptime .System.Uptime}}</td></tr>
+<tr><th>CPU</th><td>{{printf "%.1f" .CPU.UsagePercent}}% of {{.CPU.Cores}} cores</td></tr>
+<tr><th>Memory</th><td>{{.Memory.UsedMB}} of {{.Memory.TotalMB}} MB ({{printf "%.1f" .Memory.UsagePercent}}%)</td></tr>
+<tr><th>Disk</th><td>{{.Disk.UsedGB}} of {{.Disk.TotalGB}} GB ({{printf "%.1f" .Disk.UsagePercent}}%)</td></tr>
+<tr><th>Network</th><td>{{.Network.BytesSent}} bytes sent, {{.Network.BytesRecv}} received</td></tr>
+</table>
+{{else}}<p class="empty">Not available</p>{{end}}
+
+<h2>Threats ({{len .Threats}})</h2>
+{{with .Scan}}<p class="meta">Latest {{if .ScanType}}{{.ScanType}} {{end}}scan started {{when .StartTime}}{{if .Active}}, still running{{end}}: {{.ScannedFiles}} of {{.TotalFiles}} files, {{.Skipped.Total}} could not be read</p>{{end}}
+{{if .Threats}}
+<table>
+<tr><th>Detected</th><th>Path</th><th>Threat</th><th>Severity</th><th>SHA-256</th><th>Status</th></tr>
+{{range .Threats}}<tr><td>{{when .DetectedAt}}</td><td>{{.Path}}</td><td>{{.Type}}</td><td>{{.Severity}}</td><td>{{with .Hashes}}{{.SHA256}}{{end}}</td><td>{{.Status}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">No threats in the latest scan</p>{{end}}
+{{if .FalsePositives}}
+<p>Marked as false positives:</p>
+<table>
+<tr><th>Marked</th><th>Path</th><th>Threat</th><th>SHA-256</th></tr>
+{{range .FalsePositives}}<tr><td>{{when .MarkedAt}}</td><td>{{.Path}}</td><td>{{.Type}}</td><td>{{.SHA256}}</td></tr>
+{{end}}</table>
+{{end}}
+
+<h2>Quarantine ({{len .Quarantine}})</h2>
+{{if .Quarantine}}
+<table>
+<tr><th>Quarantined</th><th>Original path</th><th>Reason</th><th>Size</th><th>SHA-256</th></tr>
+{{range .Quarantine}}<tr><td>{{when .QuarantinedAt}}</td><td>{{.OriginalPath}}</td><td>{{.Reason}}</td><td>{{.Size}}</td><td>{{.SHA256}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">Nothing quarantined</p>{{end}}
+
+<h2>Firewall rules ({{len .Firewall}})</h2>
+{{if .Firewall}}
+<table>
+<tr><th>Rule</th><th>Kind</th><th>Direction</th><th>Action</th><th>Enabled</th><th>Created by</th><th>Created</th></tr>
+{{range .Firewall}}<tr><td>{{.Name}}</td><td>{{.Kind}}</td><td>{{.Direction}}</td><td>{{.Action}}</td><td>{{if .Enabled}}Yes{{else}}No{{end}}</td><td>{{.Origin}}</td><td>{{whenPtr .CreatedAt}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">No APT Defender firewall rules</p>{{end}}
+
+<h2>Commands since {{when .AuditSince}} ({{len .Audit}})</h2>
+{{if .Audit}}
+<table>
+<tr><th>Time</th><th>Command</th><th>Target</th><th>Outcome</th><th>From</th><th>Token</th></tr>
+{{range .Audit}}<tr><td>{{when .Time}}</td><td>{{.Action}}</td><td>{{.Target}}</td><td{{if eq .Outcome "failure"}} class="failure"{{end}}>{{.Outcome}}{{if .Error}}: {{.Error}}{{end}}</td><td>{{.Remote}}</td><td>{{.Token}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">No commands</p>{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,81 @@
+package report
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/audit"
+	"github.com/apt-defender/helper-v2/internal/control"
+	"github.com/apt-defender/helper-v2/internal/quarantine"
+	"github.com/apt-defender/helper-v2/internal/scanner"
+	"github.com/apt-defender/helper-v2/internal/telemetry"
+)
+
+// Threat is a detection from the latest scan with what has happened to the file since
+type Threat struct {
+	scanner.Threat
+	Status       string `json:"status"`
+	QuarantineID string `json:"quarantine_id,omitempty"`
+}
+
+// Report is a snapshot of the PC's state for attaching to an incident
+// ticket. Sections that could not be collected are listed in Errors.
+type Report struct {
+	GeneratedAt    time.Time               `json:"generated_at"`
+	Hostname       string                  `json:"hostname"`
+	AgentVersion   string                  `json:"agent_version"`
+	PiAgentIP      string                  `json:"pi_agent_ip,omitempty"`
+	AuditSince     time.Time               `json:"audit_since"`
+	Telemetry      *telemetry.SystemStats  `json:"telemetry,omitempty"`
+	Scan           *scanner.ScanStatus     `json:"scan"`
+	Threats        []Threat                `json:"threats"`
+	FalsePositives []scanner.FalsePositive `json:"false_positives"`
+	Quarantine     []quarantine.Item       `json:"quarantine"`
+	Isolated       bool                    `json:"isolated"`
+	Firewall       []control.FirewallRule  `json:"firewall"`
+	Audit          []audit.Entry           `json:"audit"`
+	Errors         map[string]string       `json:"errors,omitempty"`
+}
+
+// Fail records why a section is missing from the report
+func (r *Report) Fail(section string, err error) {
+	if r.Errors == nil {
+		r.Errors = map[string]string{}
+	}
+	r.Errors[section] = err.Error()
+}
+
+// Filename names the bundle after the PC and the time it was generated
+func (r *Report) Filename() string {
+	return fmt.Sprintf("incident-report-%s-%s.zip", r.Hostname, r.GeneratedAt.Format("20060102-150405"))
+}
+
+// WriteBundle writes a zip holding report.json and a readable report.html
+func (r *Report) WriteBundle(w io.Writer) error {
+	bundle := zip.NewWriter(w)
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	entry, err := bundle.CreateHeader(&zip.FileHeader{Name: "report.json", Method: zip.Deflate, Modified: r.GeneratedAt})
+	if err != nil {
+		return err
+	}
+	if _, err := entry.Write(data); err != nil {
+		return err
+	}
+
+	entry, err = bundle.CreateHeader(&zip.FileHeader{Name: "report.html", Method: zip.Deflate, Modified: r.GeneratedAt})
+	if err != nil {
+		return err
+	}
+	if err := page.Execute(entry, r); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	return bundle.Close()
+}
@@ -1,53 +1,141 @@
 package scanner
 
 import (
-	"crypto/sha256"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/apt-defender/helper-v2/internal/events"
+	"github.com/apt-defender/helper-v2/internal/hashing"
+)
+
+const (
+	progressEvery        = 100  // how often scan.progress events are published
+	maxSkipSamples       = 50   // skipped paths kept in the report
+	defaultSkipWarnRatio = 0.05 // skipped/scanned ratio that raises scan.warning
+)
+
+// ScanScheduled is the scan type of scans started by the schedule
+const ScanScheduled = "scheduled"
+
+// Reasons a file could not be scanned
+const (
+	SkipAccessDenied = "access_denied"
+	SkipLocked       = "locked"
+	SkipPathTooLong  = "path_too_long"
+	SkipOther        = "other"
 )
 
 type ScanStatus struct {
-	Active        bool      `json:"active"`
-	TotalFiles    int64     `json:"total_files"`
-	ScannedFiles  int64     `json:"scanned_files"`
-	ThreatsFound  int       `json:"threats_found"`
-	Threats       []Threat  `json:"threats"`
-	StartTime     time.Time `json:"start_time"`
-	CurrentFolder string    `json:"current_folder"`
-	ScanType      string    `json:"scan_type"`
+	Active        bool       `json:"active"`
+	TotalFiles    int64      `json:"total_files"`
+	ScannedFiles  int64      `json:"scanned_files"`
+	ThreatsFound  int        `json:"threats_found"`
+	Threats       []Threat   `json:"threats"`
+	StartTime     time.Time  `json:"start_time"`
+	CurrentFolder string     `json:"current_folder"`
+	ScanType      string     `json:"scan_type"`
+	Skipped       SkipReport `json:"skipped"`
+}
+
+// SkipReport records files the scan could not read, so coverage gaps are visible
+type SkipReport struct {
+	Total    int64            `json:"total"`
+	ByReason map[string]int64 `json:"by_reason"`
+	Samples  []SkippedFile    `json:"samples"`
 }
 
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	Error  string `json:"error"`
+}
+
+// Threat is a single detection. Type is the structured threat name and
+// Signature the ID of the rule that fired; Metadata carries the full rule.
 type Threat struct {
-	Path       string    `json:"path"`
-	Type       string    `json:"type"`
-	Signature  string    `json:"signature"`
-	DetectedAt time.Time `json:"detected_at"`
+	Path       string          `json:"path"`
+	Type       string          `json:"type"`
+	Name       ThreatName      `json:"name"`
+	Signature  string          `json:"signature"`
+	Severity   string          `json:"severity"`
+	Metadata   *Signature      `json:"metadata,omitempty"`
+	Hashes     *hashing.Hashes `json:"hashes,omitempty"`
+	Source     events.Source   `json:"source"`
+	DetectedAt time.Time       `json:"detected_at"`
+}
+
+// newThreat builds a detection from the signature that matched
+func newThreat(path string, sig *Signature) *Threat {
+	name, _ := ParseThreatName(sig.Name)
+	return &Threat{
+		Path:       path,
+		Type:       sig.Name,
+		Name:       name,
+		Signature:  sig.ID,
+		Severity:   sig.Severity,
+		Metadata:   sig,
+		Hashes:     detectionHashes(path),
+		Source:     events.NewSource(events.ModuleScan, sig.ID, strconv.Itoa(sig.Version)),
+		DetectedAt: time.Now(),
+	}
+}
+
+// ScanSummary is published when a scan finishes
+type ScanSummary struct {
+	ScanType        string     `json:"scan_type"`
+	TotalFiles      int64      `json:"total_files"`
+	ScannedFiles    int64      `json:"scanned_files"`
+	ThreatsFound    int        `json:"threats_found"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	Stopped         bool       `json:"stopped"`
+	Skipped         SkipReport `json:"skipped"`
+	SkipRatio       float64    `json:"skip_ratio"`
 }
 
 type Scanner struct {
-	status     *ScanStatus
-	mutex      sync.RWMutex
-	scanPaths  []string
-	stopSignal chan struct{}
+	status         *ScanStatus
+	mutex          sync.RWMutex
+	scanPaths      []string
+	stopSignal     chan struct{}
+	events         *events.Broker
+	falsePositives *FalsePositives
+	history        *History
+	exclusions     []string
+	skipWarnRatio  float64
+	interval       time.Duration // between scheduled scans, 0 when off
 }
 
-func New(scanPaths []string) *Scanner {
+func New(scanPaths []string, broker *events.Broker, falsePositives *FalsePositives, history *History) *Scanner {
 	return &Scanner{
-		scanPaths: scanPaths,
+		scanPaths:      scanPaths,
+		events:         broker,
+		falsePositives: falsePositives,
+		history:        history,
+		skipWarnRatio:  defaultSkipWarnRatio,
 		status: &ScanStatus{
 			Active:  false,
 			Threats: []Threat{},
+			Skipped: newSkipReport(),
 		},
 	}
 }
 
+// SetScanPaths replaces the folders used by the next scan
+func (s *Scanner) SetScanPaths(paths []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.scanPaths = paths
+}
+
 func (s *Scanner) GetStatus() *ScanStatus {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -56,6 +144,7 @@ func (s *Scanner) GetStatus() *ScanStatus {
 	threatsCopy := make([]Threat, len(s.status.Threats))
 	copy(threatsCopy, s.status.Threats)
 	statusCopy.Threats = threatsCopy
+	statusCopy.Skipped = s.status.Skipped.clone()
 
 	return &statusCopy
 }
@@ -72,10 +161,16 @@ func (s *Scanner) StartScan(scanType string) error {
 		StartTime: time.Now(),
 		ScanType:  scanType,
 		Threats:   []Threat{},
+		Skipped:   newSkipReport(),
 	}
 	s.stopSignal = make(chan struct{})
 	s.mutex.Unlock()
 
+	s.events.Publish("scan.started", map[string]interface{}{
+		"scan_type": scanType,
+		"paths":     s.scanPaths,
+	})
+
 	go s.runScan()
 	return nil
 }
@@ -94,14 +189,45 @@ func (s *Scanner) runScan() {
 		s.mutex.Lock()
 		s.status.Active = false
 		s.status.CurrentFolder = "Complete"
+		startedAt, warnRatio := s.status.StartTime, s.skipWarnRatio
+		summary := ScanSummary{
+			ScanType:        s.status.ScanType,
+			TotalFiles:      atomic.LoadInt64(&s.status.TotalFiles),
+			ScannedFiles:    atomic.LoadInt64(&s.status.ScannedFiles),
+			ThreatsFound:    s.status.ThreatsFound,
+			DurationSeconds: time.Since(s.status.StartTime).Seconds(),
+			Stopped:         s.stopped(),
+			Skipped:         s.status.Skipped.clone(),
+		}
 		s.mutex.Unlock()
-		log.Printf("Scan complete: %d files scanned, %d threats found",
-			s.status.ScannedFiles, s.status.ThreatsFound)
+		if summary.ScannedFiles > 0 {
+			summary.SkipRatio = float64(summary.Skipped.Total) / float64(summary.ScannedFiles)
+		}
+		log.Printf("Scan complete: %d files scanned, %d threats found, %d skipped",
+			summary.ScannedFiles, summary.ThreatsFound, summary.Skipped.Total)
+		if summary.Skipped.Total > 0 && summary.SkipRatio > warnRatio {
+			log.Printf("⚠️ Scan skipped %.1f%% of files (%v); coverage is incomplete",
+				summary.SkipRatio*100, summary.Skipped.ByReason)
+			s.events.Publish("scan.warning", map[string]interface{}{
+				"message":    "Scan skipped a significant share of files it could not read",
+				"skip_ratio": summary.SkipRatio,
+				"skipped":    summary.Skipped,
+			})
+		}
+		s.history.Record(ScanRecord{StartedAt: startedAt, FinishedAt: time.Now(), ScanSummary: summary})
+		s.events.Publish("scan.completed", summary)
 	}()
 
+	s.mutex.RLock()
+	exclusions := s.exclusions
+	s.mutex.RUnlock()
+
 	// First pass: count files
 	for _, folder := range s.scanPaths {
 		filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+			if err == nil && excluded(path, exclusions) {
+				return skipExcluded(info)
+			}
 			if err == nil && !info.IsDir() {
 				atomic.AddInt64(&s.status.TotalFiles, 1)
 			}
@@ -128,27 +254,84 @@ func (s *Scanner) runScan() {
 			default:
 			}
 
-			if err != nil || info.IsDir() {
+			if err != nil {
+				s.recordSkip(path, err)
+				return nil
+			}
+			if excluded(path, exclusions) {
+				return skipExcluded(info)
+			}
+			if info.IsDir() {
 				return nil
 			}
 
 			// Scan the file
-			if threat := s.scanFile(path); threat != nil {
+			threat, err := s.scanFile(path)
+			if err != nil {
+				s.recordSkip(path, err)
+			}
+			if threat != nil && s.ignored(threat) {
+				log.Printf("Ignoring %s [%s], marked as a false positive", path, threat.Type)
+				threat = nil
+			}
+			if threat != nil {
 				s.mutex.Lock()
 				s.status.Threats = append(s.status.Threats, *threat)
 				s.status.ThreatsFound++
 				s.mutex.Unlock()
 				log.Printf("THREAT DETECTED: %s [%s]", path, threat.Type)
+				s.events.Publish("scan.threat", threat)
 			}
 
-			atomic.AddInt64(&s.status.ScannedFiles, 1)
+			scanned := atomic.AddInt64(&s.status.ScannedFiles, 1)
+			if scanned%progressEvery == 0 {
+				s.events.Publish("scan.progress", map[string]interface{}{
+					"scanned_files":  scanned,
+					"total_files":    atomic.LoadInt64(&s.status.TotalFiles),
+					"current_folder": folder,
+				})
+			}
 			time.Sleep(5 * time.Millisecond) // Slow down to see progress
 			return nil
 		})
 	}
 }
 
-func (s *Scanner) scanFile(path string) *Threat {
+// skipExcluded leaves out an excluded file, or everything below an excluded folder
+func skipExcluded(info os.FileInfo) error {
+	if info.IsDir() {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// stopped reports whether StopScan was called for the current scan
+func (s *Scanner) stopped() bool {
+	select {
+	case <-s.stopSignal:
+		return true
+	default:
+		return false
+	}
+}
+
+// recordSkip notes a file or folder the scan could not read
+func (s *Scanner) recordSkip(path string, err error) {
+	reason := skipReason(path, err)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	report := &s.status.Skipped
+	report.Total++
+	report.ByReason[reason]++
+	if len(report.Samples) < maxSkipSamples {
+		report.Samples = append(report.Samples, SkippedFile{Path: path, Reason: reason, Error: err.Error()})
+	}
+}
+
+// scanFile checks a single file; an error means it could not be read
+func (s *Scanner) scanFile(path string) (*Threat, error) {
 	ext := strings.ToLower(filepath.Ext(path))
 	basename := strings.ToLower(filepath.Base(path))
 
@@ -163,52 +346,45 @@ func (s *Scanner) scanFile(path string) *Threat {
 	if suspiciousExts[ext] || basename == "eicar.com" || basename == "eicar.txt" {
 		f, err := os.Open(path)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		defer f.Close()
 
 		// Read first 1KB for signature check
 		buf := make([]byte, 1024)
-		n, _ := f.Read(buf)
+		n, err := f.Read(buf)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
 		content := string(buf[:n])
 
-		// EICAR Standard Test String Check
-		if containsEicar(content) {
-			return &Threat{
-				Path:       path,
-				Type:       "Malware.Test.EICAR",
-				Signature:  "EICAR-STANDARD-ANTIVIRUS-TEST-FILE",
-				DetectedAt: time.Now(),
-			}
+		// String signatures (EICAR etc.)
+		if sig := matchContent(content); sig != nil {
+			return newThreat(path, sig), nil
 		}
 
 		// Hash-based detection for known threats
-		f.Seek(0, 0)
-		h := sha256.New()
-		if _, err := io.Copy(h, f); err == nil {
-			hash := fmt.Sprintf("%x", h.Sum(nil))
-
-			// Known malicious hashes (add more as needed)
-			knownThreats := map[string]string{
-				"44d88612fea8a8f36de82e1278abb02f":                                 "Malware.Generic.Hash",
-				"275a021bbfb6489e54d471899f7db9d1663fc695ec2fe2a2c4538aabf651fd0f": "Malware.EICAR.SHA256",
-			}
-
-			if threatType, found := knownThreats[hash]; found {
-				return &Threat{
-					Path:       path,
-					Type:       threatType,
-					Signature:  hash,
-					DetectedAt: time.Now(),
-				}
-			}
+		hashes, err := hashing.File(path, []string{hashing.MD5, hashing.SHA256})
+		if err != nil {
+			return nil, err
+		}
+		if sig := matchHash(KindSHA256, hashes.SHA256); sig != nil {
+			return newThreat(path, sig), nil
+		}
+		if sig := matchHash(KindMD5, hashes.MD5); sig != nil {
+			return newThreat(path, sig), nil
 		}
 	}
 
-	return nil
+	return nil, nil
 }
 
-func containsEicar(s string) bool {
-	eicarSignature := "X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"
-	return strings.Contains(s, eicarSignature)
+// detectionHashes computes every digest, including ssdeep, for a detected
+// file so the Pi can match older intel feeds and cluster variants
+func detectionHashes(path string) *hashing.Hashes {
+	hashes, err := hashing.File(path, hashing.All)
+	if err != nil {
+		return nil
+	}
+	return hashes
 }
@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const falsePositivesFile = "false_positives.json"
+
+// FalsePositive is a detection the user marked as benign. Files with the
+// same SHA-256 are no longer reported by later scans.
+type FalsePositive struct {
+	SHA256    string    `json:"sha256"`
+	Path      string    `json:"path"`
+	Type      string    `json:"type"`
+	Signature string    `json:"signature"`
+	MarkedAt  time.Time `json:"marked_at"`
+}
+
+// FalsePositives stores marked detections in the data directory
+type FalsePositives struct {
+	mutex     sync.Mutex
+	storePath string
+	entries   []FalsePositive
+}
+
+func NewFalsePositives(dataDir string) *FalsePositives {
+	f := &FalsePositives{
+		storePath: filepath.Join(dataDir, falsePositivesFile),
+		entries:   []FalsePositive{},
+	}
+	if data, err := os.ReadFile(f.storePath); err == nil {
+		if err := json.Unmarshal(data, &f.entries); err != nil {
+			log.Printf("⚠️ Failed to parse false positives: %v", err)
+		}
+	}
+	return f
+}
+
+// List returns every marked detection
+func (f *FalsePositives) List() []FalsePositive {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]FalsePositive{}, f.entries...)
+}
+
+// Contains reports whether a SHA-256 was marked as a false positive
+func (f *FalsePositives) Contains(sha256 string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.index(sha256) >= 0
+}
+
+// Remove unmarks a SHA-256 so detections are reported again
+func (f *FalsePositives) Remove(sha256 string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	i := f.index(sha256)
+	if i < 0 {
+		return fmt.Errorf("%s is not marked as a false positive", sha256)
+	}
+	f.entries = append(f.entries[:i], f.entries[i+1:]...)
+	return f.save()
+}
+
+func (f *FalsePositives) add(entry FalsePositive) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.index(entry.SHA256) >= 0 {
+		return nil
+	}
+	f.entries = append(f.entries, entry)
+	return f.save()
+}
+
+func (f *FalsePositives) index(sha256 string) int {
+	for i, e := range f.entries {
+		if strings.EqualFold(e.SHA256, sha256) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *FalsePositives) save() error {
+	data, err := json.MarshalIndent(f.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal false positives: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(f.storePath), 0700); err != nil {
+		return fmt.Errorf("failed to create data dir: %w", err)
+	}
+	if err := os.WriteFile(f.storePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write false positives: %w", err)
+	}
+	return nil
+}
+
+// MarkFalsePositive marks the detection at path as benign and drops every
+// detection of the same file from the current results
+func (s *Scanner) MarkFalsePositive(path string) (*FalsePositive, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var threat *Threat
+	for i := range s.status.Threats {
+		if strings.EqualFold(s.status.Threats[i].Path, path) {
+			threat = &s.status.Threats[i]
+			break
+		}
+	}
+	if threat == nil {
+		return nil, fmt.Errorf("no detection for %s in the current results", path)
+	}
+	if threat.Hashes == nil || threat.Hashes.SHA256 == "" {
+		return nil, fmt.Errorf("detection for %s has no SHA-256 to match later scans against", path)
+	}
+
+	entry := FalsePositive{
+		SHA256:    strings.ToLower(threat.Hashes.SHA256),
+		Path:      threat.Path,
+		Type:      threat.Type,
+		Signature: threat.Signature,
+		MarkedAt:  time.Now(),
+	}
+	if err := s.falsePositives.add(entry); err != nil {
+		return nil, err
+	}
+
+	kept := s.status.Threats[:0]
+	for _, t := range s.status.Threats {
+		if t.Hashes == nil || !strings.EqualFold(t.Hashes.SHA256, entry.SHA256) {
+			kept = append(kept, t)
+		}
+	}
+	s.status.Threats = kept
+	s.status.ThreatsFound = len(kept)
+	return &entry, nil
+}
+
+// FalsePositives returns the store of marked detections
+func (s *Scanner) FalsePositives() *FalsePositives {
+	return s.falsePositives
+}
+
+// ignored reports whether a detection matches a marked false positive
+func (s *Scanner) ignored(threat *Threat) bool {
+	return threat.Hashes != nil && s.falsePositives.Contains(threat.Hashes.SHA256)
+}
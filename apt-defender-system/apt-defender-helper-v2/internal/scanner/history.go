@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const historyFile = "scan_history.jsonl"
+
+// ScanRecord is a finished scan kept in the scan history. Skipped paths are
+// dropped; only the counts by reason are kept.
+type ScanRecord struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	ScanSummary
+}
+
+// History is an append-only JSON-lines log of finished scans in the data directory
+type History struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func NewHistory(dataDir string) *History {
+	return &History{path: filepath.Join(dataDir, historyFile)}
+}
+
+// Record appends a finished scan. Failures are logged and otherwise ignored.
+func (h *History) Record(r ScanRecord) {
+	r.Skipped.Samples = nil
+	if err := h.append(r); err != nil {
+		log.Printf("⚠️ Failed to record scan history: %v", err)
+	}
+}
+
+func (h *History) append(r ScanRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scan record: %w", err)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// List returns scans started at or after since (zero for all), newest
+// first, and how many matched before limit (0 for no limit) was applied
+func (h *History) List(since time.Time, limit int) ([]ScanRecord, int, error) {
+	h.mutex.Lock()
+	data, err := os.ReadFile(h.path)
+	h.mutex.Unlock()
+	if os.IsNotExist(err) {
+		return []ScanRecord{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records := []ScanRecord{}
+	lines := bytes.Split(data, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		var r ScanRecord
+		if len(bytes.TrimSpace(lines[i])) == 0 || json.Unmarshal(lines[i], &r) != nil {
+			continue
+		}
+		if !since.IsZero() && r.StartedAt.Before(since) {
+			continue
+		}
+		records = append(records, r)
+	}
+
+	total := len(records)
+	if limit > 0 && total > limit {
+		records = records[:limit]
+	}
+	return records, total, nil
+}
+
+// History returns the log of finished scans
+func (s *Scanner) History() *History {
+	return s.history
+}
@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SetExclusions replaces the folders, files and name patterns ("*.iso")
+// skipped by the next scan. Entries containing a path separator match that
+// path and everything below it; others match file and folder names.
+func (s *Scanner) SetExclusions(exclusions []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.exclusions = exclusions
+}
+
+// SetSkipWarnRatio sets the share of unreadable files that raises scan.warning
+func (s *Scanner) SetSkipWarnRatio(ratio float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if ratio <= 0 {
+		ratio = defaultSkipWarnRatio
+	}
+	s.skipWarnRatio = ratio
+}
+
+// SetSchedule sets how long after the last scan started a scheduled full
+// scan runs (0 turns scheduled scans off)
+func (s *Scanner) SetSchedule(interval time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.interval = interval
+}
+
+// StartScheduler checks every minute whether a scheduled scan is due. The
+// last scan is read from the history, so the schedule survives restarts.
+func (s *Scanner) StartScheduler() {
+	started := time.Now()
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.mutex.RLock()
+			interval, active := s.interval, s.status.Active
+			s.mutex.RUnlock()
+			if interval <= 0 || active {
+				continue
+			}
+
+			last := started
+			if scans, _, err := s.history.List(time.Time{}, 1); err == nil && len(scans) > 0 {
+				last = scans[0].StartedAt
+			}
+			if time.Since(last) < interval {
+				continue
+			}
+			log.Printf("⏰ Starting scheduled scan (every %s)", interval)
+			if err := s.StartScan(ScanScheduled); err != nil {
+				log.Printf("⚠️ Scheduled scan not started: %v", err)
+			}
+		}
+	}()
+}
+
+// excluded reports whether a path matches one of the configured exclusions
+func excluded(path string, exclusions []string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	lowered := strings.ToLower(filepath.Clean(path))
+	for _, e := range exclusions {
+		e = strings.ToLower(e)
+		if strings.ContainsAny(e, `\/`) {
+			e = filepath.Clean(e)
+			if lowered == e || strings.HasPrefix(lowered, strings.TrimSuffix(e, string(filepath.Separator))+string(filepath.Separator)) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := filepath.Match(e, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"errors"
+	"io/fs"
+	"syscall"
+)
+
+// Win32 error codes not exposed by the syscall package
+const (
+	errorSharingViolation  = syscall.Errno(32)
+	errorLockViolation     = syscall.Errno(33)
+	errorFilenameExcdRange = syscall.Errno(206)
+	maxPath                = 260
+)
+
+func newSkipReport() SkipReport {
+	return SkipReport{ByReason: make(map[string]int64), Samples: []SkippedFile{}}
+}
+
+func (r SkipReport) clone() SkipReport {
+	c := SkipReport{
+		Total:    r.Total,
+		ByReason: make(map[string]int64, len(r.ByReason)),
+		Samples:  make([]SkippedFile, len(r.Samples)),
+	}
+	for k, v := range r.ByReason {
+		c.ByReason[k] = v
+	}
+	copy(c.Samples, r.Samples)
+	return c
+}
+
+// skipReason classifies why a file could not be read
+func skipReason(path string, err error) string {
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch errno {
+		case errorSharingViolation, errorLockViolation:
+			return SkipLocked
+		case errorFilenameExcdRange:
+			return SkipPathTooLong
+		}
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return SkipAccessDenied
+	}
+	if len(path) >= maxPath {
+		return SkipPathTooLong
+	}
+	return SkipOther
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/apt-defender/helper-v2/internal/authenticode"
+	"github.com/apt-defender/helper-v2/internal/persistence"
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const (
+	servicesKey               = `HKLM\SYSTEM\CurrentControlSet\Services`
+	scManagerEnumerateService = 0x0004
+	scEnumProcessInfo         = 0
+	serviceWin32              = 0x30
+	serviceStateAll           = 3
+	errorMoreData             = 234
+)
+
+var (
+	advapi32                 = syscall.NewLazyDLL("advapi32.dll")
+	procOpenSCManager        = advapi32.NewProc("OpenSCManagerW")
+	procEnumServicesStatusEx = advapi32.NewProc("EnumServicesStatusExW")
+	procCloseServiceHandle   = advapi32.NewProc("CloseServiceHandle")
+)
+
+type serviceStatusProcess struct {
+	ServiceType             uint32
+	CurrentState            uint32
+	ControlsAccepted        uint32
+	Win32ExitCode           uint32
+	ServiceSpecificExitCode uint32
+	CheckPoint              uint32
+	WaitHint                uint32
+	ProcessID               uint32
+	ServiceFlags            uint32
+}
+
+type enumServiceStatusProcess struct {
+	ServiceName *uint16
+	DisplayName *uint16
+	Status      serviceStatusProcess
+}
+
+// serviceStatus is an enumerated service copied out of the API buffer
+type serviceStatus struct {
+	name        string
+	displayName string
+	status      serviceStatusProcess
+}
+
+var stateNames = map[uint32]string{
+	1: "stopped",
+	2: "start_pending",
+	3: "stop_pending",
+	4: "running",
+	5: "continue_pending",
+	6: "pause_pending",
+	7: "paused",
+}
+
+var startTypeNames = map[uint64]string{
+	0: "boot",
+	1: "system",
+	2: "automatic",
+	3: "manual",
+	4: "disabled",
+}
+
+// scStartTypes maps start type names to sc.exe's start= values
+var scStartTypes = map[string]string{
+	"automatic": "auto",
+	"manual":    "demand",
+	"disabled":  "disabled",
+}
+
+// Service is a user-mode Windows service
+type Service struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	State       string `json:"state"`
+	PID         uint32 `json:"pid,omitempty"`
+	StartType   string `json:"start_type"`
+	Account     string `json:"account"`
+	BinaryPath  string `json:"binary_path"`
+	ImagePath   string `json:"image_path,omitempty"`
+	ServiceDLL  string `json:"service_dll,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+	Signer      string `json:"signer,omitempty"`
+}
+
+// List returns every user-mode service with its configuration and, when
+// withSignatures is set, the Authenticode status of its image
+func List(withSignatures bool) ([]Service, error) {
+	statuses, err := enumerate()
+	if err != nil {
+		return nil, err
+	}
+
+	var services []Service
+	for _, st := range statuses {
+		svc := Service{
+			Name:        st.name,
+			DisplayName: st.displayName,
+			State:       stateNames[st.status.CurrentState],
+			PID:         st.status.ProcessID,
+		}
+		readConfig(&svc)
+		services = append(services, svc)
+	}
+
+	if withSignatures {
+		paths := make([]string, 0, len(services)*2)
+		for _, svc := range services {
+			paths = append(paths, svc.ImagePath, svc.ServiceDLL)
+		}
+		results := authenticode.Check(paths)
+		for i := range services {
+			// Shared svchost services are judged by their DLL
+			target := services[i].ImagePath
+			if services[i].ServiceDLL != "" {
+				target = services[i].ServiceDLL
+			}
+			if r, ok := results[strings.ToLower(target)]; ok {
+				services[i].Signature = r.Status
+				services[i].Signer = r.Signer
+			}
+		}
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return strings.ToLower(services[i].Name) < strings.ToLower(services[j].Name)
+	})
+	return services, nil
+}
+
+// Stop asks the service control manager to stop a service
+func Stop(name string) error {
+	if err := exists(name); err != nil {
+		return err
+	}
+	if output, err := exec.Command("sc", "stop", name).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stop service %s: %v, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	log.Printf("⏹️ Stopped service %s", name)
+	return nil
+}
+
+// Start asks the service control manager to start a service
+func Start(name string) error {
+	if err := exists(name); err != nil {
+		return err
+	}
+	if output, err := exec.Command("sc", "start", name).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to start service %s: %v, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	log.Printf("▶️ Started service %s", name)
+	return nil
+}
+
+// Disable sets a service's start type to disabled so it won't start again
+func Disable(name string) error {
+	if err := SetStartType(name, "disabled"); err != nil {
+		return err
+	}
+	log.Printf("🚫 Disabled service %s", name)
+	return nil
+}
+
+// Get returns the registry configuration and current state of one service
+func Get(name string) (*Service, error) {
+	if err := exists(name); err != nil {
+		return nil, err
+	}
+	svc := &Service{Name: name}
+	readConfig(svc)
+
+	if output, err := exec.Command("sc", "query", name).Output(); err == nil && strings.Contains(string(output), "RUNNING") {
+		svc.State = "running"
+	} else {
+		svc.State = "stopped"
+	}
+	return svc, nil
+}
+
+// SetStartType changes a service's start type ("automatic", "manual" or "disabled")
+func SetStartType(name, startType string) error {
+	if err := exists(name); err != nil {
+		return err
+	}
+	scValue, ok := scStartTypes[startType]
+	if !ok {
+		return fmt.Errorf("unsupported start type %q", startType)
+	}
+	if output, err := exec.Command("sc", "config", name, "start=", scValue).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set start type of %s: %v, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func exists(name string) error {
+	if name == "" || strings.ContainsAny(name, `\/`) {
+		return fmt.Errorf("invalid service name %q", name)
+	}
+	key, err := winreg.Open(servicesKey + `\` + name)
+	if err != nil {
+		return fmt.Errorf("service not found: %s", name)
+	}
+	key.Close()
+	return nil
+}
+
+// readConfig fills the registry-backed fields of a service
+func readConfig(svc *Service) {
+	key, err := winreg.Open(servicesKey + `\` + svc.Name)
+	if err != nil {
+		return
+	}
+	defer key.Close()
+
+	svc.BinaryPath, _ = key.GetString("ImagePath")
+	svc.ImagePath = persistence.ImageFromCommand(svc.BinaryPath)
+	svc.Account, _ = key.GetString("ObjectName")
+	if start, err := key.GetUint("Start"); err == nil {
+		svc.StartType = startTypeNames[start]
+	}
+
+	if params, err := winreg.Open(key.Path + `\Parameters`); err == nil {
+		if dll, err := params.GetString("ServiceDll"); err == nil {
+			svc.ServiceDLL = persistence.ImageFromCommand(dll)
+		}
+		params.Close()
+	}
+}
+
+// enumerate calls EnumServicesStatusExW until the whole list fits
+func enumerate() ([]serviceStatus, error) {
+	scm, _, err := procOpenSCManager.Call(0, 0, scManagerEnumerateService)
+	if scm == 0 {
+		return nil, fmt.Errorf("OpenSCManager failed: %w", err)
+	}
+	defer procCloseServiceHandle.Call(scm)
+
+	var result []serviceStatus
+	var resume uint32
+	buf := make([]uint64, 8192)
+
+	for {
+		var needed, returned uint32
+		ret, _, callErr := procEnumServicesStatusEx.Call(
+			scm,
+			scEnumProcessInfo,
+			serviceWin32,
+			serviceStateAll,
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(len(buf)*8),
+			uintptr(unsafe.Pointer(&needed)),
+			uintptr(unsafe.Pointer(&returned)),
+			uintptr(unsafe.Pointer(&resume)),
+			0,
+		)
+
+		entries := unsafe.Slice((*enumServiceStatusProcess)(unsafe.Pointer(&buf[0])), returned)
+		for _, e := range entries {
+			// Copy strings out now; the buffer is reused on the next call
+			result = append(result, serviceStatus{
+				name:        syscall.UTF16ToString(unsafe.Slice(e.ServiceName, wcslen(e.ServiceName))),
+				displayName: syscall.UTF16ToString(unsafe.Slice(e.DisplayName, wcslen(e.DisplayName))),
+				status:      e.Status,
+			})
+		}
+
+		if ret != 0 {
+			return result, nil
+		}
+		if errno, ok := callErr.(syscall.Errno); !ok || errno != errorMoreData {
+			return nil, fmt.Errorf("EnumServicesStatusEx failed: %w", callErr)
+		}
+		if int(needed) > len(buf)*8 {
+			buf = make([]uint64, needed/8+1)
+		}
+	}
+}
+
+func wcslen(p *uint16) int {
+	if p == nil {
+		return 0
+	}
+	n := 0
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; n++ {
+		ptr = unsafe.Add(ptr, 2)
+	}
+	return n
+}
@@ -0,0 +1,136 @@
+package staging
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Artifact is a collected file (memory dump, capture, triage package)
+// waiting in the staging area for download or upload
+type Artifact struct {
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Area is a directory holding collected artifacts
+type Area struct {
+	dir string
+}
+
+func New(dir string) *Area {
+	return &Area{dir: dir}
+}
+
+// Dir returns the staging directory
+func (a *Area) Dir() string {
+	return a.dir
+}
+
+// NewPath reserves a unique file name such as dump-20240101-120000-ab12cd.dmp
+func (a *Area) NewPath(kind, ext string) (string, error) {
+	if err := os.MkdirAll(a.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create staging dir: %w", err)
+	}
+
+	buf := make([]byte, 3)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate artifact name: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s-%s%s", kind, time.Now().Format("20060102-150405"), hex.EncodeToString(buf), ext)
+	return filepath.Join(a.dir, name), nil
+}
+
+// Describe hashes a staged file and returns its artifact record
+func (a *Area) Describe(path string) (*Artifact, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+
+	return &Artifact{
+		Name:      info.Name(),
+		Kind:      kindOf(info.Name()),
+		Size:      info.Size(),
+		SHA256:    hex.EncodeToString(h.Sum(nil)),
+		CreatedAt: info.ModTime(),
+	}, nil
+}
+
+// List returns staged artifacts, newest first (without hashing them)
+func (a *Area) List() ([]Artifact, error) {
+	entries, err := os.ReadDir(a.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Artifact{}, nil
+		}
+		return nil, err
+	}
+
+	artifacts := []Artifact{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, Artifact{
+			Name:      e.Name(),
+			Kind:      kindOf(e.Name()),
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].CreatedAt.After(artifacts[j].CreatedAt) })
+	return artifacts, nil
+}
+
+// Path resolves an artifact name to its file, rejecting anything that
+// would escape the staging directory
+func (a *Area) Path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.ContainsAny(name, `:\/`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid artifact name %q", name)
+	}
+	path := filepath.Join(a.dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("artifact not found: %s", name)
+	}
+	return path, nil
+}
+
+// Remove deletes a staged artifact
+func (a *Area) Remove(name string) error {
+	path, err := a.Path(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func kindOf(name string) string {
+	kind, _, _ := strings.Cut(name, "-")
+	return kind
+}
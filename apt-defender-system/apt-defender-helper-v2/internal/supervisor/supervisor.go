@@ -0,0 +1,358 @@
+package supervisor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvSupervised is set for the helper processes the supervisor starts
+const EnvSupervised = "HELPER_SUPERVISED"
+
+// ExitRestart is the exit code a helper uses to be started again at once,
+// e.g. after installing an update over its own executable
+const ExitRestart = 75
+
+// EnvServiceStarted carries the supervisor's own start time (RFC 3339) to
+// the helper, so it can report service uptime across restarts
+const EnvServiceStarted = "HELPER_SERVICE_STARTED"
+
+const (
+	startupGrace   = 60 * time.Second // before the first health check
+	healthInterval = 30 * time.Second
+	hangThreshold  = 3 // failed health checks before the helper is considered hung
+	restartMin     = time.Second
+	restartMax     = time.Minute
+	stableAfter    = 10 * time.Minute // uptime that resets the restart backoff
+	stderrTail     = 64 * 1024
+	keepDumps      = 20
+)
+
+const (
+	historyFile = "restarts.jsonl"
+	pendingFile = "restarts-pending.jsonl"
+	addressFile = "listen-address" // where the helper's API listens, see RecordAddress
+)
+
+// Restart records one time the supervisor had to restart the helper
+type Restart struct {
+	Time     time.Time `json:"time"`
+	Reason   string    `json:"reason"` // crash or hang
+	ExitCode int       `json:"exit_code"`
+	Uptime   string    `json:"uptime"`
+	Dump     string    `json:"dump,omitempty"`
+}
+
+// Supervisor runs the helper as a child process and restarts it when it
+// exits abnormally or stops answering its health endpoint. A protection
+// agent that silently dies is worse than none.
+type Supervisor struct {
+	args    []string
+	addr    string
+	dataDir string
+	http    *http.Client
+	started time.Time
+}
+
+// New supervises the current executable run with args. addr is the
+// host:port the helper's API is expected on until the helper records
+// where it actually listens.
+func New(args []string, addr, dataDir string) *Supervisor {
+	return &Supervisor{
+		args:    args,
+		addr:    addr,
+		dataDir: dataDir,
+		http:    &http.Client{Timeout: 10 * time.Second},
+		started: time.Now(),
+	}
+}
+
+// RecordAddress stores the host:port a supervised helper's API listens on.
+// The helper calls it whenever its listener moves, so a changed port isn't
+// mistaken for a hang.
+func RecordAddress(dataDir, addr string) error {
+	return os.WriteFile(filepath.Join(dataDir, addressFile), []byte(addr), 0600)
+}
+
+// Run keeps the helper running until it exits cleanly or the supervisor is
+// interrupted, and returns the exit code
+func (s *Supervisor) Run() int {
+	exe, err := os.Executable()
+	if err != nil {
+		log.Printf("❌ Supervisor can't locate the helper executable: %v", err)
+		return 1
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	delay := restartMin
+	for attempt := 0; ; attempt++ {
+		args := s.args
+		if attempt > 0 {
+			// Restarts shouldn't open another dashboard window
+			args = append([]string{"--no-browser"}, args...)
+		}
+
+		started := time.Now()
+		reason, code, tail, err := s.runOnce(exe, args, interrupt)
+		if err != nil {
+			log.Printf("❌ Supervisor failed to start the helper: %v", err)
+			return 1
+		}
+		if reason == "restart" {
+			log.Printf("🔁 Helper asked to be restarted")
+			delay = restartMin
+			continue
+		}
+		if reason == "" {
+			log.Printf("🛑 Helper exited with code %d, supervisor stopping", code)
+			return code
+		}
+
+		uptime := time.Since(started)
+		restart := Restart{Time: time.Now(), Reason: reason, ExitCode: code, Uptime: uptime.Round(time.Second).String()}
+		if dump, err := s.writeDump(restart, tail); err != nil {
+			log.Printf("⚠️ Failed to write crash dump: %v", err)
+		} else {
+			restart.Dump = dump
+		}
+		s.record(restart)
+
+		if uptime > stableAfter {
+			delay = restartMin
+		}
+		log.Printf("🔁 Helper %s (exit code %d after %s), restarting in %s", reason, code, restart.Uptime, delay)
+		time.Sleep(delay)
+		delay = min(delay*2, restartMax)
+	}
+}
+
+// runOnce starts the helper and waits for it to exit. reason is "crash" or
+// "hang" when it should be restarted, "restart" when it asked to be, and
+// empty when it exited cleanly or the supervisor was interrupted.
+func (s *Supervisor) runOnce(exe string, args []string, interrupt chan os.Signal) (reason string, code int, tail []byte, err error) {
+	stderr := &tailBuffer{max: stderrTail}
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = &teeWriter{os.Stderr, stderr}
+	cmd.Stdin = os.Stdin
+	// A full goroutine dump on panic makes the crash dump useful
+	cmd.Env = append(os.Environ(), EnvSupervised+"=1", EnvServiceStarted+"="+s.started.Format(time.RFC3339Nano), "GOTRACEBACK=all")
+	// The previous run's address may be stale; the new helper records its own
+	os.Remove(filepath.Join(s.dataDir, addressFile))
+	if err := cmd.Start(); err != nil {
+		return "", 0, nil, err
+	}
+	log.Printf("🐕 Supervisor started helper (pid %d)", cmd.Process.Pid)
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	hung := make(chan struct{})
+	stopHealth := make(chan struct{})
+	defer close(stopHealth)
+	go s.watchHealth(hung, stopHealth)
+
+	select {
+	case <-exited:
+		code = cmd.ProcessState.ExitCode()
+		if code == 0 {
+			return "", 0, nil, nil
+		}
+		if code == ExitRestart {
+			return "restart", code, nil, nil
+		}
+		return "crash", code, stderr.Bytes(), nil
+	case <-hung:
+		log.Printf("🚨 Helper stopped answering %s, killing it", s.healthURL())
+		cmd.Process.Kill()
+		<-exited
+		return "hang", cmd.ProcessState.ExitCode(), stderr.Bytes(), nil
+	case <-interrupt:
+		cmd.Process.Kill()
+		<-exited
+		return "", 0, nil, nil
+	}
+}
+
+// watchHealth closes hung once the helper fails hangThreshold health checks in a row
+func (s *Supervisor) watchHealth(hung, stop chan struct{}) {
+	select {
+	case <-time.After(startupGrace):
+	case <-stop:
+		return
+	}
+
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		if s.healthy() {
+			failures = 0
+		} else if failures++; failures >= hangThreshold {
+			close(hung)
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// healthURL is the helper's health endpoint at the address it last
+// recorded, read again on every check so it follows a rebind
+func (s *Supervisor) healthURL() string {
+	addr := s.addr
+	if data, err := os.ReadFile(filepath.Join(s.dataDir, addressFile)); err == nil && len(bytes.TrimSpace(data)) > 0 {
+		addr = string(bytes.TrimSpace(data))
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr + "/api/v1/health"
+	}
+	// A helper listening on every interface answers on loopback too
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/api/v1/health"
+}
+
+func (s *Supervisor) healthy() bool {
+	resp, err := s.http.Get(s.healthURL())
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// writeDump saves the helper's last stderr output, which holds the panic and
+// goroutine stacks for a crash, and prunes old dumps
+func (s *Supervisor) writeDump(restart Restart, tail []byte) (string, error) {
+	dir := filepath.Join(s.dataDir, "crashes")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Helper %s at %s\nExit code: %d\nUptime: %s\nArgs: %s\n\n",
+		restart.Reason, restart.Time.Format(time.RFC3339), restart.ExitCode, restart.Uptime, strings.Join(s.args, " "))
+	buf.Write(tail)
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.log", restart.Reason, restart.Time.Format("20060102-150405")))
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return "", err
+	}
+
+	dumps, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	sort.Strings(dumps)
+	if len(dumps) > keepDumps {
+		for _, old := range dumps[:len(dumps)-keepDumps] {
+			os.Remove(old)
+		}
+	}
+	return path, nil
+}
+
+// record appends the restart to the history and to the pending list the
+// helper reports to the Pi Agent when it comes back up
+func (s *Supervisor) record(restart Restart) {
+	line, _ := json.Marshal(restart)
+	for _, name := range []string{historyFile, pendingFile} {
+		f, err := os.OpenFile(filepath.Join(s.dataDir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			log.Printf("⚠️ Failed to record restart: %v", err)
+			continue
+		}
+		f.Write(append(line, '\n'))
+		f.Close()
+	}
+}
+
+// History returns every restart the supervisor recorded, newest first
+func History(dataDir string) ([]Restart, error) {
+	restarts, err := readRestarts(filepath.Join(dataDir, historyFile))
+	for i, j := 0, len(restarts)-1; i < j; i, j = i+1, j-1 {
+		restarts[i], restarts[j] = restarts[j], restarts[i]
+	}
+	return restarts, err
+}
+
+// TakePending returns the restarts not yet reported and clears them
+func TakePending(dataDir string) ([]Restart, error) {
+	path := filepath.Join(dataDir, pendingFile)
+	restarts, err := readRestarts(path)
+	if err != nil || len(restarts) == 0 {
+		return restarts, err
+	}
+	return restarts, os.Remove(path)
+}
+
+func readRestarts(path string) ([]Restart, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []Restart{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	restarts := []Restart{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var r Restart
+		if len(bytes.TrimSpace(line)) > 0 && json.Unmarshal(line, &r) == nil {
+			restarts = append(restarts, r)
+		}
+	}
+	return restarts, nil
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	mutex sync.Mutex
+	max   int
+	buf   []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = append([]byte{}, t.buf[len(t.buf)-t.max:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) Bytes() []byte {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]byte{}, t.buf...)
+}
+
+type teeWriter struct {
+	console *os.File
+	tail    *tailBuffer
+}
+
+func (w *teeWriter) Write(p []byte) (int, error) {
+	w.tail.Write(p)
+	w.console.Write(p)
+	return len(p), nil
+}
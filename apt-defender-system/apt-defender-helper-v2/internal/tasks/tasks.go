@@ -0,0 +1,138 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// Column positions in "schtasks /Query /FO CSV /V /NH" output. Positions are
+// stable across Windows display languages, unlike the header names.
+const (
+	colTaskName   = 1
+	colNextRun    = 2
+	colStatus     = 3
+	colLastRun    = 5
+	colLastResult = 6
+	colAuthor     = 7
+	colTaskToRun  = 8
+	colState      = 11
+	colRunAs      = 14
+	colSchedule   = 18
+	minColumns    = 19
+)
+
+// Task is a registered Task Scheduler task
+type Task struct {
+	Name       string   `json:"name"`
+	Status     string   `json:"status"`
+	Enabled    bool     `json:"enabled"`
+	NextRun    string   `json:"next_run"`
+	LastRun    string   `json:"last_run"`
+	LastResult string   `json:"last_result"`
+	Author     string   `json:"author"`
+	Command    string   `json:"command"`
+	RunAs      string   `json:"run_as"`
+	Schedules  []string `json:"schedules"`
+}
+
+// List returns every task; tasks with several triggers are merged into one entry
+func List() ([]Task, error) {
+	out, err := exec.Command("schtasks", "/Query", "/FO", "CSV", "/V", "/NH").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled tasks: %w", err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(out))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schtasks output: %w", err)
+	}
+
+	var tasks []Task
+	index := make(map[string]int)
+	for _, rec := range records {
+		if len(rec) < minColumns || !strings.HasPrefix(rec[colTaskName], `\`) {
+			continue
+		}
+
+		name := rec[colTaskName]
+		if i, ok := index[name]; ok {
+			tasks[i].Schedules = append(tasks[i].Schedules, rec[colSchedule])
+			continue
+		}
+
+		index[name] = len(tasks)
+		tasks = append(tasks, Task{
+			Name:       name,
+			Status:     rec[colStatus],
+			Enabled:    !strings.EqualFold(rec[colState], "Disabled") && !strings.EqualFold(rec[colStatus], "Disabled"),
+			NextRun:    rec[colNextRun],
+			LastRun:    rec[colLastRun],
+			LastResult: rec[colLastResult],
+			Author:     rec[colAuthor],
+			Command:    rec[colTaskToRun],
+			RunAs:      rec[colRunAs],
+			Schedules:  []string{rec[colSchedule]},
+		})
+	}
+
+	return tasks, nil
+}
+
+// Disable stops a task from running on its triggers
+func Disable(name string) error {
+	return change(name, "/DISABLE")
+}
+
+// Enable re-enables a disabled task
+func Enable(name string) error {
+	return change(name, "/ENABLE")
+}
+
+// Export returns the task's XML definition, suitable for schtasks /Create /XML
+func Export(name string) ([]byte, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	out, err := exec.Command("schtasks", "/Query", "/TN", name, "/XML").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export task %s: %w", name, err)
+	}
+	return out, nil
+}
+
+// Delete unregisters a task
+func Delete(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if output, err := exec.Command("schtasks", "/Delete", "/TN", name, "/F").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to delete task %s: %v, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	log.Printf("🗑️ Deleted scheduled task %s", name)
+	return nil
+}
+
+func change(name, flag string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if output, err := exec.Command("schtasks", "/Change", "/TN", name, flag).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to change task %s: %v, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	log.Printf("🗓️ Scheduled task %s %s", name, strings.ToLower(strings.TrimPrefix(flag, "/")))
+	return nil
+}
+
+// validateName requires a full task path such as \Folder\Task
+func validateName(name string) error {
+	if !strings.HasPrefix(name, `\`) || strings.ContainsAny(name, "\"\r\n") {
+		return fmt.Errorf("invalid task name %q: expected a full path like \\Folder\\Task", name)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sync"
 	"syscall"
 	"time"
 	"unsafe"
@@ -56,6 +57,18 @@ var (
 	procGetSystemTimes = kernel32.NewProc("GetSystemTimes")
 )
 
+// cpuSample is the previous GetSystemTimes reading; usage is measured
+// between readings rather than averaged since boot
+var cpuSample struct {
+	sync.Mutex
+	at          time.Time
+	idle, total int64
+	usage       float64
+}
+
+// minCPUInterval keeps readings taken close together from giving noise
+const minCPUInterval = 500 * time.Millisecond
+
 // GetSystemStats collects comprehensive system statistics
 func GetSystemStats() (*SystemStats, error) {
 	stats := &SystemStats{
@@ -80,20 +93,27 @@ func GetSystemStats() (*SystemStats, error) {
 		stats.Disk = *diskStats
 	}
 
+	// Network Info
+	netStats, err := getNetworkStats()
+	if err == nil {
+		stats.Network = *netStats
+	}
+
 	// System Info
 	hostname, _ := os.Hostname()
 	stats.System = SysInfo{
 		Hostname: hostname,
 		OS:       "Windows",
 		Platform: runtime.GOARCH,
-		Uptime:   getUptime(),
+		Uptime:   Uptime(),
 	}
 
 	return stats, nil
 }
 
+// getCPUUsage is the share of CPU time spent busy since the previous call,
+// or since boot on the first one
 func getCPUUsage() float64 {
-	// Simple CPU usage estimation
 	var idleTime, kernelTime, userTime syscall.Filetime
 
 	ret, _, _ := procGetSystemTimes.Call(
@@ -106,10 +126,20 @@ func getCPUUsage() float64 {
 		return 0.0
 	}
 
-	idle := float64(idleTime.Nanoseconds())
-	system := float64(kernelTime.Nanoseconds() + userTime.Nanoseconds())
+	// Kernel time includes idle time
+	idleNs := idleTime.Nanoseconds()
+	totalNs := kernelTime.Nanoseconds() + userTime.Nanoseconds()
+
+	cpuSample.Lock()
+	defer cpuSample.Unlock()
+	if !cpuSample.at.IsZero() && time.Since(cpuSample.at) < minCPUInterval {
+		return cpuSample.usage
+	}
+	idle := float64(idleNs - cpuSample.idle)
+	system := float64(totalNs - cpuSample.total)
+	cpuSample.at, cpuSample.idle, cpuSample.total = time.Now(), idleNs, totalNs
 
-	if system == 0 {
+	if system <= 0 {
 		return 0.0
 	}
 
@@ -121,6 +151,7 @@ func getCPUUsage() float64 {
 		usage = 100
 	}
 
+	cpuSample.usage = usage
 	return usage
 }
 
@@ -198,7 +229,8 @@ func getDiskStats(path string) (*DiskStats, error) {
 	}, nil
 }
 
-func getUptime() uint64 {
+// Uptime returns seconds since Windows booted
+func Uptime() uint64 {
 	kernel32 := syscall.NewLazyDLL("kernel32.dll")
 	procGetTickCount64 := kernel32.NewProc("GetTickCount64")
 	ret, _, _ := procGetTickCount64.Call()
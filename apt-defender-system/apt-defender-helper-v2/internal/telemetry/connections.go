@@ -0,0 +1,124 @@
+package telemetry
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+	"unsafe"
+
+	"github.com/apt-defender/helper-v2/internal/process"
+)
+
+const (
+	afInet                = 2
+	tcpTableOwnerPIDAll   = 5
+	errorInsufficientBuff = 122
+)
+
+var (
+	iphlpapi                = syscall.NewLazyDLL("iphlpapi.dll")
+	procGetExtendedTcpTable = iphlpapi.NewProc("GetExtendedTcpTable")
+)
+
+type Connection struct {
+	Protocol      string `json:"protocol"`
+	LocalAddress  string `json:"local_address"`
+	LocalPort     uint16 `json:"local_port"`
+	RemoteAddress string `json:"remote_address"`
+	RemotePort    uint16 `json:"remote_port"`
+	State         string `json:"state"`
+	PID           uint32 `json:"pid"`
+	ProcessName   string `json:"process_name"`
+}
+
+type mibTCPRowOwnerPID struct {
+	State      uint32
+	LocalAddr  uint32
+	LocalPort  uint32
+	RemoteAddr uint32
+	RemotePort uint32
+	OwningPID  uint32
+}
+
+var tcpStates = map[uint32]string{
+	1: "CLOSED", 2: "LISTEN", 3: "SYN_SENT", 4: "SYN_RECEIVED",
+	5: "ESTABLISHED", 6: "FIN_WAIT1", 7: "FIN_WAIT2", 8: "CLOSE_WAIT",
+	9: "CLOSING", 10: "LAST_ACK", 11: "TIME_WAIT", 12: "DELETE_TCB",
+}
+
+// GetNetworkConnections returns all IPv4 TCP connections with their owning process
+func GetNetworkConnections() ([]Connection, error) {
+	buf, err := extendedTable(procGetExtendedTcpTable, afInet, tcpTableOwnerPIDAll)
+	if err != nil {
+		return nil, err
+	}
+
+	count := binary.LittleEndian.Uint32(buf[:4])
+	rowSize := unsafe.Sizeof(mibTCPRowOwnerPID{})
+	names := process.NameMap()
+
+	connections := make([]Connection, 0, count)
+	for i := uint32(0); i < count; i++ {
+		offset := 4 + uintptr(i)*rowSize
+		if offset+rowSize > uintptr(len(buf)) {
+			break
+		}
+		row := (*mibTCPRowOwnerPID)(unsafe.Pointer(&buf[offset]))
+
+		connections = append(connections, Connection{
+			Protocol:      "tcp",
+			LocalAddress:  ipv4String(row.LocalAddr),
+			LocalPort:     portFromNetwork(row.LocalPort),
+			RemoteAddress: ipv4String(row.RemoteAddr),
+			RemotePort:    portFromNetwork(row.RemotePort),
+			State:         tcpStates[row.State],
+			PID:           row.OwningPID,
+			ProcessName:   names[row.OwningPID],
+		})
+	}
+
+	return connections, nil
+}
+
+// extendedTable fetches a GetExtendedTcpTable/GetExtendedUdpTable table,
+// growing the buffer while connections come and go between calls
+func extendedTable(proc *syscall.LazyProc, family, class uintptr) ([]byte, error) {
+	var size uint32
+
+	// First call reports the required buffer size
+	proc.Call(0, uintptr(unsafe.Pointer(&size)), 0, family, class, 0)
+	if size == 0 {
+		return nil, fmt.Errorf("%s returned no size", proc.Name)
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		buf := make([]byte, size)
+		ret, _, _ := proc.Call(
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&size)),
+			0,
+			family,
+			class,
+			0,
+		)
+		if ret == 0 {
+			return buf, nil
+		}
+		if ret != errorInsufficientBuff {
+			return nil, fmt.Errorf("%s failed: error %d", proc.Name, ret)
+		}
+	}
+	// A buffer that was still too small holds no usable table
+	return nil, fmt.Errorf("%s: table kept growing, gave up after 3 attempts", proc.Name)
+}
+
+// ipv4String converts an address stored in network byte order
+func ipv4String(addr uint32) string {
+	return net.IPv4(byte(addr), byte(addr>>8), byte(addr>>16), byte(addr>>24)).String()
+}
+
+// portFromNetwork converts the low 16 bits of a network-order port
+func portFromNetwork(port uint32) uint16 {
+	return uint16(port>>8)&0xff | uint16(port&0xff)<<8
+}
@@ -0,0 +1,78 @@
+package telemetry
+
+import (
+	"sync"
+	"time"
+)
+
+// Sample is one point on the dashboard's usage graphs
+type Sample struct {
+	Time          time.Time `json:"time"`
+	CPUPercent    float64   `json:"cpu_percent"`
+	MemoryPercent float64   `json:"memory_percent"`
+	SentPerSec    uint64    `json:"sent_bytes_per_sec"`
+	RecvPerSec    uint64    `json:"recv_bytes_per_sec"`
+}
+
+// History samples CPU, memory and network use at a fixed interval and keeps
+// the latest samples, so graphs have data as soon as they are opened
+type History struct {
+	mutex   sync.RWMutex
+	samples []Sample
+	size    int
+}
+
+// NewHistory starts sampling every interval, keeping size samples
+func NewHistory(interval time.Duration, size int) *History {
+	h := &History{size: size}
+	go h.run(interval)
+	return h
+}
+
+func (h *History) run(interval time.Duration) {
+	getCPUUsage()
+	previous, _ := getNetworkStats()
+	last := time.Now()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		sample := Sample{Time: now, CPUPercent: getCPUUsage()}
+		if mem, err := getMemoryStats(); err == nil {
+			sample.MemoryPercent = mem.UsagePercent
+		}
+		// Counters restart when an adapter is reset; that interval reads as 0
+		if current, err := getNetworkStats(); err == nil {
+			if previous != nil {
+				seconds := now.Sub(last).Seconds()
+				if current.BytesSent >= previous.BytesSent {
+					sample.SentPerSec = uint64(float64(current.BytesSent-previous.BytesSent) / seconds)
+				}
+				if current.BytesRecv >= previous.BytesRecv {
+					sample.RecvPerSec = uint64(float64(current.BytesRecv-previous.BytesRecv) / seconds)
+				}
+			}
+			previous, last = current, now
+		}
+
+		h.mutex.Lock()
+		h.samples = append(h.samples, sample)
+		if len(h.samples) > h.size {
+			h.samples = h.samples[len(h.samples)-h.size:]
+		}
+		h.mutex.Unlock()
+	}
+}
+
+// Samples returns the kept samples taken after since, oldest first
+func (h *History) Samples(since time.Time) []Sample {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	samples := []Sample{}
+	for _, sample := range h.samples {
+		if sample.Time.After(since) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
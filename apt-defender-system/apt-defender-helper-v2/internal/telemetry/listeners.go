@@ -0,0 +1,128 @@
+package telemetry
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+	"unsafe"
+
+	"github.com/apt-defender/helper-v2/internal/process"
+)
+
+const (
+	afInet6                  = 23
+	tcpTableOwnerPIDListener = 3
+	udpTableOwnerPID         = 1
+)
+
+var procGetExtendedUdpTable = iphlpapi.NewProc("GetExtendedUdpTable")
+
+// Listener is a listening TCP socket or bound UDP socket
+type Listener struct {
+	Protocol    string `json:"protocol"` // tcp, tcp6, udp, udp6
+	Address     string `json:"address"`
+	Port        uint16 `json:"port"`
+	PID         uint32 `json:"pid"`
+	ProcessName string `json:"process_name"`
+	ImagePath   string `json:"image_path,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+	Signer      string `json:"signer,omitempty"`
+	Exposed     bool   `json:"exposed"` // reachable from other hosts, i.e. not loopback-only
+}
+
+type mibTCP6RowOwnerPID struct {
+	LocalAddr     [16]byte
+	LocalScopeID  uint32
+	LocalPort     uint32
+	RemoteAddr    [16]byte
+	RemoteScopeID uint32
+	RemotePort    uint32
+	State         uint32
+	OwningPID     uint32
+}
+
+type mibUDPRowOwnerPID struct {
+	LocalAddr uint32
+	LocalPort uint32
+	OwningPID uint32
+}
+
+type mibUDP6RowOwnerPID struct {
+	LocalAddr    [16]byte
+	LocalScopeID uint32
+	LocalPort    uint32
+	OwningPID    uint32
+}
+
+// GetListeners returns every listening TCP and bound UDP socket, IPv4 and
+// IPv6, sorted by protocol and port
+func GetListeners() ([]Listener, error) {
+	names := process.NameMap()
+	listeners := []Listener{}
+
+	add := func(protocol string, ip net.IP, port uint32, pid uint32) {
+		listeners = append(listeners, Listener{
+			Protocol:    protocol,
+			Address:     ip.String(),
+			Port:        portFromNetwork(port),
+			PID:         pid,
+			ProcessName: names[pid],
+			Exposed:     !ip.IsLoopback(),
+		})
+	}
+
+	buf, err := extendedTable(procGetExtendedTcpTable, afInet, tcpTableOwnerPIDListener)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows[mibTCPRowOwnerPID](buf) {
+		add("tcp", ipv4(row.LocalAddr), row.LocalPort, row.OwningPID)
+	}
+
+	if buf, err := extendedTable(procGetExtendedTcpTable, afInet6, tcpTableOwnerPIDListener); err == nil {
+		for _, row := range rows[mibTCP6RowOwnerPID](buf) {
+			add("tcp6", net.IP(row.LocalAddr[:]), row.LocalPort, row.OwningPID)
+		}
+	}
+	if buf, err := extendedTable(procGetExtendedUdpTable, afInet, udpTableOwnerPID); err == nil {
+		for _, row := range rows[mibUDPRowOwnerPID](buf) {
+			add("udp", ipv4(row.LocalAddr), row.LocalPort, row.OwningPID)
+		}
+	}
+	if buf, err := extendedTable(procGetExtendedUdpTable, afInet6, udpTableOwnerPID); err == nil {
+		for _, row := range rows[mibUDP6RowOwnerPID](buf) {
+			add("udp6", net.IP(row.LocalAddr[:]), row.LocalPort, row.OwningPID)
+		}
+	}
+
+	sort.SliceStable(listeners, func(i, j int) bool {
+		if listeners[i].Protocol != listeners[j].Protocol {
+			return listeners[i].Protocol < listeners[j].Protocol
+		}
+		return listeners[i].Port < listeners[j].Port
+	})
+	return listeners, nil
+}
+
+// rows decodes a MIB_*TABLE: a DWORD count followed by fixed-size rows
+func rows[T any](buf []byte) []T {
+	if len(buf) < 4 {
+		return nil
+	}
+	count := binary.LittleEndian.Uint32(buf[:4])
+	size := unsafe.Sizeof(*new(T))
+
+	result := make([]T, 0, count)
+	for i := uint32(0); i < count; i++ {
+		offset := 4 + uintptr(i)*size
+		if offset+size > uintptr(len(buf)) {
+			break
+		}
+		result = append(result, *(*T)(unsafe.Pointer(&buf[offset])))
+	}
+	return result
+}
+
+func ipv4(addr uint32) net.IP {
+	return net.IPv4(byte(addr), byte(addr>>8), byte(addr>>16), byte(addr>>24))
+}
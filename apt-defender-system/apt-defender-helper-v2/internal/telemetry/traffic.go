@@ -0,0 +1,89 @@
+package telemetry
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+const (
+	ifTypeSoftwareLoopback = 24
+	ifFlagFilterInterface  = 0x02
+)
+
+var (
+	procGetIfTable2  = iphlpapi.NewProc("GetIfTable2")
+	procFreeMibTable = iphlpapi.NewProc("FreeMibTable")
+)
+
+// mibIfRow2 is MIB_IF_ROW2; only the counters are read
+type mibIfRow2 struct {
+	InterfaceLuid               uint64
+	InterfaceIndex              uint32
+	InterfaceGUID               [16]byte
+	Alias                       [257]uint16
+	Description                 [257]uint16
+	PhysicalAddressLength       uint32
+	PhysicalAddress             [32]byte
+	PermanentPhysicalAddress    [32]byte
+	Mtu                         uint32
+	Type                        uint32
+	TunnelType                  uint32
+	MediaType                   uint32
+	PhysicalMediumType          uint32
+	AccessType                  uint32
+	DirectionType               uint32
+	InterfaceAndOperStatusFlags uint8
+	OperStatus                  uint32
+	AdminStatus                 uint32
+	MediaConnectState           uint32
+	NetworkGUID                 [16]byte
+	ConnectionType              uint32
+	TransmitLinkSpeed           uint64
+	ReceiveLinkSpeed            uint64
+	InOctets                    uint64
+	InUcastPkts                 uint64
+	InNUcastPkts                uint64
+	InDiscards                  uint64
+	InErrors                    uint64
+	InUnknownProtos             uint64
+	InUcastOctets               uint64
+	InMulticastOctets           uint64
+	InBroadcastOctets           uint64
+	OutOctets                   uint64
+	OutUcastPkts                uint64
+	OutNUcastPkts               uint64
+	OutDiscards                 uint64
+	OutErrors                   uint64
+	OutUcastOctets              uint64
+	OutMulticastOctets          uint64
+	OutBroadcastOctets          uint64
+	OutQLen                     uint64
+}
+
+// getNetworkStats totals traffic over the PC's interfaces since they came
+// up. Loopback and filter drivers are left out; filters repeat the counts of
+// the adapter they sit on.
+func getNetworkStats() (*NetStats, error) {
+	var table unsafe.Pointer
+	if ret, _, _ := procGetIfTable2.Call(uintptr(unsafe.Pointer(&table))); ret != 0 {
+		return nil, fmt.Errorf("GetIfTable2 failed: %d", ret)
+	}
+	defer procFreeMibTable.Call(uintptr(table))
+
+	// MIB_IF_TABLE2 is a count padded to 8 bytes, then the rows
+	count := *(*uint32)(table)
+	rows := unsafe.Slice((*mibIfRow2)(unsafe.Add(table, 8)), count)
+
+	stats := &NetStats{}
+	for i := range rows {
+		row := &rows[i]
+		if row.Type == ifTypeSoftwareLoopback || row.InterfaceAndOperStatusFlags&ifFlagFilterInterface != 0 {
+			continue
+		}
+		stats.BytesSent += row.OutOctets
+		stats.BytesRecv += row.InOctets
+		stats.PacketsSent += row.OutUcastPkts + row.OutNUcastPkts
+		stats.PacketsRecv += row.InUcastPkts + row.InNUcastPkts
+	}
+	return stats, nil
+}
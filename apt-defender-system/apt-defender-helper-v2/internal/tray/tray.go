@@ -0,0 +1,328 @@
+package tray
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	user32   = syscall.NewLazyDLL("user32.dll")
+	shell32  = syscall.NewLazyDLL("shell32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procRegisterClassEx       = user32.NewProc("RegisterClassExW")
+	procCreateWindowEx        = user32.NewProc("CreateWindowExW")
+	procDefWindowProc         = user32.NewProc("DefWindowProcW")
+	procGetMessage            = user32.NewProc("GetMessageW")
+	procTranslateMessage      = user32.NewProc("TranslateMessage")
+	procDispatchMessage       = user32.NewProc("DispatchMessageW")
+	procLoadIcon              = user32.NewProc("LoadIconW")
+	procCreatePopupMenu       = user32.NewProc("CreatePopupMenu")
+	procAppendMenu            = user32.NewProc("AppendMenuW")
+	procTrackPopupMenu        = user32.NewProc("TrackPopupMenu")
+	procDestroyMenu           = user32.NewProc("DestroyMenu")
+	procGetCursorPos          = user32.NewProc("GetCursorPos")
+	procSetForegroundWindow   = user32.NewProc("SetForegroundWindow")
+	procRegisterWindowMessage = user32.NewProc("RegisterWindowMessageW")
+	procMessageBox            = user32.NewProc("MessageBoxW")
+	procShellNotifyIcon       = shell32.NewProc("Shell_NotifyIconW")
+	procGetModuleHandle       = kernel32.NewProc("GetModuleHandleW")
+	procProcessIdToSessionId  = kernel32.NewProc("ProcessIdToSessionId")
+)
+
+const (
+	wmApp           = 0x8000
+	wmCallback      = wmApp + 1
+	wmContextMenu   = 0x007B
+	wmRButtonUp     = 0x0205
+	wmLButtonDblClk = 0x0203
+
+	nimAdd    = 0
+	nimModify = 1
+	nimDelete = 2
+
+	nifMessage = 0x01
+	nifIcon    = 0x02
+	nifTip     = 0x04
+	nifInfo    = 0x10
+
+	niifInfo    = 0x01
+	niifWarning = 0x02
+
+	mfString    = 0x0000
+	mfSeparator = 0x0800
+	mfGrayed    = 0x0001
+
+	tpmReturnCmd   = 0x0100
+	tpmRightButton = 0x0002
+
+	mbIconInformation = 0x40
+
+	idiShield = 32518
+)
+
+type notifyIconData struct {
+	Size            uint32
+	Wnd             uintptr
+	ID              uint32
+	Flags           uint32
+	CallbackMessage uint32
+	Icon            uintptr
+	Tip             [128]uint16
+	State           uint32
+	StateMask       uint32
+	Info            [256]uint16
+	TimeoutVersion  uint32
+	InfoTitle       [64]uint16
+	InfoFlags       uint32
+	GUIDItem        [16]byte
+	BalloonIcon     uintptr
+}
+
+type wndClassEx struct {
+	Size       uint32
+	Style      uint32
+	WndProc    uintptr
+	ClsExtra   int32
+	WndExtra   int32
+	Instance   uintptr
+	Icon       uintptr
+	Cursor     uintptr
+	Background uintptr
+	MenuName   *uint16
+	ClassName  *uint16
+	IconSm     uintptr
+}
+
+type point struct {
+	X, Y int32
+}
+
+type msg struct {
+	Wnd     uintptr
+	Message uint32
+	WParam  uintptr
+	LParam  uintptr
+	Time    uint32
+	Pt      point
+	Private uint32
+}
+
+// Item is an entry in the icon's right-click menu. An item without an
+// action is shown greyed out; one without a label is a separator.
+type Item struct {
+	Label  string
+	Action func()
+}
+
+// Icon is the helper's icon in the notification area
+type Icon struct {
+	mutex          sync.Mutex
+	data           notifyIconData
+	items          []Item
+	open           func()
+	taskbarCreated uint32
+}
+
+// There is one icon per process; the window procedure finds it here
+var current *Icon
+
+// Interactive reports whether this process runs in a user's session rather
+// than session 0, where services run and there is no desktop to show
+// anything on
+func Interactive() bool {
+	var session uint32
+	ret, _, _ := procProcessIdToSessionId.Call(uintptr(os.Getpid()), uintptr(unsafe.Pointer(&session)))
+	return ret == 0 || session != 0
+}
+
+// Start adds the icon with tooltip tip. Double-clicking it calls open and
+// right-clicking shows items. Menu actions run on their own goroutine so a
+// slow one doesn't freeze the icon. Start fails when there is no desktop,
+// e.g. in a service's session 0.
+func Start(tip string, open func(), items []Item) (*Icon, error) {
+	if current != nil {
+		return nil, fmt.Errorf("tray icon already started")
+	}
+	icon := &Icon{items: items, open: open}
+	ready := make(chan error)
+	go icon.run(tip, ready)
+	if err := <-ready; err != nil {
+		return nil, err
+	}
+	return icon, nil
+}
+
+// run creates a hidden window that owns the icon and pumps its messages;
+// windows belong to the thread that created them. It is a top-level window
+// rather than a message-only one so it hears Explorer's TaskbarCreated.
+func (i *Icon) run(tip string, ready chan<- error) {
+	runtime.LockOSThread()
+
+	instance, _, _ := procGetModuleHandle.Call(0)
+	className, _ := syscall.UTF16PtrFromString("APTDefenderHelperTray")
+	class := wndClassEx{
+		WndProc:   syscall.NewCallback(wndProc),
+		Instance:  instance,
+		ClassName: className,
+	}
+	class.Size = uint32(unsafe.Sizeof(class))
+	if ret, _, err := procRegisterClassEx.Call(uintptr(unsafe.Pointer(&class))); ret == 0 {
+		ready <- fmt.Errorf("failed to register tray window class: %v", err)
+		return
+	}
+
+	wnd, _, err := procCreateWindowEx.Call(0, uintptr(unsafe.Pointer(className)), 0, 0, 0, 0, 0, 0, 0, 0, instance, 0)
+	if wnd == 0 {
+		ready <- fmt.Errorf("failed to create tray window: %v", err)
+		return
+	}
+
+	// Explorer broadcasts this when it restarts; the icon has to be added again
+	taskbarCreated, _ := syscall.UTF16PtrFromString("TaskbarCreated")
+	msgID, _, _ := procRegisterWindowMessage.Call(uintptr(unsafe.Pointer(taskbarCreated)))
+	shield, _, _ := procLoadIcon.Call(0, idiShield)
+
+	i.mutex.Lock()
+	i.taskbarCreated = uint32(msgID)
+	i.data.Size = uint32(unsafe.Sizeof(i.data))
+	i.data.Wnd = wnd
+	i.data.ID = 1
+	i.data.Flags = nifMessage | nifIcon | nifTip
+	i.data.CallbackMessage = wmCallback
+	i.data.Icon = shield
+	copyUTF16(i.data.Tip[:], tip)
+	ret, _, err := procShellNotifyIcon.Call(nimAdd, uintptr(unsafe.Pointer(&i.data)))
+	i.mutex.Unlock()
+	if ret == 0 {
+		ready <- fmt.Errorf("failed to add tray icon: %v", err)
+		return
+	}
+
+	current = i
+	ready <- nil
+
+	var m msg
+	for {
+		ret, _, _ := procGetMessage.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(ret) <= 0 {
+			return
+		}
+		procTranslateMessage.Call(uintptr(unsafe.Pointer(&m)))
+		procDispatchMessage.Call(uintptr(unsafe.Pointer(&m)))
+	}
+}
+
+func wndProc(wnd uintptr, message uint32, wParam, lParam uintptr) uintptr {
+	i := current
+	if i != nil {
+		switch {
+		case message == wmCallback && (lParam == wmRButtonUp || lParam == wmContextMenu):
+			i.showMenu(wnd)
+			return 0
+		case message == wmCallback && lParam == wmLButtonDblClk:
+			if i.open != nil {
+				go i.open()
+			}
+			return 0
+		case message == i.taskbarCreated && message != 0:
+			i.mutex.Lock()
+			procShellNotifyIcon.Call(nimAdd, uintptr(unsafe.Pointer(&i.data)))
+			i.mutex.Unlock()
+			return 0
+		}
+	}
+	ret, _, _ := procDefWindowProc.Call(wnd, uintptr(message), wParam, lParam)
+	return ret
+}
+
+// showMenu shows the right-click menu at the cursor and runs the picked item
+func (i *Icon) showMenu(wnd uintptr) {
+	menu, _, _ := procCreatePopupMenu.Call()
+	if menu == 0 {
+		return
+	}
+	defer procDestroyMenu.Call(menu)
+
+	for n, item := range i.items {
+		if item.Label == "" {
+			procAppendMenu.Call(menu, mfSeparator, 0, 0)
+			continue
+		}
+		flags := uintptr(mfString)
+		if item.Action == nil {
+			flags |= mfGrayed
+		}
+		label, _ := syscall.UTF16PtrFromString(item.Label)
+		procAppendMenu.Call(menu, flags, uintptr(n+1), uintptr(unsafe.Pointer(label)))
+	}
+
+	var pt point
+	procGetCursorPos.Call(uintptr(unsafe.Pointer(&pt)))
+	// Without this the menu doesn't close when the user clicks elsewhere
+	procSetForegroundWindow.Call(wnd)
+	picked, _, _ := procTrackPopupMenu.Call(menu, tpmReturnCmd|tpmRightButton, uintptr(pt.X), uintptr(pt.Y), 0, wnd, 0)
+	if picked > 0 && int(picked) <= len(i.items) {
+		if action := i.items[picked-1].Action; action != nil {
+			go action()
+		}
+	}
+}
+
+// SetTip changes the text shown when hovering over the icon
+func (i *Icon) SetTip(tip string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.data.Flags = nifMessage | nifIcon | nifTip
+	copyUTF16(i.data.Tip[:], tip)
+	procShellNotifyIcon.Call(nimModify, uintptr(unsafe.Pointer(&i.data)))
+}
+
+// Balloon shows a notification from the icon. Windows 10 and later show it
+// as a toast.
+func (i *Icon) Balloon(title, message string, warning bool) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.data.Flags = nifMessage | nifIcon | nifTip | nifInfo
+	copyUTF16(i.data.InfoTitle[:], title)
+	copyUTF16(i.data.Info[:], message)
+	i.data.InfoFlags = niifInfo
+	if warning {
+		i.data.InfoFlags = niifWarning
+	}
+	ret, _, err := procShellNotifyIcon.Call(nimModify, uintptr(unsafe.Pointer(&i.data)))
+	i.data.Flags &^= nifInfo
+	if ret == 0 {
+		return fmt.Errorf("failed to show tray notification: %v", err)
+	}
+	return nil
+}
+
+// Remove takes the icon out of the notification area, e.g. before exiting
+func (i *Icon) Remove() {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	procShellNotifyIcon.Call(nimDelete, uintptr(unsafe.Pointer(&i.data)))
+}
+
+// MessageBox shows an information dialog; it returns once it is closed
+func MessageBox(title, text string) {
+	t, _ := syscall.UTF16PtrFromString(title)
+	m, _ := syscall.UTF16PtrFromString(text)
+	procMessageBox.Call(0, uintptr(unsafe.Pointer(m)), uintptr(unsafe.Pointer(t)), mbIconInformation)
+}
+
+// copyUTF16 fills a fixed-size field, truncating s to fit its terminator
+func copyUTF16(dst []uint16, s string) {
+	encoded, _ := syscall.UTF16FromString(s)
+	if len(encoded) > len(dst) {
+		encoded = encoded[:len(dst)]
+		encoded[len(encoded)-1] = 0
+	}
+	clear(dst)
+	copy(dst, encoded)
+}
@@ -0,0 +1,160 @@
+package triage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/apt-defender/helper-v2/internal/persistence"
+	"github.com/apt-defender/helper-v2/internal/process"
+	"github.com/apt-defender/helper-v2/internal/tasks"
+	"github.com/apt-defender/helper-v2/internal/telemetry"
+)
+
+const eventLogWindowMS = 7 * 24 * 60 * 60 * 1000 // last 7 days
+
+// Event logs exported with wevtutil; channels that don't exist are recorded as failures
+var eventLogs = []string{
+	"System",
+	"Security",
+	"Application",
+	"Microsoft-Windows-PowerShell/Operational",
+	"Microsoft-Windows-TaskScheduler/Operational",
+	"Microsoft-Windows-Windows Defender/Operational",
+	"Microsoft-Windows-Sysmon/Operational",
+}
+
+// Browser history databases, relative to each user profile
+var browserHistory = map[string]string{
+	"chrome":  `AppData\Local\Google\Chrome\User Data\*\History`,
+	"edge":    `AppData\Local\Microsoft\Edge\User Data\*\History`,
+	"brave":   `AppData\Local\BraveSoftware\Brave-Browser\User Data\*\History`,
+	"firefox": `AppData\Roaming\Mozilla\Firefox\Profiles\*\places.sqlite`,
+}
+
+// sources are collected in order; volatile state first
+var sources = []func(p *writer){
+	collectProcesses,
+	collectNetstat,
+	collectAutoruns,
+	collectTasks,
+	collectHosts,
+	collectPrefetch,
+	collectEventLogs,
+	collectBrowserHistory,
+}
+
+type processEntry struct {
+	process.Process
+	ImagePath string `json:"image_path,omitempty"`
+}
+
+func collectProcesses(p *writer) {
+	p.addJSON("processes.json", "processes", func() (interface{}, error) {
+		list, err := process.List()
+		if err != nil {
+			return nil, err
+		}
+		result := make([]processEntry, 0, len(list))
+		for _, proc := range list {
+			image, _ := process.ImagePath(proc.PID)
+			result = append(result, processEntry{Process: proc, ImagePath: image})
+		}
+		return result, nil
+	})
+}
+
+func collectNetstat(p *writer) {
+	p.addJSON("netstat.json", "netstat", func() (interface{}, error) {
+		return telemetry.GetNetworkConnections()
+	})
+	p.add("netstat.txt", "netstat", func(w io.Writer) error {
+		return runTo(w, "netstat", "-ano")
+	})
+}
+
+func collectAutoruns(p *writer) {
+	p.addJSON("autoruns.json", "autoruns", func() (interface{}, error) {
+		return persistence.GetAutoruns()
+	})
+}
+
+func collectTasks(p *writer) {
+	p.addJSON("scheduled_tasks.json", "tasks", func() (interface{}, error) {
+		return tasks.List()
+	})
+}
+
+func collectHosts(p *writer) {
+	hosts := filepath.Join(os.Getenv("SystemRoot"), "System32", "drivers", "etc", "hosts")
+	p.addFile("hosts", "hosts", hosts)
+}
+
+func collectPrefetch(p *writer) {
+	files, err := filepath.Glob(filepath.Join(os.Getenv("SystemRoot"), "Prefetch", "*.pf"))
+	if err != nil || len(files) == 0 {
+		p.addFailure("prefetch/", "prefetch", fmt.Errorf("no prefetch files (prefetch may be disabled)"))
+		return
+	}
+	for _, f := range files {
+		p.addFile("prefetch/"+filepath.Base(f), "prefetch", f)
+	}
+}
+
+func collectEventLogs(p *writer) {
+	tmp, err := os.MkdirTemp("", "aptd-triage-")
+	if err != nil {
+		p.addFailure("eventlogs/", "eventlogs", err)
+		return
+	}
+	defer os.RemoveAll(tmp)
+
+	query := fmt.Sprintf("/q:*[System[TimeCreated[timediff(@SystemTime) <= %d]]]", eventLogWindowMS)
+	for _, channel := range eventLogs {
+		file := strings.ReplaceAll(channel, "/", "%4") + ".evtx"
+		name := "eventlogs/" + file
+		exported := filepath.Join(tmp, file)
+
+		if err := runTo(io.Discard, "wevtutil", "epl", channel, exported, query); err != nil {
+			p.addFailure(name, "eventlogs", err)
+			continue
+		}
+		p.addFile(name, "eventlogs", exported)
+	}
+}
+
+func collectBrowserHistory(p *writer) {
+	usersDir := filepath.Join(os.Getenv("SystemDrive")+`\`, "Users")
+	profiles, err := os.ReadDir(usersDir)
+	if err != nil {
+		p.addFailure("browser_history/", "browser_history", err)
+		return
+	}
+
+	for _, user := range profiles {
+		if !user.IsDir() {
+			continue
+		}
+		for browser, pattern := range browserHistory {
+			matches, _ := filepath.Glob(filepath.Join(usersDir, user.Name(), pattern))
+			for _, db := range matches {
+				profile := filepath.Base(filepath.Dir(db))
+				name := fmt.Sprintf("browser_history/%s/%s/%s/%s", user.Name(), browser, profile, filepath.Base(db))
+				p.addFile(name, "browser_history", db)
+			}
+		}
+	}
+}
+
+// runTo runs a command and writes its output to w
+func runTo(w io.Writer, name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %v: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	_, err = w.Write(output)
+	return err
+}
@@ -0,0 +1,293 @@
+package triage
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/events"
+	"github.com/apt-defender/helper-v2/internal/staging"
+	"github.com/apt-defender/helper-v2/internal/version"
+)
+
+const maxJobs = 10
+
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Item is one file in the triage package
+type Item struct {
+	Path   string `json:"path"`
+	Source string `json:"source"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Manifest is written to manifest.json inside the package
+type Manifest struct {
+	Hostname     string    `json:"hostname"`
+	AgentVersion string    `json:"agent_version"`
+	StartedAt    time.Time `json:"started_at"`
+	CompletedAt  time.Time `json:"completed_at"`
+	Items        []Item    `json:"items"`
+}
+
+// Job tracks one triage collection
+type Job struct {
+	ID          string            `json:"id"`
+	Status      string            `json:"status"`
+	StartedAt   time.Time         `json:"started_at"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	Items       int               `json:"items"`
+	Failed      int               `json:"failed"`
+	Artifact    *staging.Artifact `json:"artifact,omitempty"`
+	Upload      string            `json:"upload,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// Upload sends a finished package to the Pi Agent
+type Upload func(path string, artifact *staging.Artifact) error
+
+type Collector struct {
+	mutex    sync.RWMutex
+	staging  *staging.Area
+	maxBytes int64
+	upload   Upload
+	events   *events.Broker
+	jobs     []*Job
+}
+
+func New(area *staging.Area, maxBytes int64, upload Upload, broker *events.Broker) *Collector {
+	return &Collector{
+		staging:  area,
+		maxBytes: maxBytes,
+		upload:   upload,
+		events:   broker,
+	}
+}
+
+// Start begins a collection in the background
+func (c *Collector) Start(upload bool) (*Job, error) {
+	c.mutex.Lock()
+	for _, j := range c.jobs {
+		if j.Status == StatusRunning {
+			c.mutex.Unlock()
+			return nil, fmt.Errorf("triage collection %s already running", j.ID)
+		}
+	}
+
+	job := &Job{
+		ID:        fmt.Sprintf("triage-%d", time.Now().UnixNano()),
+		Status:    StatusRunning,
+		StartedAt: time.Now(),
+	}
+	c.jobs = append(c.jobs, job)
+	if len(c.jobs) > maxJobs {
+		c.jobs = c.jobs[len(c.jobs)-maxJobs:]
+	}
+	jobCopy := *job
+	c.mutex.Unlock()
+
+	go c.run(job, upload)
+	return &jobCopy, nil
+}
+
+// Jobs returns recent collections, newest first
+func (c *Collector) Jobs() []Job {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	result := make([]Job, 0, len(c.jobs))
+	for i := len(c.jobs) - 1; i >= 0; i-- {
+		result = append(result, *c.jobs[i])
+	}
+	return result
+}
+
+func (c *Collector) run(job *Job, upload bool) {
+	log.Printf("🧰 Triage collection %s started", job.ID)
+
+	artifact, manifest, path, err := c.collect()
+
+	c.mutex.Lock()
+	now := time.Now()
+	job.CompletedAt = &now
+	if err != nil {
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusCompleted
+		job.Artifact = artifact
+		job.Items = len(manifest.Items)
+		for _, item := range manifest.Items {
+			if item.Error != "" {
+				job.Failed++
+			}
+		}
+		if upload {
+			job.Upload = "pending"
+		}
+	}
+	c.mutex.Unlock()
+
+	if err != nil {
+		log.Printf("⚠️ Triage collection %s failed: %v", job.ID, err)
+		c.publish(job)
+		return
+	}
+	log.Printf("✅ Triage package %s staged (%d items, %d bytes)", artifact.Name, job.Items, artifact.Size)
+
+	if upload {
+		status := "completed"
+		if err := c.upload(path, artifact); err != nil {
+			status = "failed: " + err.Error()
+			log.Printf("⚠️ Triage upload failed: %v", err)
+		}
+		c.mutex.Lock()
+		job.Upload = status
+		c.mutex.Unlock()
+	}
+
+	c.publish(job)
+}
+
+func (c *Collector) publish(job *Job) {
+	c.mutex.RLock()
+	jobCopy := *job
+	c.mutex.RUnlock()
+	c.events.Publish("triage.completed", jobCopy)
+}
+
+// collect writes every source into a zip in the staging area
+func (c *Collector) collect() (*staging.Artifact, *Manifest, string, error) {
+	path, err := c.staging.NewPath("triage", ".zip")
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to create package: %w", err)
+	}
+
+	hostname, _ := os.Hostname()
+	manifest := &Manifest{
+		Hostname:     hostname,
+		AgentVersion: version.Version,
+		StartedAt:    time.Now(),
+	}
+
+	pkg := &writer{zip: zip.NewWriter(f), manifest: manifest}
+	for _, collect := range sources {
+		collect(pkg)
+	}
+	manifest.CompletedAt = time.Now()
+
+	data, _ := json.MarshalIndent(manifest, "", "  ")
+	err = pkg.add("manifest.json", "manifest", func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err == nil {
+		err = pkg.zip.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return nil, nil, "", fmt.Errorf("failed to write package: %w", err)
+	}
+
+	artifact, err := c.staging.Describe(path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if c.maxBytes > 0 && artifact.Size > c.maxBytes {
+		os.Remove(path)
+		return nil, nil, "", fmt.Errorf("package is %d bytes, staging limit is %d", artifact.Size, c.maxBytes)
+	}
+
+	return artifact, manifest, path, nil
+}
+
+// writer adds entries to the package and records them in the manifest
+type writer struct {
+	zip      *zip.Writer
+	manifest *Manifest
+}
+
+// add writes one entry, hashing it on the way in. Failures are recorded in
+// the manifest rather than aborting the collection.
+func (p *writer) add(name, source string, fill func(w io.Writer) error) error {
+	item := Item{Path: name, Source: source}
+
+	entry, err := p.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	counter := &countingWriter{}
+	if err := fill(io.MultiWriter(entry, h, counter)); err != nil {
+		item.Error = err.Error()
+	}
+	item.Size = counter.n
+	item.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	if name != "manifest.json" {
+		p.manifest.Items = append(p.manifest.Items, item)
+	}
+	return nil
+}
+
+// addFile copies a file from disk into the package
+func (p *writer) addFile(name, source, path string) {
+	p.add(name, source, func(w io.Writer) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	})
+}
+
+// addJSON stores the result of a collector function as JSON
+func (p *writer) addJSON(name, source string, collect func() (interface{}, error)) {
+	p.add(name, source, func(w io.Writer) error {
+		v, err := collect()
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	})
+}
+
+// addFailure records a source that could not be read at all
+func (p *writer) addFailure(name, source string, err error) {
+	p.manifest.Items = append(p.manifest.Items, Item{Path: name, Source: source, Error: err.Error()})
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	c.n += int64(len(b))
+	return len(b), nil
+}
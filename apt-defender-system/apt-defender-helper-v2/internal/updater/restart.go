@@ -0,0 +1,70 @@
+package updater
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/supervisor"
+)
+
+// EnvReplaces carries the PID of the helper a restarted one replaces, so the
+// new process can wait for it to let go of the API port
+const EnvReplaces = "HELPER_REPLACES"
+
+// executable is resolved at startup: once Install has renamed the running
+// file, asking Windows again may name the .old copy
+var executable, executableErr = os.Executable()
+
+// Restart ends this process and starts the executable at its path again,
+// which is the new build after Install. Under the supervisor it exits with
+// supervisor.ExitRestart and leaves the start to it. Otherwise it starts
+// the new build itself, without opening another dashboard window. It only
+// returns if that fails.
+func Restart() error {
+	if os.Getenv(supervisor.EnvSupervised) != "" {
+		log.Printf("🔁 Exiting for the supervisor to start the updated helper")
+		os.Exit(supervisor.ExitRestart)
+	}
+
+	if executableErr != nil {
+		return fmt.Errorf("can't locate the helper executable: %w", executableErr)
+	}
+	cmd := exec.Command(executable, append([]string{"--no-browser"}, os.Args[1:]...)...)
+	cmd.Env = append(os.Environ(), EnvReplaces+"="+strconv.Itoa(os.Getpid()))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start the updated helper: %w", err)
+	}
+	log.Printf("🔁 Started the updated helper (pid %d), exiting", cmd.Process.Pid)
+	os.Exit(0)
+	return nil
+}
+
+// WaitForPrevious waits up to timeout for the helper this process replaces,
+// if any, to exit
+func WaitForPrevious(timeout time.Duration) {
+	pid, err := strconv.Atoi(os.Getenv(EnvReplaces))
+	if err != nil {
+		return
+	}
+	os.Unsetenv(EnvReplaces)
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return
+	}
+	exited := make(chan struct{})
+	go func() {
+		process.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(timeout):
+		log.Printf("⚠️ Previous helper (pid %d) still running after %s", pid, timeout)
+	}
+}
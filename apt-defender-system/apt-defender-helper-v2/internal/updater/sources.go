@@ -0,0 +1,117 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"runtime"
+	"time"
+)
+
+// maxManifest bounds the manifest a source may return
+const maxManifest = 64 * 1024
+
+// maxBuild bounds a build downloaded from a URL; helper builds are tens of
+// megabytes, so anything past this is not one and would only fill the disk
+const maxBuild = 256 * 1024 * 1024
+
+// URLSource reads a manifest from a fixed HTTPS URL, e.g. a file share's
+// web server or a release page. A manifest's relative URL is resolved
+// against the manifest's own.
+type URLSource struct {
+	URL  string
+	http *http.Client
+}
+
+// NewURLSource checks manifestURL
+func NewURLSource(manifestURL string) *URLSource {
+	return &URLSource{URL: manifestURL, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (s *URLSource) Name() string {
+	return s.URL
+}
+
+func (s *URLSource) Latest(current string) (*Manifest, error) {
+	resp, err := s.http.Get(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", s.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s returned %d", s.URL, resp.StatusCode)
+	}
+	var manifest Manifest
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifest)).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid update manifest at %s: %w", s.URL, err)
+	}
+	if manifest.Version == "" {
+		return nil, nil
+	}
+	base, _ := url.Parse(s.URL)
+	if ref, err := url.Parse(manifest.URL); err == nil && base != nil {
+		manifest.URL = base.ResolveReference(ref).String()
+	}
+	return &manifest, nil
+}
+
+func (s *URLSource) Download(fileURL string, w io.Writer) error {
+	// Builds are large; only the connection is bounded, not the transfer
+	client := &http.Client{Transport: s.http.Transport}
+	resp, err := client.Get(fileURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %d", fileURL, resp.StatusCode)
+	}
+	n, err := io.Copy(w, io.LimitReader(resp.Body, maxBuild+1))
+	if err != nil {
+		return err
+	}
+	if n > maxBuild {
+		return fmt.Errorf("%s is larger than %d MB, the most a build may be", fileURL, maxBuild>>20)
+	}
+	return nil
+}
+
+// PiClient is the part of the Pi Agent client the updater uses
+type PiClient interface {
+	HelperUpdate(current, arch string) (json.RawMessage, error)
+	Download(url string, w io.Writer) error
+}
+
+// PiSource asks the paired Pi Agent, over its pinned connection, for the
+// build it has for this PC's architecture
+type PiSource struct {
+	Client PiClient
+}
+
+func (s PiSource) Name() string {
+	return "Pi Agent"
+}
+
+func (s PiSource) Latest(current string) (*Manifest, error) {
+	data, err := s.Client.HelperUpdate(current, runtime.GOARCH)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid update manifest from the Pi Agent: %w", err)
+	}
+	if manifest.Version == "" {
+		return nil, nil
+	}
+	return &manifest, nil
+}
+
+func (s PiSource) Download(fileURL string, w io.Writer) error {
+	return s.Client.Download(fileURL, w)
+}
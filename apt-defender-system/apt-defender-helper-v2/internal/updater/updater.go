@@ -0,0 +1,242 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/version"
+)
+
+// Update states reported in Status
+const (
+	StateIdle        = "idle"
+	StateChecking    = "checking"
+	StateDownloading = "downloading"
+	StateInstalled   = "installed" // waiting for the restart into the new build
+	StateFailed      = "failed"
+)
+
+// ErrBusy is returned while another check or install is running, or once
+// an update is waiting for the restart
+var ErrBusy = errors.New("updater busy")
+
+// Manifest describes a helper build offered by an update source
+type Manifest struct {
+	Version   string    `json:"version"`
+	URL       string    `json:"url"`
+	SHA256    string    `json:"sha256"`
+	Signature string    `json:"signature"` // base64 Ed25519 signature of SignedMessage
+	Notes     string    `json:"notes,omitempty"`
+	Published time.Time `json:"published,omitempty"`
+}
+
+// SignedMessage is what the release key signs. It binds the version to the
+// build's hash, so a signature can't be reused for another build or to
+// pass an old build off as a new one.
+func (m Manifest) SignedMessage() []byte {
+	return []byte("apt-defender-helper\n" + m.Version + "\n" + strings.ToLower(m.SHA256) + "\n")
+}
+
+// Source is where the updater looks for builds
+type Source interface {
+	Name() string
+	// Latest returns the newest build, or nil when the source has none
+	Latest(current string) (*Manifest, error)
+	Download(url string, w io.Writer) error
+}
+
+// Status is what the updater last did
+type Status struct {
+	Current     string    `json:"current"`
+	Source      string    `json:"source"`
+	State       string    `json:"state"`
+	LastChecked time.Time `json:"last_checked,omitempty"`
+	Available   *Manifest `json:"available,omitempty"` // newer than Current
+	Error       string    `json:"error,omitempty"`
+	CanInstall  bool      `json:"can_install"` // a release key is configured
+}
+
+// Updater checks a Source for newer signed builds and swaps the running
+// executable for one
+type Updater struct {
+	mutex  sync.Mutex
+	source Source
+	key    ed25519.PublicKey
+	keyErr error
+	status Status
+}
+
+// New checks source for builds signed by publicKey (base64 Ed25519).
+// Without a valid key updates can still be found but not installed.
+func New(source Source, publicKey string) *Updater {
+	u := &Updater{
+		source: source,
+		status: Status{Current: version.Version, Source: source.Name(), State: StateIdle},
+	}
+	u.key, u.keyErr = parseKey(publicKey)
+	u.status.CanInstall = u.keyErr == nil
+	return u
+}
+
+func parseKey(publicKey string) (ed25519.PublicKey, error) {
+	if strings.TrimSpace(publicKey) == "" {
+		return nil, fmt.Errorf("update_public_key is not set, so updates can't be verified")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("update_public_key is not a base64 Ed25519 public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Status returns a copy of the current status
+func (u *Updater) Status() Status {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return u.status
+}
+
+// Check asks the source for a newer build. It returns nil when this one is
+// the latest.
+func (u *Updater) Check() (*Manifest, error) {
+	if err := u.begin(StateChecking); err != nil {
+		return nil, err
+	}
+	manifest, err := u.source.Latest(version.Version)
+	if err == nil && manifest != nil && version.Compare(manifest.Version, version.Version) <= 0 {
+		manifest = nil
+	}
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	u.status.LastChecked = time.Now()
+	u.status.State = StateIdle
+	u.status.Error = ""
+	if err != nil {
+		u.status.State = StateFailed
+		u.status.Error = err.Error()
+		return nil, err
+	}
+	u.status.Available = manifest
+	return manifest, nil
+}
+
+// Install downloads the available build, verifies it against the release
+// key and puts it in place of the running executable. The old executable
+// is kept next to it as .old. The caller restarts the helper afterwards.
+func (u *Updater) Install() (*Manifest, error) {
+	manifest, err := u.install()
+	if errors.Is(err, ErrBusy) {
+		return nil, err
+	}
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	if err != nil {
+		u.status.State = StateFailed
+		u.status.Error = err.Error()
+		return nil, err
+	}
+	u.status.State = StateInstalled
+	u.status.Error = ""
+	return manifest, nil
+}
+
+func (u *Updater) install() (*Manifest, error) {
+	if u.keyErr != nil {
+		return nil, u.keyErr
+	}
+	manifest := u.Status().Available
+	if manifest == nil {
+		var err error
+		if manifest, err = u.Check(); err != nil {
+			return nil, err
+		}
+		if manifest == nil {
+			return nil, fmt.Errorf("version %s is the latest", version.Version)
+		}
+	}
+	if err := u.begin(StateDownloading); err != nil {
+		return nil, err
+	}
+
+	// Checked before downloading so a bad manifest costs nothing
+	signature, err := base64.StdEncoding.DecodeString(manifest.Signature)
+	if err != nil || !ed25519.Verify(u.key, manifest.SignedMessage(), signature) {
+		return nil, fmt.Errorf("the signature of version %s does not match the release key", manifest.Version)
+	}
+
+	if executableErr != nil {
+		return nil, fmt.Errorf("can't locate the helper executable: %w", executableErr)
+	}
+	exe, err := filepath.EvalSymlinks(executable)
+	if err != nil {
+		return nil, fmt.Errorf("can't locate the helper executable: %w", err)
+	}
+
+	// Downloaded next to the executable so the swap is a rename on one volume
+	staged := exe + ".new"
+	if err := u.download(manifest, staged); err != nil {
+		os.Remove(staged)
+		return nil, err
+	}
+
+	// Windows won't overwrite a running executable but will rename it
+	old := exe + ".old"
+	os.Remove(old)
+	if err := os.Rename(exe, old); err != nil {
+		os.Remove(staged)
+		return nil, fmt.Errorf("failed to move the running executable aside: %w", err)
+	}
+	if err := os.Rename(staged, exe); err != nil {
+		os.Rename(old, exe)
+		os.Remove(staged)
+		return nil, fmt.Errorf("failed to put the new executable in place: %w", err)
+	}
+	log.Printf("⬆️ Installed version %s from %s, previous build kept as %s", manifest.Version, u.source.Name(), old)
+	return manifest, nil
+}
+
+// download writes the build to path and checks its hash
+func (u *Updater) download(manifest *Manifest, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	hash := sha256.New()
+	err = u.source.Download(manifest.URL, io.MultiWriter(f, hash))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download version %s: %w", manifest.Version, err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(got, manifest.SHA256) {
+		return fmt.Errorf("downloaded version %s has SHA-256 %s, expected %s", manifest.Version, got, manifest.SHA256)
+	}
+	return nil
+}
+
+// begin moves to a busy state, refusing when another check or install runs
+func (u *Updater) begin(state string) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	switch u.status.State {
+	case StateChecking, StateDownloading:
+		return fmt.Errorf("%w: a check or install is already running", ErrBusy)
+	case StateInstalled:
+		return fmt.Errorf("%w: an update is installed and waiting for the helper to restart", ErrBusy)
+	}
+	u.status.State = state
+	return nil
+}
@@ -0,0 +1,193 @@
+package usb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/winreg"
+)
+
+const (
+	usbstorKey = `HKLM\SYSTEM\CurrentControlSet\Enum\USBSTOR`
+
+	// Device property set holding install/arrival/removal FILETIMEs
+	devicePropertySet = `Properties\{83da6326-97a6-4088-9453-a1923f573b29}`
+	propFirstInstall  = "0064"
+	propLastArrival   = "0066"
+	propLastRemoval   = "0067"
+
+	setupAPISectionStart = ">>>  Section start "
+	setupAPIInstall      = ">>>  [Device Install"
+)
+
+// Device is a USB mass storage device that has been connected to this machine
+type Device struct {
+	InstanceID   string     `json:"instance_id"`
+	Vendor       string     `json:"vendor"`
+	Product      string     `json:"product"`
+	Revision     string     `json:"revision"`
+	Serial       string     `json:"serial"`
+	FriendlyName string     `json:"friendly_name,omitempty"`
+	FirstSeen    *time.Time `json:"first_seen,omitempty"`
+	LastSeen     *time.Time `json:"last_seen,omitempty"`
+	LastRemoved  *time.Time `json:"last_removed,omitempty"`
+}
+
+// History lists USBSTOR devices from the registry, with first-seen times
+// backfilled from setupapi.dev.log when the device properties are missing
+func History() ([]Device, error) {
+	root, err := winreg.Open(usbstorKey)
+	if err != nil {
+		// No mass storage device has ever been connected
+		return []Device{}, nil
+	}
+	defer root.Close()
+
+	installs := setupAPIInstalls()
+
+	devices := []Device{}
+	for _, class := range root.SubKeys() {
+		vendor, product, revision := parseClass(class)
+
+		classKey, err := winreg.Open(usbstorKey + `\` + class)
+		if err != nil {
+			continue
+		}
+		for _, instance := range classKey.SubKeys() {
+			d := Device{
+				InstanceID: `USBSTOR\` + class + `\` + instance,
+				Vendor:     vendor,
+				Product:    product,
+				Revision:   revision,
+				Serial:     serialFromInstance(instance),
+			}
+
+			path := usbstorKey + `\` + class + `\` + instance
+			if key, err := winreg.Open(path); err == nil {
+				d.FriendlyName, _ = key.GetString("FriendlyName")
+				key.Close()
+			}
+			d.FirstSeen = propertyTime(path, propFirstInstall)
+			d.LastSeen = propertyTime(path, propLastArrival)
+			d.LastRemoved = propertyTime(path, propLastRemoval)
+
+			if t, ok := installs[strings.ToUpper(d.InstanceID)]; ok && (d.FirstSeen == nil || t.Before(*d.FirstSeen)) {
+				d.FirstSeen = &t
+			}
+			if d.LastSeen == nil {
+				d.LastSeen = d.FirstSeen
+			}
+
+			devices = append(devices, d)
+		}
+		classKey.Close()
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		a, b := devices[i].LastSeen, devices[j].LastSeen
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.After(*b)
+	})
+	return devices, nil
+}
+
+// parseClass splits Disk&Ven_SanDisk&Prod_Cruzer_Blade&Rev_1.00
+func parseClass(class string) (vendor, product, revision string) {
+	for _, part := range strings.Split(class, "&") {
+		switch {
+		case strings.HasPrefix(part, "Ven_"):
+			vendor = strings.ReplaceAll(strings.TrimPrefix(part, "Ven_"), "_", " ")
+		case strings.HasPrefix(part, "Prod_"):
+			product = strings.ReplaceAll(strings.TrimPrefix(part, "Prod_"), "_", " ")
+		case strings.HasPrefix(part, "Rev_"):
+			revision = strings.TrimPrefix(part, "Rev_")
+		}
+	}
+	return vendor, product, revision
+}
+
+// serialFromInstance strips the &0 suffix. A second character of '&' means
+// the device has no serial and Windows generated the ID.
+func serialFromInstance(instance string) string {
+	if len(instance) > 1 && instance[1] == '&' {
+		return ""
+	}
+	serial, _, _ := strings.Cut(instance, "&")
+	return serial
+}
+
+// propertyTime reads a FILETIME device property, which Windows stores as the
+// default value of Properties\{set}\<id> (or its 00000000 subkey)
+func propertyTime(devicePath, id string) *time.Time {
+	base := devicePath + `\` + devicePropertySet + `\` + id
+	for _, path := range []string{base, base + `\00000000`} {
+		key, err := winreg.Open(path)
+		if err != nil {
+			continue
+		}
+		raw, err := key.GetBinary("")
+		key.Close()
+		if err != nil || len(raw) < 8 {
+			continue
+		}
+		ft := binary.LittleEndian.Uint64(raw[:8])
+		if ft == 0 {
+			continue
+		}
+		t := filetimeToTime(ft)
+		return &t
+	}
+	return nil
+}
+
+func filetimeToTime(ft uint64) time.Time {
+	// 100ns intervals since 1601-01-01
+	const epochDiff = 116444736000000000
+	return time.Unix(0, int64(ft-epochDiff)*100).UTC()
+}
+
+// setupAPIInstalls maps USBSTOR instance IDs to the time their driver was
+// first installed, from setupapi.dev.log and its rotated copies
+func setupAPIInstalls() map[string]time.Time {
+	installs := map[string]time.Time{}
+
+	logs, _ := filepath.Glob(filepath.Join(os.Getenv("SystemRoot"), "INF", "setupapi.dev*.log"))
+	for _, path := range logs {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		var pending string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if strings.HasPrefix(line, setupAPIInstall) {
+				pending = ""
+				if _, id, ok := strings.Cut(line, " - "); ok && strings.HasPrefix(strings.ToUpper(id), "USBSTOR\\") {
+					pending = strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(id), "]"))
+				}
+				continue
+			}
+			if pending != "" && strings.HasPrefix(line, setupAPISectionStart) {
+				stamp := strings.TrimSpace(strings.TrimPrefix(line, setupAPISectionStart))
+				if t, err := time.ParseInLocation("2006/01/02 15:04:05.000", stamp, time.Local); err == nil {
+					if prev, ok := installs[pending]; !ok || t.Before(prev) {
+						installs[pending] = t.UTC()
+					}
+				}
+				pending = ""
+			}
+		}
+		f.Close()
+	}
+
+	return installs
+}
@@ -0,0 +1,229 @@
+package version
+
+// Release is one changelog entry
+type Release struct {
+	Version string   `json:"version"`
+	Date    string   `json:"date"`
+	Changes []string `json:"changes"`
+}
+
+// Changelog lists releases newest first
+var Changelog = []Release{
+	{
+		Version: "2.1.0",
+		Date:    "2026-10-16",
+		Changes: []string{
+			"Live scan events over SSE",
+			"DNS query monitoring with blocklist and DGA heuristics",
+			"Path policy for file operations",
+			"Connection monitoring with beacon detection",
+			"File integrity monitoring",
+			"Local response playbooks",
+			"Persistence enumeration, removal and autoruns listing",
+			"LAN health mesh",
+			"MD5/SHA1/ssdeep hashing and structured threat names",
+			"Alert source attribution",
+			"Skipped-file reporting in scans",
+			"Scheduled task management",
+			"Service listing with signing status, stop and disable",
+			"Kill processes by name or image path glob",
+			"Loaded module and open handle listings",
+			"Process memory dumps with a staging area and Pi upload",
+			"Short packet captures via Npcap",
+			"Forensic triage package collection",
+			"File fetch and put with an audit log",
+			"Restore files from quarantine",
+			"Quarantine listing and retention policy",
+			"Domain blocking via hosts sinkhole or firewall",
+			"Port and protocol blocking",
+			"Per-adapter disable and enable",
+			"Managed firewall rule listing and cleanup",
+			"Close individual TCP connections",
+			"Disable and restore RDP, WinRM and SSH",
+			"USB storage connection history",
+			"Application allowlisting via AppLocker with audit and enforce modes",
+			"Delayed shutdown with a user countdown and cancellation",
+			"Sleep and hibernate",
+			"Session listing and forced logoff",
+			"Popup, toast and full-screen user notifications",
+			"Wake-on-LAN relay",
+			"System Restore points before destructive actions",
+			"Microsoft Defender status, scans and real-time protection recovery",
+			"BitLocker status per volume",
+			"Installed hotfix and OS build inventory",
+			"Installed software inventory",
+			"Local user account audit",
+			"Listening sockets with owning binary and signing status",
+			"Environment variable and command-line overrides for every config field",
+			"Config backups on every save with API and command-line rollback",
+			"Automatic migration of helper-service (v1) config, keeping pairing state",
+			"Config schema_version with forward migrations applied on load",
+			"Scoped API tokens (read, scan, control) enforced per route",
+			"allowed_sources IP/CIDR allowlist for API callers, defaulting to the paired Pi",
+			"Pairing over HTTPS with Pi certificate fingerprint verification and pinning",
+			"mDNS discovery of the Pi Agent, following it across DHCP address changes",
+			"Pi-initiated unpair that rotates the auth token and clears the pinned certificate",
+			"Challenge-response pairing; the pairing code never leaves the PC",
+			"Pairing issues an mTLS client certificate signed by the Pi Agent's device CA",
+			"Reconnects to the Pi Agent with backoff, re-registers and alerts the dashboard while it is unreachable",
+			"Pi Agent certificate pinned on first use for helpers paired before pinning; mismatches raise pi.cert_mismatch",
+			"Structured JSON logging honoring log_level, with component, request ID and device ID fields",
+			"Log and security event shipping to the Pi Agent or syslog, spooled to disk while offline",
+			"Watchdog supervisor (--supervise) restarts the helper after crashes or hangs, keeps crash dumps and reports restarts",
+			"Audit log query API (/api/v1/audit) with action, outcome and time filters, shown in the dashboard",
+			"/api/v2 namespace for breaking changes, with /api/v1 frozen and /api/versions for negotiation",
+			"gzip compression of large JSON responses, negotiated with Accept-Encoding",
+			"Process listing with user, CPU and memory, filters, sorting and pagination",
+			"NDJSON streaming of process, connection and autorun listings with Accept: application/x-ndjson",
+			"Server-sent command results stream with Last-Event-ID catch-up",
+			"Persistent outbox for Pi Agent notifications, replayed in order on reconnect",
+			"HMAC-signed webhook callbacks on scan completion, threats and containment actions, with retries",
+			"Configurable CORS policy so browser consoles on the Pi can call the API",
+			"Replay protection for token requests with X-Timestamp skew checks, single-use X-Nonce and an X-Signature HMAC",
+			"Self-test endpoint checking privileges, firewall and hosts access, writable directories and certificates",
+			"Health reports build hash, process and service start times and uptime, and OS uptime",
+			"Dashboard limited to this PC, with PIN sign-in and session cookies for other machines",
+			"Dashboard threats and quarantine view with quarantine, restore, delete and false-positive marking",
+			"Dashboard process explorer with search, CPU and memory sorting, kill and network block",
+			"Remote IP blocking and a dashboard connections view with reverse DNS, ASN and country hints",
+			"Dashboard firewall view to enable, disable and remove helper rules, with a banner while isolation is active",
+			"Persistent scan history with dashboard charts of files scanned, threats found and scan durations",
+			"Dashboard settings page for scan folders, exclusions, scheduled scans, the skip warning threshold and notifications",
+			"Dashboard light and dark themes, following the system preference until one is picked",
+			"Dashboard command history timeline showing what the Pi Agent and others told this PC to do",
+			"One-click incident report export (JSON and HTML) with telemetry, threats, quarantine, firewall rules and audit entries",
+			"Dashboard pairing page: pair with a confirmed fingerprint, view pairing status and unpair from the PC",
+			"Tray icon with dashboard, status, scan and exit menu items and threat notifications (--no-browser skips only the browser)",
+			"Desktop notifications for detections, finished scans (notify_scans) and remote lock, shutdown, restart and isolation (notify_commands)",
+			"Scan progress bar and current folder in the dashboard; stop scan, progress and threat list in the tray icon",
+			"Restore quarantined detections from the threat list and open a detection's folder in Explorer from the local dashboard",
+			"Start the helper at login and start it minimized to the tray, opening the dashboard only while pairing is needed",
+			"Log viewer in the dashboard with level, component and text filters, copy, and following the log file as it grows",
+			"CPU, memory and network graphs on the dashboard from telemetry the helper samples every 2 seconds; network totals are now filled in",
+			"Dashboard theme stored in the config as theme (system, light or dark), with system following the Windows app mode",
+			"Update checks against the Pi Agent or update_url, installing Ed25519-signed builds in place and restarting into them",
+			"Pairing health in the dashboard header and tray with the last contact time, pi.reconnecting on the first failed check, POST /api/v1/pi/check and a re-pair wizard",
+			"--headless (HELPER_HEADLESS) as the name for --no-gui, chosen automatically in session 0",
+			"Web dashboard as the one helper UI: tray Threats and Pairing items open its pages, and the setup docs build helper-v2 instead of helper-service",
+		},
+	},
+	{
+		Version: "2.0.0",
+		Date:    "2025-01-01",
+		Changes: []string{
+			"File scanning with EICAR and hash detection",
+			"Remote shutdown, restart and lock",
+			"File locking and network blocking",
+			"Local dashboard",
+		},
+	},
+}
+
+// Capabilities lists the features this build exposes, so the Pi can tell
+// what changed after an update
+var Capabilities = []string{
+	"allowlist",
+	"api.cors",
+	"api.gzip",
+	"api.ndjson",
+	"api.versions",
+	"audit",
+	"auth.replay",
+	"auth.scopes",
+	"auth.sources",
+	"autoruns",
+	"config",
+	"config.rollback",
+	"dashboard.auth",
+	"defender",
+	"discovery.mdns",
+	"dns.queries",
+	"files.fetch",
+	"files.hash",
+	"files.lock",
+	"files.put",
+	"files.quarantine",
+	"files.restore",
+	"files.reveal",
+	"fim",
+	"inventory.patches",
+	"inventory.software",
+	"inventory.users",
+	"logging.ship",
+	"logging.structured",
+	"logging.view",
+	"mesh",
+	"network.adapters",
+	"network.beacons",
+	"network.block",
+	"network.block_domain",
+	"network.block_ip",
+	"network.block_port",
+	"network.capture",
+	"network.connections",
+	"network.ip_hints",
+	"network.kill_connection",
+	"network.listeners",
+	"network.rules",
+	"network.rules_toggle",
+	"network.wol",
+	"pair.challenge",
+	"pair.client_cert",
+	"pair.local_unpair",
+	"pair.pin_tofu",
+	"pair.tls",
+	"pair.unpair",
+	"persistence",
+	"persistence.remove",
+	"pi.check",
+	"pi.outbox",
+	"pi.reconnect",
+	"playbooks",
+	"process.dump",
+	"process.handles",
+	"process.kill",
+	"process.kill_pid",
+	"process.list",
+	"process.modules",
+	"quarantine",
+	"report.export",
+	"results.stream",
+	"scan",
+	"scan.events",
+	"scan.exclusions",
+	"scan.history",
+	"scan.schedule",
+	"selftest",
+	"services",
+	"signatures",
+	"staging",
+	"supervisor",
+	"system.autostart",
+	"system.control",
+	"system.encryption",
+	"system.logoff",
+	"system.notify",
+	"system.notify_commands",
+	"system.remote_access",
+	"system.restore_point",
+	"system.shutdown_delay",
+	"system.sleep",
+	"tasks",
+	"telemetry.history",
+	"threats.false_positive",
+	"triage",
+	"update",
+	"usb.history",
+	"webhooks",
+}
+
+// Since returns the releases newer than the given version
+func Since(previous string) []Release {
+	var releases []Release
+	for _, r := range Changelog {
+		if previous == "" || Compare(r.Version, previous) > 0 {
+			releases = append(releases, r)
+		}
+	}
+	return releases
+}
@@ -0,0 +1,104 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateFile = "version-state.json"
+
+// State is what the helper last reported about itself
+type State struct {
+	Version      string    `json:"version"`
+	BuildHash    string    `json:"build_hash"`
+	Capabilities []string  `json:"capabilities"`
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+// Report describes the running build relative to the previous one
+type Report struct {
+	Version         string         `json:"version"`
+	BuildHash       string         `json:"build_hash"`
+	PreviousVersion string         `json:"previous_version,omitempty"`
+	PreviousHash    string         `json:"previous_build_hash,omitempty"`
+	Updated         bool           `json:"updated"`
+	Changelog       []Release      `json:"changelog"`
+	Capabilities    []string       `json:"capabilities"`
+	CapabilityDiff  CapabilityDiff `json:"capability_diff"`
+}
+
+type CapabilityDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// Track compares the running build with the state saved by the previous
+// run, then records the current build for next time
+func Track(dataDir string) (*Report, error) {
+	path := filepath.Join(dataDir, stateFile)
+
+	var previous State
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &previous)
+	}
+
+	report := &Report{
+		Version:         Version,
+		BuildHash:       Hash(),
+		PreviousVersion: previous.Version,
+		PreviousHash:    previous.BuildHash,
+		Capabilities:    Capabilities,
+		CapabilityDiff:  diff(previous.Capabilities, Capabilities),
+	}
+	report.Updated = previous.Version != "" &&
+		(previous.Version != report.Version || previous.BuildHash != report.BuildHash)
+	report.Changelog = Since(previous.Version)
+
+	current := State{
+		Version:      report.Version,
+		BuildHash:    report.BuildHash,
+		Capabilities: Capabilities,
+		RecordedAt:   time.Now(),
+	}
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return report, fmt.Errorf("failed to marshal version state: %w", err)
+	}
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return report, fmt.Errorf("failed to create data dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return report, fmt.Errorf("failed to write version state: %w", err)
+	}
+
+	return report, nil
+}
+
+func diff(before, after []string) CapabilityDiff {
+	result := CapabilityDiff{Added: []string{}, Removed: []string{}}
+	if before == nil {
+		// First run: nothing to compare against
+		return result
+	}
+
+	had := make(map[string]bool, len(before))
+	for _, c := range before {
+		had[c] = true
+	}
+	has := make(map[string]bool, len(after))
+	for _, c := range after {
+		has[c] = true
+		if !had[c] {
+			result.Added = append(result.Added, c)
+		}
+	}
+	for _, c := range before {
+		if !has[c] {
+			result.Removed = append(result.Removed, c)
+		}
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package version
+
+import (
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the helper engine version reported in alerts and the API
+const Version = "2.1.0"
+
+// BuildHash is set at build time with
+// -ldflags "-X github.com/apt-defender/helper-v2/internal/version.BuildHash=<commit>"
+var BuildHash = ""
+
+// Started is when this helper process started
+var Started = time.Now()
+
+// Hash returns the build commit, falling back to the VCS stamp Go embeds
+func Hash() string {
+	if BuildHash != "" {
+		return BuildHash
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "dev"
+}
+
+// Compare returns -1, 0 or 1 comparing two dotted semantic versions.
+// Pre-release suffixes are ignored.
+func Compare(a, b string) int {
+	pa, pb := parts(a), parts(b)
+	for i := 0; i < 3; i++ {
+		if pa[i] < pb[i] {
+			return -1
+		}
+		if pa[i] > pb[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func parts(v string) [3]int {
+	var result [3]int
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "-")
+	for i, p := range strings.SplitN(v, ".", 3) {
+		result[i], _ = strconv.Atoi(p)
+	}
+	return result
+}
@@ -0,0 +1,380 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apt-defender/helper-v2/internal/events"
+)
+
+const (
+	storeFile       = "webhooks.json"
+	queueSize       = 256
+	deliveryTimeout = 10 * time.Second
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-APT-Defender-Event"
+	HeaderDelivery  = "X-APT-Defender-Delivery"
+	HeaderTimestamp = "X-APT-Defender-Timestamp"
+	HeaderSignature = "X-APT-Defender-Signature"
+)
+
+// Events are the event types a webhook can subscribe to
+var Events = []string{"containment.executed", "playbook.executed", "scan.completed", "scan.threat"}
+
+// retryDelays are the waits before each retry of a failed delivery
+var retryDelays = []time.Duration{2 * time.Second, 10 * time.Second, 30 * time.Second, 2 * time.Minute}
+
+// Hook is a registered callback URL. The secret is only returned when the
+// hook is created.
+type Hook struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description,omitempty"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Status is a hook with its delivery counters
+type Status struct {
+	Hook
+	Delivered    int64     `json:"delivered"`
+	Failed       int64     `json:"failed"`
+	Queued       int       `json:"queued"`
+	LastDelivery time.Time `json:"last_delivery,omitempty"`
+	LastError    string    `json:"last_error,omitempty"`
+}
+
+// Payload is the JSON body POSTed to a hook
+type Payload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Hostname  string      `json:"hostname"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+type target struct {
+	hook  Hook
+	queue chan Payload
+	stop  chan struct{}
+
+	mutex  sync.Mutex
+	status Status
+}
+
+// Manager delivers scan, detection and containment events to registered
+// webhooks, signing each payload with the hook's secret and retrying with
+// backoff when the receiver is down
+type Manager struct {
+	mutex     sync.Mutex
+	storePath string
+	targets   map[string]*target
+	http      *http.Client
+}
+
+func New(dataDir string) *Manager {
+	m := &Manager{
+		storePath: filepath.Join(dataDir, storeFile),
+		targets:   make(map[string]*target),
+		http:      &http.Client{Timeout: deliveryTimeout},
+	}
+
+	var hooks []Hook
+	if data, err := os.ReadFile(m.storePath); err == nil {
+		if err := json.Unmarshal(data, &hooks); err != nil {
+			log.Printf("⚠️ Failed to parse webhooks: %v", err)
+		}
+	}
+	for _, h := range hooks {
+		m.targets[h.ID] = m.newTarget(h)
+	}
+	return m
+}
+
+// Start delivers matching broker events to the registered hooks
+func (m *Manager) Start(broker *events.Broker) {
+	ch, _ := broker.Subscribe()
+	go func() {
+		for ev := range ch {
+			if !slices.Contains(Events, ev.Type) {
+				continue
+			}
+			m.dispatch(newPayload(ev.Type, ev.Timestamp, ev.Data))
+		}
+	}()
+}
+
+// List returns the registered hooks, without their secrets
+func (m *Manager) List() []Status {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	statuses := make([]Status, 0, len(m.targets))
+	for _, t := range m.targets {
+		statuses = append(statuses, t.snapshot())
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return statuses
+}
+
+// Add registers a hook, generating a secret when none is given. No events
+// means all of them.
+func (m *Manager) Add(h Hook) (*Hook, error) {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if len(h.Events) == 0 {
+		h.Events = append([]string{}, Events...)
+	}
+	for _, e := range h.Events {
+		if !slices.Contains(Events, e) {
+			return nil, fmt.Errorf("unknown event %q", e)
+		}
+	}
+	if h.Secret == "" {
+		if h.Secret, err = randomHex(32); err != nil {
+			return nil, err
+		}
+	}
+	if h.ID, err = randomHex(8); err != nil {
+		return nil, err
+	}
+	h.CreatedAt = time.Now()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	t := m.newTarget(h)
+	m.targets[h.ID] = t
+	if err := m.save(); err != nil {
+		close(t.stop)
+		delete(m.targets, h.ID)
+		return nil, err
+	}
+
+	if u.Scheme == "http" {
+		log.Printf("⚠️ Webhook %s uses plain HTTP; payloads are signed but not encrypted", h.URL)
+	}
+	log.Printf("🪝 Webhook registered: %s (%v)", h.URL, h.Events)
+	return &h, nil
+}
+
+// Remove unregisters a hook, dropping anything still queued for it
+func (m *Manager) Remove(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	t, ok := m.targets[id]
+	if !ok {
+		return fmt.Errorf("webhook not found: %s", id)
+	}
+	delete(m.targets, id)
+	if err := m.save(); err != nil {
+		m.targets[id] = t
+		return err
+	}
+	close(t.stop)
+	return nil
+}
+
+// Test sends a webhook.test event once, without retries, so a receiver can
+// check it verifies the signature
+func (m *Manager) Test(id string) error {
+	m.mutex.Lock()
+	t, ok := m.targets[id]
+	m.mutex.Unlock()
+	if !ok {
+		return fmt.Errorf("webhook not found: %s", id)
+	}
+	return m.post(t.hook, newPayload("webhook.test", time.Now(), map[string]string{"message": "Webhook test"}))
+}
+
+func (m *Manager) dispatch(p Payload) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, t := range m.targets {
+		if !slices.Contains(t.hook.Events, p.Type) {
+			continue
+		}
+		select {
+		case t.queue <- p:
+		default:
+			t.record(fmt.Errorf("queue full, dropped %s", p.Type))
+			log.Printf("⚠️ Webhook %s queue full, dropped %s", t.hook.URL, p.Type)
+		}
+	}
+}
+
+// newTarget starts the hook's delivery worker. Deliveries to one hook go
+// out in order; a receiver that is down holds up only its own queue.
+func (m *Manager) newTarget(h Hook) *target {
+	t := &target{
+		hook:   h,
+		queue:  make(chan Payload, queueSize),
+		stop:   make(chan struct{}),
+		status: Status{Hook: h},
+	}
+	go func() {
+		for {
+			select {
+			case <-t.stop:
+				return
+			case p := <-t.queue:
+				t.record(m.deliver(t, p))
+			}
+		}
+	}()
+	return t
+}
+
+// deliver posts a payload, retrying network errors, 429s and 5xx with backoff
+func (m *Manager) deliver(t *target, p Payload) error {
+	err := m.post(t.hook, p)
+	for _, delay := range retryDelays {
+		if err == nil || !retryable(err) {
+			break
+		}
+		select {
+		case <-t.stop:
+			return err
+		case <-time.After(delay):
+		}
+		err = m.post(t.hook, p)
+	}
+	if err != nil {
+		log.Printf("⚠️ Webhook delivery of %s to %s failed: %v", p.Type, t.hook.URL, err)
+	}
+	return err
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("receiver returned %d", e.code)
+}
+
+func retryable(err error) bool {
+	status, ok := err.(*statusError)
+	return !ok || status.code >= 500 || status.code == http.StatusTooManyRequests || status.code == http.StatusRequestTimeout
+}
+
+func (m *Manager) post(h Hook, p Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "APT-Defender-Helper")
+	req.Header.Set(HeaderEvent, p.Type)
+	req.Header.Set(HeaderDelivery, p.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(h.Secret, timestamp, body))
+
+	resp, err := m.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{code: resp.StatusCode}
+	}
+	return nil
+}
+
+// Sign is the hex HMAC-SHA256 of "<timestamp>.<body>" under the hook's
+// secret. Receivers recompute it and reject stale timestamps.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (t *target) record(err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if err != nil {
+		t.status.Failed++
+		t.status.LastError = err.Error()
+		return
+	}
+	t.status.Delivered++
+	t.status.LastDelivery = time.Now()
+	t.status.LastError = ""
+}
+
+func (t *target) snapshot() Status {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	status := t.status
+	status.Secret = ""
+	status.Queued = len(t.queue)
+	return status
+}
+
+// save persists the hooks; callers hold the mutex
+func (m *Manager) save() error {
+	hooks := make([]Hook, 0, len(m.targets))
+	for _, t := range m.targets {
+		hooks = append(hooks, t.hook)
+	}
+	slices.SortFunc(hooks, func(a, b Hook) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	data, err := json.MarshalIndent(hooks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhooks: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.storePath), 0700); err != nil {
+		return fmt.Errorf("failed to create data dir: %w", err)
+	}
+	if err := os.WriteFile(m.storePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write webhooks: %w", err)
+	}
+	return nil
+}
+
+func newPayload(eventType string, timestamp time.Time, data interface{}) Payload {
+	id, _ := randomHex(12)
+	hostname, _ := os.Hostname()
+	return Payload{ID: id, Type: eventType, Timestamp: timestamp, Hostname: hostname, Data: data}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
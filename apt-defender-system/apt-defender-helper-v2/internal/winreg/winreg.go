@@ -0,0 +1,237 @@
+package winreg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	keyRead       = 0x20019
+	keyWow64_64   = 0x0100
+	errNoMoreItem = 259
+)
+
+var (
+	advapi32         = syscall.NewLazyDLL("advapi32.dll")
+	procRegEnumValue = advapi32.NewProc("RegEnumValueW")
+)
+
+// Root hives by their conventional short names
+var roots = map[string]syscall.Handle{
+	"HKLM": syscall.HKEY_LOCAL_MACHINE,
+	"HKCU": syscall.HKEY_CURRENT_USER,
+	"HKU":  syscall.HKEY_USERS,
+	"HKCR": syscall.HKEY_CLASSES_ROOT,
+}
+
+// Key is an open registry key
+type Key struct {
+	handle syscall.Handle
+	Path   string
+}
+
+// Value is a registry value converted to a string
+type Value struct {
+	Name string
+	Type uint32
+	Data string
+}
+
+// Open opens a key for reading, e.g. Open(`HKLM\SOFTWARE\Microsoft`)
+func Open(path string) (*Key, error) {
+	rootName, subPath, _ := strings.Cut(path, `\`)
+	root, ok := roots[strings.ToUpper(rootName)]
+	if !ok {
+		return nil, fmt.Errorf("unknown registry hive: %s", rootName)
+	}
+
+	subPtr, err := syscall.UTF16PtrFromString(subPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var handle syscall.Handle
+	if err := syscall.RegOpenKeyEx(root, subPtr, 0, keyRead|keyWow64_64, &handle); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	return &Key{handle: handle, Path: path}, nil
+}
+
+// Close releases the key handle
+func (k *Key) Close() {
+	syscall.RegCloseKey(k.handle)
+}
+
+// SubKeys returns the names of all direct child keys
+func (k *Key) SubKeys() []string {
+	var names []string
+	buf := make([]uint16, 256)
+
+	for i := uint32(0); ; i++ {
+		n := uint32(len(buf))
+		if err := syscall.RegEnumKeyEx(k.handle, i, &buf[0], &n, nil, nil, nil, nil); err != nil {
+			break
+		}
+		names = append(names, syscall.UTF16ToString(buf[:n]))
+	}
+
+	return names
+}
+
+// Values returns every value under the key
+func (k *Key) Values() []Value {
+	var values []Value
+	nameBuf := make([]uint16, 16384)
+
+	for i := uint32(0); ; i++ {
+		nameLen := uint32(len(nameBuf))
+		var valType, dataLen uint32
+
+		// First call obtains the data size
+		ret, _, _ := procRegEnumValue.Call(
+			uintptr(k.handle),
+			uintptr(i),
+			uintptr(unsafe.Pointer(&nameBuf[0])),
+			uintptr(unsafe.Pointer(&nameLen)),
+			0,
+			uintptr(unsafe.Pointer(&valType)),
+			0,
+			uintptr(unsafe.Pointer(&dataLen)),
+		)
+		if ret == errNoMoreItem {
+			break
+		}
+		if ret != 0 {
+			continue
+		}
+
+		name := syscall.UTF16ToString(nameBuf[:nameLen])
+		data, typ, err := k.query(name, dataLen)
+		if err != nil {
+			continue
+		}
+		values = append(values, Value{Name: name, Type: typ, Data: data})
+	}
+
+	return values
+}
+
+// GetString reads a single value as a string
+func (k *Key) GetString(name string) (string, error) {
+	data, _, err := k.query(name, 0)
+	return data, err
+}
+
+// GetUint reads a DWORD/QWORD value
+func (k *Key) GetUint(name string) (uint64, error) {
+	raw, typ, err := k.raw(name, 0)
+	if err != nil {
+		return 0, err
+	}
+	switch typ {
+	case syscall.REG_DWORD:
+		if len(raw) >= 4 {
+			return uint64(binary.LittleEndian.Uint32(raw)), nil
+		}
+	case syscall.REG_QWORD:
+		if len(raw) >= 8 {
+			return binary.LittleEndian.Uint64(raw), nil
+		}
+	}
+	return 0, fmt.Errorf("value %s is not numeric", name)
+}
+
+// GetBinary reads a value's raw bytes
+func (k *Key) GetBinary(name string) ([]byte, error) {
+	raw, _, err := k.raw(name, 0)
+	return raw, err
+}
+
+func (k *Key) query(name string, sizeHint uint32) (string, uint32, error) {
+	raw, typ, err := k.raw(name, sizeHint)
+	if err != nil {
+		return "", 0, err
+	}
+	return decode(raw, typ), typ, nil
+}
+
+func (k *Key) raw(name string, sizeHint uint32) ([]byte, uint32, error) {
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size := sizeHint
+	if size == 0 {
+		size = 256
+	}
+
+	for attempt := 0; attempt < 4; attempt++ {
+		buf := make([]byte, size+2)
+		n := uint32(len(buf))
+		var typ uint32
+		err := syscall.RegQueryValueEx(k.handle, namePtr, nil, &typ, &buf[0], &n)
+		if err == nil {
+			return buf[:n], typ, nil
+		}
+		if err != syscall.ERROR_MORE_DATA {
+			return nil, 0, err
+		}
+		size = n
+	}
+
+	return nil, 0, fmt.Errorf("value %s keeps growing", name)
+}
+
+func decode(raw []byte, typ uint32) string {
+	switch typ {
+	case syscall.REG_SZ, syscall.REG_EXPAND_SZ:
+		return utf16Bytes(raw)
+	case syscall.REG_MULTI_SZ:
+		parts := strings.Split(utf16Bytes(raw), "\x00")
+		var out []string
+		for _, p := range parts {
+			if p != "" {
+				out = append(out, p)
+			}
+		}
+		return strings.Join(out, "; ")
+	case syscall.REG_DWORD:
+		if len(raw) >= 4 {
+			return fmt.Sprintf("%d", binary.LittleEndian.Uint32(raw))
+		}
+	case syscall.REG_QWORD:
+		if len(raw) >= 8 {
+			return fmt.Sprintf("%d", binary.LittleEndian.Uint64(raw))
+		}
+	}
+	return fmt.Sprintf("%x", raw)
+}
+
+// utf16Bytes converts a little-endian UTF-16 byte slice, keeping embedded NULs
+// (REG_MULTI_SZ separators) but dropping the trailing terminator
+func utf16Bytes(raw []byte) string {
+	u := make([]uint16, len(raw)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(raw[i*2:])
+	}
+	for len(u) > 0 && u[len(u)-1] == 0 {
+		u = u[:len(u)-1]
+	}
+
+	var sb strings.Builder
+	start := 0
+	for i, c := range u {
+		if c == 0 {
+			sb.WriteString(syscall.UTF16ToString(u[start:i]))
+			sb.WriteByte(0)
+			start = i + 1
+		}
+	}
+	sb.WriteString(syscall.UTF16ToString(u[start:]))
+	return sb.String()
+}
@@ -17,6 +17,7 @@ export default function AddDeviceScreen({ navigation }) {
     const [pairingCode, setPairingCode] = useState(null);
     const [loading, setLoading] = useState(false);
     const [expiresIn, setExpiresIn] = useState(null);
+    const [fingerprint, setFingerprint] = useState('');
     const [manualIp, setManualIp] = useState('');
     const [manualHostname, setManualHostname] = useState('');
 
@@ -27,6 +28,7 @@ export default function AddDeviceScreen({ navigation }) {
             if (response.success) {
                 setPairingCode(response.data.pairing_token);
                 setExpiresIn(response.data.expires_in_minutes);
+                setFingerprint(response.data.cert_fingerprint || '');
             }
         } catch (error) {
             console.error('Error generating code:', error);
@@ -40,7 +42,8 @@ export default function AddDeviceScreen({ navigation }) {
         if (!pairingCode) return;
         try {
             await Share.share({
-                message: `APT Defender Pairing Code: ${pairingCode}\nExpires in ${expiresIn} minutes.`,
+                message: `APT Defender Pairing Code: ${pairingCode}\nExpires in ${expiresIn} minutes.` +
+                    (fingerprint ? `\nPi certificate fingerprint: ${fingerprint}` : ''),
             });
         } catch (error) {
             console.log(error.message);
@@ -78,6 +81,12 @@ export default function AddDeviceScreen({ navigation }) {
                             <Text style={styles.codeLabel}>PAIRING CODE</Text>
                             <Text style={styles.codeText}>{pairingCode}</Text>
                             <Text style={styles.expiryText}>Expires in {expiresIn} minutes</Text>
+                            {fingerprint ? (
+                                <>
+                                    <Text style={styles.fingerprintLabel}>Check the PC shows this certificate fingerprint:</Text>
+                                    <Text style={styles.fingerprintText} selectable>{fingerprint}</Text>
+                                </>
+                            ) : null}
                         </>
                     ) : (
                         <TouchableOpacity style={styles.generateButton} onPress={generateNewCode}>
@@ -240,6 +249,19 @@ const styles = StyleSheet.create({
         color: theme.colors.textSecondary,
         marginTop: theme.spacing.sm,
     },
+    fingerprintLabel: {
+        fontSize: 12,
+        color: theme.colors.textSecondary,
+        marginTop: theme.spacing.md,
+        textAlign: 'center',
+    },
+    fingerprintText: {
+        fontSize: 11,
+        fontFamily: 'monospace',
+        color: theme.colors.textPrimary,
+        marginTop: theme.spacing.xs,
+        textAlign: 'center',
+    },
     generateButton: {
         backgroundColor: theme.colors.primary,
         paddingHorizontal: theme.spacing.xl,
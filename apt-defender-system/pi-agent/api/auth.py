@@ -7,12 +7,18 @@ from jose import JWTError, jwt
 import bcrypt
 from datetime import datetime, timedelta
 from pydantic import BaseModel, EmailStr
+from typing import Optional
 from config.settings import settings
 from database.db import get_db, Device, User, PairingToken, DeviceUser
+from api.device_ca import sign_device_csr
 from sqlalchemy import select, func
 from sqlalchemy.ext.asyncio import AsyncSession
 import secrets
 import logging
+import hashlib
+import hmac
+import ssl
+from pathlib import Path
 
 logger = logging.getLogger(__name__)
 router = APIRouter()
@@ -183,7 +189,9 @@ def verify_password(plain_password: str, hashed_password: str) -> bool:
 async def pair_device(request: PairingRequest, db: AsyncSession = Depends(get_db)):
     """
     Pair a new device using pairing token
-    This endpoint validates the one-time pairing token and issues a long-lived JWT
+    This endpoint validates the one-time pairing token and issues a long-lived JWT.
+    Legacy flow for older helpers: the code travels in the request, so new
+    helpers use /pair/challenge and /pair/verify instead.
     """
     logger.info(f"Device pairing request: {request.device_hostname} ({request.device_ip})")
     
@@ -214,6 +222,20 @@ async def pair_device(request: PairingRequest, db: AsyncSession = Depends(get_db
         logger.warning(f"Pairing failed: Token expired. Expiry: {token_entry.expires_at}, Now: {now}")
         raise HTTPException(status_code=400, detail="Pairing token has expired")
     
+    access_token, device_id = await _complete_pairing(db, token_entry, request)
+    
+    return {
+        "success": True,
+        "data": {
+            "access_token": access_token,
+            "device_id": device_id,
+            "expires_in_hours": settings.jwt_expiration_hours
+        }
+    }
+
+async def _complete_pairing(db: AsyncSession, token_entry: PairingToken, request) -> tuple:
+    """Register or update the device, consume the pairing token and issue
+    the device JWT. request carries the device_* fields of either pairing flow."""
     # Check if device already exists
     result = await db.execute(select(Device).where(Device.hostname == request.device_hostname))
     existing_device = result.scalar_one_or_none()
@@ -266,16 +288,112 @@ async def pair_device(request: PairingRequest, db: AsyncSession = Depends(get_db
         "device_id": device_id,
         "hostname": request.device_hostname
     })
+    return access_token, device_id
+
+# ============================================
+# Challenge-response pairing
+# ============================================
+# The helper proves it knows the pairing code with an HMAC over both nonces
+# instead of sending the code, and both sides derive a session key from it.
+# The Pi answers with its own proof under that key, so the helper knows it
+# reached a Pi holding the same code.
+
+PAIR_PROOF_LABEL = "aptd-pair-v1"
+PAIR_CHALLENGE_TTL_SECONDS = 120
+_pair_challenges = {}  # challenge_id -> (server_nonce, issued_at)
+
+class PairVerifyRequest(BaseModel):
+    challenge_id: str
+    client_nonce: str
+    proof: str
+    device_hostname: str
+    device_ip: str
+    device_os: str = "windows"
+    device_os_version: str = "10"
+    csr: Optional[str] = None  # PEM CSR for the helper's mTLS client certificate
+
+def _pair_mac(key: bytes, *parts: str) -> str:
+    """HMAC-SHA256 over the |-joined parts, hex encoded"""
+    return hmac.new(key, "|".join(parts).encode(), hashlib.sha256).hexdigest()
+
+def _expire_pair_challenges():
+    cutoff = datetime.utcnow() - timedelta(seconds=PAIR_CHALLENGE_TTL_SECONDS)
+    for challenge_id in [c for c, (_, issued) in _pair_challenges.items() if issued < cutoff]:
+        del _pair_challenges[challenge_id]
+
+@router.post("/pair/challenge")
+async def pair_challenge():
+    """Start a challenge-response pairing by issuing a one-time nonce"""
+    _expire_pair_challenges()
+    challenge_id = secrets.token_hex(16)
+    nonce = secrets.token_hex(32)
+    _pair_challenges[challenge_id] = (nonce, datetime.utcnow())
     
     return {
         "success": True,
         "data": {
-            "access_token": access_token,
-            "device_id": device_id,
-            "expires_in_hours": settings.jwt_expiration_hours
+            "challenge_id": challenge_id,
+            "nonce": nonce,
+            "expires_in_seconds": PAIR_CHALLENGE_TTL_SECONDS
         }
     }
 
+@router.post("/pair/verify")
+async def pair_verify(request: PairVerifyRequest, db: AsyncSession = Depends(get_db)):
+    """
+    Finish a challenge-response pairing. Each challenge allows one attempt,
+    so the pairing code can't be guessed online faster than challenges are issued.
+    """
+    logger.info(f"Device pairing (challenge-response): {request.device_hostname} ({request.device_ip})")
+    
+    _expire_pair_challenges()
+    challenge = _pair_challenges.pop(request.challenge_id, None)
+    if not challenge:
+        raise HTTPException(status_code=400, detail="Unknown or expired pairing challenge")
+    server_nonce = challenge[0]
+    
+    if len(request.client_nonce) < 32:
+        raise HTTPException(status_code=400, detail="Client nonce too short")
+    
+    now = datetime.utcnow()
+    result = await db.execute(
+        select(PairingToken)
+        .where(PairingToken.used_at.is_(None), PairingToken.expires_at > now)
+    )
+    token_entry = None
+    for t in result.scalars().all():
+        expected = _pair_mac(t.token.encode(), PAIR_PROOF_LABEL, "client", server_nonce, request.client_nonce, request.device_hostname)
+        if hmac.compare_digest(expected, request.proof.lower()):
+            token_entry = t
+            break
+    
+    if not token_entry:
+        logger.warning(f"Pairing failed: proof from {request.device_hostname} matches no active pairing code")
+        raise HTTPException(status_code=400, detail="Invalid, used or expired pairing code")
+    
+    session_key = bytes.fromhex(_pair_mac(token_entry.token.encode(), PAIR_PROOF_LABEL, "session", server_nonce, request.client_nonce))
+    access_token, device_id = await _complete_pairing(db, token_entry, request)
+    
+    data = {
+        "access_token": access_token,
+        "device_id": device_id,
+        "expires_in_hours": settings.jwt_expiration_hours,
+        "server_proof": _pair_mac(session_key, PAIR_PROOF_LABEL, "server", access_token, str(device_id))
+    }
+    
+    # Issue the helper's mTLS client certificate. A bad CSR doesn't undo the
+    # pairing; the helper just keeps working without a client certificate.
+    if request.csr:
+        try:
+            data["client_certificate"], data["ca_certificate"] = sign_device_csr(request.csr, device_id, request.device_hostname)
+        except Exception as e:
+            logger.warning(f"Could not issue client certificate for {request.device_hostname}: {e}")
+    
+    return {
+        "success": True,
+        "data": data
+    }
+
 @router.post("/refresh")
 async def refresh_token(token_data: TokenData = Depends(verify_token)):
     """Refresh JWT token"""
@@ -304,6 +422,17 @@ async def verify_current_token(token_data: TokenData = Depends(verify_token)):
         }
     }
 
+def server_cert_fingerprint() -> str:
+    """SHA-256 fingerprint of the Pi's TLS certificate (AB:CD:...), shown with
+    pairing codes so the helper can verify it is talking to this Pi"""
+    try:
+        der = ssl.PEM_cert_to_DER_cert(Path(settings.final_ssl_cert).read_text())
+    except (OSError, ValueError) as e:
+        logger.warning(f"Could not read TLS certificate for fingerprint: {e}")
+        return ""
+    digest = hashlib.sha256(der).hexdigest().upper()
+    return ":".join(digest[i:i + 2] for i in range(0, len(digest), 2))
+
 @router.post("/generate-pairing-code")
 async def generate_pairing_code(
     db: AsyncSession = Depends(get_db),
@@ -326,7 +455,8 @@ async def generate_pairing_code(
         "success": True,
         "data": {
             "pairing_token": pairing_token,
-            "expires_in_minutes": settings.pairing_token_expiry_minutes
+            "expires_in_minutes": settings.pairing_token_expiry_minutes,
+            "cert_fingerprint": server_cert_fingerprint()
         }
     }
 
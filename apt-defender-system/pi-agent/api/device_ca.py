@@ -0,0 +1,107 @@
+"""
+Device CA - signs the client certificates helpers present for mTLS
+"""
+import datetime
+import logging
+import os
+from pathlib import Path
+
+from cryptography import x509
+from cryptography.x509.oid import NameOID, ExtendedKeyUsageOID
+from cryptography.hazmat.primitives import hashes, serialization
+from cryptography.hazmat.primitives.asymmetric import ec
+
+from config.settings import settings
+
+logger = logging.getLogger(__name__)
+
+
+def load_or_create_ca():
+    """Load the device CA, generating it on first use"""
+    cert_path = Path(settings.device_ca_cert)
+    key_path = Path(settings.device_ca_key)
+
+    if cert_path.exists() and key_path.exists():
+        key = serialization.load_pem_private_key(key_path.read_bytes(), password=None)
+        cert = x509.load_pem_x509_certificate(cert_path.read_bytes())
+        return cert, key
+
+    logger.info(f"Generating device CA at {cert_path}")
+    key = ec.generate_private_key(ec.SECP256R1())
+    name = x509.Name([
+        x509.NameAttribute(NameOID.ORGANIZATION_NAME, u"APT Defender"),
+        x509.NameAttribute(NameOID.COMMON_NAME, u"APT Defender Device CA"),
+    ])
+    now = datetime.datetime.utcnow()
+    cert = x509.CertificateBuilder().subject_name(
+        name
+    ).issuer_name(
+        name
+    ).public_key(
+        key.public_key()
+    ).serial_number(
+        x509.random_serial_number()
+    ).not_valid_before(
+        now
+    ).not_valid_after(
+        now + datetime.timedelta(days=3650)
+    ).add_extension(
+        x509.BasicConstraints(ca=True, path_length=0), critical=True
+    ).add_extension(
+        x509.KeyUsage(
+            digital_signature=False, content_commitment=False, key_encipherment=False,
+            data_encipherment=False, key_agreement=False, key_cert_sign=True,
+            crl_sign=True, encipher_only=False, decipher_only=False
+        ),
+        critical=True
+    ).sign(key, hashes.SHA256())
+
+    cert_path.parent.mkdir(parents=True, exist_ok=True)
+    key_path.write_bytes(key.private_bytes(
+        encoding=serialization.Encoding.PEM,
+        format=serialization.PrivateFormat.PKCS8,
+        encryption_algorithm=serialization.NoEncryption(),
+    ))
+    os.chmod(key_path, 0o600)
+    cert_path.write_bytes(cert.public_bytes(serialization.Encoding.PEM))
+    return cert, key
+
+
+def sign_device_csr(csr_pem: str, device_id: int, hostname: str) -> tuple:
+    """
+    Sign a helper's CSR as a client certificate for device_id.
+    Only the public key is taken from the CSR; the subject is set here so a
+    helper can't claim another device's identity.
+    Returns (certificate PEM, CA certificate PEM).
+    """
+    csr = x509.load_pem_x509_csr(csr_pem.encode())
+    if not csr.is_signature_valid:
+        raise ValueError("CSR signature is invalid")
+
+    ca_cert, ca_key = load_or_create_ca()
+    now = datetime.datetime.utcnow()
+    cert = x509.CertificateBuilder().subject_name(x509.Name([
+        x509.NameAttribute(NameOID.ORGANIZATION_NAME, u"APT Defender"),
+        x509.NameAttribute(NameOID.ORGANIZATIONAL_UNIT_NAME, f"device-{device_id}"),
+        x509.NameAttribute(NameOID.COMMON_NAME, hostname[:64] or f"device-{device_id}"),
+    ])).issuer_name(
+        ca_cert.subject
+    ).public_key(
+        csr.public_key()
+    ).serial_number(
+        x509.random_serial_number()
+    ).not_valid_before(
+        now - datetime.timedelta(minutes=5)
+    ).not_valid_after(
+        now + datetime.timedelta(days=settings.device_cert_days)
+    ).add_extension(
+        x509.BasicConstraints(ca=False, path_length=None), critical=True
+    ).add_extension(
+        x509.ExtendedKeyUsage([ExtendedKeyUsageOID.CLIENT_AUTH]), critical=False
+    ).sign(ca_key, hashes.SHA256())
+
+    logger.info(f"Issued client certificate for device {device_id} ({hostname}), serial {cert.serial_number:x}")
+    return (
+        cert.public_bytes(serialization.Encoding.PEM).decode(),
+        ca_cert.public_bytes(serialization.Encoding.PEM).decode(),
+    )
@@ -3,15 +3,18 @@ Devices API Routes
 """
 from fastapi import APIRouter, Depends, HTTPException
 from pydantic import BaseModel
-from typing import List, Optional
+from typing import Any, List, Optional
 from datetime import datetime
-from api.auth import verify_user, UserTokenData, verify_user_from_query
+from api.auth import verify_user, UserTokenData, verify_user_from_query, verify_token, TokenData
 from sqlalchemy import select, func, desc, update
+from sqlalchemy.exc import IntegrityError
 from sqlalchemy.ext.asyncio import AsyncSession
 from database.db import get_db, Device, Threat, Scan, DeviceUser, ForensicTimeline
 from config.settings import settings
 import logging
+import json
 import sys
+from collections import deque
 import importlib.util
 from pathlib import Path
 
@@ -454,6 +457,144 @@ async def get_forensic_timeline(
         }
     }
 
+# ============================================
+# Helper notifications
+# ============================================
+
+# Timeline severity (0-10) of the notifications helpers send
+HELPER_EVENT_SEVERITY = {
+    "scan.threat": 8,
+    "playbook.executed": 6,
+    "fim.change": 5,
+    "peer.down": 4,
+    "helper.restarted": 4,
+}
+
+class HelperEvent(BaseModel):
+    id: Optional[str] = None
+    hostname: str
+    type: str
+    timestamp: datetime
+    data: Optional[Any] = None
+
+@router.post("/events")
+async def receive_helper_event(
+    event: HelperEvent,
+    db: AsyncSession = Depends(get_db),
+    token_data: TokenData = Depends(verify_token)
+):
+    """
+    Record a notification from a paired helper in the device's forensic
+    timeline. Helpers queue notifications while the Pi is unreachable and
+    replay them later, so the original timestamp is kept and a replayed id
+    that is already stored is acknowledged without a duplicate. The id is
+    kept in event_id, which is unique per device.
+    """
+    if event.id:
+        existing = await db.execute(
+            select(ForensicTimeline.id).where(
+                ForensicTimeline.device_id == token_data.device_id,
+                ForensicTimeline.event_id == event.id
+            ).limit(1)
+        )
+        if existing.scalar_one_or_none() is not None:
+            return {"success": True, "data": {"duplicate": True}}
+
+    db.add(ForensicTimeline(
+        device_id=token_data.device_id,
+        timestamp=event.timestamp,
+        event_type=event.type,
+        event_id=event.id or None,
+        details=json.dumps({"id": event.id, "hostname": event.hostname, "data": event.data}, default=str),
+        source="helper",
+        severity=HELPER_EVENT_SEVERITY.get(event.type, 1),
+    ))
+    try:
+        await db.commit()
+    except IntegrityError:
+        # A replay raced the original; the unique index kept one of them
+        await db.rollback()
+        return {"success": True, "data": {"duplicate": True}}
+
+    return {"success": True, "data": {"duplicate": False}}
+
+# ============================================
+# Helper log shipping
+# ============================================
+
+MAX_LOG_RECORDS_PER_BATCH = 1000
+
+class HelperLogBatch(BaseModel):
+    hostname: str
+    records: List[dict]
+
+def _helper_log_path(device_id: int) -> Path:
+    return settings.base_dir / "logs" / "helpers" / f"device-{device_id}.jsonl"
+
+@router.post("/logs")
+async def receive_helper_logs(
+    batch: HelperLogBatch,
+    token_data: TokenData = Depends(verify_token)
+):
+    """
+    Store log records and security events shipped by a paired helper, so they
+    survive even if the endpoint's own log file is tampered with
+    """
+    if len(batch.records) > MAX_LOG_RECORDS_PER_BATCH:
+        raise HTTPException(status_code=413, detail=f"At most {MAX_LOG_RECORDS_PER_BATCH} records per batch")
+    
+    path = _helper_log_path(token_data.device_id)
+    path.parent.mkdir(parents=True, exist_ok=True)
+    received_at = datetime.utcnow().isoformat()
+    with open(path, "a") as f:
+        for record in batch.records:
+            record["received_at"] = received_at
+            f.write(json.dumps(record, default=str) + "\n")
+    
+    return {
+        "success": True,
+        "data": {"stored": len(batch.records)}
+    }
+
+@router.get("/{device_id}/logs")
+async def get_helper_logs(
+    device_id: int,
+    limit: int = 200,
+    level: Optional[str] = None,
+    component: Optional[str] = None,
+    db: AsyncSession = Depends(get_db),
+    token_data: UserTokenData = Depends(verify_user)
+):
+    """Get the most recent log records shipped by a device's helper"""
+    association = await db.execute(
+        select(DeviceUser).where(DeviceUser.device_id == device_id, DeviceUser.user_id == token_data.user_id)
+    )
+    if not association.scalar_one_or_none():
+        raise HTTPException(status_code=403, detail="Access denied to this device")
+    
+    records = deque(maxlen=max(1, min(limit, 5000)))
+    path = _helper_log_path(device_id)
+    if path.exists():
+        with open(path) as f:
+            for line in f:
+                try:
+                    record = json.loads(line)
+                except ValueError:
+                    continue
+                if level and str(record.get("level", "")).upper() != level.upper():
+                    continue
+                if component and record.get("component") != component:
+                    continue
+                records.append(record)
+    
+    return {
+        "success": True,
+        "data": {
+            "records": list(reversed(records)),
+            "total": len(records)
+        }
+    }
+
 @router.get("/{device_id}/scan/{scan_id}/report")
 async def get_scan_report(
     device_id: int,
@@ -648,6 +789,15 @@ async def delete_device(
     
     if not device:
         raise HTTPException(status_code=404, detail="Device not found")
+    
+    # Revoke the pairing on the PC as well; an offline helper keeps its
+    # stale pairing until it is re-paired
+    try:
+        client = await get_device_client(device_id, db)
+        await client.unpair()
+        logger.info(f"Helper on device {device_id} unpaired")
+    except Exception as e:
+        logger.warning(f"Could not unpair helper on device {device_id}: {e}")
         
     await db.delete(device)
     await db.commit()
@@ -9,6 +9,7 @@ from api.auth import verify_user, UserTokenData
 from database.db import get_db, Device, Threat, Scan, Action, DeviceUser
 from sqlalchemy import select, func, desc
 from sqlalchemy.ext.asyncio import AsyncSession
+from config.version import health_info
 import psutil
 import logging
 
@@ -32,8 +33,7 @@ async def get_system_status(token_data: UserTokenData = Depends(verify_user)):
         "success": True,
         "data": {
             "status": "healthy",
-            "version": "1.0.0",
-            "uptime_seconds": int(datetime.utcnow().timestamp() - psutil.boot_time()),
+            **health_info(),
             "cpu_percent": cpu_percent,
             "memory_percent": memory.percent,
             "memory_available_mb": memory.available // (1024 * 1024),
@@ -3,6 +3,7 @@ FastAPI application server
 """
 from fastapi import FastAPI, Request
 from fastapi.middleware.cors import CORSMiddleware
+from fastapi.middleware.gzip import GZipMiddleware
 from fastapi.responses import JSONResponse
 from api.auth import router as auth_router
 from api.routes.devices import router as devices_router
@@ -10,6 +11,7 @@ from api.routes.threats import router as threats_router
 from api.routes.actions import router as actions_router
 from api.routes.system import router as system_router
 from api.routes.telemetry import router as telemetry_router
+from config.version import VERSION, health_info
 import logging
 
 logger = logging.getLogger(__name__)
@@ -20,7 +22,7 @@ def create_app() -> FastAPI:
     app = FastAPI(
         title="APT Defender Pi Agent API",
         description="Portable APT Detection & Response System",
-        version="1.0.0",
+        version=VERSION,
         docs_url="/api/docs",
         redoc_url="/api/redoc"
     )
@@ -34,6 +36,10 @@ def create_app() -> FastAPI:
         allow_headers=["*"],
     )
     
+    # Device lists, threat histories and shipped helper logs get large;
+    # gzip them for clients that send Accept-Encoding: gzip
+    app.add_middleware(GZipMiddleware, minimum_size=1024)
+
     # Exception handler
     @app.exception_handler(Exception)
     async def global_exception_handler(request: Request, exc: Exception):
@@ -57,7 +63,7 @@ def create_app() -> FastAPI:
             "success": True,
             "data": {
                 "status": "healthy",
-                "version": "1.0.0"
+                **health_info(),
             }
         }
     
@@ -49,6 +49,9 @@ class Settings(BaseSettings):
     auto_quarantine: bool = True
     alert_threshold_severity: int = 7
     
+    # Advertise the agent over mDNS (_aptdefender._tcp) for helper discovery
+    mdns_enabled: bool = True
+    
     # Network IDS
     network_ids_enabled: bool = True
     zeek_log_path: str = "/var/log/zeek"
@@ -68,6 +71,17 @@ class Settings(BaseSettings):
     def final_ssl_key(self) -> str:
         return self.ssl_key_path or str(self.base_dir / "certs" / "server.key")
 
+    # CA that signs the client certificates helpers receive at pairing
+    @property
+    def device_ca_cert(self) -> str:
+        return str(self.base_dir / "certs" / "device-ca.crt")
+
+    @property
+    def device_ca_key(self) -> str:
+        return str(self.base_dir / "certs" / "device-ca.key")
+
+    device_cert_days: int = 825
+
     quarantine_dir: str = ""
     yara_rules_dir: str = ""
     temp_dir: str = "/tmp/apt-defender"
@@ -0,0 +1,50 @@
+"""
+Pi Agent version and uptime, shared by every server entry point
+"""
+import os
+import subprocess
+import time
+from datetime import datetime, timezone
+from pathlib import Path
+from typing import Dict
+
+import psutil
+
+VERSION = "2.0.0"
+
+STARTED_AT = datetime.now(timezone.utc)
+_started = time.monotonic()
+
+
+def _git_commit() -> str:
+    """Commit this agent was deployed from: APT_DEFENDER_COMMIT, else git, else "dev" """
+    commit = os.environ.get("APT_DEFENDER_COMMIT")
+    if commit:
+        return commit
+    try:
+        result = subprocess.run(
+            ["git", "rev-parse", "--short", "HEAD"],
+            cwd=Path(__file__).resolve().parent,
+            capture_output=True,
+            text=True,
+            timeout=2,
+        )
+        if result.returncode == 0 and result.stdout.strip():
+            return result.stdout.strip()
+    except (OSError, subprocess.SubprocessError):
+        pass
+    return "dev"
+
+
+GIT_COMMIT = _git_commit()
+
+
+def health_info() -> Dict:
+    """Version, build and uptime fields for health and status responses"""
+    return {
+        "version": VERSION,
+        "git_commit": GIT_COMMIT,
+        "started_at": STARTED_AT.isoformat(),
+        "uptime_seconds": int(time.monotonic() - _started),
+        "os_uptime_seconds": int(time.time() - psutil.boot_time()),
+    }
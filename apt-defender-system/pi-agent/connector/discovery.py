@@ -0,0 +1,72 @@
+"""
+mDNS advertisement so helpers can find the Pi without a hardcoded IP
+"""
+import logging
+import socket
+from typing import Optional
+
+from config.settings import settings
+
+logger = logging.getLogger(__name__)
+
+SERVICE_TYPE = "_aptdefender._tcp.local."
+
+_zeroconf = None
+_service_info = None
+
+
+def _local_ip() -> Optional[str]:
+    """IP of the interface used for the default route"""
+    try:
+        with socket.socket(socket.AF_INET, socket.SOCK_DGRAM) as s:
+            s.connect(("10.255.255.255", 1))
+            return s.getsockname()[0]
+    except OSError:
+        return None
+
+
+def advertise(version: str) -> bool:
+    """Announce the Pi Agent as <hostname>._aptdefender._tcp.local."""
+    global _zeroconf, _service_info
+
+    if not settings.mdns_enabled:
+        return False
+    try:
+        from zeroconf import ServiceInfo, Zeroconf
+    except ImportError:
+        logger.warning("zeroconf is not installed, mDNS discovery disabled (pip install zeroconf)")
+        return False
+
+    ip = _local_ip()
+    if not ip:
+        logger.warning("No LAN address found, mDNS discovery disabled")
+        return False
+
+    hostname = socket.gethostname().split(".")[0]
+    _service_info = ServiceInfo(
+        SERVICE_TYPE,
+        f"{hostname}.{SERVICE_TYPE}",
+        addresses=[socket.inet_aton(ip)],
+        port=settings.port,
+        properties={"version": version, "api": "/api/v1"},
+        server=f"{hostname}.local.",
+    )
+    try:
+        _zeroconf = Zeroconf()
+        _zeroconf.register_service(_service_info)
+    except Exception as e:
+        logger.warning(f"mDNS registration failed: {e}")
+        _zeroconf = None
+        return False
+
+    logger.info(f"Advertising {hostname}.{SERVICE_TYPE} at {ip}:{settings.port}")
+    return True
+
+
+def stop():
+    """Withdraw the mDNS announcement"""
+    global _zeroconf
+    if _zeroconf is not None:
+        _zeroconf.unregister_service(_service_info)
+        _zeroconf.close()
+        _zeroconf = None
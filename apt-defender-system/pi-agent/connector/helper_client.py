@@ -1,10 +1,15 @@
 """
 Helper Service Client - Interface to communicate with PC Helper service
 """
+import hashlib
+import hmac
 import httpx
+import json
 import logging
+import secrets
 import ssl
-from typing import Dict, List, Optional
+import time
+from typing import AsyncIterator, Dict, List, Optional
 from config.settings import settings
 
 logger = logging.getLogger(__name__)
@@ -17,6 +22,14 @@ class HelperTLSConfigurationError(RuntimeError):
 class HelperServiceUnavailableError(RuntimeError):
     pass
 
+
+# Helper API versions this Pi Agent's client is written against, oldest first.
+# The helper keeps old versions frozen, so the newest one both sides speak is safe.
+SUPPORTED_API_VERSIONS = ("v1",)
+
+# Negotiated version per helper base URL
+_negotiated_versions: Dict[str, str] = {}
+
 class HelperClient:
     """Client for communicating with Helper service on target PC"""
     
@@ -27,30 +40,83 @@ class HelperClient:
         key_path: Optional[str] = None,
         ca_cert_path: Optional[str] = None,
         verify_tls: Optional[bool] = None,
+        auth_token: Optional[str] = None,
     ):
         """
         Args:
             helper_url: Base URL of helper service (e.g., https://192.168.1.100:7890)
             cert_path: Path to client certificate for mTLS
+            auth_token: Helper token to send as the bearer and sign requests with
         """
         self.base_url = helper_url.rstrip('/')
+        self.auth_token = auth_token
         self.cert_path = cert_path
         self.key_path = key_path
         self.ca_cert_path = ca_cert_path
         self.verify_tls = settings.helper_tls_verify if verify_tls is None else verify_tls
         self.timeout = settings.helper_timeout_seconds
     
+    async def api_version(self) -> str:
+        """Pick the newest API version both this client and the helper support.
+
+        Helpers that predate /api/versions only speak v1.
+        """
+        if self.base_url in _negotiated_versions:
+            return _negotiated_versions[self.base_url]
+
+        try:
+            async with httpx.AsyncClient(timeout=self.timeout, cert=self._cert(), verify=self._verify()) as client:
+                response = await client.get(f"{self.base_url}/versions")
+        except httpx.HTTPError as e:
+            logger.debug(f"API version negotiation with {self.base_url} failed, using v1: {e}")
+            return "v1"
+
+        version = "v1"
+        if response.status_code == 200:
+            offered = {v.get("version") for v in response.json().get("data", {}).get("versions", [])}
+            common = [v for v in SUPPORTED_API_VERSIONS if v in offered]
+            if common:
+                version = common[-1]
+        elif response.status_code != 404:
+            return version
+
+        _negotiated_versions[self.base_url] = version
+        logger.info(f"Using helper API {version} for {self.base_url}")
+        return version
+
+    def _cert(self):
+        if not self.cert_path:
+            return None
+        return (self.cert_path, self.key_path) if self.key_path else self.cert_path
+
+    def _verify(self):
+        return self.ca_cert_path or self.verify_tls
+
+    def _replay_headers(self, method: str, url: httpx.URL, body: bytes = b"") -> Dict[str, str]:
+        """Fresh timestamp and nonce, so a captured request can't be replayed.
+
+        With a token they are signed (X-Signature) together with the method,
+        the path and query as sent, and the SHA-256 of the body.
+        """
+        headers = {"X-Timestamp": str(int(time.time())), "X-Nonce": secrets.token_hex(16)}
+        if self.auth_token:
+            signed = "|".join([
+                headers["X-Timestamp"],
+                headers["X-Nonce"],
+                method,
+                url.raw_path.decode("ascii"),
+                hashlib.sha256(body).hexdigest(),
+            ])
+            headers["Authorization"] = f"Bearer {self.auth_token}"
+            headers["X-Signature"] = hmac.new(self.auth_token.encode(), signed.encode(), hashlib.sha256).hexdigest()
+        return headers
+
     async def _request(self, method: str, endpoint: str, **kwargs) -> Dict:
         """Make HTTP request to Helper service"""
-        url = f"{self.base_url}/v1{endpoint}"
-
-        cert = None
-        if self.cert_path:
-            cert = (self.cert_path, self.key_path) if self.key_path else self.cert_path
+        url = f"{self.base_url}/{await self.api_version()}{endpoint}"
 
-        verify = self.verify_tls
-        if self.ca_cert_path:
-            verify = self.ca_cert_path
+        cert = self._cert()
+        verify = self._verify()
 
         # First try WITH client certificate (mTLS) if configured
         try:
@@ -60,7 +126,14 @@ class HelperClient:
                 cert=cert,
                 verify=verify,
             ) as client:
-                response = await client.request(method, url, **kwargs)
+                # The body is serialized here so the signature covers the exact bytes sent
+                body = b""
+                extra = kwargs.pop("headers", {})
+                if "json" in kwargs:
+                    body = json.dumps(kwargs.pop("json")).encode()
+                    extra = {"Content-Type": "application/json", **extra}
+                headers = {**self._replay_headers(method, httpx.URL(url), body), **extra}
+                response = await client.request(method, url, headers=headers, content=body or None, **kwargs)
                 response.raise_for_status()
                 return response.json()
         
@@ -167,7 +240,39 @@ class HelperClient:
         """Get the progress of an active scan"""
         return await self._request("GET", "/scan/status")
     
+    async def stream_results(self, types: Optional[List[str]] = None, last_event_id: int = 0) -> AsyncIterator[Dict]:
+        """Yield scan, job and detection results as the helper pushes them.
+
+        Each item is the event ({"type", "timestamp", "data"}) with its "id";
+        pass the last id seen when reconnecting to receive what was missed.
+        """
+        url = f"{self.base_url}/{await self.api_version()}/results/stream"
+        params = {"types": ",".join(types)} if types else {}
+        headers = self._replay_headers("GET", httpx.URL(url, params=params))
+        if last_event_id:
+            headers["Last-Event-ID"] = str(last_event_id)
+        timeout = httpx.Timeout(self.timeout, read=None)
+
+        async with httpx.AsyncClient(timeout=timeout, cert=self._cert(), verify=self._verify()) as client:
+            async with client.stream("GET", url, params=params, headers=headers) as response:
+                response.raise_for_status()
+                event_id, data = None, []
+                async for line in response.aiter_lines():
+                    if line.startswith("id:"):
+                        event_id = int(line[3:].strip())
+                    elif line.startswith("data:"):
+                        data.append(line[5:].strip())
+                    elif line == "" and data:
+                        event = json.loads("\n".join(data))
+                        event["id"] = event_id
+                        yield event
+                        event_id, data = None, []
+
     async def get_telemetry(self) -> Dict:
         """Get system telemetry (CPU, RAM, Disk, Network stats)"""
         return await self._request("GET", "/telemetry")
 
+    async def unpair(self) -> Dict:
+        """Revoke this Pi's pairing on the helper (rotates its auth token)"""
+        return await self._request("POST", "/auth/unpair", json={})
+
@@ -3,7 +3,7 @@ Database models and initialization
 """
 from sqlalchemy.ext.asyncio import create_async_engine, AsyncSession, async_sessionmaker
 from sqlalchemy.orm import declarative_base
-from sqlalchemy import Column, Integer, String, Text, Boolean, DateTime, ForeignKey, CheckConstraint, event
+from sqlalchemy import Column, Integer, String, Text, Boolean, DateTime, ForeignKey, CheckConstraint, Index, event
 from sqlalchemy.sql import func
 from config.settings import settings
 from pathlib import Path
@@ -101,10 +101,15 @@ class ForensicTimeline(Base):
     device_id = Column(Integer, ForeignKey('devices.id', ondelete='CASCADE'), nullable=False)
     timestamp = Column(DateTime, server_default=func.now())
     event_type = Column(String, nullable=False)
+    event_id = Column(String)  # Helper event id, so replayed notifications are stored once
     details = Column(Text, nullable=False)
     source = Column(String, nullable=False)
     severity = Column(Integer, default=0)
 
+    __table_args__ = (
+        Index('idx_forensic_event_id', 'device_id', 'event_id', unique=True),
+    )
+
 class YaraRule(Base):
     __tablename__ = "yara_rules"
     
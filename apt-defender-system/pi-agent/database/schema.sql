@@ -124,6 +124,7 @@ CREATE TABLE IF NOT EXISTS forensic_timeline (
     device_id INTEGER NOT NULL,
     timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     event_type TEXT NOT NULL, -- process_created, file_modified, network_connection, etc.
+    event_id TEXT, -- helper event id; unique per device (idx_forensic_event_id, created by scripts/migrate_db.py)
     details TEXT NOT NULL, -- JSON or plain text
     source TEXT NOT NULL CHECK(source IN ('helper', 'network_ids', 'file_monitor', 'process_monitor')),
     severity INTEGER DEFAULT 0 CHECK(severity BETWEEN 0 AND 10),
@@ -9,8 +9,10 @@ from pathlib import Path
 from fastapi import FastAPI
 from api.routes import devices, threats, actions, system
 from api import auth
+from connector import discovery
 from database.db import init_database
 from config.settings import settings
+from config.version import VERSION
 import logging
 
 # Configure logging
@@ -29,7 +31,7 @@ def create_app() -> FastAPI:
     app = FastAPI(
         title="APT Defender Pi Agent",
         description="Detection and response agent for Raspberry Pi",
-        version="2.0.0"
+        version=VERSION
     )
     
     # Include API routers
@@ -43,7 +45,7 @@ def create_app() -> FastAPI:
     async def root():
         return {
             "name": "APT Defender Pi Agent",
-            "version": "2.0.0",
+            "version": VERSION,
             "status": "active"
         }
         
@@ -150,6 +152,9 @@ def main():
         # Create FastAPI app
         app = create_app()
         
+        # Let helpers find this Pi without knowing its DHCP address
+        discovery.advertise(app.version)
+        
         # Run server
         # In development, we use HTTP if certificates are tricky, 
         # but for production mTLS is required.
@@ -166,6 +171,8 @@ def main():
     except Exception as e:
         logger.critical(f"Agent failed to start: {e}")
         sys.exit(1)
+    finally:
+        discovery.stop()
 
 if __name__ == "__main__":
     main()
@@ -56,7 +56,30 @@ def migrate():
             print("DEBUG: Successfully added total_files column.")
         else:
             print("DEBUG: total_files column already exists.")
-            
+
+        # Helper events are deduplicated on their id, per device
+        cursor.execute("PRAGMA table_info(forensic_timeline)")
+        columns = [column[1] for column in cursor.fetchall()]
+
+        if "event_id" not in columns:
+            print("DEBUG: Adding event_id column to forensic_timeline table...")
+            cursor.execute("ALTER TABLE forensic_timeline ADD COLUMN event_id TEXT")
+            # Fill it from the id stored in details, once per device and id
+            cursor.execute("""
+                UPDATE forensic_timeline SET event_id = json_extract(details, '$.id')
+                WHERE id IN (
+                    SELECT MIN(id) FROM forensic_timeline
+                    WHERE source = 'helper' AND json_valid(details)
+                        AND json_extract(details, '$.id') IS NOT NULL
+                        AND json_extract(details, '$.id') != ''
+                    GROUP BY device_id, json_extract(details, '$.id')
+                )
+            """)
+            print("DEBUG: Successfully added event_id column.")
+        cursor.execute(
+            "CREATE UNIQUE INDEX IF NOT EXISTS idx_forensic_event_id ON forensic_timeline(device_id, event_id)"
+        )
+
         conn.commit()
         conn.close()
     except Exception as e: